@@ -18,7 +18,7 @@ func init() {
 		systemName = config.Namespace
 	}
 	if serviceEnv == "" {
-		serviceEnv = "dev"
+		serviceEnv = "production"
 	}
 	if logLevel == "" {
 		logLevel = "INFO"
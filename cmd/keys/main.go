@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/repository/postgres"
+	"github.com/GermanBogatov/auth-service/internal/service"
+	"github.com/GermanBogatov/auth-service/pkg/cipher"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/GermanBogatov/auth-service/pkg/postgresql"
+	"github.com/pkg/errors"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `usage: keys <command> [flags]
+
+commands:
+  list     список ключей подписи
+  rotate   внеплановая ротация ключей
+  retire   вывод ключа из оборота (-kid <kid> [-immediately])`
+
+func init() {
+	serviceEnv := config.ServiceEnv
+	if serviceEnv == "" {
+		serviceEnv = "dev"
+	}
+
+	err := logging.InitLogging(&logging.Config{
+		SystemName: config.Namespace + "_keys",
+		Env:        serviceEnv,
+		Level:      "INFO",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		logging.Fatal(usage)
+	}
+
+	command := os.Args[1]
+	switch command {
+	case "list", "rotate", "retire":
+	default:
+		logging.Fatal(usage)
+	}
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	cfgPath := flags.String("configPath", "", "path to config file")
+	kid := flags.String("kid", "", "signing key id")
+	immediately := flags.Bool("immediately", false, "stop verifying tokens signed by the key right away")
+
+	err := flags.Parse(os.Args[2:])
+	if err != nil {
+		logging.Fatalf("parse flags: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	keyRing, err := newKeyRing(ctx, *cfgPath)
+	if err != nil {
+		logging.Fatalf("init key ring: %s", err)
+	}
+
+	switch command {
+	case "list":
+		err = listKeys(ctx, keyRing)
+	case "rotate":
+		err = keyRing.RotateKeys(ctx)
+		if err == nil {
+			err = listKeys(ctx, keyRing)
+		}
+	case "retire":
+		if *kid == "" {
+			logging.Fatalf("flag `kid` is empty")
+		}
+		err = keyRing.RetireKey(ctx, *kid, *immediately)
+		if err == nil {
+			err = listKeys(ctx, keyRing)
+		}
+	}
+
+	if err != nil {
+		logging.Fatalf("%s: %s", command, err)
+	}
+}
+
+// newKeyRing - подключение к бд и сборка кольца ключей по конфигу приложения
+func newKeyRing(ctx context.Context, cfgPath string) (service.IKeyRing, error) {
+	var (
+		cfg *config.Config
+		err error
+	)
+	if cfgPath == "" {
+		cfg, err = config.NewEnvConfig()
+	} else {
+		cfg, err = config.NewEnvConfigFromFile(cfgPath)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "config")
+	}
+
+	pgClient, err := postgresql.NewPostgresqlClient(ctx, cfg.Postgres.URL, 1,
+		cfg.Postgres.ConnMaxLifetimeMinute, cfg.Postgres.ConnAttempts, cfg.Postgres.ConnTimeout)
+	if err != nil {
+		return nil, errors.Wrap(err, "connection postgresql")
+	}
+
+	secretValues, err := config.LoadSecrets(ctx, cfg.Secrets)
+	if err != nil {
+		return nil, errors.Wrap(err, "load secrets")
+	}
+
+	keyCipher, err := cipher.NewAESGCM(secretValues.KeyEncryptionKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "key cipher")
+	}
+
+	return service.NewKeyRing(postgres.NewKey(pgClient), keyCipher, cfg.Jwt.Algorithm, cfg.Jwt.RotationIntervalHour,
+		cfg.Jwt.RetiredGraceHour, cfg.Jwt.KeyRefreshIntervalSec), nil
+}
+
+// listKeys - вывод ключей в виде таблицы
+func listKeys(ctx context.Context, keyRing service.IKeyRing) error {
+	keys, err := keyRing.GetKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "KID\tALG\tSTATUS\tCREATED\tACTIVATED\tRETIRED\tEXPIRES")
+	for _, key := range keys {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", key.ID, key.Algorithm, key.Status,
+			key.CreatedDate.Format(config.IsoTimeLayout), formatDate(key.ActivatedDate),
+			formatDate(key.RetiredDate), formatDate(key.ExpiresDate))
+	}
+
+	return w.Flush()
+}
+
+func formatDate(date *time.Time) string {
+	if date == nil {
+		return "-"
+	}
+	return date.Format(config.IsoTimeLayout)
+}
@@ -4,12 +4,18 @@ import (
 	"context"
 	"fmt"
 	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
 	httpHandler "github.com/GermanBogatov/auth-service/internal/handler/http"
 	"github.com/GermanBogatov/auth-service/internal/repository/cache"
 	"github.com/GermanBogatov/auth-service/internal/repository/postgres"
 	"github.com/GermanBogatov/auth-service/internal/service"
+	"github.com/GermanBogatov/auth-service/pkg/cipher"
+	"github.com/GermanBogatov/auth-service/pkg/claims"
 	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/GermanBogatov/auth-service/pkg/mailer"
 	"github.com/GermanBogatov/auth-service/pkg/postgresql"
+	"github.com/GermanBogatov/auth-service/pkg/pwhash"
+	"github.com/GermanBogatov/auth-service/pkg/pwpolicy"
 	"github.com/GermanBogatov/auth-service/pkg/redis"
 	"github.com/GermanBogatov/auth-service/pkg/sentry"
 	"github.com/GermanBogatov/auth-service/pkg/tracer"
@@ -29,11 +35,17 @@ type App struct {
 	cfg          *config.Config
 	httpServer   *http.Server
 	router       *chi.Mux
+	keyRing      service.IKeyRing
 	cancelTracer func(ctx context.Context)
 }
 
 // NewApplication - подключаем различные бд, инициализируем слои и роуты.
 func NewApplication(ctx context.Context, cfg *config.Config) (App, error) {
+	logging.Infof("loading secrets from [%s] provider...", cfg.Secrets.Provider)
+	secretValues, err := config.LoadSecrets(ctx, cfg.Secrets)
+	if err != nil {
+		return App{}, errors.Wrap(err, "load secrets")
+	}
 
 	redisClient, err := redis.NewClient(cfg.Redis.Host, cfg.Redis.Port, cfg.Redis.Password, cfg.Redis.DB)
 	if err != nil {
@@ -48,17 +60,75 @@ func NewApplication(ctx context.Context, cfg *config.Config) (App, error) {
 	}
 
 	logging.Info("repo initializing...")
-	userRepo := postgres.NewUser(pgClient)
+	userRepo := postgres.NewUser(pgClient, cfg.Password.HistorySize)
+	roleRepo := postgres.NewRole(pgClient)
 
 	cacheRepo := cache.NewStorage(redisClient, cfg.Redis.UserTTL, cfg.Redis.RefreshTTL)
 	logging.Info("cache initializing...")
-	jwtService := service.NewJWT(userRepo, cacheRepo, config.JWTSecret, cfg.JwtTTL)
+
+	logging.Info("signing keys initializing...")
+	keyCipher, err := cipher.NewAESGCM(secretValues.KeyEncryptionKey)
+	if err != nil {
+		return App{}, errors.Wrap(err, "key cipher")
+	}
+	keyRing := service.NewKeyRing(postgres.NewKey(pgClient), keyCipher, cfg.Jwt.Algorithm, cfg.Jwt.RotationIntervalHour,
+		cfg.Jwt.RetiredGraceHour, cfg.Jwt.KeyRefreshIntervalSec)
+	err = keyRing.Init(ctx)
+	if err != nil {
+		return App{}, errors.Wrap(err, "init key ring")
+	}
 
 	logging.Info("service initializing...")
-	userService := service.NewUser(userRepo)
+	roleService := service.NewRole(roleRepo, cfg.Roles.RefreshIntervalSec)
+	err = roleService.Init(ctx)
+	if err != nil {
+		return App{}, errors.Wrap(err, "init roles")
+	}
+
+	claimsPipeline, err := newClaimsPipeline(cfg.Claims, roleService)
+	if err != nil {
+		return App{}, errors.Wrap(err, "claims pipeline")
+	}
+
+	jwtService := service.NewJWT(userRepo, cacheRepo, keyRing, claimsPipeline, cfg.JwtTTL, cfg.Redis.RefreshTTL,
+		cfg.Jwt.RefreshReuseGraceSec, cfg.Jwt.LeewaySec, cfg.OIDC.Issuer, cfg.Jwt.Issuer, cfg.Jwt.Audience,
+		cfg.EmailVerification.Mode)
+
+	passwordHasher := pwhash.NewHasher(pwhash.Params{
+		Algorithm:     cfg.Password.Algorithm,
+		Memory:        uint32(cfg.Password.Argon2MemoryKiB),
+		Iterations:    uint32(cfg.Password.Argon2Iterations),
+		Parallelism:   uint8(cfg.Password.Argon2Parallelism),
+		BcryptCost:    cfg.Password.BcryptCost,
+		Peppers:       secretValues.Peppers,
+		PepperVersion: secretValues.PepperVersion,
+	})
+	passwordPolicy, err := newPasswordPolicy(cfg.Password)
+	if err != nil {
+		return App{}, errors.Wrap(err, "password policy")
+	}
+	userService := service.NewUser(userRepo, roleRepo, jwtService, passwordHasher, passwordPolicy,
+		cfg.Password.HistorySize, secretValues.LegacyPasswordSalt)
+	sessionService := service.NewSession(cacheRepo)
+	serviceAccountService := service.NewServiceAccount(postgres.NewServiceAccount(pgClient))
+	oauthClientService := service.NewOAuthClient(postgres.NewOAuthClient(pgClient))
+	oauthService := service.NewOAuth(userRepo, cacheRepo, jwtService, cfg.OAuth.CodeTTLSec, cfg.JwtTTL)
+	federationService := service.NewFederation(userRepo, postgres.NewUserIdentity(pgClient), cacheRepo, passwordHasher,
+		cfg.Federation.Providers, cfg.Federation.StateTTLSec, cfg.OIDC.Issuer)
+	personalTokenService := service.NewPersonalToken(postgres.NewPersonalToken(pgClient), userRepo)
+	impersonationService := service.NewImpersonation(postgres.NewImpersonation(pgClient), userRepo, roleRepo, jwtService,
+		cfg.Impersonation.TTLSec)
+	appMailer := newMailer(cfg.Mailer, secretValues.MailerSMTPPassword)
+	passwordResetService := service.NewPasswordReset(userRepo, cacheRepo, userService, jwtService, appMailer,
+		cfg.PasswordReset.TokenTTLSec, cfg.PasswordReset.ResendIntervalSec, cfg.PasswordReset.LinkURL)
+	emailVerificationService := service.NewEmailVerification(userRepo, cacheRepo, appMailer,
+		cfg.EmailVerification.TokenTTLSec, cfg.EmailVerification.ResendIntervalSec, cfg.EmailVerification.LinkURL)
+	signInGuardService := service.NewSignInGuard(cacheRepo, userService, cfg.SignInGuard)
 
 	logging.Info("handler initializing...")
-	appHandler := httpHandler.NewHandler(cfg, userService, jwtService)
+	appHandler := httpHandler.NewHandler(cfg, userService, jwtService, sessionService, serviceAccountService,
+		oauthClientService, oauthService, federationService, personalTokenService, roleService, impersonationService,
+		passwordResetService, emailVerificationService, signInGuardService)
 	router := appHandler.InitRoutes()
 
 	logging.Info("tracer initializing...")
@@ -82,16 +152,89 @@ func NewApplication(ctx context.Context, cfg *config.Config) (App, error) {
 	return App{
 		cfg:          cfg,
 		router:       router,
+		keyRing:      keyRing,
 		cancelTracer: cancelTrace,
 	}, nil
 }
 
+// newClaimsPipeline - конвейер обогащения access-токенов: включенные встроенные обогатители, затем статические
+// claims клиентов, чтобы конфигурация клиента могла уточнить значения встроенных
+func newClaimsPipeline(cfg config.Claims, roleService service.IRole) (*claims.Pipeline, error) {
+	enrichers := make([]claims.Enricher, 0, len(cfg.Enrichers)+1)
+	for _, name := range cfg.Enrichers {
+		switch name {
+		case claims.EnricherProfile:
+			enrichers = append(enrichers, claims.Profile{})
+		case claims.EnricherPermissions:
+			enrichers = append(enrichers, claims.NewPermissions(func(ctx context.Context, role string) ([]string, error) {
+				permissions, err := roleService.GetPermissions(ctx, role)
+				if err != nil {
+					return nil, err
+				}
+
+				result := make([]string, 0, len(permissions))
+				for _, permission := range permissions {
+					result = append(result, string(permission))
+				}
+				return result, nil
+			}))
+		}
+	}
+
+	static := make(map[string]claims.Claims, len(cfg.Static))
+	for clientID, clientClaims := range cfg.Static {
+		static[clientID] = clientClaims
+	}
+
+	staticEnricher, err := claims.NewStatic(static, entity.ReservedClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims.NewPipeline(cfg.MaxSizeBytes, entity.ReservedClaims, append(enrichers, staticEnricher)...), nil
+}
+
+// newPasswordPolicy - политика паролей, список утекших паролей загружается целиком в память при старте
+func newPasswordPolicy(cfg config.Password) (*pwpolicy.Policy, error) {
+	var breached *pwpolicy.Corpus
+	if cfg.BreachedFile != "" {
+		var err error
+		breached, err = pwpolicy.LoadCorpus(cfg.BreachedFile)
+		if err != nil {
+			return nil, err
+		}
+		logging.Infof("loaded %d breached passwords", breached.Len())
+	}
+
+	return pwpolicy.NewPolicy(pwpolicy.Rules{
+		MinLength:           cfg.MinLength,
+		MaxLength:           cfg.MaxLength,
+		RequiredClasses:     cfg.RequiredClasses,
+		ForbiddenSubstrings: cfg.ForbiddenSubstrings,
+	}, breached), nil
+}
+
+// newMailer - доставка писем по драйверу из конфигурации
+func newMailer(cfg config.Mailer, smtpPassword string) mailer.Mailer {
+	switch cfg.Driver {
+	case mailer.DriverSMTP:
+		return mailer.NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, smtpPassword, cfg.From,
+			time.Duration(cfg.SMTPTimeoutSec)*time.Second)
+	case mailer.DriverFile:
+		return mailer.NewFile(cfg.FilePath, cfg.From)
+	default:
+		return mailer.Log{}
+	}
+}
+
 // Start - старт сервера и хеслчеков
 func (a *App) Start(ctx context.Context) error {
 	go a.gracefulShutdown([]os.Signal{syscall.SIGABRT, syscall.SIGQUIT, syscall.SIGHUP, os.Interrupt, syscall.SIGTERM})
 
 	go a.startPprof()
 
+	go a.keyRing.StartRotation(ctx)
+
 	return a.startHttpServer()
 }
 
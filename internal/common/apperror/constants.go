@@ -3,22 +3,100 @@ package apperror
 import "github.com/pkg/errors"
 
 var (
-	ErrRefreshTokenNotFound = errors.New("refresh token not found")
-	ErrUserNotFound         = errors.New("user not found")
-	ErrUserIsExistWithEmail = errors.New("user with this email exists")
-	ErrMalformedToken       = errors.New("malformed token")
-	ErrInvalidSigningMethod = errors.New("invalid signing method")
-	ErrTokenIsInspired      = errors.New("token has been inspired")
-	ErrEmptyName            = errors.New("field 'name' is empty")
-	ErrEmptySurname         = errors.New("field 'surname' is empty")
-	ErrEmptyEmail           = errors.New("field 'email' is empty")
-	ErrInvalidEmailFormat   = errors.New("invalid email format")
-	ErrEmptyPassword        = errors.New("field 'password' is empty")
-	ErrAllFieldAreEmpty     = errors.New("all fields are empty")
-	ErrInvalidParamSort     = errors.New("invalid param 'sort'")
-	ErrInvalidParamOrder    = errors.New("invalid param 'order'")
-	ErrInvalidParamRole     = errors.New("invalid param 'role'")
-	ErrInvalidRoleType      = errors.New("invalid role type")
+	ErrRefreshTokenNotFound           = errors.New("refresh token not found")
+	ErrRefreshTokenReused             = errors.New("refresh token reuse detected, session revoked")
+	ErrUserNotFound                   = errors.New("user not found")
+	ErrUserIsExistWithEmail           = errors.New("user with this email exists")
+	ErrMalformedToken                 = errors.New("malformed token")
+	ErrInvalidSigningMethod           = errors.New("invalid signing method")
+	ErrUnknownKeyID                   = errors.New("unknown signing key id")
+	ErrKeyNotFound                    = errors.New("signing key not found")
+	ErrActiveKeyNotFound              = errors.New("active signing key not found")
+	ErrTokenIsInspired                = errors.New("token has been inspired")
+	ErrTokenRevoked                   = errors.New("token has been revoked")
+	ErrEmptyToken                     = errors.New("field 'token' is empty")
+	ErrUnsupportedTokenType           = errors.New("unsupported token type")
+	ErrInvalidClient                  = errors.New("invalid client credentials")
+	ErrEmptyName                      = errors.New("field 'name' is empty")
+	ErrEmptySurname                   = errors.New("field 'surname' is empty")
+	ErrEmptyEmail                     = errors.New("field 'email' is empty")
+	ErrInvalidEmailFormat             = errors.New("invalid email format")
+	ErrEmptyPassword                  = errors.New("field 'password' is empty")
+	ErrAllFieldAreEmpty               = errors.New("all fields are empty")
+	ErrInvalidParamSort               = errors.New("invalid param 'sort'")
+	ErrInvalidParamOrder              = errors.New("invalid param 'order'")
+	ErrInvalidParamRole               = errors.New("invalid param 'role'")
+	ErrInvalidRoleType                = errors.New("invalid role type")
+	ErrDeviceNameTooLong              = errors.New("field 'deviceName' is too long")
+	ErrSessionNotFound                = errors.New("session not found")
+	ErrServiceAccountNotFound         = errors.New("service account not found")
+	ErrEmptyScope                     = errors.New("scope is empty")
+	ErrInvalidScope                   = errors.New("invalid scope")
+	ErrScopeNotAllowed                = errors.New("requested scope is not allowed")
+	ErrEmptyGrantType                 = errors.New("field 'grant_type' is empty")
+	ErrUnsupportedGrantType           = errors.New("unsupported grant type")
+	ErrOAuthClientNotFound            = errors.New("oauth client not found")
+	ErrEmptyRedirectURIs              = errors.New("field 'redirectUris' is empty")
+	ErrInvalidRedirectURI             = errors.New("invalid redirect uri")
+	ErrEmptyGrantTypes                = errors.New("field 'grantTypes' is empty")
+	ErrPublicClientSecret             = errors.New("public client has no secret")
+	ErrUnauthorizedClient             = errors.New("client is not allowed to use this grant type")
+	ErrUnsupportedResponseType        = errors.New("unsupported response type")
+	ErrInvalidCodeChallenge           = errors.New("invalid or missing code_challenge")
+	ErrUnsupportedCodeChallengeMethod = errors.New("code_challenge_method must be S256")
+	ErrEmptyCode                      = errors.New("field 'code' is empty")
+	ErrEmptyRefreshToken              = errors.New("field 'refresh_token' is empty")
+	ErrInvalidCodeVerifier            = errors.New("invalid code_verifier")
+	ErrInvalidAuthorizationCode       = errors.New("authorization code is invalid or expired")
+	ErrRedirectURIMismatch            = errors.New("redirect_uri does not match authorization request")
+	ErrInvalidOwnerID                 = errors.New("invalid field 'ownerId'")
+	ErrNonceTooLong                   = errors.New("field 'nonce' is too long")
+	ErrInsufficientScope              = errors.New("access token does not have required scope")
+	ErrProviderNotFound               = errors.New("identity provider not found")
+	ErrInvalidFederatedState          = errors.New("federated login state is invalid or expired")
+	ErrFederatedEmailRequired         = errors.New("identity provider did not return email")
+	ErrFederatedEmailNotVerified      = errors.New("account with this email exists, but provider did not verify email")
+	ErrFederatedAccountNotVerified    = errors.New("account with this email exists, but its email is not verified")
+	ErrUserIdentityNotFound           = errors.New("user identity not found")
+	ErrUserIdentityExists             = errors.New("user already has identity of this provider")
+	ErrFederatedLoginFailed           = errors.New("identity provider login failed")
+	ErrPersonalTokenNotFound          = errors.New("personal access token not found")
+	ErrInvalidPersonalToken           = errors.New("personal access token is invalid or expired")
+	ErrEmptyScopes                    = errors.New("field 'scopes' is empty")
+	ErrInvalidExpiresInDays           = errors.New("invalid field 'expiresInDays'")
+	ErrPersonalTokenNotAllowed        = errors.New("personal access token is not allowed for this operation")
+	ErrPermissionDenied               = errors.New("permission denied")
+	ErrRoleNotFound                   = errors.New("role not found")
+	ErrRoleExists                     = errors.New("role with this name already exists")
+	ErrRoleInUse                      = errors.New("role is assigned to users")
+	ErrBuiltInRole                    = errors.New("built-in role cannot be deleted or change its level")
+	ErrInvalidRoleName                = errors.New("invalid field 'name'")
+	ErrInvalidPermission              = errors.New("invalid field 'permissions'")
+	ErrInvalidRoleDescription         = errors.New("invalid field 'description'")
+	ErrInvalidRoleLevel               = errors.New("invalid field 'level'")
+	ErrRoleEscalation                 = errors.New("caller's role does not outrank the affected role")
+	ErrLastSuperAdmin                 = errors.New("last super-admin cannot be demoted or deleted")
+	ErrEmptyRoleChangeReason          = errors.New("field 'reason' is required when role changes")
+	ErrReasonTooLong                  = errors.New("field 'reason' is too long")
+	ErrEmptyReason                    = errors.New("field 'reason' is empty")
+	ErrImpersonationNotAllowed        = errors.New("user cannot be impersonated by caller")
+	ErrInvalidAudience                = errors.New("token audience is not accepted")
+	ErrEmptySubjectToken              = errors.New("field 'subject_token' is empty")
+	ErrInvalidSubjectToken            = errors.New("subject token is invalid or expired")
+	ErrEmptyAudience                  = errors.New("field 'audience' is empty")
+	ErrInvalidTarget                  = errors.New("requested audience is not allowed")
+	ErrInvalidIssuer                  = errors.New("token issuer is not accepted")
+	ErrTokenNotYetValid               = errors.New("token is not valid yet")
+	ErrInvalidClientAudience          = errors.New("invalid field 'audiences'")
+	ErrPasswordPolicy                 = errors.New("password does not satisfy policy")
+	ErrInvalidPasswordResetToken      = errors.New("password reset token is invalid or expired")
+	ErrEmailNotVerified               = errors.New("email is not verified")
+	ErrInvalidEmailVerificationToken  = errors.New("email verification token is invalid or expired")
+	ErrInvalidCurrentPassword         = errors.New("current password is invalid")
+	ErrEmptyCurrentPassword           = errors.New("field 'currentPassword' is empty")
+	ErrEmptyNewPassword               = errors.New("field 'newPassword' is empty")
+	ErrAccountLocked                  = errors.New("account is temporarily locked after failed sign-in attempts")
+	ErrTooManySignInAttempts          = errors.New("too many failed sign-in attempts, retry later")
 
 	ErrRedisNil = errors.New("не найдена запись в редисе")
 )
@@ -29,4 +107,19 @@ const (
 	ErrType404 = "NOT_FOUND"
 	ErrType401 = "UNAUTHORIZED"
 	ErrType409 = "CONFLICT"
+	ErrType403 = "FORBIDDEN"
+	ErrType423 = "LOCKED"
+	ErrType429 = "TOO_MANY_REQUESTS"
+
+	// уточненные типы ошибки 401 для токенов, отклоненных по claims: клиенту важно отличать чужой токен от просроченного
+	ErrTypeInvalidAudience  = "INVALID_AUDIENCE"
+	ErrTypeInvalidIssuer    = "INVALID_ISSUER"
+	ErrTypeTokenNotYetValid = "TOKEN_NOT_YET_VALID"
+
+	// ErrTypeEmailNotVerified - тип ошибки 403 для входа с неподтвержденным email, клиент может предложить
+	// отправить письмо повторно
+	ErrTypeEmailNotVerified = "EMAIL_NOT_VERIFIED"
+
+	// ErrTypePasswordPolicy - тип ошибки 400 для пароля, нарушающего политику, коды правил передаются в details
+	ErrTypePasswordPolicy = "PASSWORD_POLICY_VIOLATION"
 )
@@ -3,6 +3,8 @@ package apperror
 import (
 	"github.com/pkg/errors"
 	"net/http"
+	"strings"
+	"time"
 )
 
 // AppError - структура ошибки приложения
@@ -10,6 +12,10 @@ type AppError struct {
 	Err        error
 	ErrType    string
 	StatusCode int
+	// Details - коды уточнений ошибки, например нарушенные правила политики паролей
+	Details []string
+	// RetryAfter - через сколько клиент может повторить запрос, отдается в заголовке Retry-After
+	RetryAfter time.Duration
 }
 
 // Error - вывод ошибки в строку
@@ -26,8 +32,60 @@ func NewAppErr(statusCode int, errType string, err error) *AppError {
 	}
 }
 
-// BadRequestError - ошибка c кодом 400
+// PasswordPolicyError - пароль нарушает политику, Violations - коды нарушенных правил
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func NewPasswordPolicyError(violations []string) *PasswordPolicyError {
+	return &PasswordPolicyError{
+		Violations: violations,
+	}
+}
+
+// Error - вывод ошибки в строку
+func (p *PasswordPolicyError) Error() string {
+	return ErrPasswordPolicy.Error() + ": " + strings.Join(p.Violations, ", ")
+}
+
+// Is - ошибка политики паролей сравнима с ErrPasswordPolicy
+func (p *PasswordPolicyError) Is(target error) bool {
+	return target == ErrPasswordPolicy
+}
+
+// SignInThrottledError - вход временно запрещен после неудачных попыток: Err - ErrAccountLocked для заблокированной
+// учетной записи или ErrTooManySignInAttempts, RetryAfter - время до следующей разрешенной попытки
+type SignInThrottledError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func NewSignInThrottledError(err error, retryAfter time.Duration) *SignInThrottledError {
+	return &SignInThrottledError{
+		Err:        err,
+		RetryAfter: retryAfter,
+	}
+}
+
+// Error - вывод ошибки в строку
+func (s *SignInThrottledError) Error() string {
+	return s.Err.Error()
+}
+
+// Unwrap - ошибка сравнима с ErrAccountLocked или ErrTooManySignInAttempts
+func (s *SignInThrottledError) Unwrap() error {
+	return s.Err
+}
+
+// BadRequestError - ошибка c кодом 400, нарушения политики паролей получают отдельный тип и коды правил
 func BadRequestError(err error) *AppError {
+	var policyErr *PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		appErr := NewAppErr(http.StatusBadRequest, ErrTypePasswordPolicy, err)
+		appErr.Details = policyErr.Violations
+		return appErr
+	}
+
 	return NewAppErr(http.StatusBadRequest, ErrType400, err)
 }
 
@@ -43,23 +101,79 @@ func ApplicationError(err error) *AppError {
 
 // InternalServerError - ошибка c кодом 500
 func InternalServerError(err error) *AppError {
-	if errors.Is(err, ErrUserNotFound) {
+	if errors.Is(err, ErrUserNotFound) || errors.Is(err, ErrKeyNotFound) || errors.Is(err, ErrSessionNotFound) ||
+		errors.Is(err, ErrServiceAccountNotFound) || errors.Is(err, ErrOAuthClientNotFound) || errors.Is(err, ErrProviderNotFound) ||
+		errors.Is(err, ErrPersonalTokenNotFound) || errors.Is(err, ErrRoleNotFound) {
 		return NotFoundError(err)
 	}
 
-	if errors.Is(err, ErrUserIsExistWithEmail) || errors.Is(err, ErrRefreshTokenNotFound) {
+	if errors.Is(err, ErrRefreshTokenReused) || errors.Is(err, ErrTokenRevoked) || errors.Is(err, ErrInvalidFederatedState) ||
+		errors.Is(err, ErrFederatedLoginFailed) || errors.Is(err, ErrFederatedEmailRequired) {
+		return UnauthorizedError(err)
+	}
+
+	if errors.Is(err, ErrUserIsExistWithEmail) || errors.Is(err, ErrRefreshTokenNotFound) ||
+		errors.Is(err, ErrFederatedEmailNotVerified) || errors.Is(err, ErrFederatedAccountNotVerified) ||
+		errors.Is(err, ErrUserIdentityExists) || errors.Is(err, ErrRoleExists) ||
+		errors.Is(err, ErrRoleInUse) || errors.Is(err, ErrBuiltInRole) {
 		return ConflictError(err)
 	}
 
+	if errors.Is(err, ErrPasswordPolicy) || errors.Is(err, ErrInvalidPasswordResetToken) ||
+		errors.Is(err, ErrInvalidEmailVerificationToken) {
+		return BadRequestError(err)
+	}
+
+	if errors.Is(err, ErrRoleEscalation) || errors.Is(err, ErrLastSuperAdmin) || errors.Is(err, ErrImpersonationNotAllowed) ||
+		errors.Is(err, ErrEmailNotVerified) || errors.Is(err, ErrInvalidCurrentPassword) {
+		return ForbiddenError(err)
+	}
+
+	var throttledErr *SignInThrottledError
+	if errors.As(err, &throttledErr) {
+		return SignInThrottledAppError(err, throttledErr.RetryAfter)
+	}
+
 	return NewAppErr(http.StatusInternalServerError, ErrType500, err)
 
 }
 
-// UnauthorizedError - ошибка c кодом 401
+// UnauthorizedError - ошибка c кодом 401, токены с чужой аудиторией, чужим издателем или еще не действующие
+// получают отдельный тип ошибки
 func UnauthorizedError(err error) *AppError {
+	switch {
+	case errors.Is(err, ErrInvalidAudience):
+		return NewAppErr(http.StatusUnauthorized, ErrTypeInvalidAudience, err)
+	case errors.Is(err, ErrInvalidIssuer):
+		return NewAppErr(http.StatusUnauthorized, ErrTypeInvalidIssuer, err)
+	case errors.Is(err, ErrTokenNotYetValid):
+		return NewAppErr(http.StatusUnauthorized, ErrTypeTokenNotYetValid, err)
+	}
+
 	return NewAppErr(http.StatusUnauthorized, ErrType401, err)
 }
 
+// ForbiddenError - ошибка c кодом 403, вход с неподтвержденным email получает отдельный тип ошибки
+func ForbiddenError(err error) *AppError {
+	if errors.Is(err, ErrEmailNotVerified) {
+		return NewAppErr(http.StatusForbidden, ErrTypeEmailNotVerified, err)
+	}
+
+	return NewAppErr(http.StatusForbidden, ErrType403, err)
+}
+
+// SignInThrottledAppError - ошибка c кодом 423 для заблокированной учетной записи или 429 для остальных
+// ограничений входа, клиент получает время до следующей попытки
+func SignInThrottledAppError(err error, retryAfter time.Duration) *AppError {
+	appErr := NewAppErr(http.StatusTooManyRequests, ErrType429, err)
+	if errors.Is(err, ErrAccountLocked) {
+		appErr = NewAppErr(http.StatusLocked, ErrType423, err)
+	}
+	appErr.RetryAfter = retryAfter
+
+	return appErr
+}
+
 // ConflictError - ошибка c кодом 409
 func ConflictError(err error) *AppError {
 	return NewAppErr(http.StatusConflict, ErrType409, err)
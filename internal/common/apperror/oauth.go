@@ -0,0 +1,56 @@
+package apperror
+
+import "net/http"
+
+// Коды ошибок oauth-эндпоинтов (RFC 6749, раздел 5.2)
+const (
+	OAuthInvalidRequest       = "invalid_request"
+	OAuthInvalidClient        = "invalid_client"
+	OAuthInvalidGrant         = "invalid_grant"
+	OAuthUnauthorizedClient   = "unauthorized_client"
+	OAuthUnsupportedGrantType = "unsupported_grant_type"
+	OAuthInvalidScope         = "invalid_scope"
+	// OAuthInvalidTarget - запрошенная аудитория недопустима (RFC 8693, раздел 2.2.2)
+	OAuthInvalidTarget = "invalid_target"
+	// OAuthUnsupportedResponseType - код ошибки авторизационного эндпоинта (RFC 6749, раздел 4.1.2.1)
+	OAuthUnsupportedResponseType = "unsupported_response_type"
+	OAuthServerError             = "server_error"
+	// OAuthInsufficientScope - код ошибки защищенного ресурса (RFC 6750, раздел 3.1)
+	OAuthInsufficientScope = "insufficient_scope"
+)
+
+// OAuthError - ошибка oauth-эндпоинтов, отдается клиенту в формате RFC 6749
+type OAuthError struct {
+	Err        error
+	Code       string
+	StatusCode int
+}
+
+// Error - вывод ошибки в строку
+func (o *OAuthError) Error() string {
+	return o.Err.Error()
+}
+
+// NewOAuthError - создание oauth-ошибки
+func NewOAuthError(statusCode int, code string, err error) *OAuthError {
+	return &OAuthError{
+		Err:        err,
+		Code:       code,
+		StatusCode: statusCode,
+	}
+}
+
+// OAuthBadRequestError - oauth-ошибка c кодом 400
+func OAuthBadRequestError(code string, err error) *OAuthError {
+	return NewOAuthError(http.StatusBadRequest, code, err)
+}
+
+// OAuthInvalidClientError - ошибка аутентификации клиента c кодом 401
+func OAuthInvalidClientError(err error) *OAuthError {
+	return NewOAuthError(http.StatusUnauthorized, OAuthInvalidClient, err)
+}
+
+// OAuthInternalServerError - oauth-ошибка c кодом 500
+func OAuthInternalServerError(err error) *OAuthError {
+	return NewOAuthError(http.StatusInternalServerError, OAuthServerError, err)
+}
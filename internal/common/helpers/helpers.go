@@ -1,13 +1,18 @@
 package helpers
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
-	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"net"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 )
@@ -88,10 +93,142 @@ func GetLimitAndOffset(r *http.Request, keyOffset, keyLimit string) (int, int, e
 	return offset, limit, nil
 }
 
-// GeneratePasswordHash - генерация хэша пароля
-func GeneratePasswordHash(password string) string {
+// GetClientInfo - получение ip-адреса и user-agent клиента
+func GetClientInfo(r *http.Request) entity.ClientInfo {
+	return entity.ClientInfo{
+		IP:        GetClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+}
+
+// GetClientIP - получение ip-адреса клиента. Адрес за доверенными прокси подставляется в RemoteAddr мидлваре RealIP
+func GetClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// RealIP - мидлваре подстановки ip-адреса клиента из X-Forwarded-For и X-Real-IP. Заголовки учитываются, только если
+// запрос пришел от доверенного прокси, иначе клиент мог бы подменить свой адрес. В X-Forwarded-For клиентом считается
+// крайний справа недоверенный адрес: левее него значения мог дописать сам клиент
+func RealIP(trustedProxies []string) func(http.Handler) http.Handler {
+	trusted := make([]*net.IPNet, 0, len(trustedProxies))
+	for _, proxy := range trustedProxies {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(proxy))
+		if err == nil {
+			trusted = append(trusted, network)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(trusted) > 0 && isTrustedProxy(trusted, GetClientIP(r)) {
+				if ip := forwardedClientIP(trusted, r); ip != "" {
+					r.RemoteAddr = ip
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClientIP - ip-адрес клиента из заголовков доверенного прокси
+func forwardedClientIP(trusted []*net.IPNet, r *http.Request) string {
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		client := ""
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			client = hop
+			if !isTrustedProxy(trusted, hop) {
+				break
+			}
+		}
+		return client
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+
+	return ""
+}
+
+// isTrustedProxy - проверка вхождения адреса в доверенные сети прокси
+func isTrustedProxy(trusted []*net.IPNet, addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetClientCredentials - получение учетных данных клиента из basic-авторизации,
+// либо из полей client_id и client_secret формы (RFC 6749, раздел 2.3.1)
+func GetClientCredentials(r *http.Request) (string, string, bool) {
+	if clientID, clientSecret, ok := r.BasicAuth(); ok {
+		id, errID := url.QueryUnescape(clientID)
+		secret, errSecret := url.QueryUnescape(clientSecret)
+		if errID != nil || errSecret != nil {
+			return "", "", false
+		}
+		return id, secret, id != ""
+	}
+
+	clientID := r.PostFormValue("client_id")
+	clientSecret := r.PostFormValue("client_secret")
+	return clientID, clientSecret, clientID != "" && clientSecret != ""
+}
+
+// GenerateLegacyPasswordHash - хэш пароля в устаревшем формате sha256 с общей солью, нужен только для проверки
+// паролей, которые еще не пересчитаны в современный формат
+func GenerateLegacyPasswordHash(password, salt string) string {
 	hash := sha256.New()
 	hash.Write([]byte(password))
 
-	return fmt.Sprintf("%x", hash.Sum([]byte(config.PasswordSalt)))
+	return fmt.Sprintf("%x", hash.Sum([]byte(salt)))
+}
+
+// GenerateSecret - генерация случайного секрета для клиентов и токенов
+func GenerateSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", errors.Wrap(err, "rand.Read")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// HashSecret - хэш случайного секрета. Секрет имеет высокую энтропию, поэтому соль и медленный хэш не нужны
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// AddQueryParam - url с добавленным параметром запроса, остальные параметры сохраняются
+func AddQueryParam(rawURL, key, value string) (string, error) {
+	link, err := url.Parse(rawURL)
+	if err != nil {
+		return "", errors.Wrap(err, "url.Parse")
+	}
+
+	query := link.Query()
+	query.Set(key, value)
+	link.RawQuery = query.Encode()
+
+	return link.String(), nil
 }
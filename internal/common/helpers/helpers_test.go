@@ -0,0 +1,113 @@
+package helpers
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	trustedProxies := []string{"10.0.0.0/8", " 192.168.1.1/32"}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string][]string
+		want       string
+	}{
+		{
+			name:       "direct client",
+			remoteAddr: "203.0.113.7:5000",
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "untrusted peer spoofs forwarded for",
+			remoteAddr: "203.0.113.7:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "untrusted peer spoofs real ip",
+			remoteAddr: "203.0.113.7:5000",
+			headers:    map[string][]string{"X-Real-Ip": {"198.51.100.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "trusted proxy",
+			remoteAddr: "10.0.0.2:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "client prepends spoofed hop",
+			remoteAddr: "10.0.0.2:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.2.3.4, 198.51.100.1"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "chain of trusted proxies",
+			remoteAddr: "10.0.0.2:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.2.3.4, 198.51.100.1, 192.168.1.1", "10.0.0.3"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "only trusted hops",
+			remoteAddr: "10.0.0.2:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.0.0.4, 10.0.0.3"}},
+			want:       "10.0.0.4",
+		},
+		{
+			name:       "garbage hop stops walk",
+			remoteAddr: "10.0.0.2:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1, unknown, 10.0.0.3"}},
+			want:       "10.0.0.3",
+		},
+		{
+			name:       "real ip from trusted proxy",
+			remoteAddr: "192.168.1.1:5000",
+			headers:    map[string][]string{"X-Real-Ip": {"198.51.100.1"}},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "invalid real ip ignored",
+			remoteAddr: "10.0.0.2:5000",
+			headers:    map[string][]string{"X-Real-Ip": {"not-an-ip"}},
+			want:       "10.0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := RealIP(trustedProxies)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = GetClientIP(r)
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for key, values := range tt.headers {
+				for _, value := range values {
+					r.Header.Add(key, value)
+				}
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRealIPWithoutTrustedProxies(t *testing.T) {
+	var got string
+	handler := RealIP(nil)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = GetClientIP(r)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.2:5000"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, "10.0.0.2", got)
+}
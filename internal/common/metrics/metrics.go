@@ -10,6 +10,8 @@ import (
 
 type (
 	Status                   string
+	SignInScope              string
+	SignInRejectReason       string
 	DbRequestType            string
 	Validation               string
 	IntegrationServiceName   string
@@ -20,22 +22,105 @@ const (
 	OkStatus   Status = "ok"
 	FailStatus Status = "fail"
 
+	SignInScopeEmail SignInScope = "email"
+	SignInScopeIP    SignInScope = "ip"
+	SignInScopeUser  SignInScope = "user"
+
+	SignInRejectLocked    SignInRejectReason = "locked"
+	SignInRejectThrottled SignInRejectReason = "throttled"
+
 	Postgres DbRequestType = "postgres"
 	Cache    DbRequestType = "cache"
 
-	CreateUserDb                DbRequestType = "CreateUserDb"
-	GetUserByIDDb               DbRequestType = "GetUserByID"
-	GetUserByEmailAndPasswordDb DbRequestType = "GetUserByEmailAndPassword"
-	DeleteUserByIDDb            DbRequestType = "DeleteUserByID"
-	UpdateUserByIDDb            DbRequestType = "UpdateUserByID"
-	GetUsersDb                  DbRequestType = "GetUsers"
-	UpdatePrivateUserByIDDb     DbRequestType = "UpdatePrivateUserByID"
+	CreateUserDb             DbRequestType = "CreateUserDb"
+	GetUserByIDDb            DbRequestType = "GetUserByID"
+	UpdateUserPasswordHashDb DbRequestType = "UpdateUserPasswordHash"
+	DeleteUserByIDDb         DbRequestType = "DeleteUserByID"
+	UpdateUserByIDDb         DbRequestType = "UpdateUserByID"
+	GetUsersDb               DbRequestType = "GetUsers"
+	UpdatePrivateUserByIDDb  DbRequestType = "UpdatePrivateUserByID"
+	GetPasswordHistoryDb     DbRequestType = "GetPasswordHistory"
+	VerifyEmailDb            DbRequestType = "VerifyEmail"
+	CreateKeyDb              DbRequestType = "CreateKey"
+	GetKeysDb                DbRequestType = "GetKeys"
+	RotateKeysDb             DbRequestType = "RotateKeys"
+	RetireKeyDb              DbRequestType = "RetireKey"
+
+	CreateServiceAccountDb       DbRequestType = "CreateServiceAccount"
+	GetServiceAccountByIDDb      DbRequestType = "GetServiceAccountByID"
+	GetServiceAccountsDb         DbRequestType = "GetServiceAccounts"
+	UpdateServiceAccountDb       DbRequestType = "UpdateServiceAccount"
+	UpdateServiceAccountSecretDb DbRequestType = "UpdateServiceAccountSecret"
+	DeleteServiceAccountByIDDb   DbRequestType = "DeleteServiceAccountByID"
+
+	CreateOAuthClientDb       DbRequestType = "CreateOAuthClient"
+	GetOAuthClientByIDDb      DbRequestType = "GetOAuthClientByID"
+	GetOAuthClientsDb         DbRequestType = "GetOAuthClients"
+	UpdateOAuthClientDb       DbRequestType = "UpdateOAuthClient"
+	UpdateOAuthClientSecretDb DbRequestType = "UpdateOAuthClientSecret"
+	DeleteOAuthClientByIDDb   DbRequestType = "DeleteOAuthClientByID"
+
+	GetUserByEmailDb         DbRequestType = "GetUserByEmail"
+	GetUserByIdentityDb      DbRequestType = "GetUserByIdentity"
+	CreateUserIdentityDb     DbRequestType = "CreateUserIdentity"
+	CreateUserWithIdentityDb DbRequestType = "CreateUserWithIdentity"
+
+	CreatePersonalTokenDb         DbRequestType = "CreatePersonalToken"
+	GetPersonalTokensDb           DbRequestType = "GetPersonalTokens"
+	GetPersonalTokenByIDDb        DbRequestType = "GetPersonalTokenByID"
+	GetPersonalTokenByHashDb      DbRequestType = "GetPersonalTokenByHash"
+	UpdatePersonalTokenDb         DbRequestType = "UpdatePersonalToken"
+	UpdatePersonalTokenLastUsedDb DbRequestType = "UpdatePersonalTokenLastUsed"
+	DeletePersonalTokenDb         DbRequestType = "DeletePersonalToken"
+
+	CreateRoleDb    DbRequestType = "CreateRole"
+	GetRolesDb      DbRequestType = "GetRoles"
+	GetRoleByNameDb DbRequestType = "GetRoleByName"
+	UpdateRoleDb    DbRequestType = "UpdateRole"
+	DeleteRoleDb    DbRequestType = "DeleteRole"
+
+	CreateImpersonationDb DbRequestType = "CreateImpersonation"
 
 	GetCache             DbRequestType = "Get"
 	GetUserCache         DbRequestType = "GetUser"
 	DeleteCache          DbRequestType = "Delete"
 	SetUserCache         DbRequestType = "SetUser"
 	SetRefreshTokenCache DbRequestType = "SetRefreshToken"
+
+	RotateRefreshTokenCache     DbRequestType = "RotateRefreshToken"
+	GetRotatedRefreshTokenCache DbRequestType = "GetRotatedRefreshToken"
+	DeleteRefreshFamilyCache    DbRequestType = "DeleteRefreshFamily"
+
+	GetRefreshTokenCache            DbRequestType = "GetRefreshToken"
+	DeleteUserRefreshFamiliesCache  DbRequestType = "DeleteUserRefreshFamilies"
+	SetRevokedAccessTokenCache      DbRequestType = "SetRevokedAccessToken"
+	SetUserTokensRevokedBeforeCache DbRequestType = "SetUserTokensRevokedBefore"
+	GetAccessTokenRevocationCache   DbRequestType = "GetAccessTokenRevocation"
+
+	SetSessionCache      DbRequestType = "SetSession"
+	UpdateSessionCache   DbRequestType = "UpdateSession"
+	GetSessionCache      DbRequestType = "GetSession"
+	GetUserSessionsCache DbRequestType = "GetUserSessions"
+
+	SetAuthorizationCodeCache     DbRequestType = "SetAuthorizationCode"
+	ConsumeAuthorizationCodeCache DbRequestType = "ConsumeAuthorizationCode"
+
+	SetFederatedLoginStateCache     DbRequestType = "SetFederatedLoginState"
+	ConsumeFederatedLoginStateCache DbRequestType = "ConsumeFederatedLoginState"
+
+	SetPasswordResetCache     DbRequestType = "SetPasswordReset"
+	GetPasswordResetCache     DbRequestType = "GetPasswordReset"
+	ConsumePasswordResetCache DbRequestType = "ConsumePasswordReset"
+	SetPasswordResetSentCache DbRequestType = "SetPasswordResetSent"
+
+	SetEmailVerificationCache     DbRequestType = "SetEmailVerification"
+	ConsumeEmailVerificationCache DbRequestType = "ConsumeEmailVerification"
+	SetEmailVerificationSentCache DbRequestType = "SetEmailVerificationSent"
+
+	IncrSignInFailuresCache   DbRequestType = "IncrSignInFailures"
+	SetSignInLockCache        DbRequestType = "SetSignInLock"
+	GetSignInLocksCache       DbRequestType = "GetSignInLocks"
+	DeleteSignInFailuresCache DbRequestType = "DeleteSignInFailures"
 )
 
 var (
@@ -62,6 +147,30 @@ var (
 		Namespace: config.Namespace,
 		Help:      "duration postgresql requests for a single method",
 	}, []string{"database", "method"})
+
+	signInFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:      "total_sign_in_failures",
+		Namespace: config.Namespace,
+		Help:      "Number of failed sign-in attempts counted per email and per client ip",
+	}, []string{"scope"})
+
+	signInLockoutsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:      "total_sign_in_lockouts",
+		Namespace: config.Namespace,
+		Help:      "Number of sign-in lockouts of accounts and client ips",
+	}, []string{"scope"})
+
+	signInRejectedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name:      "total_sign_in_rejected",
+		Namespace: config.Namespace,
+		Help:      "Number of sign-in attempts rejected by lockout or progressive delay",
+	}, []string{"reason"})
+
+	signInUnlocksTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name:      "total_sign_in_unlocks",
+		Namespace: config.Namespace,
+		Help:      "Number of accounts unlocked by administrators",
+	})
 )
 
 // IncRequestTotal - метод для увеличения счетчика запросов к сервису тегов
@@ -91,3 +200,23 @@ func ObserveRequestDurationPerMethodDB(db, method DbRequestType) func() {
 		requestsPsqlDurationsPerMethod.With(prometheus.Labels{"database": string(db), "method": string(method)}).Observe(duration)
 	}
 }
+
+// IncSignInFailures - метод для увеличения счетчика неудачных попыток входа
+func IncSignInFailures(scope SignInScope) {
+	signInFailuresTotal.With(prometheus.Labels{"scope": string(scope)}).Inc()
+}
+
+// IncSignInLockouts - метод для увеличения счетчика блокировок входа
+func IncSignInLockouts(scope SignInScope) {
+	signInLockoutsTotal.With(prometheus.Labels{"scope": string(scope)}).Inc()
+}
+
+// IncSignInRejected - метод для увеличения счетчика попыток входа, отклоненных до проверки пароля
+func IncSignInRejected(reason SignInRejectReason) {
+	signInRejectedTotal.With(prometheus.Labels{"reason": string(reason)}).Inc()
+}
+
+// IncSignInUnlocks - метод для увеличения счетчика снятых администратором блокировок
+func IncSignInUnlocks() {
+	signInUnlocksTotal.Inc()
+}
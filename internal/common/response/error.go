@@ -4,15 +4,34 @@ import (
 	"github.com/GermanBogatov/auth-service/internal/common/apperror"
 	"github.com/GermanBogatov/auth-service/pkg/logging"
 	"github.com/pkg/errors"
+	"math"
 	"net/http"
+	"strconv"
 )
 
 // The RespondError function helps to convert the business error to standardized JSON response
 func RespondError(w http.ResponseWriter, r *http.Request, err error) {
 	w.Header().Set("Content-Type", "application/json")
+
+	var oauthErr *apperror.OAuthError
+	if errors.As(err, &oauthErr) {
+		logging.Errorf("code [%d] method [%s] path [%s]: %v", oauthErr.StatusCode, r.Method, r.URL.Path, err)
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(oauthErr.StatusCode)
+		_, errWrite := w.Write(NewOAuthErrorResponse(oauthErr).Marshal())
+		if errWrite != nil {
+			logging.Errorf("error write response: %s", errWrite)
+		}
+
+		return
+	}
+
 	var appErr *apperror.AppError
 	if errors.As(err, &appErr) {
 		logging.Errorf("code [%d] method [%s] path [%s]: %v", appErr.StatusCode, r.Method, r.URL.Path, err)
+		if appErr.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+		}
 		w.WriteHeader(appErr.StatusCode)
 		_, errWrite := w.Write(NewAppErrorResponse(appErr).Marshal())
 		if errWrite != nil {
@@ -36,5 +55,19 @@ func NewAppErrorResponse(err *apperror.AppError) *ViewResponse {
 		Code:      err.StatusCode,
 		Error:     err.Error(),
 		ErrorType: err.ErrType,
+		Details:   err.Details,
+	}
+}
+
+// NewOAuthErrorResponse - json ответ с oauth-ошибкой, детали внутренних ошибок клиенту не отдаются
+func NewOAuthErrorResponse(err *apperror.OAuthError) *OAuthErrorResponse {
+	description := err.Error()
+	if err.StatusCode >= http.StatusInternalServerError {
+		description = http.StatusText(err.StatusCode)
+	}
+
+	return &OAuthErrorResponse{
+		Error:            err.Code,
+		ErrorDescription: description,
 	}
 }
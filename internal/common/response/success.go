@@ -1,6 +1,7 @@
 package response
 
 import (
+	"encoding/json"
 	"github.com/GermanBogatov/auth-service/pkg/logging"
 	"net/http"
 )
@@ -17,6 +18,18 @@ func RespondSuccessCreate(w http.ResponseWriter, resp ViewResponse) error {
 	return nil
 }
 
+// RespondSuccessAccepted - метод по возврату ответа о принятом запросе, результат которого клиенту не сообщается
+func RespondSuccessAccepted(w http.ResponseWriter, resp ViewResponse) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_, err := w.Write(resp.Marshal())
+	if err != nil {
+		logging.Errorf("error write response: %s", err)
+	}
+
+	return nil
+}
+
 // RespondSuccess - метод по возврату успешного ответа
 func RespondSuccess(w http.ResponseWriter, resp ViewResponse) error {
 	w.Header().Set("Content-Type", "application/json")
@@ -28,3 +41,20 @@ func RespondSuccess(w http.ResponseWriter, resp ViewResponse) error {
 
 	return nil
 }
+
+// RespondJSON - метод по возврату произвольной json-структуры (для ответов по стандартам OAuth2/OIDC/JWKS)
+func RespondJSON(w http.ResponseWriter, code int, resp any) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, err = w.Write(data)
+	if err != nil {
+		logging.Errorf("error write response: %s", err)
+	}
+
+	return nil
+}
@@ -9,6 +9,7 @@ type ViewResponse struct {
 	Result    interface{} `json:"result"`
 	Error     string      `json:"error"`
 	ErrorType string      `json:"errorType"`
+	Details   []string    `json:"details,omitempty"`
 	Code      int         `json:"code"`
 }
 
@@ -20,3 +21,18 @@ func (r *ViewResponse) Marshal() []byte {
 	}
 	return bytes
 }
+
+// OAuthErrorResponse - структура ответа с ошибкой oauth-эндпоинтов (RFC 6749, раздел 5.2)
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// Marshal - маршалинг oauth-ошибки
+func (r *OAuthErrorResponse) Marshal() []byte {
+	bytes, err := json.Marshal(r)
+	if err != nil {
+		return nil
+	}
+	return bytes
+}
@@ -1,12 +1,21 @@
 package config
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/ilyakaznacheev/cleanenv"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
 )
 
 var Namespace = "user_service"
 
+var providerNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
 type Health struct {
 	CheckIntervalSec int `env:"USER_SERVICE_HEALTH_CHECK_INTERVAL" env-required:"10"`
 }
@@ -33,6 +42,8 @@ type Http struct {
 	Port         string `env:"USER_SERVICE_HTTP_PORT" env-required:"true"`
 	WriteTimeout int    `env:"USER_SERVICE_HTTP_WRITE_TIMEOUT_SEC" env-default:"60"`
 	ReadTimeout  int    `env:"USER_SERVICE_HTTP_READ_TIMEOUT_SEC" env-default:"60"`
+	// TrustedProxies - сети прокси в нотации CIDR, от которых принимаются X-Forwarded-For и X-Real-IP
+	TrustedProxies []string `env:"USER_SERVICE_HTTP_TRUSTED_PROXIES" env-separator:","`
 }
 
 type Tracer struct {
@@ -41,6 +52,176 @@ type Tracer struct {
 	TraceRatioFraction float64 `env:"TRACE_RATIO_FRACTION" env-default:"1.0"`
 }
 
+type Jwt struct {
+	Algorithm             string `env:"USER_SERVICE_JWT_ALGORITHM" env-default:"RS256"`
+	RotationIntervalHour  int    `env:"USER_SERVICE_JWT_ROTATION_INTERVAL_HOUR" env-default:"720"`
+	RetiredGraceHour      int    `env:"USER_SERVICE_JWT_RETIRED_GRACE_HOUR" env-default:"24"`
+	KeyRefreshIntervalSec int    `env:"USER_SERVICE_JWT_KEY_REFRESH_INTERVAL_SEC" env-default:"60"`
+	RefreshReuseGraceSec  int    `env:"USER_SERVICE_JWT_REFRESH_REUSE_GRACE_SEC" env-default:"10"`
+	// Issuer - iss access-токенов, по умолчанию совпадает с oidc.Issuer
+	Issuer string `env:"USER_SERVICE_JWT_ISSUER"`
+	// Audience - аудитория access-токенов, которые принимает api самого сервиса
+	Audience string `env:"USER_SERVICE_JWT_AUDIENCE" env-default:"users"`
+	// LeewaySec - допустимое расхождение часов при проверке exp, nbf и iat
+	LeewaySec int `env:"USER_SERVICE_JWT_LEEWAY_SEC" env-default:"30"`
+}
+
+type Password struct {
+	// Algorithm - алгоритм хэширования паролей: argon2id или bcrypt, хэши прежним алгоритмом пересчитываются при входе
+	Algorithm string `env:"USER_SERVICE_PASSWORD_ALGORITHM" env-default:"argon2id"`
+	// Argon2MemoryKiB, Argon2Iterations, Argon2Parallelism - параметры argon2id, по умолчанию рекомендация OWASP
+	Argon2MemoryKiB   int `env:"USER_SERVICE_PASSWORD_ARGON2_MEMORY_KIB" env-default:"19456"`
+	Argon2Iterations  int `env:"USER_SERVICE_PASSWORD_ARGON2_ITERATIONS" env-default:"2"`
+	Argon2Parallelism int `env:"USER_SERVICE_PASSWORD_ARGON2_PARALLELISM" env-default:"1"`
+	// BcryptCost - стоимость bcrypt
+	BcryptCost int `env:"USER_SERVICE_PASSWORD_BCRYPT_COST" env-default:"12"`
+
+	// MinLength - минимальная длина пароля в символах
+	MinLength int `env:"USER_SERVICE_PASSWORD_MIN_LENGTH" env-default:"8"`
+	// MaxLength - максимальная длина пароля в байтах utf-8, bcrypt учитывает не больше 72 байт
+	MaxLength int `env:"USER_SERVICE_PASSWORD_MAX_LENGTH" env-default:"128"`
+	// RequiredClasses - обязательные классы символов через запятую: lower, upper, digit, symbol
+	RequiredClasses []string `env:"USER_SERVICE_PASSWORD_REQUIRED_CLASSES" env-separator:","`
+	// ForbiddenSubstrings - подстроки, запрещенные в пароле без учета регистра, через запятую
+	ForbiddenSubstrings []string `env:"USER_SERVICE_PASSWORD_FORBIDDEN_SUBSTRINGS" env-separator:","`
+	// BreachedFile - файл со списком sha1 утекших паролей, отсортированным по хэшу, пустой - проверка отключена
+	BreachedFile string `env:"USER_SERVICE_PASSWORD_BREACHED_FILE"`
+	// HistorySize - сколько предыдущих паролей нельзя использовать повторно, 0 - история не ведется
+	HistorySize int `env:"USER_SERVICE_PASSWORD_HISTORY_SIZE" env-default:"5"`
+}
+
+type PasswordReset struct {
+	// TokenTTLSec - время жизни токена сброса пароля
+	TokenTTLSec int `env:"USER_SERVICE_PASSWORD_RESET_TOKEN_TTL_SEC" env-default:"900"`
+	// ResendIntervalSec - минимальный интервал между письмами сброса пароля одному пользователю
+	ResendIntervalSec int `env:"USER_SERVICE_PASSWORD_RESET_RESEND_INTERVAL_SEC" env-default:"60"`
+	// LinkURL - страница смены пароля во фронтенде, токен добавляется параметром token. Пустой - в письмо
+	// попадает только токен
+	LinkURL string `env:"USER_SERVICE_PASSWORD_RESET_LINK_URL"`
+}
+
+type EmailVerification struct {
+	// Mode - что делать с неподтвержденным email: claim - выдавать токены с claim email_verified=false,
+	// block - не пускать пользователя до подтверждения
+	Mode string `env:"USER_SERVICE_EMAIL_VERIFICATION_MODE" env-default:"claim"`
+	// TokenTTLSec - время жизни токена подтверждения
+	TokenTTLSec int `env:"USER_SERVICE_EMAIL_VERIFICATION_TOKEN_TTL_SEC" env-default:"86400"`
+	// ResendIntervalSec - минимальный интервал между письмами подтверждения одному пользователю
+	ResendIntervalSec int `env:"USER_SERVICE_EMAIL_VERIFICATION_RESEND_INTERVAL_SEC" env-default:"60"`
+	// LinkURL - страница подтверждения во фронтенде, токен добавляется параметром token. Пустой - ссылка ведет
+	// прямо на GET /public/v1/auth/verify-email сервиса
+	LinkURL string `env:"USER_SERVICE_EMAIL_VERIFICATION_LINK_URL"`
+}
+
+type SignInGuard struct {
+	// FailureWindowSec - окно подсчета неудачных попыток входа, счетчик сбрасывается через это время после первой ошибки
+	FailureWindowSec int `env:"USER_SERVICE_SIGN_IN_FAILURE_WINDOW_SEC" env-default:"900"`
+	// DelayThreshold - после стольких ошибок входа в учетную запись каждая следующая попытка ждет задержку,
+	// задержка удваивается с каждой ошибкой от BaseDelaySec до MaxDelaySec
+	DelayThreshold int `env:"USER_SERVICE_SIGN_IN_DELAY_THRESHOLD" env-default:"3"`
+	BaseDelaySec   int `env:"USER_SERVICE_SIGN_IN_BASE_DELAY_SEC" env-default:"1"`
+	MaxDelaySec    int `env:"USER_SERVICE_SIGN_IN_MAX_DELAY_SEC" env-default:"60"`
+	// LockoutThreshold - после стольких ошибок входа учетная запись блокируется на LockoutDurationSec
+	LockoutThreshold int `env:"USER_SERVICE_SIGN_IN_LOCKOUT_THRESHOLD" env-default:"10"`
+	// IPLockoutThreshold - после стольких ошибок входа с одного ip вход с него блокируется на LockoutDurationSec,
+	// порог выше, чем для учетной записи: за одним ip может быть много пользователей
+	IPLockoutThreshold int `env:"USER_SERVICE_SIGN_IN_IP_LOCKOUT_THRESHOLD" env-default:"100"`
+	LockoutDurationSec int `env:"USER_SERVICE_SIGN_IN_LOCKOUT_DURATION_SEC" env-default:"900"`
+}
+
+type Mailer struct {
+	// Driver - доставка писем: smtp, file (файл FilePath) или log (лог сервиса), file и log - только для dev-окружения
+	Driver string `env:"USER_SERVICE_MAILER_DRIVER" env-default:"log"`
+	// From - адрес отправителя
+	From string `env:"USER_SERVICE_MAILER_FROM" env-default:"no-reply@localhost"`
+	// SMTPHost, SMTPPort, SMTPUsername - SMTP-сервер, на порту 465 используется неявный TLS, на остальных STARTTLS.
+	// Пароль SMTP загружается как секрет
+	SMTPHost       string `env:"USER_SERVICE_MAILER_SMTP_HOST"`
+	SMTPPort       int    `env:"USER_SERVICE_MAILER_SMTP_PORT" env-default:"587"`
+	SMTPUsername   string `env:"USER_SERVICE_MAILER_SMTP_USERNAME"`
+	SMTPTimeoutSec int    `env:"USER_SERVICE_MAILER_SMTP_TIMEOUT_SEC" env-default:"10"`
+	// FilePath - файл драйвера file
+	FilePath string `env:"USER_SERVICE_MAILER_FILE_PATH" env-default:"mail.log"`
+}
+
+type Introspection struct {
+	// Clients - клиенты, которым разрешена интроспекция токенов, в формате id:secret через запятую
+	Clients map[string]string `env:"USER_SERVICE_INTROSPECTION_CLIENTS"`
+}
+
+type TokenExchange struct {
+	// Audiences - аудитории (внутренние сервисы), для которых можно обменять токен пользователя, через запятую
+	Audiences []string `env:"USER_SERVICE_TOKEN_EXCHANGE_AUDIENCES" env-separator:","`
+}
+
+type OAuth struct {
+	// CodeTTLSec - время жизни кода авторизации
+	CodeTTLSec int `env:"USER_SERVICE_OAUTH_CODE_TTL_SEC" env-default:"60"`
+}
+
+type OIDC struct {
+	// Issuer - публичный базовый url сервиса, он же iss в id_token и основа адресов в discovery
+	Issuer string `env:"USER_SERVICE_OIDC_ISSUER" env-required:"true"`
+}
+
+type Federation struct {
+	// Providers - внешние OIDC-провайдеры для входа в формате json-массива
+	Providers FederationProviders `env:"USER_SERVICE_FEDERATION_PROVIDERS"`
+	// StateTTLSec - время, за которое пользователь должен вернуться с провайдера
+	StateTTLSec int `env:"USER_SERVICE_FEDERATION_STATE_TTL_SEC" env-default:"300"`
+}
+
+// FederationProvider - настройки внешнего OIDC-провайдера
+type FederationProvider struct {
+	Name         string   `json:"name"`
+	DisplayName  string   `json:"displayName"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"clientId"`
+	ClientSecret string   `json:"clientSecret"`
+	Scopes       []string `json:"scopes"`
+}
+
+type FederationProviders []FederationProvider
+
+// SetValue - разбор списка провайдеров из переменной окружения
+func (f *FederationProviders) SetValue(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(value), f)
+}
+
+type Roles struct {
+	// RefreshIntervalSec - интервал перечитывания ролей из бд, изменения ролей на других инстансах видны с этой задержкой
+	RefreshIntervalSec int `env:"USER_SERVICE_ROLES_REFRESH_INTERVAL_SEC" env-default:"30"`
+}
+
+type Impersonation struct {
+	// TTLSec - время жизни токена имперсонации, рефреш-токен к нему не выдается
+	TTLSec int `env:"USER_SERVICE_IMPERSONATION_TTL_SEC" env-default:"120"`
+}
+
+type Claims struct {
+	// Enrichers - встроенные обогатители access-токенов через запятую: profile, permissions
+	Enrichers []string `env:"USER_SERVICE_CLAIMS_ENRICHERS" env-separator:","`
+	// Static - статические claims по oauth-клиентам в формате json-объекта {"client-id": {"claim": value}}
+	Static StaticClaims `env:"USER_SERVICE_CLAIMS_STATIC"`
+	// MaxSizeBytes - ограничение размера дополнительных claims в json, токен передается в каждом запросе
+	MaxSizeBytes int `env:"USER_SERVICE_CLAIMS_MAX_SIZE_BYTES" env-default:"2048"`
+}
+
+type StaticClaims map[string]map[string]any
+
+// SetValue - разбор статических claims из переменной окружения
+func (s *StaticClaims) SetValue(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(value), s)
+}
+
 type Sentry struct {
 	DSN   string `env:"SENTRY_DSN"`
 	Debug bool   `env:"SENTRY_DEBUG" env-default:"false"`
@@ -54,6 +235,21 @@ type Config struct {
 	Http               Http
 	Tracer             Tracer
 	Sentry             Sentry
+	Jwt                Jwt
+	Password           Password
+	PasswordReset      PasswordReset
+	EmailVerification  EmailVerification
+	SignInGuard        SignInGuard
+	Mailer             Mailer
+	Introspection      Introspection
+	OAuth              OAuth
+	TokenExchange      TokenExchange
+	OIDC               OIDC
+	Federation         Federation
+	Roles              Roles
+	Impersonation      Impersonation
+	Claims             Claims
+	Secrets            Secrets
 	ShutdownTimeoutSec int `env:"USER_SERVICE_SHUTDOWN_TIMEOUT_SEC" env-default:"5"`
 	JwtTTL             int `env:"USER_SERVICE_JWT_TTL" env-default:"300"`
 }
@@ -104,6 +300,11 @@ func validateENV(config *Config) error {
 	if len(config.Http.PprofPort) == 0 {
 		return errors.New("empty http.PprofPort")
 	}
+	for _, proxy := range config.Http.TrustedProxies {
+		if _, _, err := net.ParseCIDR(strings.TrimSpace(proxy)); err != nil {
+			return errors.New("invalid http.TrustedProxies")
+		}
+	}
 
 	if len(config.Redis.Host) == 0 {
 		return errors.New("empty redis.Host")
@@ -119,5 +320,295 @@ func validateENV(config *Config) error {
 		return errors.New("empty tracer.Port")
 	}
 
+	switch config.Jwt.Algorithm {
+	case "RS256", "ES256", "EdDSA":
+	default:
+		return errors.New("invalid jwt.Algorithm")
+	}
+	if config.Jwt.RotationIntervalHour <= 0 || config.Jwt.KeyRefreshIntervalSec <= 0 {
+		return errors.New("invalid jwt rotation intervals")
+	}
+	// выведенный ключ должен проверять подписи как минимум до истечения выпущенных им токенов
+	if config.Jwt.RetiredGraceHour*3600 < config.JwtTTL {
+		return errors.New("jwt.RetiredGraceHour must be greater than JwtTTL")
+	}
+
+	err := validatePassword(config.Password)
+	if err != nil {
+		return err
+	}
+
+	err = validateSecrets(config.Secrets)
+	if err != nil {
+		return err
+	}
+
+	// токен из письма заменяет пароль, поэтому живет не дольше часа
+	if config.PasswordReset.TokenTTLSec <= 0 || config.PasswordReset.TokenTTLSec > 3600 {
+		return errors.New("invalid passwordReset.TokenTTLSec")
+	}
+	if config.PasswordReset.ResendIntervalSec <= 0 {
+		return errors.New("invalid passwordReset.ResendIntervalSec")
+	}
+	if config.PasswordReset.LinkURL != "" {
+		link, errParse := url.Parse(config.PasswordReset.LinkURL)
+		if errParse != nil || !link.IsAbs() || link.Host == "" || link.Fragment != "" {
+			return errors.New("invalid passwordReset.LinkURL")
+		}
+	}
+
+	err = validateEmailVerification(config.EmailVerification)
+	if err != nil {
+		return err
+	}
+
+	err = validateSignInGuard(config.SignInGuard)
+	if err != nil {
+		return err
+	}
+
+	err = validateMailer(config.Mailer)
+	if err != nil {
+		return err
+	}
+
+	for clientID, clientSecret := range config.Introspection.Clients {
+		if clientID == "" || clientSecret == "" {
+			return errors.New("invalid introspection.Clients")
+		}
+	}
+
+	// обмен не должен выдавать токен, который снова примет api самого сервиса
+	for _, audience := range config.TokenExchange.Audiences {
+		if audience == "" || audience == config.Jwt.Audience {
+			return errors.New("invalid tokenExchange.Audiences")
+		}
+	}
+
+	// RFC 6749, раздел 4.1.2: код должен жить недолго, рекомендуемый максимум - 10 минут
+	if config.OAuth.CodeTTLSec <= 0 || config.OAuth.CodeTTLSec > 600 {
+		return errors.New("invalid oauth.CodeTTLSec")
+	}
+
+	// OpenID Connect Discovery 1.0, раздел 3: issuer - абсолютный url без query и фрагмента
+	issuer, err := url.Parse(config.OIDC.Issuer)
+	if err != nil || !issuer.IsAbs() || issuer.Host == "" || issuer.RawQuery != "" || issuer.Fragment != "" ||
+		strings.HasSuffix(config.OIDC.Issuer, "/") {
+		return errors.New("invalid oidc.Issuer")
+	}
+
+	if config.Jwt.Issuer == "" {
+		config.Jwt.Issuer = config.OIDC.Issuer
+	}
+	if config.EmailVerification.LinkURL == "" {
+		config.EmailVerification.LinkURL = config.OIDC.Issuer + "/public/v1/auth/verify-email"
+	}
+	if strings.TrimSpace(config.Jwt.Audience) == "" {
+		return errors.New("empty jwt.Audience")
+	}
+	// расхождение часов не должно сравниться с временем жизни токена, иначе истекший токен будет считаться действующим
+	if config.Jwt.LeewaySec < 0 || config.Jwt.LeewaySec >= config.JwtTTL {
+		return errors.New("invalid jwt.LeewaySec")
+	}
+
+	err = validateFederation(config.Federation)
+	if err != nil {
+		return err
+	}
+
+	if config.Roles.RefreshIntervalSec <= 0 {
+		return errors.New("invalid roles.RefreshIntervalSec")
+	}
+
+	// токен имперсонации не должен жить дольше обычного access-токена
+	if config.Impersonation.TTLSec <= 0 || config.Impersonation.TTLSec > config.JwtTTL {
+		return errors.New("invalid impersonation.TTLSec")
+	}
+
+	for _, enricher := range config.Claims.Enrichers {
+		if enricher != "profile" && enricher != "permissions" {
+			return errors.New("invalid claims.Enrichers")
+		}
+	}
+	for clientID := range config.Claims.Static {
+		if clientID == "" {
+			return errors.New("invalid claims.Static")
+		}
+	}
+	if config.Claims.MaxSizeBytes <= 0 {
+		return errors.New("invalid claims.MaxSizeBytes")
+	}
+
+	return nil
+}
+
+// validatePassword - проверка параметров хэширования и политики паролей: argon2id требует не меньше 8 KiB памяти на поток
+func validatePassword(password Password) error {
+	switch password.Algorithm {
+	case "argon2id", "bcrypt":
+	default:
+		return errors.New("invalid password.Algorithm")
+	}
+
+	if password.Argon2Iterations <= 0 || password.Argon2Parallelism <= 0 || password.Argon2Parallelism > 255 ||
+		password.Argon2MemoryKiB < 8*password.Argon2Parallelism {
+		return errors.New("invalid password argon2 params")
+	}
+
+	if password.BcryptCost < 10 || password.BcryptCost > 31 {
+		return errors.New("invalid password.BcryptCost")
+	}
+
+	if password.MinLength <= 0 || password.MaxLength < password.MinLength ||
+		(password.Algorithm == "bcrypt" && password.MaxLength > 72) {
+		return errors.New("invalid password length limits")
+	}
+	for _, class := range password.RequiredClasses {
+		switch class {
+		case "lower", "upper", "digit", "symbol":
+		default:
+			return errors.New("invalid password.RequiredClasses")
+		}
+	}
+	// каждый хэш истории проверяется при смене пароля, длинная история делает смену пароля заметно дороже
+	if password.HistorySize < 0 || password.HistorySize > 24 {
+		return errors.New("invalid password.HistorySize")
+	}
+
+	return nil
+}
+
+// validateSecrets - проверка настроек провайдера, сами секреты проверяются при загрузке
+func validateSecrets(secrets Secrets) error {
+	switch secrets.Provider {
+	case "env":
+	case "file":
+		if secrets.Dir == "" {
+			return errors.New("empty secrets.Dir")
+		}
+	case "vault":
+		vaultAddr, err := url.Parse(secrets.VaultAddr)
+		if err != nil || !vaultAddr.IsAbs() || vaultAddr.Host == "" {
+			return errors.New("invalid secrets.VaultAddr")
+		}
+		if secrets.VaultToken == "" || strings.Trim(secrets.VaultPath, "/") == "" {
+			return errors.New("empty secrets vault token or path")
+		}
+		if secrets.VaultTimeoutSec <= 0 {
+			return errors.New("invalid secrets.VaultTimeoutSec")
+		}
+	default:
+		return errors.New("invalid secrets.Provider")
+	}
+
+	return nil
+}
+
+// validateEmailVerification - проверка режима и сроков подтверждения email
+func validateEmailVerification(verification EmailVerification) error {
+	switch verification.Mode {
+	case EmailVerificationModeClaim, EmailVerificationModeBlock:
+	default:
+		return errors.New("invalid emailVerification.Mode")
+	}
+
+	// срок указывается в письме в часах
+	if verification.TokenTTLSec < 3600 || verification.TokenTTLSec > 7*24*3600 {
+		return errors.New("invalid emailVerification.TokenTTLSec")
+	}
+	if verification.ResendIntervalSec <= 0 {
+		return errors.New("invalid emailVerification.ResendIntervalSec")
+	}
+	if verification.LinkURL != "" {
+		link, err := url.Parse(verification.LinkURL)
+		if err != nil || !link.IsAbs() || link.Host == "" || link.Fragment != "" {
+			return errors.New("invalid emailVerification.LinkURL")
+		}
+	}
+
+	return nil
+}
+
+// validateSignInGuard - проверка порогов защиты входа: задержки должны начинаться раньше блокировки
+func validateSignInGuard(guard SignInGuard) error {
+	if guard.FailureWindowSec <= 0 {
+		return errors.New("invalid signInGuard.FailureWindowSec")
+	}
+	if guard.LockoutThreshold <= 0 || guard.IPLockoutThreshold < guard.LockoutThreshold {
+		return errors.New("invalid signInGuard lockout thresholds")
+	}
+	if guard.DelayThreshold <= 0 || guard.DelayThreshold >= guard.LockoutThreshold {
+		return errors.New("invalid signInGuard.DelayThreshold")
+	}
+	if guard.BaseDelaySec <= 0 || guard.MaxDelaySec < guard.BaseDelaySec {
+		return errors.New("invalid signInGuard delays")
+	}
+	if guard.LockoutDurationSec <= 0 {
+		return errors.New("invalid signInGuard.LockoutDurationSec")
+	}
+
+	return nil
+}
+
+// validateMailer - проверка драйвера почты: вне dev-окружения письма должны уходить пользователю, а не в лог
+func validateMailer(mailer Mailer) error {
+	if _, err := mail.ParseAddress(mailer.From); err != nil {
+		return errors.New("invalid mailer.From")
+	}
+
+	switch mailer.Driver {
+	case "smtp":
+		if mailer.SMTPHost == "" {
+			return errors.New("empty mailer.SMTPHost")
+		}
+		if mailer.SMTPPort <= 0 || mailer.SMTPPort > 65535 {
+			return errors.New("invalid mailer.SMTPPort")
+		}
+		if mailer.SMTPTimeoutSec <= 0 {
+			return errors.New("invalid mailer.SMTPTimeoutSec")
+		}
+	case "file", "log":
+		if !IsDevEnv(ServiceEnv) {
+			return errors.New("mailer.Driver must be smtp outside dev environment")
+		}
+		if mailer.Driver == "file" && mailer.FilePath == "" {
+			return errors.New("empty mailer.FilePath")
+		}
+	default:
+		return errors.New("invalid mailer.Driver")
+	}
+
+	return nil
+}
+
+// validateFederation - проверка провайдеров: имя входит в адрес callback и ключ привязки учетных записей
+func validateFederation(federation Federation) error {
+	if federation.StateTTLSec <= 0 {
+		return errors.New("invalid federation.StateTTLSec")
+	}
+
+	names := make(map[string]struct{}, len(federation.Providers))
+	for _, provider := range federation.Providers {
+		if !providerNameRegexp.MatchString(provider.Name) {
+			return errors.New("invalid federation provider name")
+		}
+		if _, ok := names[provider.Name]; ok {
+			return errors.New("duplicate federation provider name")
+		}
+		names[provider.Name] = struct{}{}
+
+		issuer, err := url.Parse(provider.Issuer)
+		if err != nil || !issuer.IsAbs() || issuer.Host == "" {
+			return errors.New("invalid federation provider issuer")
+		}
+		if provider.ClientID == "" {
+			return errors.New("empty federation provider clientId")
+		}
+
+		if len(provider.Scopes) != 0 && !slices.Contains(provider.Scopes, "openid") {
+			return errors.New("federation provider scopes must contain openid")
+		}
+	}
+
 	return nil
 }
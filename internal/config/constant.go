@@ -9,16 +9,20 @@ var (
 )
 
 const (
-	PasswordSalt  = "sad342mslfd23412sdfsdf1234hgf"
-	JWTSecret     = "4FC82B26AECB47D2868C4EFBE3581732A3E7CBCC6C2EFB32062C08170A05EEB8"
 	IsoTimeLayout = "2006-01-02T15:04:05Z" // Формат ISO 8601
 
-	ParamID     = "id"
-	ParamRole   = "role"
-	ParamOffset = "offset"
-	ParamLimit  = "limit"
-	ParamSort   = "sort"
-	ParamOrder  = "order"
+	ParamID          = "id"
+	ParamRole        = "role"
+	ParamClaims      = "claims"
+	ParamSession     = "sessionID"
+	ParamProvider    = "provider"
+	ParamCallerType  = "callerType"
+	ParamPermissions = "permissions"
+	ParamActor       = "actor"
+	ParamOffset      = "offset"
+	ParamLimit       = "limit"
+	ParamSort        = "sort"
+	ParamOrder       = "order"
 
 	OrderName          = "name"
 	OrderSurname       = "surname"
@@ -28,15 +32,75 @@ const (
 	SortDesc           = "desc"
 	SortAsc            = "asc"
 
+	// режимы для пользователей с неподтвержденным email: токены с claim email_verified=false или запрет входа
+	EmailVerificationModeClaim = "claim"
+	EmailVerificationModeBlock = "block"
+
 	SpanServiceCreateUser                     = "service-create-user"
 	SpanServiceGetUserByID                    = "service-get-user-by-id"
 	SpanServiceDeleteUserByID                 = "service-delete-user-by-id"
 	SpanServiceGetUserByEmailAndPassword      = "service-get-user-by-email-and-password"
 	SpanServiceUpdateUserByID                 = "service-update-user-by-id"
+	SpanServiceValidatePassword               = "service-validate-password"
 	SpanServiceGetUsers                       = "service-get-users"
 	SpanServiceUpdatePrivateUserByID          = "service-update-private-user-by-id"
 	SpanServiceUpdateRefreshToken             = "service-update-refresh-token"
 	SpanServiceGenerateAccessAndRefreshTokens = "service-generate-access-and-refresh-tokens"
+	SpanServiceParseAccessToken               = "service-parse-access-token"
+	SpanServiceGetJWKS                        = "service-get-jwks"
+	SpanServiceRevokeSession                  = "service-revoke-session"
+	SpanServiceRevokeAllSessions              = "service-revoke-all-sessions"
+	SpanServiceRevokeToken                    = "service-revoke-token"
+	SpanServiceIntrospect                     = "service-introspect"
+	SpanServiceGenerateClientCredentialsToken = "service-generate-client-credentials-token"
+	SpanServiceCreateServiceAccount           = "service-create-service-account"
+	SpanServiceGetServiceAccountByID          = "service-get-service-account-by-id"
+	SpanServiceGetServiceAccounts             = "service-get-service-accounts"
+	SpanServiceUpdateServiceAccount           = "service-update-service-account"
+	SpanServiceResetServiceAccountSecret      = "service-reset-service-account-secret"
+	SpanServiceDeleteServiceAccountByID       = "service-delete-service-account-by-id"
+	SpanServiceAuthenticateServiceAccount     = "service-authenticate-service-account"
+	SpanServiceCreateOAuthClient              = "service-create-oauth-client"
+	SpanServiceGetOAuthClientByID             = "service-get-oauth-client-by-id"
+	SpanServiceGetOAuthClients                = "service-get-oauth-clients"
+	SpanServiceUpdateOAuthClient              = "service-update-oauth-client"
+	SpanServiceResetOAuthClientSecret         = "service-reset-oauth-client-secret"
+	SpanServiceDeleteOAuthClientByID          = "service-delete-oauth-client-by-id"
+	SpanServiceAuthenticateOAuthClient        = "service-authenticate-oauth-client"
+	SpanServiceCreateAuthorizationCode        = "service-create-authorization-code"
+	SpanServiceExchangeAuthorizationCode      = "service-exchange-authorization-code"
+	SpanServiceRefreshOAuthToken              = "service-refresh-oauth-token"
+	SpanServiceGenerateIDToken                = "service-generate-id-token"
+	SpanServiceGetUserSessions                = "service-get-user-sessions"
+	SpanServiceRevokeUserSession              = "service-revoke-user-session"
+	SpanServiceRotateKeys                     = "service-rotate-keys"
+	SpanServiceRetireKey                      = "service-retire-key"
+	SpanServiceBeginFederatedLogin            = "service-begin-federated-login"
+	SpanServiceCompleteFederatedLogin         = "service-complete-federated-login"
+	SpanServiceCreatePersonalToken            = "service-create-personal-token"
+	SpanServiceGetPersonalTokens              = "service-get-personal-tokens"
+	SpanServiceGetPersonalTokenByID           = "service-get-personal-token-by-id"
+	SpanServiceUpdatePersonalToken            = "service-update-personal-token"
+	SpanServiceDeletePersonalToken            = "service-delete-personal-token"
+	SpanServiceAuthenticatePersonalToken      = "service-authenticate-personal-token"
+	SpanServiceCreateRole                     = "service-create-role"
+	SpanServiceGetRoles                       = "service-get-roles"
+	SpanServiceGetRoleByName                  = "service-get-role-by-name"
+	SpanServiceUpdateRole                     = "service-update-role"
+	SpanServiceDeleteRole                     = "service-delete-role"
+	SpanServiceImpersonate                    = "service-impersonate"
+	SpanServiceGenerateImpersonationToken     = "service-generate-impersonation-token"
+	SpanServiceExchangeToken                  = "service-exchange-token"
+	SpanServiceForgotPassword                 = "service-forgot-password"
+	SpanServiceResetPassword                  = "service-reset-password"
+	SpanServiceSendEmailVerification          = "service-send-email-verification"
+	SpanServiceResendEmailVerification        = "service-resend-email-verification"
+	SpanServiceVerifyEmail                    = "service-verify-email"
+	SpanServiceChangePassword                 = "service-change-password"
+	SpanServiceRevokeOtherSessions            = "service-revoke-other-sessions"
+	SpanServiceGuardedSignIn                  = "service-guarded-sign-in"
+	SpanServiceUnlockSignIn                   = "service-unlock-sign-in"
+	SpanServiceGuardedChangePassword          = "service-guarded-change-password"
 
 	SpanCacheGet             = "cache-get"
 	SpanCacheDelete          = "cache-delete"
@@ -44,11 +108,86 @@ const (
 	SpanCacheSetUser         = "cache-set-user"
 	SpanCacheSetRefreshToken = "cache-set-refresh-token"
 
-	SpanPostgresCreateUser                = "postgres-create-user"
-	SpanPostgresGetUserByID               = "postgres-get-user-by-id"
-	SpanPostgresGetUserByEmailAndPassword = "postgres-get-user-by-email-and-password"
-	SpanPostgresDeleteUserByID            = "postgres-delete-user-by-id"
-	SpanPostgresUpdateUserByID            = "postgres-update-user-by-id"
-	SpanPostgresGetUsers                  = "postgres-get-users"
-	SpanPostgresUpdatePrivateUserByID     = "postgres-update-private-user-by-id"
+	SpanCacheRotateRefreshToken     = "cache-rotate-refresh-token"
+	SpanCacheGetRotatedRefreshToken = "cache-get-rotated-refresh-token"
+	SpanCacheDeleteRefreshFamily    = "cache-delete-refresh-family"
+
+	SpanCacheGetRefreshToken            = "cache-get-refresh-token"
+	SpanCacheDeleteUserRefreshFamilies  = "cache-delete-user-refresh-families"
+	SpanCacheSetRevokedAccessToken      = "cache-set-revoked-access-token"
+	SpanCacheSetUserTokensRevokedBefore = "cache-set-user-tokens-revoked-before"
+	SpanCacheGetAccessTokenRevocation   = "cache-get-access-token-revocation"
+
+	SpanCacheSetSession      = "cache-set-session"
+	SpanCacheUpdateSession   = "cache-update-session"
+	SpanCacheGetSession      = "cache-get-session"
+	SpanCacheGetUserSessions = "cache-get-user-sessions"
+
+	SpanCacheSetAuthorizationCode     = "cache-set-authorization-code"
+	SpanCacheConsumeAuthorizationCode = "cache-consume-authorization-code"
+
+	SpanCacheSetFederatedLoginState     = "cache-set-federated-login-state"
+	SpanCacheConsumeFederatedLoginState = "cache-consume-federated-login-state"
+
+	SpanCacheSetPasswordReset     = "cache-set-password-reset"
+	SpanCacheGetPasswordReset     = "cache-get-password-reset"
+	SpanCacheConsumePasswordReset = "cache-consume-password-reset"
+	SpanCacheSetPasswordResetSent = "cache-set-password-reset-sent"
+
+	SpanCacheSetEmailVerification     = "cache-set-email-verification"
+	SpanCacheConsumeEmailVerification = "cache-consume-email-verification"
+	SpanCacheSetEmailVerificationSent = "cache-set-email-verification-sent"
+	SpanCacheIncrSignInFailures       = "cache-incr-sign-in-failures"
+	SpanCacheSetSignInLock            = "cache-set-sign-in-lock"
+	SpanCacheGetSignInLocks           = "cache-get-sign-in-locks"
+	SpanCacheDeleteSignInFailures     = "cache-delete-sign-in-failures"
+
+	SpanPostgresCreateUser             = "postgres-create-user"
+	SpanPostgresGetUserByID            = "postgres-get-user-by-id"
+	SpanPostgresUpdateUserPasswordHash = "postgres-update-user-password-hash"
+	SpanPostgresDeleteUserByID         = "postgres-delete-user-by-id"
+	SpanPostgresUpdateUserByID         = "postgres-update-user-by-id"
+	SpanPostgresGetUsers               = "postgres-get-users"
+	SpanPostgresUpdatePrivateUserByID  = "postgres-update-private-user-by-id"
+	SpanPostgresGetPasswordHistory     = "postgres-get-password-history"
+	SpanPostgresVerifyEmail            = "postgres-verify-email"
+	SpanPostgresCreateKey              = "postgres-create-key"
+	SpanPostgresGetKeys                = "postgres-get-keys"
+	SpanPostgresRotateKeys             = "postgres-rotate-keys"
+	SpanPostgresRetireKey              = "postgres-retire-key"
+
+	SpanPostgresCreateServiceAccount       = "postgres-create-service-account"
+	SpanPostgresGetServiceAccountByID      = "postgres-get-service-account-by-id"
+	SpanPostgresGetServiceAccounts         = "postgres-get-service-accounts"
+	SpanPostgresUpdateServiceAccount       = "postgres-update-service-account"
+	SpanPostgresUpdateServiceAccountSecret = "postgres-update-service-account-secret"
+	SpanPostgresDeleteServiceAccountByID   = "postgres-delete-service-account-by-id"
+
+	SpanPostgresCreateOAuthClient       = "postgres-create-oauth-client"
+	SpanPostgresGetOAuthClientByID      = "postgres-get-oauth-client-by-id"
+	SpanPostgresGetOAuthClients         = "postgres-get-oauth-clients"
+	SpanPostgresUpdateOAuthClient       = "postgres-update-oauth-client"
+	SpanPostgresUpdateOAuthClientSecret = "postgres-update-oauth-client-secret"
+	SpanPostgresDeleteOAuthClientByID   = "postgres-delete-oauth-client-by-id"
+
+	SpanPostgresGetUserByEmail         = "postgres-get-user-by-email"
+	SpanPostgresGetUserByIdentity      = "postgres-get-user-by-identity"
+	SpanPostgresCreateUserIdentity     = "postgres-create-user-identity"
+	SpanPostgresCreateUserWithIdentity = "postgres-create-user-with-identity"
+
+	SpanPostgresCreatePersonalToken         = "postgres-create-personal-token"
+	SpanPostgresGetPersonalTokens           = "postgres-get-personal-tokens"
+	SpanPostgresGetPersonalTokenByID        = "postgres-get-personal-token-by-id"
+	SpanPostgresGetPersonalTokenByHash      = "postgres-get-personal-token-by-hash"
+	SpanPostgresUpdatePersonalToken         = "postgres-update-personal-token"
+	SpanPostgresUpdatePersonalTokenLastUsed = "postgres-update-personal-token-last-used"
+	SpanPostgresDeletePersonalToken         = "postgres-delete-personal-token"
+
+	SpanPostgresCreateRole    = "postgres-create-role"
+	SpanPostgresGetRoles      = "postgres-get-roles"
+	SpanPostgresGetRoleByName = "postgres-get-role-by-name"
+	SpanPostgresUpdateRole    = "postgres-update-role"
+	SpanPostgresDeleteRole    = "postgres-delete-role"
+
+	SpanPostgresCreateImpersonation = "postgres-create-impersonation"
 )
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/GermanBogatov/auth-service/pkg/secrets"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// имена секретов у провайдеров file и vault
+const (
+	SecretJwtKeyEncryptionKey = "jwt-key-encryption-key"
+	SecretPasswordPeppers     = "password-peppers"
+	SecretPasswordLegacySalt  = "password-legacy-salt"
+	SecretMailerSMTPPassword  = "mailer-smtp-password"
+)
+
+// minPepperLength - минимальная длина перца, короткий перец подбирается вместе с паролем
+const minPepperLength = 16
+
+// defaultSecrets - значения из примеров конфигурации в репозитории, вне dev-окружения с ними сервис не стартует
+var defaultSecrets = []string{
+	"8f3b1c2d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9",
+	"dev-pepper-change-me-in-production",
+}
+
+type Secrets struct {
+	// Provider - источник секретов: env (окружение или файл конфигурации), file (смонтированные файлы) или vault
+	Provider string `env:"USER_SERVICE_SECRETS_PROVIDER" env-default:"env"`
+	// Dir - каталог провайдера file, имя файла совпадает с именем секрета
+	Dir string `env:"USER_SERVICE_SECRETS_DIR" env-default:"/var/run/secrets/auth-service"`
+	// VaultAddr, VaultToken, VaultPath - адрес Vault, токен и путь записи с секретами, например secret/data/auth-service
+	VaultAddr       string `env:"USER_SERVICE_SECRETS_VAULT_ADDR"`
+	VaultToken      string `env:"USER_SERVICE_SECRETS_VAULT_TOKEN"`
+	VaultPath       string `env:"USER_SERVICE_SECRETS_VAULT_PATH"`
+	VaultTimeoutSec int    `env:"USER_SERVICE_SECRETS_VAULT_TIMEOUT_SEC" env-default:"5"`
+
+	// значения провайдера env
+	KeyEncryptionKey   string `env:"USER_SERVICE_JWT_KEY_ENCRYPTION_KEY"`
+	PasswordPeppers    string `env:"USER_SERVICE_PASSWORD_PEPPERS"`
+	PasswordLegacySalt string `env:"USER_SERVICE_PASSWORD_LEGACY_SALT"`
+	MailerSMTPPassword string `env:"USER_SERVICE_MAILER_SMTP_PASSWORD"`
+}
+
+// SecretValues - секреты, полученные от провайдера при старте
+type SecretValues struct {
+	// KeyEncryptionKey - ключ шифрования приватных ключей подписи в бд, 32 байта в hex
+	KeyEncryptionKey string
+	// Peppers - версии перца паролей, новые хэши получают перец с наибольшей версией
+	Peppers       map[int][]byte
+	PepperVersion int
+	// LegacyPasswordSalt - общая соль паролей в устаревшем формате sha256. Она уже опубликована и новые хэши
+	// не защищает, но без нее не войдут пользователи, чьи хэши еще не пересчитаны
+	LegacyPasswordSalt string
+	// MailerSMTPPassword - пароль SMTP-сервера, пустой - отправка без авторизации
+	MailerSMTPPassword string
+}
+
+// IsDevEnv - окружение разработки, в котором допустимы секреты из примеров конфигурации. Окружение должно быть задано
+// явно: незаданное окружение считается production, чтобы забытая переменная не ослабляла проверки
+func IsDevEnv(serviceEnv string) bool {
+	switch serviceEnv {
+	case "dev", "local", "test":
+		return true
+	default:
+		return false
+	}
+}
+
+// LoadSecrets - загрузка секретов у настроенного провайдера и отказ от секретов по умолчанию вне dev-окружения
+func LoadSecrets(ctx context.Context, cfg Secrets) (SecretValues, error) {
+	provider, err := newSecretsProvider(cfg)
+	if err != nil {
+		return SecretValues{}, err
+	}
+
+	var values SecretValues
+	values.KeyEncryptionKey, err = provider.Get(ctx, SecretJwtKeyEncryptionKey)
+	if err != nil {
+		return SecretValues{}, fmt.Errorf("secret %s: %w", SecretJwtKeyEncryptionKey, err)
+	}
+	if len(values.KeyEncryptionKey) != 64 {
+		return SecretValues{}, errors.New("jwt key encryption key must be 32 bytes in hex")
+	}
+
+	peppers, err := optionalSecret(ctx, provider, SecretPasswordPeppers)
+	if err != nil {
+		return SecretValues{}, err
+	}
+	values.Peppers, values.PepperVersion, err = parsePeppers(peppers)
+	if err != nil {
+		return SecretValues{}, err
+	}
+
+	values.LegacyPasswordSalt, err = optionalSecret(ctx, provider, SecretPasswordLegacySalt)
+	if err != nil {
+		return SecretValues{}, err
+	}
+
+	values.MailerSMTPPassword, err = optionalSecret(ctx, provider, SecretMailerSMTPPassword)
+	if err != nil {
+		return SecretValues{}, err
+	}
+
+	if !IsDevEnv(ServiceEnv) {
+		if slices.Contains(defaultSecrets, values.KeyEncryptionKey) {
+			return SecretValues{}, fmt.Errorf("default %s is not allowed in [%s] environment",
+				SecretJwtKeyEncryptionKey, ServiceEnv)
+		}
+		if len(values.Peppers) == 0 {
+			return SecretValues{}, fmt.Errorf("%s is required in [%s] environment", SecretPasswordPeppers, ServiceEnv)
+		}
+		for _, pepper := range values.Peppers {
+			if slices.Contains(defaultSecrets, string(pepper)) {
+				return SecretValues{}, fmt.Errorf("default %s is not allowed in [%s] environment",
+					SecretPasswordPeppers, ServiceEnv)
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// newSecretsProvider - провайдер секретов по конфигурации
+func newSecretsProvider(cfg Secrets) (secrets.Provider, error) {
+	switch cfg.Provider {
+	case secrets.ProviderEnv:
+		return secrets.Static{
+			SecretJwtKeyEncryptionKey: cfg.KeyEncryptionKey,
+			SecretPasswordPeppers:     cfg.PasswordPeppers,
+			SecretPasswordLegacySalt:  cfg.PasswordLegacySalt,
+			SecretMailerSMTPPassword:  cfg.MailerSMTPPassword,
+		}, nil
+	case secrets.ProviderFile:
+		return secrets.NewFile(cfg.Dir), nil
+	case secrets.ProviderVault:
+		return secrets.NewVault(cfg.VaultAddr, cfg.VaultToken, cfg.VaultPath,
+			time.Duration(cfg.VaultTimeoutSec)*time.Second), nil
+	default:
+		return nil, errors.New("invalid secrets.Provider")
+	}
+}
+
+// optionalSecret - секрет, отсутствие которого не ошибка
+func optionalSecret(ctx context.Context, provider secrets.Provider, name string) (string, error) {
+	value, err := provider.Get(ctx, name)
+	if err != nil {
+		if errors.Is(err, secrets.ErrNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("secret %s: %w", name, err)
+	}
+	return value, nil
+}
+
+// parsePeppers - разбор версий перца в формате <версия>:<перец> через запятую. Текущая версия - наибольшая,
+// для ротации добавляется новая версия, а старая удаляется, когда все хэши с ней пересчитаны при входе
+func parsePeppers(value string) (map[int][]byte, int, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, 0, nil
+	}
+
+	peppers := make(map[int][]byte)
+	current := 0
+	for _, item := range strings.Split(value, ",") {
+		rawVersion, pepper, ok := strings.Cut(strings.TrimSpace(item), ":")
+		version, err := strconv.Atoi(rawVersion)
+		if !ok || err != nil || version <= 0 {
+			return nil, 0, errors.New("invalid password pepper version")
+		}
+		if len(pepper) < minPepperLength {
+			return nil, 0, fmt.Errorf("password pepper must be at least %d characters", minPepperLength)
+		}
+		if _, ok = peppers[version]; ok {
+			return nil, 0, errors.New("duplicate password pepper version")
+		}
+
+		peppers[version] = []byte(pepper)
+		current = max(current, version)
+	}
+
+	return peppers, current, nil
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIsDevEnv(t *testing.T) {
+	tests := []struct {
+		serviceEnv string
+		want       bool
+	}{
+		{serviceEnv: "dev", want: true},
+		{serviceEnv: "local", want: true},
+		{serviceEnv: "test", want: true},
+		{serviceEnv: ""},
+		{serviceEnv: "production"},
+		{serviceEnv: "stage"},
+		{serviceEnv: "Dev"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.serviceEnv, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsDevEnv(tt.serviceEnv))
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package entity
+
+import "time"
+
+// FederatedProvider - внешний OIDC-провайдер, через который доступен вход
+type FederatedProvider struct {
+	Name        string
+	DisplayName string
+}
+
+// FederatedLoginState - состояние входа через внешний провайдер, хранится до возврата пользователя с провайдера
+type FederatedLoginState struct {
+	CreatedDate  time.Time
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	DeviceName   string
+}
+
+// FederatedUser - пользователь, подтвержденный внешним провайдером
+type FederatedUser struct {
+	Provider      string
+	Subject       string
+	Email         string
+	Name          string
+	Surname       string
+	EmailVerified bool
+}
+
+// UserIdentity - привязка пользователя к учетной записи внешнего провайдера
+type UserIdentity struct {
+	CreatedDate time.Time
+	Provider    string
+	Subject     string
+	UserID      string
+	Email       string
+}
+
+// NewUserIdentity - привязка учетной записи провайдера к пользователю
+func NewUserIdentity(federatedUser FederatedUser, userID string) UserIdentity {
+	return UserIdentity{
+		CreatedDate: time.Now().UTC(),
+		Provider:    federatedUser.Provider,
+		Subject:     federatedUser.Subject,
+		UserID:      userID,
+		Email:       federatedUser.Email,
+	}
+}
@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// ActorClaim - claim act (RFC 8693, раздел 4.1): кто действует от имени субъекта токена.
+// Вложенный act - предыдущий участник цепочки делегирования
+type ActorClaim struct {
+	Subject string      `json:"sub"`
+	Actor   *ActorClaim `json:"act,omitempty"`
+}
+
+// Impersonation - выдача токена имперсонации пользователя, хранится в бд для аудита
+type Impersonation struct {
+	CreatedDate time.Time
+	ExpiresDate time.Time
+	// ID - jti выданного токена
+	ID      string
+	UserID  string
+	ActorID string
+	Reason  string
+}
+
+// ImpersonationToken - выданный токен имперсонации
+type ImpersonationToken struct {
+	Impersonation
+	AccessToken string
+}
+
+// NewImpersonation - выдача токена имперсонации со сроком действия ttl
+func NewImpersonation(userID, actorID, reason string, ttl time.Duration) Impersonation {
+	now := time.Now().UTC()
+	return Impersonation{
+		CreatedDate: now,
+		ExpiresDate: now.Add(ttl),
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		ActorID:     actorID,
+		Reason:      reason,
+	}
+}
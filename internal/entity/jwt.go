@@ -1,9 +1,199 @@
 package entity
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"time"
+)
+
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+)
+
+type KeyStatus string
+
+const (
+	KeyStatusNext    KeyStatus = "next"
+	KeyStatusActive  KeyStatus = "active"
+	KeyStatusRetired KeyStatus = "retired"
+)
 
 type UserClaims struct {
 	jwt.RegisteredClaims
-	Email string `json:"email"`
-	Role  string `json:"role"`
+	Email     string `json:"email,omitempty"`
+	Role      string `json:"role,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	// Actor - администратор, действующий от имени пользователя по токену имперсонации
+	Actor *ActorClaim `json:"act,omitempty"`
+	// EmailVerified - выставляется в false пользователям, не подтвердившим email
+	EmailVerified *bool `json:"email_verified,omitempty"`
+	// Extra - дополнительные claims от конвейера обогащения, при разборе токена не заполняются
+	Extra map[string]any `json:"-"`
+}
+
+// ReservedClaims - claims сервиса сверх зарегистрированных claims jwt, обогатители не могут их перекрыть
+var ReservedClaims = []string{"email", "email_verified", "role", "sid", "client_id", "scope", "act"}
+
+// MarshalJSON - claims токена вместе с дополнительными, совпадающие имена остаются за claims сервиса
+func (c UserClaims) MarshalJSON() ([]byte, error) {
+	type plain UserClaims
+	data, err := json.Marshal(plain(c))
+	if err != nil || len(c.Extra) == 0 {
+		return data, err
+	}
+
+	merged := make(map[string]json.RawMessage)
+	err = json.Unmarshal(data, &merged)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range c.Extra {
+		if _, ok := merged[name]; ok {
+			continue
+		}
+
+		raw, errMarshal := json.Marshal(value)
+		if errMarshal != nil {
+			return nil, errMarshal
+		}
+		merged[name] = raw
+	}
+
+	return json.Marshal(merged)
+}
+
+// CallerType - тип вызывающего: у токенов client_credentials субъектом является сам клиент
+func (c UserClaims) CallerType() CallerType {
+	if c.ClientID != "" && c.Subject == c.ClientID {
+		return CallerTypeService
+	}
+	return CallerTypeUser
+}
+
+// HasScope - выдан ли токену скоуп
+func (c UserClaims) HasScope(scope string) bool {
+	for _, granted := range ParseScope(c.Scope) {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenRevocation - состояние отзыва access-токена
+type TokenRevocation struct {
+	// RevokedBefore - все токены пользователя, выпущенные не позже этого момента, отозваны
+	RevokedBefore *time.Time
+	// RevokedBeforeExceptSessionID - сессия, на токены которой RevokedBefore не распространяется (сессия, из которой
+	// сменили пароль)
+	RevokedBeforeExceptSessionID string
+	// Revoked - jti токена находится в denylist
+	Revoked bool
+	// FamilyRevoked - сессия, к которой относится токен, завершена
+	FamilyRevoked bool
+}
+
+// IsRevoked - отозван ли токен сессии sessionID с учетом всех признаков. Токены без сессии передают пустой sessionID
+func (r TokenRevocation) IsRevoked(issuedAt time.Time, sessionID string) bool {
+	if r.Revoked || r.FamilyRevoked {
+		return true
+	}
+	if sessionID != "" && sessionID == r.RevokedBeforeExceptSessionID {
+		return false
+	}
+	// iat и момент отзыва хранятся с точностью до микросекунды: токены, выпущенные в ту же секунду до отзыва,
+	// отклоняются, а выпущенные после него (например, при входе сразу после смены пароля) - нет
+	return r.RevokedBefore != nil && !issuedAt.After(*r.RevokedBefore)
+}
+
+// TokenIntrospection - результат интроспекции токена (RFC 7662)
+type TokenIntrospection struct {
+	ExpiresAt *time.Time
+	IssuedAt  *time.Time
+	Active    bool
+	TokenType string
+	Subject   string
+	Email     string
+	Role      string
+	// ID - jti access-токена
+	ID        string
+	SessionID string
+	ClientID  string
+	Scope     string
+	// Actor - администратор, действующий от имени пользователя (RFC 8693, раздел 4.1)
+	Actor *ActorClaim
+}
+
+// RefreshToken - рефреш-токен, принадлежащий семейству токенов одной сессии
+type RefreshToken struct {
+	CreatedDate time.Time
+	UserID      string
+	FamilyID    string
+	// ClientID - oauth-клиент, которому выдан токен, пустой для входа через api сервиса
+	ClientID string `json:",omitempty"`
+}
+
+// RotatedRefreshToken - запись об уже использованном рефреш-токене
+type RotatedRefreshToken struct {
+	RotatedDate time.Time
+	RefreshToken
+	Fingerprint string
+}
+
+// ClientInfo - данные о клиенте, выполняющем запрос
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+	// DeviceName - название устройства, указанное клиентом при входе
+	DeviceName string
+	// ClientID - oauth-клиент, от имени которого выполняется вход
+	ClientID string
+	// Scope - скоупы, выданные oauth-клиенту
+	Scope string
+	// Audiences - дополнительные аудитории oauth-клиента
+	Audiences []string
+}
+
+// Fingerprint - отпечаток клиента для сравнения запросов между собой
+func (c ClientInfo) Fingerprint() string {
+	sum := sha256.Sum256([]byte(c.UserAgent + "|" + c.IP))
+	return hex.EncodeToString(sum[:])
+}
+
+// SigningKey - ключ подписи jwt-токенов
+type SigningKey struct {
+	CreatedDate   time.Time
+	ActivatedDate *time.Time
+	RetiredDate   *time.Time
+	ExpiresDate   *time.Time
+	PrivateKey    crypto.Signer
+	ID            string
+	Algorithm     string
+	Status        KeyStatus
+	// EncryptedKey - зашифрованный PEM приватного ключа в том виде, в котором он хранится в бд
+	EncryptedKey []byte
+}
+
+// KeyRotation - параметры ротации ключей подписи
+type KeyRotation struct {
+	// Next - новый ключ, который станет следующим
+	Next SigningKey
+	// Active - ключ, который станет активным, если следующего ключа нет
+	Active SigningKey
+	// RetiredUntil - до какого момента выведенный ключ продолжает проверять подписи
+	RetiredUntil time.Time
+	// ActivatedBefore - ротация выполняется, только если активный ключ активирован раньше этого момента
+	ActivatedBefore *time.Time
+}
+
+// PublicKey - публичная часть ключа подписи
+func (k SigningKey) PublicKey() crypto.PublicKey {
+	return k.PrivateKey.Public()
 }
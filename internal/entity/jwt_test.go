@@ -0,0 +1,96 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTokenRevocationIsRevoked(t *testing.T) {
+	cutoff := time.Date(2026, 10, 18, 12, 0, 0, 500*int(time.Millisecond), time.UTC)
+
+	tests := []struct {
+		name       string
+		revocation TokenRevocation
+		issuedAt   time.Time
+		sessionID  string
+		want       bool
+	}{
+		{
+			name:     "not revoked",
+			issuedAt: cutoff,
+		},
+		{
+			name:       "jti in denylist",
+			revocation: TokenRevocation{Revoked: true},
+			issuedAt:   cutoff.Add(time.Hour),
+			want:       true,
+		},
+		{
+			name:       "session finished",
+			revocation: TokenRevocation{FamilyRevoked: true},
+			issuedAt:   cutoff.Add(time.Hour),
+			want:       true,
+		},
+		{
+			name:       "issued second before cutoff",
+			revocation: TokenRevocation{RevokedBefore: &cutoff},
+			issuedAt:   cutoff.Add(-time.Second),
+			want:       true,
+		},
+		{
+			name:       "issued in same second just before cutoff",
+			revocation: TokenRevocation{RevokedBefore: &cutoff},
+			issuedAt:   cutoff.Add(-time.Millisecond),
+			want:       true,
+		},
+		{
+			name:       "issued at cutoff",
+			revocation: TokenRevocation{RevokedBefore: &cutoff},
+			issuedAt:   cutoff,
+			want:       true,
+		},
+		{
+			name:       "issued in same second just after cutoff",
+			revocation: TokenRevocation{RevokedBefore: &cutoff},
+			issuedAt:   cutoff.Add(time.Millisecond),
+		},
+		{
+			name:       "issued after cutoff",
+			revocation: TokenRevocation{RevokedBefore: &cutoff},
+			issuedAt:   cutoff.Add(time.Second),
+		},
+		{
+			name:       "excepted session before cutoff",
+			revocation: TokenRevocation{RevokedBefore: &cutoff, RevokedBeforeExceptSessionID: "session-1"},
+			issuedAt:   cutoff.Add(-time.Second),
+			sessionID:  "session-1",
+		},
+		{
+			name:       "other session before cutoff with exception",
+			revocation: TokenRevocation{RevokedBefore: &cutoff, RevokedBeforeExceptSessionID: "session-1"},
+			issuedAt:   cutoff.Add(-time.Second),
+			sessionID:  "session-2",
+			want:       true,
+		},
+		{
+			name:       "token without session before cutoff with exception",
+			revocation: TokenRevocation{RevokedBefore: &cutoff, RevokedBeforeExceptSessionID: "session-1"},
+			issuedAt:   cutoff.Add(-time.Second),
+			want:       true,
+		},
+		{
+			name:       "excepted session finished",
+			revocation: TokenRevocation{RevokedBefore: &cutoff, RevokedBeforeExceptSessionID: "session-1", FamilyRevoked: true},
+			issuedAt:   cutoff.Add(-time.Second),
+			sessionID:  "session-1",
+			want:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.revocation.IsRevoked(tt.issuedAt, tt.sessionID))
+		})
+	}
+}
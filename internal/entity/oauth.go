@@ -0,0 +1,59 @@
+package entity
+
+import "strings"
+
+const (
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+	// TokenTypeAccessToken - идентификатор типа токена для обмена (RFC 8693, раздел 3)
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+
+	ResponseTypeCode = "code"
+
+	TokenTypeBearer = "Bearer"
+)
+
+type CallerType string
+
+const (
+	CallerTypeUser    CallerType = "user"
+	CallerTypeService CallerType = "service"
+)
+
+// OAuthToken - ответ токен-эндпоинта (RFC 6749, раздел 5.1)
+type OAuthToken struct {
+	AccessToken  string
+	RefreshToken string
+	IDToken      string
+	TokenType    string
+	Scope        string
+	// IssuedTokenType - тип выданного токена, только для обмена токенов (RFC 8693, раздел 2.2.1)
+	IssuedTokenType string
+	ExpiresIn       int
+}
+
+// TokenExchange - запрос обмена токена пользователя на токен для другого сервиса (RFC 8693, раздел 2.1)
+type TokenExchange struct {
+	SubjectToken       string
+	SubjectTokenType   string
+	RequestedTokenType string
+	Audience           string
+	Scopes             []string
+}
+
+// ExchangeScopes - скоупы, которые можно запросить при обмене токена: скоупы OpenID Connect и скоупы прав на api.
+// Скоупы возможностей сервисных аккаунтов (introspect, token-exchange) пользовательскому токену не выдаются
+var ExchangeScopes = append([]string{ScopeOpenID, ScopeProfile, ScopeEmail}, PersonalTokenScopes...)
+
+// ParseScope - разбор строки скоупов, разделенных пробелами
+func ParseScope(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// FormatScope - сборка строки скоупов через пробел
+func FormatScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
@@ -0,0 +1,95 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// OAuthClient - зарегистрированный oauth-клиент (приложение), получающий токены пользователей
+type OAuthClient struct {
+	CreatedDate time.Time
+	UpdatedDate *time.Time
+	// SecretHash - хэш секрета, у публичных клиентов отсутствует
+	SecretHash *string
+	ID         string
+	Name       string
+	// Secret - секрет в открытом виде, известен только при создании и сбросе
+	Secret       string
+	RedirectURIs []string
+	GrantTypes   []string
+	Scopes       []string
+	// Audiences - дополнительные аудитории access-токенов клиента (сервисы, которые их принимают)
+	Audiences []string
+	// Public - клиент не может хранить секрет (spa, мобильное приложение)
+	Public bool
+}
+
+// OAuthClientUpdate - модель редактирования oauth-клиента
+type OAuthClientUpdate struct {
+	Name         *string
+	RedirectURIs *[]string
+	GrantTypes   *[]string
+	Scopes       *[]string
+	Audiences    *[]string
+	ID           string
+}
+
+func (c *OAuthClient) GenerateID() {
+	c.ID = uuid.New().String()
+}
+
+func (c *OAuthClient) GenerateCreatedDate() {
+	c.CreatedDate = time.Now().UTC()
+}
+
+// HasRedirectURI - зарегистрирован ли у клиента redirect_uri, сравнение только точное
+func (c OAuthClient) HasRedirectURI(redirectURI string) bool {
+	for _, registered := range c.RedirectURIs {
+		if registered == redirectURI {
+			return true
+		}
+	}
+	return false
+}
+
+// HasGrantType - разрешен ли клиенту тип гранта
+func (c OAuthClient) HasGrantType(grantType string) bool {
+	for _, allowed := range c.GrantTypes {
+		if allowed == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorizeRequest - запрос авторизации (RFC 6749, раздел 4.1.1 и RFC 7636, раздел 4.3)
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	// Nonce - значение OpenID Connect, возвращается в id_token без изменений
+	Nonce string
+}
+
+// AuthorizationCode - выданный код авторизации, одноразовый и короткоживущий
+type AuthorizationCode struct {
+	CreatedDate   time.Time
+	AuthTime      time.Time // момент входа пользователя
+	ClientID      string
+	UserID        string
+	RedirectURI   string
+	Scope         string
+	CodeChallenge string
+	Nonce         string
+}
+
+// CodeExchange - параметры обмена кода авторизации на токены
+type CodeExchange struct {
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+}
@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"strings"
+	"time"
+)
+
+// Скоупы OpenID Connect (OpenID Connect Core 1.0, раздел 5.4)
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// UserInfo - claims пользователя, доступные по выданным скоупам
+type UserInfo struct {
+	Email      string `json:"email,omitempty"`
+	Name       string `json:"name,omitempty"`
+	GivenName  string `json:"given_name,omitempty"`
+	FamilyName string `json:"family_name,omitempty"`
+}
+
+// NewUserInfo - сборка claims пользователя: email - по скоупу email, имя - по скоупу profile
+func NewUserInfo(user User, scopes []string) UserInfo {
+	var info UserInfo
+	for _, scope := range scopes {
+		switch scope {
+		case ScopeEmail:
+			info.Email = user.Email
+		case ScopeProfile:
+			info.Name = strings.TrimSpace(user.Name + " " + user.Surname)
+			info.GivenName = user.Name
+			info.FamilyName = user.Surname
+		}
+	}
+	return info
+}
+
+// IDTokenClaims - claims id_token (OpenID Connect Core 1.0, раздел 2)
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	Nonce    string           `json:"nonce,omitempty"`
+	UserInfo
+}
+
+// IDTokenRequest - данные для выпуска id_token
+type IDTokenRequest struct {
+	AuthTime time.Time
+	User     User
+	ClientID string
+	Nonce    string
+	Scopes   []string
+}
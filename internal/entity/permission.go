@@ -0,0 +1,138 @@
+package entity
+
+import "slices"
+
+// Permission - право на операцию. Суффикс self ограничивает операцию ресурсами самого вызывающего,
+// суффикс any разрешает ее над ресурсами любого пользователя
+type Permission string
+
+const (
+	PermissionUsersRead          Permission = "users:read"
+	PermissionUsersUpdateSelf    Permission = "users:update:self"
+	PermissionUsersUpdateAny     Permission = "users:update:any"
+	PermissionUsersDeleteSelf    Permission = "users:delete:self"
+	PermissionPasswordChangeSelf Permission = "password:change:self"
+	PermissionSessionsReadSelf   Permission = "sessions:read:self"
+	PermissionSessionsReadAny    Permission = "sessions:read:any"
+	PermissionSessionsDeleteSelf Permission = "sessions:delete:self"
+	PermissionSessionsDeleteAny  Permission = "sessions:delete:any"
+	PermissionTokensManageSelf   Permission = "tokens:manage:self"
+	PermissionServiceAccounts    Permission = "service-accounts:manage"
+	PermissionOAuthClients       Permission = "oauth-clients:manage"
+	PermissionRolesManage        Permission = "roles:manage"
+	PermissionUsersImpersonate   Permission = "users:impersonate"
+)
+
+// AllPermissions - права, известные сервису, только их можно выдавать ролям
+var AllPermissions = Permissions{
+	PermissionUsersRead,
+	PermissionUsersUpdateSelf,
+	PermissionUsersUpdateAny,
+	PermissionUsersDeleteSelf,
+	PermissionPasswordChangeSelf,
+	PermissionSessionsReadSelf,
+	PermissionSessionsReadAny,
+	PermissionSessionsDeleteSelf,
+	PermissionSessionsDeleteAny,
+	PermissionTokensManageSelf,
+	PermissionServiceAccounts,
+	PermissionOAuthClients,
+	PermissionRolesManage,
+	PermissionUsersImpersonate,
+}
+
+// selfPermissions - права над ресурсами самого вызывающего, роль только с ними не считается привилегированной
+var selfPermissions = Permissions{
+	PermissionUsersRead,
+	PermissionUsersUpdateSelf,
+	PermissionUsersDeleteSelf,
+	PermissionPasswordChangeSelf,
+	PermissionSessionsReadSelf,
+	PermissionSessionsDeleteSelf,
+	PermissionTokensManageSelf,
+}
+
+// Permissions - набор прав вызывающего
+type Permissions []Permission
+
+// Has - есть ли в наборе все перечисленные права
+func (p Permissions) Has(permissions ...Permission) bool {
+	for _, permission := range permissions {
+		if !slices.Contains(p, permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// impersonationDeniedPermissions - права, недоступные по токену имперсонации: через них администратор мог бы
+// сохранить доступ к пользователю после истечения токена или необратимо изменить учетную запись
+var impersonationDeniedPermissions = Permissions{
+	PermissionTokensManageSelf,
+	PermissionUsersDeleteSelf,
+	PermissionPasswordChangeSelf,
+}
+
+// WithoutImpersonationDenied - набор без прав, недоступных по токену имперсонации
+func (p Permissions) WithoutImpersonationDenied() Permissions {
+	permissions := make(Permissions, 0, len(p))
+	for _, permission := range p {
+		if !slices.Contains(impersonationDeniedPermissions, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions
+}
+
+// Privileged - есть ли в наборе права сверх прав над собственными ресурсами
+func (p Permissions) Privileged() bool {
+	return !selfPermissions.Has(p...)
+}
+
+// ServicePermissions - права сервисных аккаунтов, вызывающих api по токену client_credentials
+var ServicePermissions = Permissions{
+	PermissionUsersRead,
+}
+
+// scopePermissions - права, которые открывает скоуп персонального токена или токена oauth-клиента. Смену пароля
+// и управление персональными токенами не открывает ни один скоуп: утекший токен не должен позволять захватить
+// учетную запись
+var scopePermissions = map[string]Permissions{
+	PersonalScopeUsersRead:     {PermissionUsersRead},
+	PersonalScopeUsersWrite:    {PermissionUsersUpdateSelf, PermissionUsersDeleteSelf},
+	PersonalScopeSessionsRead:  {PermissionSessionsReadSelf},
+	PersonalScopeSessionsWrite: {PermissionSessionsDeleteSelf},
+	PersonalScopeAdmin: {
+		PermissionUsersUpdateAny,
+		PermissionSessionsReadAny,
+		PermissionSessionsDeleteAny,
+		PermissionServiceAccounts,
+		PermissionOAuthClients,
+		PermissionRolesManage,
+	},
+}
+
+// LimitedByScopes - права из набора, которые открывает хотя бы один из скоупов
+func (p Permissions) LimitedByScopes(scopes []string) Permissions {
+	permissions := make(Permissions, 0)
+	for _, permission := range p {
+		for _, scope := range scopes {
+			if slices.Contains(scopePermissions[scope], permission) {
+				permissions = append(permissions, permission)
+				break
+			}
+		}
+	}
+	return permissions
+}
+
+// Permissions - права персонального токена: права роли владельца, ограниченные скоупами токена
+func (p PersonalToken) Permissions(rolePermissions Permissions) Permissions {
+	return rolePermissions.LimitedByScopes(p.Scopes)
+}
+
+// ClientPermissions - права токена пользователя, выданного oauth-клиенту: права роли пользователя, ограниченные
+// выданными клиенту скоупами. Скоупы OpenID Connect прав на api не открывают
+func (c UserClaims) ClientPermissions(rolePermissions Permissions) Permissions {
+	return rolePermissions.LimitedByScopes(ParseScope(c.Scope))
+}
@@ -0,0 +1,141 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var adminRolePermissions = Permissions{
+	PermissionUsersRead,
+	PermissionUsersUpdateSelf,
+	PermissionUsersDeleteSelf,
+	PermissionPasswordChangeSelf,
+	PermissionSessionsReadSelf,
+	PermissionSessionsDeleteSelf,
+	PermissionTokensManageSelf,
+	PermissionUsersUpdateAny,
+	PermissionSessionsReadAny,
+	PermissionRolesManage,
+}
+
+func TestPersonalTokenPermissions(t *testing.T) {
+	tests := []struct {
+		name            string
+		scopes          []string
+		rolePermissions Permissions
+		want            Permissions
+	}{
+		{
+			name:            "read scope",
+			scopes:          []string{PersonalScopeUsersRead},
+			rolePermissions: adminRolePermissions,
+			want:            Permissions{PermissionUsersRead},
+		},
+		{
+			name:            "several scopes",
+			scopes:          []string{PersonalScopeUsersWrite, PersonalScopeSessionsRead, PersonalScopeSessionsWrite},
+			rolePermissions: adminRolePermissions,
+			want: Permissions{PermissionUsersUpdateSelf, PermissionUsersDeleteSelf, PermissionSessionsReadSelf,
+				PermissionSessionsDeleteSelf},
+		},
+		{
+			name:            "admin scope limited by role",
+			scopes:          []string{PersonalScopeAdmin},
+			rolePermissions: adminRolePermissions,
+			want:            Permissions{PermissionUsersUpdateAny, PermissionSessionsReadAny, PermissionRolesManage},
+		},
+		{
+			name:            "admin scope without privileged role",
+			scopes:          []string{PersonalScopeAdmin},
+			rolePermissions: selfPermissions,
+			want:            Permissions{},
+		},
+		{
+			name:            "unknown scope",
+			scopes:          []string{"users:delete"},
+			rolePermissions: adminRolePermissions,
+			want:            Permissions{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			permissions := PersonalToken{Scopes: tt.scopes}.Permissions(tt.rolePermissions)
+			assert.Equal(t, tt.want, permissions)
+			assert.False(t, permissions.Has(PermissionPasswordChangeSelf))
+			assert.False(t, permissions.Has(PermissionTokensManageSelf))
+		})
+	}
+}
+
+func TestUserClaimsClientPermissions(t *testing.T) {
+	tests := []struct {
+		name  string
+		scope string
+		want  Permissions
+	}{
+		{
+			name:  "openid scopes only",
+			scope: FormatScope([]string{ScopeOpenID, ScopeProfile, ScopeEmail}),
+			want:  Permissions{},
+		},
+		{
+			name:  "api scopes",
+			scope: FormatScope([]string{ScopeOpenID, PersonalScopeUsersRead, PersonalScopeSessionsRead}),
+			want:  Permissions{PermissionUsersRead, PermissionSessionsReadSelf},
+		},
+		{
+			name:  "no scopes",
+			scope: "",
+			want:  Permissions{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := UserClaims{ClientID: "client", Scope: tt.scope}
+			assert.Equal(t, tt.want, claims.ClientPermissions(adminRolePermissions))
+		})
+	}
+}
+
+func TestPermissionsWithoutImpersonationDenied(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions Permissions
+		want        Permissions
+	}{
+		{
+			name:        "self permissions",
+			permissions: selfPermissions,
+			want: Permissions{PermissionUsersRead, PermissionUsersUpdateSelf, PermissionSessionsReadSelf,
+				PermissionSessionsDeleteSelf},
+		},
+		{
+			name:        "privileged permissions kept",
+			permissions: adminRolePermissions,
+			want: Permissions{PermissionUsersRead, PermissionUsersUpdateSelf, PermissionSessionsReadSelf,
+				PermissionSessionsDeleteSelf, PermissionUsersUpdateAny, PermissionSessionsReadAny, PermissionRolesManage},
+		},
+		{
+			name:        "only denied permissions",
+			permissions: Permissions{PermissionTokensManageSelf, PermissionUsersDeleteSelf, PermissionPasswordChangeSelf},
+			want:        Permissions{},
+		},
+		{
+			name:        "empty",
+			permissions: nil,
+			want:        Permissions{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			permissions := tt.permissions.WithoutImpersonationDenied()
+			assert.Equal(t, tt.want, permissions)
+			for _, denied := range impersonationDeniedPermissions {
+				assert.False(t, permissions.Has(denied))
+			}
+		})
+	}
+}
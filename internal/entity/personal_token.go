@@ -0,0 +1,75 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// PersonalTokenPrefix - префикс персональных токенов, по нему токен отличается от jwt и узнается в логах и сканерах секретов
+const PersonalTokenPrefix = "pat_"
+
+// personalTokenDisplayLength - длина начала токена, которое хранится в открытом виде для отображения в списке
+const personalTokenDisplayLength = 12
+
+// Скоупы персональных токенов, каждый открывает группу операций
+const (
+	PersonalScopeUsersRead     = "users:read"
+	PersonalScopeUsersWrite    = "users:write"
+	PersonalScopeSessionsRead  = "sessions:read"
+	PersonalScopeSessionsWrite = "sessions:write"
+	PersonalScopeAdmin         = "admin"
+)
+
+// PersonalTokenScopes - скоупы, которые можно выдать персональному токену
+var PersonalTokenScopes = []string{
+	PersonalScopeUsersRead,
+	PersonalScopeUsersWrite,
+	PersonalScopeSessionsRead,
+	PersonalScopeSessionsWrite,
+	PersonalScopeAdmin,
+}
+
+// PersonalToken - персональный токен доступа пользователя для скриптов и интеграций
+type PersonalToken struct {
+	CreatedDate  time.Time
+	UpdatedDate  *time.Time
+	ExpiresDate  *time.Time
+	LastUsedDate *time.Time
+	ID           string
+	UserID       string
+	Name         string
+	// Token - токен в открытом виде, известен только при создании
+	Token       string
+	TokenHash   string
+	TokenPrefix string
+	LastUsedIP  *string
+	Scopes      []string
+}
+
+// PersonalTokenUpdate - модель редактирования персонального токена
+type PersonalTokenUpdate struct {
+	Name   *string
+	Scopes *[]string
+	ID     string
+	UserID string
+}
+
+func (p *PersonalToken) GenerateID() {
+	p.ID = uuid.New().String()
+}
+
+func (p *PersonalToken) GenerateCreatedDate() {
+	p.CreatedDate = time.Now().UTC()
+}
+
+// SetToken - установка нового токена: хранится только хэш и начало токена для отображения
+func (p *PersonalToken) SetToken(token, hash string) {
+	p.Token = token
+	p.TokenHash = hash
+	p.TokenPrefix = token[:min(len(token), personalTokenDisplayLength)]
+}
+
+// IsExpired - истек ли срок действия токена
+func (p PersonalToken) IsExpired(now time.Time) bool {
+	return p.ExpiresDate != nil && !now.Before(*p.ExpiresDate)
+}
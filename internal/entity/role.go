@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// Role - роль пользователя с набором прав, хранится в бд
+type Role struct {
+	CreatedDate time.Time
+	UpdatedDate *time.Time
+	Name        string
+	Description string
+	Permissions Permissions
+	// Level - уровень роли: выдавать роли и управлять пользователями и ролями можно только уровнем ниже своего
+	Level int
+	// BuiltIn - встроенная роль, создается миграцией и не может быть удалена
+	BuiltIn bool
+}
+
+// RoleUpdate - модель редактирования роли
+type RoleUpdate struct {
+	Description *string
+	Permissions *Permissions
+	Level       *int
+	Name        string
+}
+
+// Actor - пользователь, выполняющий изменение пользователей или ролей
+type Actor struct {
+	ID   string
+	Role RoleType
+}
+
+// RoleChange - запись аудита смены роли пользователя
+type RoleChange struct {
+	CreatedDate time.Time
+	ID          string
+	UserID      string
+	ActorID     string
+	OldRole     RoleType
+	NewRole     RoleType
+	Reason      string
+}
+
+func (r *Role) GenerateCreatedDate() {
+	r.CreatedDate = time.Now().UTC()
+}
+
+// Outranks - роль выше указанного уровня. Суперадминистратор выше любой роли, в том числе своей
+func (r Role) Outranks(level int) bool {
+	return r.Name == string(RoleSuperAdmin) || r.Level > level
+}
+
+// IsLastSuperAdmin - пользователь userID единственный среди суперадминистраторов superAdminIDs: такой пользователь
+// не может лишиться роли или быть удален
+func IsLastSuperAdmin(superAdminIDs []string, userID string) bool {
+	return len(superAdminIDs) == 1 && strings.EqualFold(superAdminIDs[0], userID)
+}
+
+// NewRoleChange - запись аудита смены роли пользователя
+func NewRoleChange(userID, actorID string, oldRole, newRole RoleType, reason string) RoleChange {
+	return RoleChange{
+		CreatedDate: time.Now().UTC(),
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		ActorID:     actorID,
+		OldRole:     oldRole,
+		NewRole:     newRole,
+		Reason:      reason,
+	}
+}
@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRoleOutranks(t *testing.T) {
+	tests := []struct {
+		name  string
+		role  Role
+		level int
+		want  bool
+	}{
+		{
+			name:  "higher level",
+			role:  Role{Name: string(RoleAdmin), Level: 50},
+			level: 30,
+			want:  true,
+		},
+		{
+			name:  "same level",
+			role:  Role{Name: string(RoleAdmin), Level: 50},
+			level: 50,
+		},
+		{
+			name:  "lower level",
+			role:  Role{Name: "support", Level: 30},
+			level: 50,
+		},
+		{
+			name:  "super-admin over own level",
+			role:  Role{Name: string(RoleSuperAdmin), Level: 100},
+			level: 100,
+			want:  true,
+		},
+		{
+			name:  "custom role with super-admin level",
+			role:  Role{Name: "owner", Level: 100},
+			level: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.role.Outranks(tt.level))
+		})
+	}
+}
+
+func TestIsLastSuperAdmin(t *testing.T) {
+	tests := []struct {
+		name          string
+		superAdminIDs []string
+		userID        string
+		want          bool
+	}{
+		{
+			name:          "last super-admin",
+			superAdminIDs: []string{"5b0b1f4e-8a39-4c55-a2a4-2a3c0f1e9d11"},
+			userID:        "5b0b1f4e-8a39-4c55-a2a4-2a3c0f1e9d11",
+			want:          true,
+		},
+		{
+			name:          "last super-admin in other case",
+			superAdminIDs: []string{"5b0b1f4e-8a39-4c55-a2a4-2a3c0f1e9d11"},
+			userID:        "5B0B1F4E-8A39-4C55-A2A4-2A3C0F1E9D11",
+			want:          true,
+		},
+		{
+			name:          "other super-admins remain",
+			superAdminIDs: []string{"5b0b1f4e-8a39-4c55-a2a4-2a3c0f1e9d11", "0f4c2a1e-1b2c-4d5e-8f90-a1b2c3d4e5f6"},
+			userID:        "5b0b1f4e-8a39-4c55-a2a4-2a3c0f1e9d11",
+		},
+		{
+			name:          "user is not super-admin",
+			superAdminIDs: []string{"5b0b1f4e-8a39-4c55-a2a4-2a3c0f1e9d11"},
+			userID:        "0f4c2a1e-1b2c-4d5e-8f90-a1b2c3d4e5f6",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsLastSuperAdmin(tt.superAdminIDs, tt.userID))
+		})
+	}
+}
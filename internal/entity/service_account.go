@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// ScopeIntrospect - скоуп, дающий сервисному аккаунту право интроспекции токенов
+const ScopeIntrospect = "introspect"
+
+// ScopeTokenExchange - скоуп, дающий сервисному аккаунту право обменивать токены пользователей (RFC 8693)
+const ScopeTokenExchange = "token-exchange"
+
+// ServiceAccount - сервисный аккаунт (oauth-клиент для client_credentials)
+type ServiceAccount struct {
+	CreatedDate time.Time
+	UpdatedDate *time.Time
+	ID          string
+	Name        string
+	OwnerID     string
+	SecretHash  string
+	// Secret - секрет в открытом виде, известен только при создании и сбросе
+	Secret string
+	Scopes []string
+	// Audiences - дополнительные аудитории токенов аккаунта (сервисы, которые их принимают)
+	Audiences []string
+}
+
+// ServiceAccountUpdate - модель редактирования сервисного аккаунта
+type ServiceAccountUpdate struct {
+	Name      *string
+	Scopes    *[]string
+	Audiences *[]string
+	ID        string
+}
+
+func (s *ServiceAccount) GenerateID() {
+	s.ID = uuid.New().String()
+}
+
+func (s *ServiceAccount) GenerateCreatedDate() {
+	s.CreatedDate = time.Now().UTC()
+}
+
+// HasScope - разрешен ли сервисному аккаунту скоуп
+func (s ServiceAccount) HasScope(scope string) bool {
+	for _, allowed := range s.Scopes {
+		if allowed == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package entity
+
+import "time"
+
+// Session - сессия пользователя на устройстве. Идентификатор сессии совпадает с идентификатором семейства рефреш-токенов
+type Session struct {
+	CreatedDate  time.Time
+	LastUsedDate time.Time
+	ID           string
+	UserID       string
+	DeviceName   string
+	UserAgent    string
+	IP           string
+	// ClientID, Scope и Audiences - oauth-клиент, выданные ему скоупы и его аудитории на момент входа,
+	// переносятся во все access-токены сессии
+	ClientID  string   `json:",omitempty"`
+	Scope     string   `json:",omitempty"`
+	Audiences []string `json:",omitempty"`
+}
+
+// NewSession - создание сессии для клиента
+func NewSession(id, userID string, client ClientInfo) Session {
+	now := time.Now().UTC()
+	return Session{
+		CreatedDate:  now,
+		LastUsedDate: now,
+		ID:           id,
+		UserID:       userID,
+		DeviceName:   client.DeviceName,
+		UserAgent:    client.UserAgent,
+		IP:           client.IP,
+		ClientID:     client.ClientID,
+		Scope:        client.Scope,
+		Audiences:    client.Audiences,
+	}
+}
+
+// Touch - отметка об использовании сессии клиентом
+func (s *Session) Touch(client ClientInfo) {
+	s.LastUsedDate = time.Now().UTC()
+	s.UserAgent = client.UserAgent
+	s.IP = client.IP
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// виды ограничений входа после неудачных попыток
+const (
+	// SignInLockLockout - блокировка до истечения срока или снятия администратором
+	SignInLockLockout = "lockout"
+	// SignInLockDelay - прогрессивная задержка перед следующей попыткой
+	SignInLockDelay = "delay"
+)
+
+// SignInLock - ограничение входа. Subject - учетная запись или ip с префиксом области (email:, ip:),
+// TTL - время до снятия ограничения
+type SignInLock struct {
+	Subject string
+	Kind    string
+	TTL     time.Duration
+}
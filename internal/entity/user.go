@@ -5,8 +5,10 @@ import (
 	"time"
 )
 
+// RoleType - название роли пользователя, роли и их права хранятся в бд
 type RoleType string
 
+// Встроенные роли
 const (
 	RoleUser       RoleType = "user"
 	RoleAdmin      RoleType = "admin"
@@ -24,6 +26,8 @@ type User struct {
 	Password    string
 	Role        RoleType
 	JWT         JWT
+	// EmailVerified - пользователь подтвердил владение email
+	EmailVerified bool
 }
 
 // UserUpdateBase - базовая модель пользователя для редактирования
@@ -43,6 +47,8 @@ type UserUpdate struct {
 // UserUpdatePrivate - модель приватного обновления пользователя
 type UserUpdatePrivate struct {
 	Role *RoleType
+	// Reason - причина смены роли, сохраняется в аудите
+	Reason string
 	UserUpdateBase
 }
 
@@ -83,3 +89,17 @@ type JWT struct {
 	Token        string
 	RefreshToken string
 }
+
+// PasswordReset - выданный токен сброса пароля, хранится по хэшу токена. Токен привязан к хэшу пароля на момент
+// выдачи, поэтому после любой смены пароля ранее выданные токены недействительны
+type PasswordReset struct {
+	UserID              string `json:"userId"`
+	PasswordFingerprint string `json:"passwordFingerprint"`
+}
+
+// EmailVerification - выданный токен подтверждения email, хранится по хэшу токена. Токен подтверждает только email,
+// на который было отправлено письмо
+type EmailVerification struct {
+	UserID string `json:"userId"`
+	Email  string `json:"email"`
+}
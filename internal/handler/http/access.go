@@ -0,0 +1,79 @@
+package http
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/go-chi/chi/v5"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+)
+
+// access - требования роута к вызывающему, объявляются при регистрации роута в InitRoutes
+type access struct {
+	// public - роут доступен без авторизации
+	public bool
+	// permissions - права, которые должны быть у вызывающего
+	permissions entity.Permissions
+	// selfParam - параметр пути с идентификатором пользователя, который должен совпадать с вызывающим
+	selfParam string
+}
+
+// public - роут без авторизации
+func public() access {
+	return access{public: true}
+}
+
+// authenticated - роут для любого авторизованного вызывающего, кроме персональных токенов
+func authenticated() access {
+	return access{}
+}
+
+// require - роут для вызывающих со всеми перечисленными правами
+func require(permissions ...entity.Permission) access {
+	return access{permissions: permissions}
+}
+
+// requireSelf - роут над ресурсом самого вызывающего: нужны права и совпадение параметра id пути с вызывающим
+func requireSelf(permissions ...entity.Permission) access {
+	return access{permissions: permissions, selfParam: config.ParamID}
+}
+
+// authorize - проверка требований роута к вызывающему
+func (a access) authorize(r *http.Request, caller principal) error {
+	if caller.personalToken && len(a.permissions) == 0 {
+		return apperror.ForbiddenError(apperror.ErrPersonalTokenNotAllowed)
+	}
+
+	for _, permission := range a.permissions {
+		if !caller.permissions.Has(permission) {
+			return apperror.ForbiddenError(errors.Wrapf(apperror.ErrPermissionDenied,
+				"caller [%s] does not have permission [%s]", caller.claims.Subject, permission))
+		}
+	}
+
+	if a.selfParam != "" && !strings.EqualFold(chi.URLParam(r, a.selfParam), caller.claims.Subject) {
+		return apperror.ForbiddenError(errors.Wrapf(apperror.ErrPermissionDenied,
+			"caller [%s] does not own resource [%s]", caller.claims.Subject, chi.URLParam(r, a.selfParam)))
+	}
+
+	return nil
+}
+
+// principal - авторизованный вызывающий
+type principal struct {
+	claims      entity.UserClaims
+	permissions entity.Permissions
+	// personalToken - вызов выполнен по персональному токену
+	personalToken bool
+}
+
+// callerActor - вызывающий как исполнитель изменения, сервисы сверяют уровень его роли с затрагиваемыми ролями
+func callerActor(r *http.Request) entity.Actor {
+	claims := r.Context().Value(config.ParamClaims).(entity.UserClaims)
+	return entity.Actor{
+		ID:   claims.Subject,
+		Role: entity.RoleType(claims.Role),
+	}
+}
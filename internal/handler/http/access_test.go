@@ -0,0 +1,129 @@
+package http
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testCallerID = "5b0b1f4e-8a39-4c55-a2a4-2a3c0f1e9d11"
+
+func TestAccessAuthorize(t *testing.T) {
+	caller := principal{
+		claims:      entity.UserClaims{RegisteredClaims: jwt.RegisteredClaims{Subject: testCallerID}},
+		permissions: entity.Permissions{entity.PermissionUsersRead, entity.PermissionUsersUpdateSelf},
+	}
+	personalToken := caller
+	personalToken.personalToken = true
+
+	tests := []struct {
+		name    string
+		rule    access
+		caller  principal
+		pathID  string
+		wantErr error
+	}{
+		{
+			name:   "authenticated caller",
+			rule:   authenticated(),
+			caller: caller,
+		},
+		{
+			name:    "personal token on route without permissions",
+			rule:    authenticated(),
+			caller:  personalToken,
+			wantErr: apperror.ErrPersonalTokenNotAllowed,
+		},
+		{
+			name:   "personal token with required permission",
+			rule:   require(entity.PermissionUsersRead),
+			caller: personalToken,
+		},
+		{
+			name:   "all permissions present",
+			rule:   require(entity.PermissionUsersRead, entity.PermissionUsersUpdateSelf),
+			caller: caller,
+		},
+		{
+			name:    "one permission missing",
+			rule:    require(entity.PermissionUsersRead, entity.PermissionUsersUpdateAny),
+			caller:  caller,
+			wantErr: apperror.ErrPermissionDenied,
+		},
+		{
+			name:    "caller without permissions",
+			rule:    require(entity.PermissionUsersRead),
+			caller:  principal{claims: caller.claims},
+			wantErr: apperror.ErrPermissionDenied,
+		},
+		{
+			name:   "own resource",
+			rule:   requireSelf(entity.PermissionUsersUpdateSelf),
+			caller: caller,
+			pathID: testCallerID,
+		},
+		{
+			name:   "own resource in upper case",
+			rule:   requireSelf(entity.PermissionUsersUpdateSelf),
+			caller: caller,
+			pathID: "5B0B1F4E-8A39-4C55-A2A4-2A3C0F1E9D11",
+		},
+		{
+			name:    "other user resource",
+			rule:    requireSelf(entity.PermissionUsersUpdateSelf),
+			caller:  caller,
+			pathID:  "0f4c2a1e-1b2c-4d5e-8f90-a1b2c3d4e5f6",
+			wantErr: apperror.ErrPermissionDenied,
+		},
+		{
+			name:    "own resource without permission",
+			rule:    requireSelf(entity.PermissionUsersDeleteSelf),
+			caller:  caller,
+			pathID:  testCallerID,
+			wantErr: apperror.ErrPermissionDenied,
+		},
+		{
+			name:    "own resource without path id",
+			rule:    requireSelf(entity.PermissionUsersUpdateSelf),
+			caller:  caller,
+			wantErr: apperror.ErrPermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routeCtx := chi.NewRouteContext()
+			if tt.pathID != "" {
+				routeCtx.URLParams.Add("id", tt.pathID)
+			}
+			r := httptest.NewRequest(http.MethodPatch, "/public/v1/users/"+tt.pathID, nil)
+			r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+
+			err := tt.rule.authorize(r, tt.caller)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var appErr *apperror.AppError
+			if !assert.ErrorAs(t, err, &appErr) {
+				return
+			}
+			assert.Equal(t, http.StatusForbidden, appErr.StatusCode)
+			assert.ErrorIs(t, appErr.Err, tt.wantErr)
+		})
+	}
+}
+
+func TestAccessPublic(t *testing.T) {
+	assert.True(t, public().public)
+	assert.False(t, authenticated().public)
+	assert.False(t, require(entity.PermissionUsersRead).public)
+	assert.False(t, requireSelf(entity.PermissionUsersUpdateSelf).public)
+}
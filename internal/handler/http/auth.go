@@ -6,6 +6,7 @@ import (
 	"github.com/GermanBogatov/auth-service/internal/common/helpers"
 	"github.com/GermanBogatov/auth-service/internal/common/response"
 	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
 	"github.com/GermanBogatov/auth-service/internal/handler/http/mapper"
 	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
 	"github.com/GermanBogatov/auth-service/internal/handler/http/validator"
@@ -36,24 +37,42 @@ func (h *Handler) SignUp(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	user := mapper.MapToEntityUser(createUser)
+	err = h.userService.ValidatePassword(ctx, user, createUser.Password)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	passwordHash, err := h.userService.HashPassword(createUser.Password)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
 	user.GenerateID()
-	user.SetPasswordHash(helpers.GeneratePasswordHash(createUser.Password))
+	user.SetPasswordHash(passwordHash)
 	user.GenerateCreatedDate()
 	// todo когда админ появится условия предусмотреть
 	user.AddRoleUser()
 
-	token, refreshToken, err := h.jwtService.GenerateAccessAndRefreshTokens(ctx, user)
-	if err != nil {
-		return apperror.InternalServerError(err)
-	}
+	// в режиме блокировки токены выдаются только после подтверждения email
+	if h.cfg.EmailVerification.Mode != config.EmailVerificationModeBlock {
+		client := helpers.GetClientInfo(r)
+		client.DeviceName = createUser.DeviceName
 
-	user.SetJWT(token, refreshToken)
+		token, refreshToken, errToken := h.jwtService.GenerateAccessAndRefreshTokens(ctx, user, client)
+		if errToken != nil {
+			return apperror.InternalServerError(errToken)
+		}
+
+		user.SetJWT(token, refreshToken)
+	}
 
 	err = h.userService.CreateUser(ctx, user)
 	if err != nil {
 		return apperror.InternalServerError(err)
 	}
 
+	h.emailVerificationService.SendVerification(ctx, user)
+
 	return response.RespondSuccessCreate(w, mapper.MapToUserWithJWTResponse(http.StatusCreated, user))
 
 }
@@ -79,13 +98,15 @@ func (h *Handler) SignIn(w http.ResponseWriter, r *http.Request) error {
 		return apperror.BadRequestError(errors.Wrap(err, "validate create user"))
 	}
 
-	passwordHash := helpers.GeneratePasswordHash(signInUser.Password)
-	user, err := h.userService.GetUserByEmailAndPassword(ctx, signInUser.Email, passwordHash)
+	user, err := h.signInGuardService.SignIn(ctx, signInUser.Email, signInUser.Password, helpers.GetClientIP(r))
 	if err != nil {
 		return apperror.InternalServerError(err)
 	}
 
-	token, refreshToken, err := h.jwtService.GenerateAccessAndRefreshTokens(ctx, user)
+	client := helpers.GetClientInfo(r)
+	client.DeviceName = signInUser.DeviceName
+
+	token, refreshToken, err := h.jwtService.GenerateAccessAndRefreshTokens(ctx, user, client)
 	if err != nil {
 		return apperror.InternalServerError(err)
 	}
@@ -104,10 +125,184 @@ func (h *Handler) UpdateRefreshToken(w http.ResponseWriter, r *http.Request) err
 		return apperror.BadRequestError(errors.Wrap(err, "get refresh token from header"))
 	}
 
-	token, newRefreshToken, errToken := h.jwtService.UpdateRefreshToken(ctx, refreshToken)
+	token, newRefreshToken, errToken := h.jwtService.UpdateRefreshToken(ctx, refreshToken, helpers.GetClientInfo(r))
 	if errToken != nil {
 		return apperror.InternalServerError(errToken)
 	}
 
 	return response.RespondSuccess(w, mapper.MapToJWTResponse(http.StatusOK, token, newRefreshToken))
 }
+
+// Logout - хэндлер завершения текущей сессии
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	claims := ctx.Value(config.ParamClaims).(entity.UserClaims)
+
+	err := h.jwtService.RevokeSession(ctx, claims)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, response.ViewResponse{Code: http.StatusOK})
+}
+
+// LogoutAll - хэндлер завершения всех сессий пользователя
+func (h *Handler) LogoutAll(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	selfUserID := ctx.Value(config.ParamID).(string)
+
+	err := h.jwtService.RevokeAllSessions(ctx, selfUserID)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, response.ViewResponse{Code: http.StatusOK})
+}
+
+// ForgotPassword - хэндлер запроса письма для сброса пароля. Ответ всегда 202, чтобы по нему нельзя было узнать,
+// зарегистрирован ли email
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var forgotPassword model.ForgotPasswordRequest
+	defer func() {
+		err := r.Body.Close()
+		if err != nil {
+			logging.Error("error close request body")
+		}
+	}()
+
+	if err := json.NewDecoder(r.Body).Decode(&forgotPassword); err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "json decode"))
+	}
+
+	err := validator.ValidateForgotPassword(forgotPassword)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate forgot password"))
+	}
+
+	h.passwordResetService.ForgotPassword(ctx, forgotPassword.Email)
+
+	return response.RespondSuccessAccepted(w, response.ViewResponse{Code: http.StatusAccepted})
+}
+
+// ResetPassword - хэндлер смены пароля по токену из письма
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var resetPassword model.ResetPasswordRequest
+	defer func() {
+		err := r.Body.Close()
+		if err != nil {
+			logging.Error("error close request body")
+		}
+	}()
+
+	if err := json.NewDecoder(r.Body).Decode(&resetPassword); err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "json decode"))
+	}
+
+	err := validator.ValidateResetPassword(resetPassword)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate reset password"))
+	}
+
+	err = h.passwordResetService.ResetPassword(ctx, resetPassword.Token, resetPassword.Password)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, response.ViewResponse{Code: http.StatusOK})
+}
+
+// VerifyEmail - хэндлер подтверждения email: GET - переход по ссылке из письма с токеном в параметре token,
+// POST - токен в теле запроса от фронтенда
+func (h *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var verifyEmail model.VerifyEmailRequest
+	if r.Method == http.MethodGet {
+		verifyEmail.Token = r.URL.Query().Get("token")
+	} else {
+		defer func() {
+			err := r.Body.Close()
+			if err != nil {
+				logging.Error("error close request body")
+			}
+		}()
+
+		if err := json.NewDecoder(r.Body).Decode(&verifyEmail); err != nil {
+			return apperror.BadRequestError(errors.Wrap(err, "json decode"))
+		}
+	}
+
+	err := validator.ValidateVerifyEmail(verifyEmail)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate verify email"))
+	}
+
+	err = h.emailVerificationService.VerifyEmail(ctx, verifyEmail.Token)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, response.ViewResponse{Code: http.StatusOK})
+}
+
+// ResendEmailVerification - хэндлер повторной отправки письма подтверждения email. Ответ всегда 202, чтобы по нему
+// нельзя было узнать, зарегистрирован ли email
+func (h *Handler) ResendEmailVerification(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var resend model.ResendEmailVerificationRequest
+	defer func() {
+		err := r.Body.Close()
+		if err != nil {
+			logging.Error("error close request body")
+		}
+	}()
+
+	if err := json.NewDecoder(r.Body).Decode(&resend); err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "json decode"))
+	}
+
+	err := validator.ValidateResendEmailVerification(resend)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate resend email verification"))
+	}
+
+	h.emailVerificationService.ResendVerification(ctx, resend.Email)
+
+	return response.RespondSuccessAccepted(w, response.ViewResponse{Code: http.StatusAccepted})
+}
+
+// RevokeToken - хэндлер отзыва токена (RFC 7009)
+func (h *Handler) RevokeToken(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	err := r.ParseForm()
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "parse form"))
+	}
+
+	revokeRequest := model.TokenRequest{
+		Token:         r.PostForm.Get("token"),
+		TokenTypeHint: r.PostForm.Get("token_type_hint"),
+	}
+
+	err = validator.ValidateTokenRequest(revokeRequest)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate revoke request"))
+	}
+
+	err = h.jwtService.RevokeToken(ctx, revokeRequest.Token, revokeRequest.TokenTypeHint)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	// по RFC 7009 сервер отвечает 200 и для неизвестных токенов
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
@@ -0,0 +1,310 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"embed"
+	"fmt"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/validator"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"html/template"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	templateLogin = "login"
+	templateError = "error"
+)
+
+const (
+	// authorizeCSRFCookie - cookie с csrf-токеном формы входа (double-submit). Префикс __Host- требует Secure и
+	// Path=/ и запрещает Domain, поэтому cookie не может подменить поддомен
+	authorizeCSRFCookie = "__Host-authorize_csrf"
+	// authorizeCSRFField - поле формы входа с копией csrf-токена
+	authorizeCSRFField  = "csrf_token"
+	authorizeCSRFMaxAge = time.Hour
+)
+
+//go:embed templates/authorize.html
+var templatesFS embed.FS
+
+var authorizeTemplates = template.Must(template.ParseFS(templatesFS, "templates/authorize.html"))
+
+// authorizePage - данные страницы входа
+type authorizePage struct {
+	ClientName string
+	Email      string
+	Error      string
+	CSRFToken  string
+	Request    entity.AuthorizeRequest
+}
+
+// Authorize - авторизационный эндпоинт OAuth2 (RFC 6749, раздел 3.1): страница входа пользователя
+func (h *Handler) Authorize(w http.ResponseWriter, r *http.Request) error {
+	request := parseAuthorizeRequest(r.URL.Query())
+
+	client, err := h.checkAuthorizeRequest(r.Context(), &request)
+	if err != nil {
+		return authorizeError(w, r, request, err)
+	}
+
+	csrfToken, err := setAuthorizeCSRFCookie(w)
+	if err != nil {
+		return authorizeError(w, r, request, apperror.OAuthInternalServerError(err))
+	}
+
+	return renderAuthorizePage(w, http.StatusOK, templateLogin, authorizePage{
+		ClientName: client.Name,
+		CSRFToken:  csrfToken,
+		Request:    request,
+	})
+}
+
+// AuthorizeSubmit - вход пользователя со страницы авторизации и возврат кода клиенту на redirect_uri
+func (h *Handler) AuthorizeSubmit(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	err := r.ParseForm()
+	if err != nil {
+		logging.Errorf("authorize: parse form: %s", err)
+		return renderAuthorizePage(w, http.StatusBadRequest, templateError, authorizePage{Error: "Некорректный запрос"})
+	}
+
+	// форма, отправленная с чужого сайта, не знает токена из cookie: без проверки такой сайт мог бы войти
+	// пользователем в учетную запись атакующего (login csrf) и получить код на свой redirect_uri
+	csrfToken, ok := checkAuthorizeCSRF(r)
+	if !ok {
+		logging.Errorf("authorize: csrf token mismatch")
+		return renderAuthorizePage(w, http.StatusForbidden, templateError,
+			authorizePage{Error: "Сессия входа устарела, откройте страницу входа заново"})
+	}
+
+	request := parseAuthorizeRequest(r.PostForm)
+
+	client, err := h.checkAuthorizeRequest(ctx, &request)
+	if err != nil {
+		return authorizeError(w, r, request, err)
+	}
+
+	page := authorizePage{
+		ClientName: client.Name,
+		Email:      r.PostForm.Get("email"),
+		CSRFToken:  csrfToken,
+		Request:    request,
+	}
+
+	password := r.PostForm.Get("password")
+	if page.Email == "" || password == "" {
+		page.Error = "Введите email и пароль"
+		return renderAuthorizePage(w, http.StatusBadRequest, templateLogin, page)
+	}
+
+	user, err := h.signInGuardService.SignIn(ctx, page.Email, password, helpers.GetClientIP(r))
+	if err != nil {
+		if errors.Is(err, apperror.ErrUserNotFound) {
+			page.Error = "Неверный email или пароль"
+			return renderAuthorizePage(w, http.StatusUnauthorized, templateLogin, page)
+		}
+		var throttledErr *apperror.SignInThrottledError
+		if errors.As(err, &throttledErr) {
+			appErr := apperror.SignInThrottledAppError(err, throttledErr.RetryAfter)
+			retryAfterSec := int(math.Ceil(throttledErr.RetryAfter.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSec))
+
+			page.Error = fmt.Sprintf("Слишком много неудачных попыток входа, повторите через %d с", retryAfterSec)
+			if errors.Is(err, apperror.ErrAccountLocked) {
+				page.Error = fmt.Sprintf("Учетная запись временно заблокирована, повторите через %d с", retryAfterSec)
+			}
+			return renderAuthorizePage(w, appErr.StatusCode, templateLogin, page)
+		}
+		return authorizeError(w, r, request, apperror.OAuthInternalServerError(err))
+	}
+	if !user.EmailVerified && h.cfg.EmailVerification.Mode == config.EmailVerificationModeBlock {
+		page.Error = "Подтвердите email по ссылке из письма"
+		return renderAuthorizePage(w, http.StatusForbidden, templateLogin, page)
+	}
+
+	code, err := h.oauthService.CreateAuthorizationCode(ctx, request, user.ID)
+	if err != nil {
+		return authorizeError(w, r, request, apperror.OAuthInternalServerError(err))
+	}
+
+	params := url.Values{"code": {code}}
+	if request.State != "" {
+		params.Set("state", request.State)
+	}
+
+	return redirectToClient(w, r, request.RedirectURI, params)
+}
+
+// checkAuthorizeRequest - проверка запроса авторизации. Пока клиент и redirect_uri не подтверждены,
+// возвращаются обычные ошибки, после - oauth-ошибки, которые можно отдать клиенту через redirect_uri
+func (h *Handler) checkAuthorizeRequest(ctx context.Context, request *entity.AuthorizeRequest) (entity.OAuthClient, error) {
+	_, err := uuid.Parse(request.ClientID)
+	if err != nil {
+		return entity.OAuthClient{}, apperror.ErrInvalidClient
+	}
+
+	client, err := h.oauthClientService.GetOAuthClientByID(ctx, request.ClientID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrOAuthClientNotFound) {
+			return entity.OAuthClient{}, apperror.ErrInvalidClient
+		}
+		return entity.OAuthClient{}, err
+	}
+
+	if !client.HasRedirectURI(request.RedirectURI) {
+		return entity.OAuthClient{}, apperror.ErrInvalidRedirectURI
+	}
+
+	err = validator.ValidateAuthorizeRequest(*request)
+	if err != nil {
+		switch {
+		case errors.Is(err, apperror.ErrUnsupportedResponseType):
+			return entity.OAuthClient{}, apperror.OAuthBadRequestError(apperror.OAuthUnsupportedResponseType, err)
+		case errors.Is(err, apperror.ErrInvalidScope), errors.Is(err, apperror.ErrEmptyScope):
+			return entity.OAuthClient{}, apperror.OAuthBadRequestError(apperror.OAuthInvalidScope, err)
+		default:
+			return entity.OAuthClient{}, apperror.OAuthBadRequestError(apperror.OAuthInvalidRequest, err)
+		}
+	}
+
+	if !client.HasGrantType(entity.GrantTypeAuthorizationCode) {
+		return entity.OAuthClient{}, apperror.OAuthBadRequestError(apperror.OAuthUnauthorizedClient, apperror.ErrUnauthorizedClient)
+	}
+
+	scopes, err := grantedScopes(client.Scopes, entity.ParseScope(request.Scope))
+	if err != nil {
+		return entity.OAuthClient{}, apperror.OAuthBadRequestError(apperror.OAuthInvalidScope, err)
+	}
+	request.Scope = entity.FormatScope(scopes)
+
+	return client, nil
+}
+
+// authorizeError - ошибка авторизационного эндпоинта: oauth-ошибки возвращаются клиенту на redirect_uri,
+// остальные показываются пользователю, так как redirect_uri не подтвержден (RFC 6749, раздел 4.1.2.1)
+func authorizeError(w http.ResponseWriter, r *http.Request, request entity.AuthorizeRequest, err error) error {
+	logging.Errorf("authorize: client [%s]: %s", request.ClientID, err)
+
+	var oauthErr *apperror.OAuthError
+	if errors.As(err, &oauthErr) {
+		params := url.Values{"error": {oauthErr.Code}}
+		if oauthErr.StatusCode < http.StatusInternalServerError {
+			params.Set("error_description", oauthErr.Error())
+		}
+		if request.State != "" {
+			params.Set("state", request.State)
+		}
+		return redirectToClient(w, r, request.RedirectURI, params)
+	}
+
+	switch {
+	case errors.Is(err, apperror.ErrInvalidClient):
+		return renderAuthorizePage(w, http.StatusBadRequest, templateError, authorizePage{Error: "Неизвестное приложение"})
+	case errors.Is(err, apperror.ErrInvalidRedirectURI):
+		return renderAuthorizePage(w, http.StatusBadRequest, templateError, authorizePage{Error: "Адрес возврата не зарегистрирован для приложения"})
+	default:
+		return renderAuthorizePage(w, http.StatusInternalServerError, templateError, authorizePage{Error: "Сервис временно недоступен"})
+	}
+}
+
+// setAuthorizeCSRFCookie - выпуск csrf-токена формы входа: токен ставится в cookie и встраивается в форму
+func setAuthorizeCSRFCookie(w http.ResponseWriter) (string, error) {
+	token, err := helpers.GenerateSecret()
+	if err != nil {
+		return "", errors.Wrap(err, "helpers.GenerateSecret")
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     authorizeCSRFCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(authorizeCSRFMaxAge.Seconds()),
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	return token, nil
+}
+
+// checkAuthorizeCSRF - проверка, что токен из формы совпадает с токеном из cookie
+func checkAuthorizeCSRF(r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(authorizeCSRFCookie)
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+
+	token := r.PostForm.Get(authorizeCSRFField)
+	if subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(token)) != 1 {
+		return "", false
+	}
+
+	return token, true
+}
+
+// parseAuthorizeRequest - разбор параметров запроса авторизации
+func parseAuthorizeRequest(values url.Values) entity.AuthorizeRequest {
+	return entity.AuthorizeRequest{
+		ResponseType:        values.Get("response_type"),
+		ClientID:            values.Get("client_id"),
+		RedirectURI:         values.Get("redirect_uri"),
+		Scope:               values.Get("scope"),
+		State:               values.Get("state"),
+		CodeChallenge:       values.Get("code_challenge"),
+		CodeChallengeMethod: values.Get("code_challenge_method"),
+		Nonce:               values.Get("nonce"),
+	}
+}
+
+// redirectToClient - перенаправление пользователя на зарегистрированный redirect_uri клиента с параметрами ответа
+func redirectToClient(w http.ResponseWriter, r *http.Request, redirectURI string, params url.Values) error {
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		logging.Errorf("authorize: parse redirect uri [%s]: %s", redirectURI, err)
+		return renderAuthorizePage(w, http.StatusInternalServerError, templateError, authorizePage{Error: "Сервис временно недоступен"})
+	}
+
+	query := target.Query()
+	for key, value := range params {
+		query[key] = value
+	}
+	target.RawQuery = query.Encode()
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, target.String(), http.StatusFound)
+	return nil
+}
+
+// renderAuthorizePage - отрисовка страницы авторизации, страницу нельзя встраивать во фреймы
+func renderAuthorizePage(w http.ResponseWriter, code int, name string, page authorizePage) error {
+	var buf bytes.Buffer
+	err := authorizeTemplates.ExecuteTemplate(&buf, name, page)
+	if err != nil {
+		return errors.Wrap(err, "execute template")
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	w.WriteHeader(code)
+	_, err = w.Write(buf.Bytes())
+	if err != nil {
+		logging.Errorf("error write response: %s", err)
+	}
+
+	return nil
+}
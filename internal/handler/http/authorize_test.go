@@ -0,0 +1,163 @@
+package http
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+var testOAuthClient = entity.OAuthClient{
+	ID:           "7d2f0c3a-51a4-4c2e-9a0b-3f1f6e2d8c10",
+	Name:         "Test App",
+	RedirectURIs: []string{"https://app.example.com/callback"},
+	GrantTypes:   []string{entity.GrantTypeAuthorizationCode},
+	Scopes:       []string{entity.ScopeOpenID},
+	Public:       true,
+}
+
+func newAuthorizeHandler(signInGuard *fakeSignInGuard) *Handler {
+	return &Handler{
+		oauthClientService: &fakeOAuthClient{client: testOAuthClient},
+		oauthService:       &fakeOAuth{code: "code-1"},
+		signInGuardService: signInGuard,
+		cfg:                &config.Config{},
+	}
+}
+
+func authorizeParams() url.Values {
+	return url.Values{
+		"response_type":         {entity.ResponseTypeCode},
+		"client_id":             {testOAuthClient.ID},
+		"redirect_uri":          {testOAuthClient.RedirectURIs[0]},
+		"scope":                 {entity.ScopeOpenID},
+		"state":                 {"xyz"},
+		"code_challenge":        {"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"},
+		"code_challenge_method": {"S256"},
+	}
+}
+
+func TestAuthorizeSetsCSRFCookie(t *testing.T) {
+	h := newAuthorizeHandler(&fakeSignInGuard{})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+authorizeParams().Encode(), nil)
+	err := h.Authorize(w, r)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	cookies := w.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	cookie := cookies[0]
+	assert.Equal(t, authorizeCSRFCookie, cookie.Name)
+	assert.NotEmpty(t, cookie.Value)
+	assert.Equal(t, "/", cookie.Path)
+	assert.True(t, cookie.Secure)
+	assert.True(t, cookie.HttpOnly)
+	assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+	assert.Contains(t, w.Body.String(), `name="csrf_token" value="`+cookie.Value+`"`)
+
+	// каждая страница входа получает свой токен
+	w = httptest.NewRecorder()
+	err = h.Authorize(w, r)
+	if assert.NoError(t, err) && assert.Len(t, w.Result().Cookies(), 1) {
+		assert.NotEqual(t, cookie.Value, w.Result().Cookies()[0].Value)
+	}
+}
+
+func TestAuthorizeSubmitCSRF(t *testing.T) {
+	const csrfToken = "csrf-token-1"
+
+	tests := []struct {
+		name        string
+		cookie      string
+		formToken   string
+		signInErr   error
+		wantStatus  int
+		wantSignIns int
+	}{
+		{
+			name:        "matching token",
+			cookie:      csrfToken,
+			formToken:   csrfToken,
+			wantStatus:  http.StatusFound,
+			wantSignIns: 1,
+		},
+		{
+			name:        "matching token, wrong password",
+			cookie:      csrfToken,
+			formToken:   csrfToken,
+			signInErr:   apperror.ErrUserNotFound,
+			wantStatus:  http.StatusUnauthorized,
+			wantSignIns: 1,
+		},
+		{
+			name:       "no cookie",
+			formToken:  csrfToken,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "no form token",
+			cookie:     csrfToken,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "token mismatch",
+			cookie:     csrfToken,
+			formToken:  "attacker-token",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "empty cookie and form token",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signInGuard := &fakeSignInGuard{user: entity.User{ID: "user-1", EmailVerified: true}, err: tt.signInErr}
+			h := newAuthorizeHandler(signInGuard)
+
+			form := authorizeParams()
+			form.Set("email", "ivan.petrov@example.com")
+			form.Set("password", "Password-8")
+			if tt.formToken != "" {
+				form.Set(authorizeCSRFField, tt.formToken)
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/oauth/authorize", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: authorizeCSRFCookie, Value: tt.cookie})
+			}
+
+			err := h.AuthorizeSubmit(w, r)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantSignIns, signInGuard.signIns)
+
+			switch tt.wantStatus {
+			case http.StatusFound:
+				assert.Equal(t, "https://app.example.com/callback?code=code-1&state=xyz", w.Header().Get("Location"))
+			case http.StatusUnauthorized:
+				// повторно показанная форма несет тот же токен
+				assert.Contains(t, w.Body.String(), `name="csrf_token" value="`+csrfToken+`"`)
+			case http.StatusForbidden:
+				assert.Empty(t, w.Header().Get("Location"))
+				assert.NotContains(t, w.Body.String(), "<form")
+			}
+		})
+	}
+}
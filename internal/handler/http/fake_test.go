@@ -0,0 +1,88 @@
+package http
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/service"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	err := logging.InitLogging(&logging.Config{Output: io.Discard, SystemName: "test", Env: "test"})
+	if err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+// fakeSignInGuard - защита входа, возвращающая заданную ошибку и запоминающая сессию смены пароля
+type fakeSignInGuard struct {
+	service.ISignInGuard
+
+	user      entity.User
+	err       error
+	sessionID string
+	signIns   int
+}
+
+func (g *fakeSignInGuard) SignIn(_ context.Context, _, _, _ string) (entity.User, error) {
+	g.signIns++
+	return g.user, g.err
+}
+
+func (g *fakeSignInGuard) ChangePassword(_ context.Context, _, sessionID, _, _ string) error {
+	g.sessionID = sessionID
+	return g.err
+}
+
+// fakeJWT - проверка access-токена, возвращающая заданные claims
+type fakeJWT struct {
+	service.IJWT
+
+	claims entity.UserClaims
+}
+
+func (j *fakeJWT) ParseAccessToken(_ context.Context, _ string) (entity.UserClaims, error) {
+	return j.claims, nil
+}
+
+// fakeRole - права ролей в памяти
+type fakeRole struct {
+	service.IRole
+
+	permissions map[string]entity.Permissions
+}
+
+func (r *fakeRole) GetPermissions(_ context.Context, role string) (entity.Permissions, error) {
+	return r.permissions[role], nil
+}
+
+// fakeOAuthClient - один зарегистрированный oauth-клиент
+type fakeOAuthClient struct {
+	service.IOAuthClient
+
+	client entity.OAuthClient
+}
+
+func (c *fakeOAuthClient) GetOAuthClientByID(_ context.Context, id string) (entity.OAuthClient, error) {
+	if id != c.client.ID {
+		return entity.OAuthClient{}, apperror.ErrOAuthClientNotFound
+	}
+	return c.client, nil
+}
+
+// fakeOAuth - выдача заданного кода авторизации
+type fakeOAuth struct {
+	service.IOAuth
+
+	code string
+}
+
+func (o *fakeOAuth) CreateAuthorizationCode(_ context.Context, _ entity.AuthorizeRequest, _ string) (string, error) {
+	return o.code, nil
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/mapper"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/validator"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// GetFederatedProviders - хэндлер получения внешних провайдеров, через которые доступен вход
+func (h *Handler) GetFederatedProviders(w http.ResponseWriter, _ *http.Request) error {
+	return response.RespondSuccess(w, mapper.MapToFederatedProvidersResponse(http.StatusOK, h.federationService.GetProviders()))
+}
+
+// FederatedLogin - хэндлер начала входа через внешний провайдер, пользователь перенаправляется на провайдера
+func (h *Handler) FederatedLogin(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	provider, err := helpers.GetStringFromPath(r, config.ParamProvider)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get provider from path"))
+	}
+
+	deviceName := r.URL.Query().Get("deviceName")
+	err = validator.ValidateDeviceName(deviceName)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate device name"))
+	}
+
+	authURL, err := h.federationService.BeginLogin(ctx, provider, deviceName)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, authURL, http.StatusFound)
+	return nil
+}
+
+// FederatedCallback - хэндлер возврата с внешнего провайдера: выдает токены пользователю,
+// найденному, привязанному или созданному по учетной записи провайдера
+func (h *Handler) FederatedCallback(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	provider, err := helpers.GetStringFromPath(r, config.ParamProvider)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get provider from path"))
+	}
+
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		return apperror.UnauthorizedError(errors.Wrapf(apperror.ErrFederatedLoginFailed, "provider error [%s]", providerErr))
+	}
+
+	state, code := query.Get("state"), query.Get("code")
+	if state == "" {
+		return apperror.UnauthorizedError(apperror.ErrInvalidFederatedState)
+	}
+	if code == "" {
+		return apperror.BadRequestError(apperror.ErrEmptyCode)
+	}
+
+	user, loginState, err := h.federationService.CompleteLogin(ctx, provider, state, code)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	client := helpers.GetClientInfo(r)
+	client.DeviceName = loginState.DeviceName
+
+	token, refreshToken, err := h.jwtService.GenerateAccessAndRefreshTokens(ctx, user, client)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	user.SetJWT(token, refreshToken)
+
+	return response.RespondSuccess(w, mapper.MapToUserWithJWTResponse(http.StatusOK, user))
+}
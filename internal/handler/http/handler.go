@@ -2,7 +2,9 @@ package http
 
 import (
 	_ "github.com/GermanBogatov/auth-service/docs"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
 	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
 	"github.com/GermanBogatov/auth-service/internal/service"
 	"github.com/GermanBogatov/auth-service/pkg/tracer"
 	"github.com/go-chi/chi/v5"
@@ -19,6 +21,8 @@ const (
 	privateV1     = "/private/v1"
 	integrationV1 = "/integration/v1"
 	authV1        = "/public/v1/auth"
+	wellKnown     = "/.well-known"
+	oauth         = "/oauth"
 
 	livePath       = "/live"
 	readinessPath  = "/readiness"
@@ -26,22 +30,49 @@ const (
 )
 
 type Handler struct {
-	userService service.IUser
-	jwtService  service.IJWT
-	cfg         *config.Config
+	userService              service.IUser
+	jwtService               service.IJWT
+	sessionService           service.ISession
+	serviceAccountService    service.IServiceAccount
+	oauthClientService       service.IOAuthClient
+	oauthService             service.IOAuth
+	federationService        service.IFederation
+	personalTokenService     service.IPersonalToken
+	roleService              service.IRole
+	impersonationService     service.IImpersonation
+	passwordResetService     service.IPasswordReset
+	emailVerificationService service.IEmailVerification
+	signInGuardService       service.ISignInGuard
+	cfg                      *config.Config
 }
 
-func NewHandler(cfg *config.Config, userService service.IUser, jwtService service.IJWT) *Handler {
+func NewHandler(cfg *config.Config, userService service.IUser, jwtService service.IJWT, sessionService service.ISession,
+	serviceAccountService service.IServiceAccount, oauthClientService service.IOAuthClient, oauthService service.IOAuth,
+	federationService service.IFederation, personalTokenService service.IPersonalToken, roleService service.IRole,
+	impersonationService service.IImpersonation, passwordResetService service.IPasswordReset,
+	emailVerificationService service.IEmailVerification, signInGuardService service.ISignInGuard) *Handler {
 	return &Handler{
-		userService: userService,
-		jwtService:  jwtService,
-		cfg:         cfg,
+		userService:              userService,
+		jwtService:               jwtService,
+		sessionService:           sessionService,
+		serviceAccountService:    serviceAccountService,
+		oauthClientService:       oauthClientService,
+		oauthService:             oauthService,
+		federationService:        federationService,
+		personalTokenService:     personalTokenService,
+		roleService:              roleService,
+		impersonationService:     impersonationService,
+		passwordResetService:     passwordResetService,
+		emailVerificationService: emailVerificationService,
+		signInGuardService:       signInGuardService,
+		cfg:                      cfg,
 	}
 }
 
 // InitRoutes - инициализация роутера приложения
 func (h *Handler) InitRoutes() *chi.Mux {
 	r := chi.NewRouter()
+	r.Use(helpers.RealIP(h.cfg.Http.TrustedProxies))
 	r.Use(middleware.Recoverer)
 	r.Use(tracer.TcpMiddleware)
 
@@ -62,21 +93,79 @@ func (h *Handler) InitRoutes() *chi.Mux {
 	})
 	r.Get(swaggerPattern, httpSwagger.Handler())
 
+	r.Route(wellKnown, func(r chi.Router) {
+		r.Get("/jwks.json", h.appMiddleware(h.JWKS, public()))
+		r.Get("/openid-configuration", h.appMiddleware(h.OpenIDConfiguration, public()))
+	})
+
 	r.Route(authV1, func(r chi.Router) {
-		r.Post("/sign-up", appMiddleware(h.SignUp))
-		r.Post("/sign-in", appMiddleware(h.SignIn))
-		r.Get("/refresh/{id}", appMiddleware(h.UpdateRefreshToken))
+		r.Post("/sign-up", h.appMiddleware(h.SignUp, public()))
+		r.Post("/sign-in", h.appMiddleware(h.SignIn, public()))
+		r.Get("/refresh/{id}", h.appMiddleware(h.UpdateRefreshToken, public()))
+		r.Post("/logout", h.appMiddleware(h.Logout, authenticated()))
+		r.Post("/logout-all", h.appMiddleware(h.LogoutAll, authenticated()))
+		r.Post("/revoke", h.appMiddleware(h.RevokeToken, public()))
+		r.Post("/password/forgot", h.appMiddleware(h.ForgotPassword, public()))
+		r.Post("/password/reset", h.appMiddleware(h.ResetPassword, public()))
+		r.Get("/verify-email", h.appMiddleware(h.VerifyEmail, public()))
+		r.Post("/verify-email", h.appMiddleware(h.VerifyEmail, public()))
+		r.Post("/verify-email/resend", h.appMiddleware(h.ResendEmailVerification, public()))
+		r.Get("/sso", h.appMiddleware(h.GetFederatedProviders, public()))
+		r.Get("/sso/{provider}", h.appMiddleware(h.FederatedLogin, public()))
+		r.Get("/sso/{provider}/callback", h.appMiddleware(h.FederatedCallback, public()))
+	})
+
+	r.Route(oauth, func(r chi.Router) {
+		r.Get("/authorize", h.appMiddleware(h.Authorize, public()))
+		r.Post("/authorize", h.appMiddleware(h.AuthorizeSubmit, public()))
+		r.Post("/token", h.appMiddleware(h.Token, public()))
+		r.Get("/userinfo", h.appMiddleware(h.UserInfo, authenticated()))
+		r.Post("/userinfo", h.appMiddleware(h.UserInfo, authenticated()))
+	})
+
+	r.Route(integrationV1, func(r chi.Router) {
+		r.Post("/introspect", h.appMiddleware(h.Introspect, public()))
 	})
 
 	r.Route(publicV1, func(r chi.Router) {
-		r.Get("/users", appMiddleware(h.GetUsers))
-		r.Get("/users/{id}", appMiddleware(h.GetUserByID))
-		r.Delete("/users/{id}", appMiddleware(h.DeleteUserByID))
-		r.Patch("/users/{id}", appMiddleware(h.UpdateUserByID))
+		r.Get("/users", h.appMiddleware(h.GetUsers, require(entity.PermissionUsersRead)))
+		r.Get("/users/{id}", h.appMiddleware(h.GetUserByID, require(entity.PermissionUsersRead)))
+		r.Delete("/users/{id}", h.appMiddleware(h.DeleteUserByID, requireSelf(entity.PermissionUsersDeleteSelf)))
+		r.Patch("/users/{id}", h.appMiddleware(h.UpdateUserByID, requireSelf(entity.PermissionUsersUpdateSelf)))
+		r.Post("/me/password", h.appMiddleware(h.ChangePassword, require(entity.PermissionPasswordChangeSelf)))
+		r.Get("/me/sessions", h.appMiddleware(h.GetSessions, require(entity.PermissionSessionsReadSelf)))
+		r.Delete("/me/sessions/{sessionID}", h.appMiddleware(h.DeleteSession, require(entity.PermissionSessionsDeleteSelf)))
+		r.Post("/me/tokens", h.appMiddleware(h.CreatePersonalToken, require(entity.PermissionTokensManageSelf)))
+		r.Get("/me/tokens", h.appMiddleware(h.GetPersonalTokens, require(entity.PermissionTokensManageSelf)))
+		r.Get("/me/tokens/{id}", h.appMiddleware(h.GetPersonalTokenByID, require(entity.PermissionTokensManageSelf)))
+		r.Patch("/me/tokens/{id}", h.appMiddleware(h.UpdatePersonalToken, require(entity.PermissionTokensManageSelf)))
+		r.Delete("/me/tokens/{id}", h.appMiddleware(h.DeletePersonalToken, require(entity.PermissionTokensManageSelf)))
 	})
 
 	r.Route(privateV1, func(r chi.Router) {
-		r.Patch("/users/{id}", appMiddleware(h.PrivateUpdateUser))
+		r.Patch("/users/{id}", h.appMiddleware(h.PrivateUpdateUser, require(entity.PermissionUsersUpdateAny)))
+		r.Get("/users/{id}/sessions", h.appMiddleware(h.PrivateGetUserSessions, require(entity.PermissionSessionsReadAny)))
+		r.Delete("/users/{id}/sessions", h.appMiddleware(h.PrivateDeleteUserSessions, require(entity.PermissionSessionsDeleteAny)))
+		r.Delete("/users/{id}/sessions/{sessionID}", h.appMiddleware(h.PrivateDeleteUserSession, require(entity.PermissionSessionsDeleteAny)))
+		r.Delete("/users/{id}/lockout", h.appMiddleware(h.PrivateUnlockUser, require(entity.PermissionUsersUpdateAny)))
+		r.Post("/users/{id}/impersonate", h.appMiddleware(h.ImpersonateUser, require(entity.PermissionUsersImpersonate)))
+		r.Post("/service-accounts", h.appMiddleware(h.CreateServiceAccount, require(entity.PermissionServiceAccounts)))
+		r.Get("/service-accounts", h.appMiddleware(h.GetServiceAccounts, require(entity.PermissionServiceAccounts)))
+		r.Get("/service-accounts/{id}", h.appMiddleware(h.GetServiceAccountByID, require(entity.PermissionServiceAccounts)))
+		r.Patch("/service-accounts/{id}", h.appMiddleware(h.UpdateServiceAccount, require(entity.PermissionServiceAccounts)))
+		r.Post("/service-accounts/{id}/secret", h.appMiddleware(h.ResetServiceAccountSecret, require(entity.PermissionServiceAccounts)))
+		r.Delete("/service-accounts/{id}", h.appMiddleware(h.DeleteServiceAccount, require(entity.PermissionServiceAccounts)))
+		r.Post("/oauth-clients", h.appMiddleware(h.CreateOAuthClient, require(entity.PermissionOAuthClients)))
+		r.Get("/oauth-clients", h.appMiddleware(h.GetOAuthClients, require(entity.PermissionOAuthClients)))
+		r.Get("/oauth-clients/{id}", h.appMiddleware(h.GetOAuthClientByID, require(entity.PermissionOAuthClients)))
+		r.Patch("/oauth-clients/{id}", h.appMiddleware(h.UpdateOAuthClient, require(entity.PermissionOAuthClients)))
+		r.Post("/oauth-clients/{id}/secret", h.appMiddleware(h.ResetOAuthClientSecret, require(entity.PermissionOAuthClients)))
+		r.Delete("/oauth-clients/{id}", h.appMiddleware(h.DeleteOAuthClient, require(entity.PermissionOAuthClients)))
+		r.Post("/roles", h.appMiddleware(h.CreateRole, require(entity.PermissionRolesManage)))
+		r.Get("/roles", h.appMiddleware(h.GetRoles, require(entity.PermissionRolesManage)))
+		r.Get("/roles/{role}", h.appMiddleware(h.GetRoleByName, require(entity.PermissionRolesManage)))
+		r.Patch("/roles/{role}", h.appMiddleware(h.UpdateRole, require(entity.PermissionRolesManage)))
+		r.Delete("/roles/{role}", h.appMiddleware(h.DeleteRole, require(entity.PermissionRolesManage)))
 	})
 
 	return r
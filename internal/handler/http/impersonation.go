@@ -0,0 +1,51 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/mapper"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/validator"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// ImpersonateUser - хэндлер выдачи токена имперсонации пользователя
+func (h *Handler) ImpersonateUser(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	userID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	var request model.ImpersonationRequest
+	defer func() {
+		errClose := r.Body.Close()
+		if errClose != nil {
+			logging.Error("error close request body")
+		}
+	}()
+
+	if errDecode := json.NewDecoder(r.Body).Decode(&request); errDecode != nil {
+		return apperror.BadRequestError(errors.Wrap(errDecode, "json decode"))
+	}
+
+	err = validator.ValidateImpersonation(request)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate impersonation"))
+	}
+
+	actor := callerActor(r)
+	token, err := h.impersonationService.Impersonate(ctx, actor, userID.String(), request.Reason)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	logging.Infof("impersonation token issued: actor [%s] user [%s] jti [%s]", actor.ID, token.UserID, token.ID)
+	return response.RespondSuccessCreate(w, mapper.MapToImpersonationResponse(http.StatusCreated, token))
+}
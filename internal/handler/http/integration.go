@@ -0,0 +1,75 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/mapper"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/validator"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// Introspect - хэндлер интроспекции токена для ресурсных серверов (RFC 7662)
+func (h *Handler) Introspect(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	err := r.ParseForm()
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "parse form"))
+	}
+
+	clientID, clientSecret, ok := helpers.GetClientCredentials(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="introspection"`)
+		return apperror.UnauthorizedError(apperror.ErrInvalidClient)
+	}
+
+	allowed, err := h.isIntrospectionClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+	if !allowed {
+		w.Header().Set("WWW-Authenticate", `Basic realm="introspection"`)
+		return apperror.UnauthorizedError(apperror.ErrInvalidClient)
+	}
+
+	introspectRequest := model.TokenRequest{
+		Token:         r.PostForm.Get("token"),
+		TokenTypeHint: r.PostForm.Get("token_type_hint"),
+	}
+
+	err = validator.ValidateTokenRequest(introspectRequest)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate introspect request"))
+	}
+
+	introspection, err := h.jwtService.Introspect(ctx, introspectRequest.Token, introspectRequest.TokenTypeHint)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondJSON(w, http.StatusOK, mapper.MapToIntrospectionResponse(introspection))
+}
+
+// isIntrospectionClient - проверка учетных данных клиента интроспекции: клиент из конфига,
+// либо сервисный аккаунт со скоупом introspect
+func (h *Handler) isIntrospectionClient(ctx context.Context, clientID, clientSecret string) (bool, error) {
+	if secret, ok := h.cfg.Introspection.Clients[clientID]; ok {
+		return subtle.ConstantTimeCompare([]byte(secret), []byte(clientSecret)) == 1, nil
+	}
+
+	account, err := h.serviceAccountService.Authenticate(ctx, clientID, clientSecret)
+	if err != nil {
+		if errors.Is(err, apperror.ErrInvalidClient) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return account.HasScope(entity.ScopeIntrospect), nil
+}
@@ -0,0 +1,22 @@
+package mapper
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+)
+
+// MapToFederatedProvidersResponse - маппинг внешних провайдеров в модель ответа
+func MapToFederatedProvidersResponse(code int, providers []entity.FederatedProvider) response.ViewResponse {
+	result := make([]model.FederatedProviderResponse, 0, len(providers))
+	for _, provider := range providers {
+		result = append(result, model.FederatedProviderResponse{
+			Name:        provider.Name,
+			DisplayName: provider.DisplayName,
+		})
+	}
+	return response.ViewResponse{
+		Code:   code,
+		Result: result,
+	}
+}
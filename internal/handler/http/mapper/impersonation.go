@@ -0,0 +1,23 @@
+package mapper
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+)
+
+// MapToImpersonationResponse - маппинг токена имперсонации в модель ответ
+func MapToImpersonationResponse(code int, token entity.ImpersonationToken) response.ViewResponse {
+	return response.ViewResponse{
+		Code: code,
+		Result: model.ImpersonationResponse{
+			AccessToken: token.AccessToken,
+			TokenType:   entity.TokenTypeBearer,
+			ExpiresIn:   int(token.ExpiresDate.Sub(token.CreatedDate).Seconds()),
+			ExpiresDate: token.ExpiresDate.Format(config.IsoTimeLayout),
+			UserID:      token.UserID,
+			ActorID:     token.ActorID,
+		},
+	}
+}
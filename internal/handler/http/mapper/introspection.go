@@ -0,0 +1,36 @@
+package mapper
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+)
+
+// MapToIntrospectionResponse - маппинг результата интроспекции в модель ответ, у неактивного токена отдается только active
+func MapToIntrospectionResponse(introspection entity.TokenIntrospection) model.IntrospectionResponse {
+	if !introspection.Active {
+		return model.IntrospectionResponse{}
+	}
+
+	result := model.IntrospectionResponse{
+		Active:    true,
+		TokenType: introspection.TokenType,
+		Sub:       introspection.Subject,
+		Email:     introspection.Email,
+		Role:      introspection.Role,
+		Jti:       introspection.ID,
+		Sid:       introspection.SessionID,
+		ClientID:  introspection.ClientID,
+		Scope:     introspection.Scope,
+	}
+	if introspection.ExpiresAt != nil {
+		result.Exp = introspection.ExpiresAt.Unix()
+	}
+	if introspection.IssuedAt != nil {
+		result.Iat = introspection.IssuedAt.Unix()
+	}
+	if introspection.Actor != nil {
+		result.Act = &model.IntrospectionActor{Sub: introspection.Actor.Subject}
+	}
+
+	return result
+}
@@ -0,0 +1,19 @@
+package mapper
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+)
+
+// MapToOAuthTokenResponse - маппинг выпущенного токена в ответ токен-эндпоинта
+func MapToOAuthTokenResponse(token entity.OAuthToken) model.OAuthTokenResponse {
+	return model.OAuthTokenResponse{
+		AccessToken:     token.AccessToken,
+		TokenType:       token.TokenType,
+		ExpiresIn:       token.ExpiresIn,
+		RefreshToken:    token.RefreshToken,
+		Scope:           token.Scope,
+		IDToken:         token.IDToken,
+		IssuedTokenType: token.IssuedTokenType,
+	}
+}
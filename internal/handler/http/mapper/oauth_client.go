@@ -0,0 +1,95 @@
+package mapper
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+)
+
+// MapToEntityOAuthClient - маппинг в модель oauth-клиента
+func MapToEntityOAuthClient(client model.OAuthClientCreateRequest) entity.OAuthClient {
+	scopes := client.Scopes
+	if scopes == nil {
+		scopes = make([]string, 0)
+	}
+
+	audiences := client.Audiences
+	if audiences == nil {
+		audiences = make([]string, 0)
+	}
+
+	return entity.OAuthClient{
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		GrantTypes:   client.GrantTypes,
+		Scopes:       scopes,
+		Audiences:    audiences,
+		Public:       client.Public,
+	}
+}
+
+// MapToEntityOAuthClientUpdate - маппинг в модель редактирования oauth-клиента
+func MapToEntityOAuthClientUpdate(id string, client model.OAuthClientUpdateRequest) entity.OAuthClientUpdate {
+	return entity.OAuthClientUpdate{
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		GrantTypes:   client.GrantTypes,
+		Scopes:       client.Scopes,
+		Audiences:    client.Audiences,
+		ID:           id,
+	}
+}
+
+// mapOAuthClientToResponse - маппинг oauth-клиента в модель ответ
+func mapOAuthClientToResponse(client entity.OAuthClient) model.OAuthClientResponse {
+	var updatedDate *string
+	if client.UpdatedDate != nil {
+		updateTime := client.UpdatedDate.Format(config.IsoTimeLayout)
+		updatedDate = &updateTime
+	}
+
+	return model.OAuthClientResponse{
+		ID:           client.ID,
+		ClientID:     client.ID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		GrantTypes:   client.GrantTypes,
+		Scopes:       client.Scopes,
+		Audiences:    client.Audiences,
+		Public:       client.Public,
+		CreatedDate:  client.CreatedDate.Format(config.IsoTimeLayout),
+		UpdatedDate:  updatedDate,
+	}
+}
+
+// MapToOAuthClientResponse - маппинг oauth-клиента в модель ответ
+func MapToOAuthClientResponse(code int, client entity.OAuthClient) response.ViewResponse {
+	return response.ViewResponse{
+		Code:   code,
+		Result: mapOAuthClientToResponse(client),
+	}
+}
+
+// MapToOAuthClientWithSecretResponse - маппинг oauth-клиента с секретом в модель ответ
+func MapToOAuthClientWithSecretResponse(code int, client entity.OAuthClient) response.ViewResponse {
+	return response.ViewResponse{
+		Code: code,
+		Result: model.OAuthClientWithSecretResponse{
+			OAuthClientResponse: mapOAuthClientToResponse(client),
+			ClientSecret:        client.Secret,
+		},
+	}
+}
+
+// MapToOAuthClientsResponse - маппинг oauth-клиентов в модель ответ
+func MapToOAuthClientsResponse(code int, clients []entity.OAuthClient) response.ViewResponse {
+	result := make([]model.OAuthClientResponse, 0, len(clients))
+	for _, client := range clients {
+		result = append(result, mapOAuthClientToResponse(client))
+	}
+	return response.ViewResponse{
+		Code:   code,
+		Result: result,
+	}
+}
@@ -0,0 +1,17 @@
+package mapper
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+)
+
+// MapToUserInfoResponse - маппинг claims пользователя в ответ userinfo-эндпоинта
+func MapToUserInfoResponse(subject string, info entity.UserInfo) model.UserInfoResponse {
+	return model.UserInfoResponse{
+		Sub:        subject,
+		Email:      info.Email,
+		Name:       info.Name,
+		GivenName:  info.GivenName,
+		FamilyName: info.FamilyName,
+	}
+}
@@ -0,0 +1,90 @@
+package mapper
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+	"time"
+)
+
+// MapToEntityPersonalToken - маппинг в модель персонального токена
+func MapToEntityPersonalToken(token model.PersonalTokenCreateRequest, userID string) entity.PersonalToken {
+	var expiresDate *time.Time
+	if token.ExpiresInDays != nil {
+		expires := time.Now().UTC().AddDate(0, 0, *token.ExpiresInDays)
+		expiresDate = &expires
+	}
+
+	return entity.PersonalToken{
+		UserID:      userID,
+		Name:        token.Name,
+		Scopes:      token.Scopes,
+		ExpiresDate: expiresDate,
+	}
+}
+
+// MapToEntityPersonalTokenUpdate - маппинг в модель редактирования персонального токена
+func MapToEntityPersonalTokenUpdate(id, userID string, token model.PersonalTokenUpdateRequest) entity.PersonalTokenUpdate {
+	return entity.PersonalTokenUpdate{
+		Name:   token.Name,
+		Scopes: token.Scopes,
+		ID:     id,
+		UserID: userID,
+	}
+}
+
+// mapPersonalTokenToResponse - маппинг персонального токена в модель ответ
+func mapPersonalTokenToResponse(token entity.PersonalToken) model.PersonalTokenResponse {
+	return model.PersonalTokenResponse{
+		ID:           token.ID,
+		Name:         token.Name,
+		TokenPrefix:  token.TokenPrefix,
+		Scopes:       token.Scopes,
+		ExpiresDate:  formatOptionalDate(token.ExpiresDate),
+		LastUsedDate: formatOptionalDate(token.LastUsedDate),
+		LastUsedIP:   token.LastUsedIP,
+		CreatedDate:  token.CreatedDate.Format(config.IsoTimeLayout),
+		UpdatedDate:  formatOptionalDate(token.UpdatedDate),
+	}
+}
+
+// MapToPersonalTokenResponse - маппинг персонального токена в модель ответ
+func MapToPersonalTokenResponse(code int, token entity.PersonalToken) response.ViewResponse {
+	return response.ViewResponse{
+		Code:   code,
+		Result: mapPersonalTokenToResponse(token),
+	}
+}
+
+// MapToPersonalTokenWithSecretResponse - маппинг созданного персонального токена в модель ответ
+func MapToPersonalTokenWithSecretResponse(code int, token entity.PersonalToken) response.ViewResponse {
+	return response.ViewResponse{
+		Code: code,
+		Result: model.PersonalTokenWithSecretResponse{
+			PersonalTokenResponse: mapPersonalTokenToResponse(token),
+			Token:                 token.Token,
+		},
+	}
+}
+
+// MapToPersonalTokensResponse - маппинг персональных токенов в модель ответ
+func MapToPersonalTokensResponse(code int, tokens []entity.PersonalToken) response.ViewResponse {
+	result := make([]model.PersonalTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		result = append(result, mapPersonalTokenToResponse(token))
+	}
+	return response.ViewResponse{
+		Code:   code,
+		Result: result,
+	}
+}
+
+// formatOptionalDate - форматирование необязательной даты
+func formatOptionalDate(date *time.Time) *string {
+	if date == nil {
+		return nil
+	}
+	formatted := date.Format(config.IsoTimeLayout)
+	return &formatted
+}
@@ -0,0 +1,86 @@
+package mapper
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+)
+
+// MapToEntityRole - маппинг в модель роли
+func MapToEntityRole(role model.RoleCreateRequest) entity.Role {
+	return entity.Role{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: mapToEntityPermissions(role.Permissions),
+		Level:       role.Level,
+	}
+}
+
+// MapToEntityRoleUpdate - маппинг в модель редактирования роли
+func MapToEntityRoleUpdate(name string, role model.RoleUpdateRequest) entity.RoleUpdate {
+	roleUpdate := entity.RoleUpdate{
+		Description: role.Description,
+		Level:       role.Level,
+		Name:        name,
+	}
+	if role.Permissions != nil {
+		permissions := mapToEntityPermissions(*role.Permissions)
+		roleUpdate.Permissions = &permissions
+	}
+
+	return roleUpdate
+}
+
+// mapToEntityPermissions - маппинг прав
+func mapToEntityPermissions(permissions []string) entity.Permissions {
+	result := make(entity.Permissions, 0, len(permissions))
+	for _, permission := range permissions {
+		result = append(result, entity.Permission(permission))
+	}
+	return result
+}
+
+// mapRoleToResponse - маппинг роли в модель ответ
+func mapRoleToResponse(role entity.Role) model.RoleResponse {
+	var updatedDate *string
+	if role.UpdatedDate != nil {
+		updateTime := role.UpdatedDate.Format(config.IsoTimeLayout)
+		updatedDate = &updateTime
+	}
+
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, string(permission))
+	}
+
+	return model.RoleResponse{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+		Level:       role.Level,
+		BuiltIn:     role.BuiltIn,
+		CreatedDate: role.CreatedDate.Format(config.IsoTimeLayout),
+		UpdatedDate: updatedDate,
+	}
+}
+
+// MapToRoleResponse - маппинг роли в модель ответ
+func MapToRoleResponse(code int, role entity.Role) response.ViewResponse {
+	return response.ViewResponse{
+		Code:   code,
+		Result: mapRoleToResponse(role),
+	}
+}
+
+// MapToRolesResponse - маппинг ролей в модель ответ
+func MapToRolesResponse(code int, roles []entity.Role) response.ViewResponse {
+	result := make([]model.RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		result = append(result, mapRoleToResponse(role))
+	}
+	return response.ViewResponse{
+		Code:   code,
+		Result: result,
+	}
+}
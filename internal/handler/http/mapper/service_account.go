@@ -0,0 +1,89 @@
+package mapper
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+)
+
+// MapToEntityServiceAccount - маппинг в модель сервисного аккаунта
+func MapToEntityServiceAccount(account model.ServiceAccountCreateRequest, ownerID string) entity.ServiceAccount {
+	scopes := account.Scopes
+	if scopes == nil {
+		scopes = make([]string, 0)
+	}
+
+	audiences := account.Audiences
+	if audiences == nil {
+		audiences = make([]string, 0)
+	}
+
+	return entity.ServiceAccount{
+		Name:      account.Name,
+		OwnerID:   ownerID,
+		Scopes:    scopes,
+		Audiences: audiences,
+	}
+}
+
+// MapToEntityServiceAccountUpdate - маппинг в модель редактирования сервисного аккаунта
+func MapToEntityServiceAccountUpdate(id string, account model.ServiceAccountUpdateRequest) entity.ServiceAccountUpdate {
+	return entity.ServiceAccountUpdate{
+		Name:      account.Name,
+		Scopes:    account.Scopes,
+		Audiences: account.Audiences,
+		ID:        id,
+	}
+}
+
+// mapServiceAccountToResponse - маппинг сервисного аккаунта в модель ответ
+func mapServiceAccountToResponse(account entity.ServiceAccount) model.ServiceAccountResponse {
+	var updatedDate *string
+	if account.UpdatedDate != nil {
+		updateTime := account.UpdatedDate.Format(config.IsoTimeLayout)
+		updatedDate = &updateTime
+	}
+
+	return model.ServiceAccountResponse{
+		ID:          account.ID,
+		ClientID:    account.ID,
+		Name:        account.Name,
+		OwnerID:     account.OwnerID,
+		Scopes:      account.Scopes,
+		Audiences:   account.Audiences,
+		CreatedDate: account.CreatedDate.Format(config.IsoTimeLayout),
+		UpdatedDate: updatedDate,
+	}
+}
+
+// MapToServiceAccountResponse - маппинг сервисного аккаунта в модель ответ
+func MapToServiceAccountResponse(code int, account entity.ServiceAccount) response.ViewResponse {
+	return response.ViewResponse{
+		Code:   code,
+		Result: mapServiceAccountToResponse(account),
+	}
+}
+
+// MapToServiceAccountWithSecretResponse - маппинг сервисного аккаунта с секретом в модель ответ
+func MapToServiceAccountWithSecretResponse(code int, account entity.ServiceAccount) response.ViewResponse {
+	return response.ViewResponse{
+		Code: code,
+		Result: model.ServiceAccountWithSecretResponse{
+			ServiceAccountResponse: mapServiceAccountToResponse(account),
+			ClientSecret:           account.Secret,
+		},
+	}
+}
+
+// MapToServiceAccountsResponse - маппинг сервисных аккаунтов в модель ответ
+func MapToServiceAccountsResponse(code int, accounts []entity.ServiceAccount) response.ViewResponse {
+	result := make([]model.ServiceAccountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		result = append(result, mapServiceAccountToResponse(account))
+	}
+	return response.ViewResponse{
+		Code:   code,
+		Result: result,
+	}
+}
@@ -0,0 +1,28 @@
+package mapper
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+)
+
+// MapToSessionsResponse - маппинг сессий в модель ответ, currentSessionID отмечает сессию текущего запроса
+func MapToSessionsResponse(code int, sessions []entity.Session, currentSessionID string) response.ViewResponse {
+	result := make([]model.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, model.SessionResponse{
+			ID:           session.ID,
+			DeviceName:   session.DeviceName,
+			UserAgent:    session.UserAgent,
+			IP:           session.IP,
+			CreatedDate:  session.CreatedDate.Format(config.IsoTimeLayout),
+			LastUsedDate: session.LastUsedDate.Format(config.IsoTimeLayout),
+			Current:      currentSessionID != "" && session.ID == currentSessionID,
+		})
+	}
+	return response.ViewResponse{
+		Code:   code,
+		Result: result,
+	}
+}
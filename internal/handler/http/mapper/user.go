@@ -36,7 +36,10 @@ func MapToEntityUserUpdatePrivate(user model.UserUpdatePrivate) entity.UserUpdat
 	if user.Role != nil {
 		role := entity.RoleType(*user.Role)
 		u.Role = &role
-		return u
+	}
+
+	if user.Reason != nil {
+		u.Reason = *user.Reason
 	}
 
 	return u
@@ -69,17 +72,21 @@ func mapOrderType(order string) string {
 	}
 }
 
-// MapToUserWithJWTResponse - маппинг пользователя с jwt в ответ
+// MapToUserWithJWTResponse - маппинг пользователя с jwt в ответ, jwt не передается, если токены не выдавались
 func MapToUserWithJWTResponse(code int, user entity.User) response.ViewResponse {
+	result := model.SignUpResponse{
+		UserResponse: mapUserToResponse(user),
+	}
+	if user.JWT.Token != "" {
+		result.JWT = &model.JWT{
+			Token:        user.JWT.Token,
+			RefreshToken: user.JWT.RefreshToken,
+		}
+	}
+
 	return response.ViewResponse{
-		Code: code,
-		Result: model.SignUpResponse{
-			UserResponse: mapUserToResponse(user),
-			JWT: model.JWT{
-				Token:        user.JWT.Token,
-				RefreshToken: user.JWT.RefreshToken,
-			},
-		},
+		Code:   code,
+		Result: result,
 	}
 }
 
@@ -95,13 +102,14 @@ func mapUserToResponse(user entity.User) model.UserResponse {
 	}
 
 	return model.UserResponse{
-		ID:          user.ID,
-		Name:        user.Name,
-		Surname:     user.Surname,
-		Email:       user.Email,
-		CreatedDate: user.CreatedDate.Format(config.IsoTimeLayout),
-		UpdatedDate: updatedDate,
-		Role:        string(user.Role),
+		ID:            user.ID,
+		Name:          user.Name,
+		Surname:       user.Surname,
+		Email:         user.Email,
+		CreatedDate:   user.CreatedDate.Format(config.IsoTimeLayout),
+		UpdatedDate:   updatedDate,
+		Role:          string(user.Role),
+		EmailVerified: user.EmailVerified,
 	}
 }
 
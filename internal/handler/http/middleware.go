@@ -3,10 +3,12 @@ package http
 import (
 	"context"
 	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
 	"github.com/GermanBogatov/auth-service/internal/common/metrics"
 	"github.com/GermanBogatov/auth-service/internal/common/response"
 	"github.com/GermanBogatov/auth-service/internal/config"
 	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/pkg/errors"
@@ -16,15 +18,14 @@ import (
 
 type appHandler func(http.ResponseWriter, *http.Request) error
 
-// appMiddleware - мидлваре для приложения
-func appMiddleware(h appHandler) http.HandlerFunc {
+// appMiddleware - мидлваре для приложения: авторизует вызывающего по требованиям роута
+func (h *Handler) appMiddleware(handler appHandler, rule access) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		method := r.Method
-		routeContext := chi.RouteContext(r.Context())
-		pattern := routeContext.RoutePattern()
+		pattern := chi.RouteContext(r.Context()).RoutePattern()
 		defer metrics.ObserveRequestDurationSeconds(method, pattern)()
 
-		if routeContext.RoutePatterns[0] != authV1+"/*" && routeContext.RoutePatterns[0] != integrationV1+"/*" {
+		if !rule.public {
 
 			authHeader := strings.Split(r.Header.Get("Authorization"), "Bearer ")
 			if len(authHeader) != 2 {
@@ -33,41 +34,34 @@ func appMiddleware(h appHandler) http.HandlerFunc {
 				return
 			}
 
-			accessToken := authHeader[1]
-			key := []byte(config.JWTSecret)
-
-			token, err := jwt.ParseWithClaims(accessToken, &entity.UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					metrics.IncRequestTotal(metrics.FailStatus, method, pattern)
-					return nil, apperror.ErrInvalidSigningMethod
-				}
-				return key, nil
-			})
-
+			caller, err := h.authenticate(r, authHeader[1])
 			if err != nil {
 				metrics.IncRequestTotal(metrics.FailStatus, method, pattern)
-				response.RespondError(w, r, apperror.UnauthorizedError(errors.Wrap(err, apperror.ErrMalformedToken.Error())))
+				response.RespondError(w, r, err)
 				return
 			}
 
-			if !token.Valid {
+			err = rule.authorize(r, caller)
+			if err != nil {
 				metrics.IncRequestTotal(metrics.FailStatus, method, pattern)
-				response.RespondError(w, r, apperror.UnauthorizedError(apperror.ErrTokenIsInspired))
+				response.RespondError(w, r, err)
 				return
 			}
 
-			claims, ok := token.Claims.(*entity.UserClaims)
-			if !ok {
-				metrics.IncRequestTotal(metrics.FailStatus, method, pattern)
-				response.RespondError(w, r, apperror.UnauthorizedError(err))
-				return
-			}
+			setCtxValue(r, config.ParamID, caller.claims.Subject)
+			setCtxValue(r, config.ParamRole, caller.claims.Role)
+			setCtxValue(r, config.ParamClaims, caller.claims)
+			setCtxValue(r, config.ParamCallerType, caller.claims.CallerType())
+			setCtxValue(r, config.ParamPermissions, caller.permissions)
+			setCtxValue(r, config.ParamActor, caller.claims.Actor)
 
-			setCtxValue(r, config.ParamID, claims.ID)
-			setCtxValue(r, config.ParamRole, claims.Role)
+			if caller.claims.Actor != nil {
+				logging.Infof("impersonated request: actor [%s] subject [%s] method [%s] path [%s]",
+					caller.claims.Actor.Subject, caller.claims.Subject, method, r.URL.Path)
+			}
 		}
 
-		err := h(w, r)
+		err := handler(w, r)
 		if err != nil {
 			metrics.IncRequestTotal(metrics.FailStatus, method, pattern)
 			response.RespondError(w, r, err)
@@ -78,6 +72,62 @@ func appMiddleware(h appHandler) http.HandlerFunc {
 	}
 }
 
+// authenticate - проверка токена из заголовка Authorization: персональный токен узнается по префиксу,
+// остальные токены проверяются как jwt
+func (h *Handler) authenticate(r *http.Request, token string) (principal, error) {
+	if !strings.HasPrefix(token, entity.PersonalTokenPrefix) {
+		claims, err := h.jwtService.ParseAccessToken(r.Context(), token)
+		if err != nil {
+			return principal{}, apperror.UnauthorizedError(err)
+		}
+
+		if claims.CallerType() == entity.CallerTypeService {
+			return principal{claims: claims, permissions: entity.ServicePermissions}, nil
+		}
+
+		permissions, err := h.roleService.GetPermissions(r.Context(), claims.Role)
+		if err != nil {
+			return principal{}, apperror.InternalServerError(err)
+		}
+		// токен oauth-клиента дает только права, открытые выданными клиенту скоупами
+		if claims.ClientID != "" {
+			permissions = claims.ClientPermissions(permissions)
+		}
+		if claims.Actor != nil {
+			permissions = permissions.WithoutImpersonationDenied()
+		}
+		return principal{claims: claims, permissions: permissions}, nil
+	}
+
+	personalToken, user, err := h.personalTokenService.Authenticate(r.Context(), token, helpers.GetClientIP(r))
+	if err != nil {
+		// неизвестный, истекший или отозванный токен - ошибка клиента, остальное - сбой хранилища
+		if errors.Is(err, apperror.ErrInvalidPersonalToken) {
+			return principal{}, apperror.UnauthorizedError(err)
+		}
+		return principal{}, apperror.InternalServerError(err)
+	}
+
+	rolePermissions, err := h.roleService.GetPermissions(r.Context(), string(user.Role))
+	if err != nil {
+		return principal{}, apperror.InternalServerError(err)
+	}
+
+	return principal{
+		claims: entity.UserClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject: user.ID,
+				ID:      personalToken.ID,
+			},
+			Email: user.Email,
+			Role:  string(user.Role),
+			Scope: entity.FormatScope(personalToken.Scopes),
+		},
+		permissions:   personalToken.Permissions(rolePermissions),
+		personalToken: true,
+	}, nil
+}
+
 // setCtxValue - прокинуть значение в контексте
 func setCtxValue(r *http.Request, key, value any) {
 	ctx := r.Context()
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticateImpersonation(t *testing.T) {
+	userPermissions := entity.Permissions{
+		entity.PermissionUsersRead,
+		entity.PermissionUsersUpdateSelf,
+		entity.PermissionUsersDeleteSelf,
+		entity.PermissionPasswordChangeSelf,
+		entity.PermissionSessionsReadSelf,
+		entity.PermissionSessionsDeleteSelf,
+		entity.PermissionTokensManageSelf,
+	}
+
+	tests := []struct {
+		name   string
+		actor  *entity.ActorClaim
+		want   entity.Permissions
+		denied bool
+	}{
+		{
+			name: "user token",
+			want: userPermissions,
+		},
+		{
+			name:  "impersonation token",
+			actor: &entity.ActorClaim{Subject: "admin-1"},
+			want: entity.Permissions{entity.PermissionUsersRead, entity.PermissionUsersUpdateSelf,
+				entity.PermissionSessionsReadSelf, entity.PermissionSessionsDeleteSelf},
+			denied: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := entity.UserClaims{Role: string(entity.RoleUser), Actor: tt.actor}
+			claims.Subject = "user-1"
+			h := &Handler{
+				jwtService:  &fakeJWT{claims: claims},
+				roleService: &fakeRole{permissions: map[string]entity.Permissions{string(entity.RoleUser): userPermissions}},
+			}
+
+			caller, err := h.authenticate(httptest.NewRequest(http.MethodGet, "/", nil), "access-token")
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, caller.permissions)
+			assert.Equal(t, !tt.denied, caller.permissions.Has(entity.PermissionPasswordChangeSelf))
+			assert.Equal(t, !tt.denied, caller.permissions.Has(entity.PermissionTokensManageSelf))
+			assert.Equal(t, !tt.denied, caller.permissions.Has(entity.PermissionUsersDeleteSelf))
+		})
+	}
+}
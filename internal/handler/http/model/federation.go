@@ -0,0 +1,7 @@
+package model
+
+// FederatedProviderResponse - модель внешнего провайдера, доступного для входа
+type FederatedProviderResponse struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
@@ -0,0 +1,16 @@
+package model
+
+// ImpersonationRequest - модель запроса токена имперсонации
+type ImpersonationRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ImpersonationResponse - модель токена имперсонации, рефреш-токен не выдается
+type ImpersonationResponse struct {
+	AccessToken string `json:"accessToken"`
+	TokenType   string `json:"tokenType"`
+	ExpiresIn   int    `json:"expiresIn"`
+	ExpiresDate string `json:"expiresDate"`
+	UserID      string `json:"userId"`
+	ActorID     string `json:"actorId"`
+}
@@ -0,0 +1,23 @@
+package model
+
+// IntrospectionResponse - модель ответа интроспекции токена (RFC 7662)
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	TokenType string `json:"token_type,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Role      string `json:"role,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+	Sid       string `json:"sid,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	// Act - администратор, действующий от имени пользователя (RFC 8693, раздел 4.1)
+	Act *IntrospectionActor `json:"act,omitempty"`
+}
+
+// IntrospectionActor - claim act в ответе интроспекции
+type IntrospectionActor struct {
+	Sub string `json:"sub"`
+}
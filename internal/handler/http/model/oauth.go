@@ -0,0 +1,13 @@
+package model
+
+// OAuthTokenResponse - модель ответа токен-эндпоинта (RFC 6749, раздел 5.1)
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	// IssuedTokenType - тип выданного токена при обмене (RFC 8693, раздел 2.2.1)
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
+}
@@ -0,0 +1,42 @@
+package model
+
+// OAuthClientCreateRequest - модель регистрации oauth-клиента
+type OAuthClientCreateRequest struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirectUris"`
+	GrantTypes   []string `json:"grantTypes"`
+	Scopes       []string `json:"scopes"`
+	// Audiences - дополнительные аудитории access-токенов клиента
+	Audiences []string `json:"audiences"`
+	// Public - клиент без секрета (spa, мобильное приложение)
+	Public bool `json:"public"`
+}
+
+// OAuthClientUpdateRequest - модель редактирования oauth-клиента
+type OAuthClientUpdateRequest struct {
+	Name         *string   `json:"name"`
+	RedirectURIs *[]string `json:"redirectUris"`
+	GrantTypes   *[]string `json:"grantTypes"`
+	Scopes       *[]string `json:"scopes"`
+	Audiences    *[]string `json:"audiences"`
+}
+
+// OAuthClientResponse - модель oauth-клиента
+type OAuthClientResponse struct {
+	ID           string   `json:"id"`
+	ClientID     string   `json:"clientId"`
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirectUris"`
+	GrantTypes   []string `json:"grantTypes"`
+	Scopes       []string `json:"scopes"`
+	Audiences    []string `json:"audiences"`
+	Public       bool     `json:"public"`
+	CreatedDate  string   `json:"createdDate"`
+	UpdatedDate  *string  `json:"updatedDate"`
+}
+
+// OAuthClientWithSecretResponse - модель oauth-клиента с секретом, отдается только при создании и сбросе секрета
+type OAuthClientWithSecretResponse struct {
+	OAuthClientResponse
+	ClientSecret string `json:"clientSecret,omitempty"`
+}
@@ -0,0 +1,34 @@
+package model
+
+// PersonalTokenCreateRequest - модель создания персонального токена
+type PersonalTokenCreateRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// ExpiresInDays - срок действия в днях, без него токен бессрочный
+	ExpiresInDays *int `json:"expiresInDays"`
+}
+
+// PersonalTokenUpdateRequest - модель редактирования персонального токена
+type PersonalTokenUpdateRequest struct {
+	Name   *string   `json:"name"`
+	Scopes *[]string `json:"scopes"`
+}
+
+// PersonalTokenResponse - модель персонального токена
+type PersonalTokenResponse struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	TokenPrefix  string   `json:"tokenPrefix"`
+	Scopes       []string `json:"scopes"`
+	ExpiresDate  *string  `json:"expiresDate"`
+	LastUsedDate *string  `json:"lastUsedDate"`
+	LastUsedIP   *string  `json:"lastUsedIp"`
+	CreatedDate  string   `json:"createdDate"`
+	UpdatedDate  *string  `json:"updatedDate"`
+}
+
+// PersonalTokenWithSecretResponse - модель персонального токена с самим токеном, отдается только при создании
+type PersonalTokenWithSecretResponse struct {
+	PersonalTokenResponse
+	Token string `json:"token"`
+}
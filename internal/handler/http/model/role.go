@@ -0,0 +1,27 @@
+package model
+
+// RoleCreateRequest - модель создания роли
+type RoleCreateRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	Level       int      `json:"level"`
+}
+
+// RoleUpdateRequest - модель редактирования роли
+type RoleUpdateRequest struct {
+	Description *string   `json:"description"`
+	Permissions *[]string `json:"permissions"`
+	Level       *int      `json:"level"`
+}
+
+// RoleResponse - модель роли
+type RoleResponse struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	Level       int      `json:"level"`
+	BuiltIn     bool     `json:"builtIn"`
+	CreatedDate string   `json:"createdDate"`
+	UpdatedDate *string  `json:"updatedDate"`
+}
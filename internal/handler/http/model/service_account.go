@@ -0,0 +1,36 @@
+package model
+
+// ServiceAccountCreateRequest - модель создания сервисного аккаунта
+type ServiceAccountCreateRequest struct {
+	Name string `json:"name"`
+	// OwnerID - ответственный пользователь, по умолчанию создающий админ
+	OwnerID *string  `json:"ownerId"`
+	Scopes  []string `json:"scopes"`
+	// Audiences - дополнительные аудитории токенов аккаунта
+	Audiences []string `json:"audiences"`
+}
+
+// ServiceAccountUpdateRequest - модель редактирования сервисного аккаунта
+type ServiceAccountUpdateRequest struct {
+	Name      *string   `json:"name"`
+	Scopes    *[]string `json:"scopes"`
+	Audiences *[]string `json:"audiences"`
+}
+
+// ServiceAccountResponse - модель сервисного аккаунта
+type ServiceAccountResponse struct {
+	ID          string   `json:"id"`
+	ClientID    string   `json:"clientId"`
+	Name        string   `json:"name"`
+	OwnerID     string   `json:"ownerId"`
+	Scopes      []string `json:"scopes"`
+	Audiences   []string `json:"audiences"`
+	CreatedDate string   `json:"createdDate"`
+	UpdatedDate *string  `json:"updatedDate"`
+}
+
+// ServiceAccountWithSecretResponse - модель сервисного аккаунта с секретом, отдается только при создании и сбросе секрета
+type ServiceAccountWithSecretResponse struct {
+	ServiceAccountResponse
+	ClientSecret string `json:"clientSecret"`
+}
@@ -0,0 +1,12 @@
+package model
+
+// SessionResponse - модель сессии пользователя
+type SessionResponse struct {
+	ID           string `json:"id"`
+	DeviceName   string `json:"deviceName"`
+	UserAgent    string `json:"userAgent"`
+	IP           string `json:"ip"`
+	CreatedDate  string `json:"createdDate"`
+	LastUsedDate string `json:"lastUsedDate"`
+	Current      bool   `json:"current"`
+}
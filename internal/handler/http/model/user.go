@@ -9,14 +9,15 @@ type SignUpRequest struct {
 
 // SignInRequest - модель для авторизации пользователя
 type SignInRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	DeviceName string `json:"deviceName"`
 }
 
-// SignUpResponse - модель ответа после регистрации
+// SignUpResponse - модель ответа после регистрации или входа, без jwt - вход ждет подтверждения email
 type SignUpResponse struct {
 	UserResponse
-	JWT JWT `json:"jwt"`
+	JWT *JWT `json:"jwt,omitempty"`
 }
 
 // UserUpdateBase - базовая модель для редактирования пользователя
@@ -27,15 +28,15 @@ type UserUpdateBase struct {
 	ID      string  `json:"id"`
 }
 
-// UserUpdate - модель при редактировании пользователя
+// UserUpdate - модель при редактировании пользователя, пароль меняется только через ChangePasswordRequest
 type UserUpdate struct {
-	Password *string `json:"password"`
 	UserUpdateBase
 }
 
 // UserUpdatePrivate - модель при приватном редактировании пользователя
 type UserUpdatePrivate struct {
-	Role *string `json:"role"`
+	Role   *string `json:"role"`
+	Reason *string `json:"reason"`
 	UserUpdateBase
 }
 
@@ -48,10 +49,45 @@ type UserResponse struct {
 	CreatedDate string  `json:"createdDate"`
 	UpdatedDate *string `json:"updatedDate"`
 	Role        string  `json:"role"`
+	// EmailVerified - пользователь подтвердил email
+	EmailVerified bool `json:"emailVerified"`
 }
 
 // JWT - модель для токена с рефрешом
 type JWT struct {
 	Token        string `json:"token"`
-	RefreshToken string `json:"refreshToken"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+}
+
+// ForgotPasswordRequest - модель запроса письма для сброса пароля
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest - модель смены пароля по токену из письма
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// ChangePasswordRequest - модель смены пароля текущим пользователем
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+// VerifyEmailRequest - модель подтверждения email по токену из письма
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// ResendEmailVerificationRequest - модель повторной отправки письма подтверждения email
+type ResendEmailVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+// TokenRequest - модель запроса с токеном для отзыва (RFC 7009) или интроспекции (RFC 7662)
+type TokenRequest struct {
+	Token         string
+	TokenTypeHint string
 }
@@ -0,0 +1,242 @@
+package http
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/mapper"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/validator"
+	"github.com/pkg/errors"
+	"net/http"
+	"slices"
+)
+
+// Token - токен-эндпоинт OAuth2 (RFC 6749, раздел 3.2)
+func (h *Handler) Token(w http.ResponseWriter, r *http.Request) error {
+	err := r.ParseForm()
+	if err != nil {
+		return apperror.OAuthBadRequestError(apperror.OAuthInvalidRequest, errors.Wrap(err, "parse form"))
+	}
+
+	grantType := r.PostForm.Get("grant_type")
+	err = validator.ValidateGrantType(grantType)
+	if err != nil {
+		if errors.Is(err, apperror.ErrUnsupportedGrantType) {
+			return apperror.OAuthBadRequestError(apperror.OAuthUnsupportedGrantType, err)
+		}
+		return apperror.OAuthBadRequestError(apperror.OAuthInvalidRequest, err)
+	}
+
+	var token entity.OAuthToken
+	switch grantType {
+	case entity.GrantTypeClientCredentials:
+		token, err = h.clientCredentialsGrant(w, r)
+	case entity.GrantTypeAuthorizationCode:
+		token, err = h.authorizationCodeGrant(w, r)
+	case entity.GrantTypeRefreshToken:
+		token, err = h.refreshTokenGrant(w, r)
+	case entity.GrantTypeTokenExchange:
+		token, err = h.tokenExchangeGrant(w, r)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	return response.RespondJSON(w, http.StatusOK, mapper.MapToOAuthTokenResponse(token))
+}
+
+// clientCredentialsGrant - выпуск токена сервисному аккаунту по его учетным данным
+func (h *Handler) clientCredentialsGrant(w http.ResponseWriter, r *http.Request) (entity.OAuthToken, error) {
+	ctx := r.Context()
+
+	account, err := h.authenticateClient(w, r)
+	if err != nil {
+		return entity.OAuthToken{}, err
+	}
+
+	scopes, err := grantedScopes(account.Scopes, entity.ParseScope(r.PostForm.Get("scope")))
+	if err != nil {
+		return entity.OAuthToken{}, apperror.OAuthBadRequestError(apperror.OAuthInvalidScope, err)
+	}
+
+	token, err := h.jwtService.GenerateClientCredentialsToken(ctx, account, scopes)
+	if err != nil {
+		return entity.OAuthToken{}, apperror.OAuthInternalServerError(err)
+	}
+
+	return token, nil
+}
+
+// authorizationCodeGrant - обмен кода авторизации на токены пользователя с проверкой PKCE
+func (h *Handler) authorizationCodeGrant(w http.ResponseWriter, r *http.Request) (entity.OAuthToken, error) {
+	ctx := r.Context()
+
+	client, err := h.authenticateOAuthClient(w, r, entity.GrantTypeAuthorizationCode)
+	if err != nil {
+		return entity.OAuthToken{}, err
+	}
+
+	exchange := entity.CodeExchange{
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+	}
+
+	err = validator.ValidateCodeExchange(exchange)
+	if err != nil {
+		return entity.OAuthToken{}, apperror.OAuthBadRequestError(apperror.OAuthInvalidRequest, err)
+	}
+
+	token, err := h.oauthService.ExchangeAuthorizationCode(ctx, client, exchange, helpers.GetClientInfo(r))
+	if err != nil {
+		if isInvalidGrant(err) {
+			return entity.OAuthToken{}, apperror.OAuthBadRequestError(apperror.OAuthInvalidGrant, err)
+		}
+		return entity.OAuthToken{}, apperror.OAuthInternalServerError(err)
+	}
+
+	return token, nil
+}
+
+// refreshTokenGrant - ротация рефреш-токена, выданного oauth-клиенту
+func (h *Handler) refreshTokenGrant(w http.ResponseWriter, r *http.Request) (entity.OAuthToken, error) {
+	ctx := r.Context()
+
+	client, err := h.authenticateOAuthClient(w, r, entity.GrantTypeRefreshToken)
+	if err != nil {
+		return entity.OAuthToken{}, err
+	}
+
+	refreshToken := r.PostForm.Get("refresh_token")
+	if refreshToken == "" {
+		return entity.OAuthToken{}, apperror.OAuthBadRequestError(apperror.OAuthInvalidRequest, apperror.ErrEmptyRefreshToken)
+	}
+
+	token, err := h.oauthService.RefreshToken(ctx, client, refreshToken, helpers.GetClientInfo(r))
+	if err != nil {
+		if isInvalidGrant(err) {
+			return entity.OAuthToken{}, apperror.OAuthBadRequestError(apperror.OAuthInvalidGrant, err)
+		}
+		return entity.OAuthToken{}, apperror.OAuthInternalServerError(err)
+	}
+
+	return token, nil
+}
+
+// tokenExchangeGrant - обмен токена пользователя на токен для другого сервиса (RFC 8693).
+// Обменивать токены может сервисный аккаунт со скоупом token-exchange
+func (h *Handler) tokenExchangeGrant(w http.ResponseWriter, r *http.Request) (entity.OAuthToken, error) {
+	ctx := r.Context()
+
+	account, err := h.authenticateClient(w, r)
+	if err != nil {
+		return entity.OAuthToken{}, err
+	}
+
+	if !account.HasScope(entity.ScopeTokenExchange) {
+		return entity.OAuthToken{}, apperror.OAuthBadRequestError(apperror.OAuthUnauthorizedClient, apperror.ErrUnauthorizedClient)
+	}
+
+	exchange := entity.TokenExchange{
+		SubjectToken:       r.PostForm.Get("subject_token"),
+		SubjectTokenType:   r.PostForm.Get("subject_token_type"),
+		RequestedTokenType: r.PostForm.Get("requested_token_type"),
+		Audience:           r.PostForm.Get("audience"),
+		Scopes:             entity.ParseScope(r.PostForm.Get("scope")),
+	}
+
+	err = validator.ValidateTokenExchange(exchange, h.cfg.TokenExchange.Audiences)
+	if err != nil {
+		if errors.Is(err, apperror.ErrInvalidTarget) {
+			return entity.OAuthToken{}, apperror.OAuthBadRequestError(apperror.OAuthInvalidTarget, err)
+		}
+		return entity.OAuthToken{}, apperror.OAuthBadRequestError(apperror.OAuthInvalidRequest, err)
+	}
+
+	token, err := h.jwtService.ExchangeToken(ctx, account, exchange)
+	if err != nil {
+		if errors.Is(err, apperror.ErrInvalidSubjectToken) {
+			return entity.OAuthToken{}, apperror.OAuthBadRequestError(apperror.OAuthInvalidRequest, err)
+		}
+		if errors.Is(err, apperror.ErrScopeNotAllowed) {
+			return entity.OAuthToken{}, apperror.OAuthBadRequestError(apperror.OAuthInvalidScope, err)
+		}
+		return entity.OAuthToken{}, apperror.OAuthInternalServerError(err)
+	}
+
+	return token, nil
+}
+
+// authenticateOAuthClient - аутентификация зарегистрированного oauth-клиента и проверка, что ему разрешен грант.
+// Публичный клиент передает только client_id в форме
+func (h *Handler) authenticateOAuthClient(w http.ResponseWriter, r *http.Request, grantType string) (entity.OAuthClient, error) {
+	clientID, clientSecret, ok := helpers.GetClientCredentials(r)
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	if clientID == "" {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		return entity.OAuthClient{}, apperror.OAuthInvalidClientError(apperror.ErrInvalidClient)
+	}
+
+	client, err := h.oauthClientService.Authenticate(r.Context(), clientID, clientSecret)
+	if err != nil {
+		if errors.Is(err, apperror.ErrInvalidClient) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+			return entity.OAuthClient{}, apperror.OAuthInvalidClientError(err)
+		}
+		return entity.OAuthClient{}, apperror.OAuthInternalServerError(err)
+	}
+
+	if !client.HasGrantType(grantType) {
+		return entity.OAuthClient{}, apperror.OAuthBadRequestError(apperror.OAuthUnauthorizedClient, apperror.ErrUnauthorizedClient)
+	}
+
+	return client, nil
+}
+
+// isInvalidGrant - ошибки, означающие недействительный код авторизации или рефреш-токен
+func isInvalidGrant(err error) bool {
+	return errors.Is(err, apperror.ErrInvalidAuthorizationCode) || errors.Is(err, apperror.ErrRedirectURIMismatch) ||
+		errors.Is(err, apperror.ErrInvalidCodeVerifier) || errors.Is(err, apperror.ErrUserNotFound) ||
+		errors.Is(err, apperror.ErrRefreshTokenNotFound) || errors.Is(err, apperror.ErrRefreshTokenReused) ||
+		errors.Is(err, apperror.ErrEmailNotVerified)
+}
+
+// authenticateClient - аутентификация сервисного аккаунта по basic-авторизации или полям формы
+func (h *Handler) authenticateClient(w http.ResponseWriter, r *http.Request) (entity.ServiceAccount, error) {
+	clientID, clientSecret, ok := helpers.GetClientCredentials(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		return entity.ServiceAccount{}, apperror.OAuthInvalidClientError(apperror.ErrInvalidClient)
+	}
+
+	account, err := h.serviceAccountService.Authenticate(r.Context(), clientID, clientSecret)
+	if err != nil {
+		if errors.Is(err, apperror.ErrInvalidClient) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+			return entity.ServiceAccount{}, apperror.OAuthInvalidClientError(err)
+		}
+		return entity.ServiceAccount{}, apperror.OAuthInternalServerError(err)
+	}
+
+	return account, nil
+}
+
+// grantedScopes - запрошенные скоупы должны быть разрешены клиенту, без запроса выдаются все разрешенные
+func grantedScopes(allowed, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return allowed, nil
+	}
+
+	for _, scope := range requested {
+		if !slices.Contains(allowed, scope) {
+			return nil, errors.Wrapf(apperror.ErrScopeNotAllowed, "scope [%s]", scope)
+		}
+	}
+
+	return requested, nil
+}
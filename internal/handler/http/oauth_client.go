@@ -0,0 +1,145 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/mapper"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/validator"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// CreateOAuthClient - хэндлер регистрации oauth-клиента
+func (h *Handler) CreateOAuthClient(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var createClient model.OAuthClientCreateRequest
+	defer func() {
+		err := r.Body.Close()
+		if err != nil {
+			logging.Error("error close request body")
+		}
+	}()
+
+	if err := json.NewDecoder(r.Body).Decode(&createClient); err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "json decode"))
+	}
+
+	err := validator.ValidateOAuthClientCreate(createClient)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate oauth client"))
+	}
+
+	client, err := h.oauthClientService.CreateOAuthClient(ctx, mapper.MapToEntityOAuthClient(createClient))
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccessCreate(w, mapper.MapToOAuthClientWithSecretResponse(http.StatusCreated, client))
+}
+
+// GetOAuthClients - хэндлер получения oauth-клиентов
+func (h *Handler) GetOAuthClients(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	clients, err := h.oauthClientService.GetOAuthClients(ctx)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToOAuthClientsResponse(http.StatusOK, clients))
+}
+
+// GetOAuthClientByID - хэндлер получения oauth-клиента по идентификатору
+func (h *Handler) GetOAuthClientByID(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	clientID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	client, err := h.oauthClientService.GetOAuthClientByID(ctx, clientID.String())
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToOAuthClientResponse(http.StatusOK, client))
+}
+
+// UpdateOAuthClient - хэндлер редактирования oauth-клиента
+func (h *Handler) UpdateOAuthClient(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	clientID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	var updateClient model.OAuthClientUpdateRequest
+	defer func() {
+		errClose := r.Body.Close()
+		if errClose != nil {
+			logging.Error("error close request body")
+		}
+	}()
+
+	if errDecode := json.NewDecoder(r.Body).Decode(&updateClient); errDecode != nil {
+		return apperror.BadRequestError(errors.Wrap(errDecode, "json decode"))
+	}
+
+	err = validator.ValidateOAuthClientUpdate(updateClient)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate oauth client"))
+	}
+
+	client, err := h.oauthClientService.UpdateOAuthClient(ctx, mapper.MapToEntityOAuthClientUpdate(clientID.String(), updateClient))
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToOAuthClientResponse(http.StatusOK, client))
+}
+
+// ResetOAuthClientSecret - хэндлер выпуска нового секрета конфиденциального oauth-клиента
+func (h *Handler) ResetOAuthClientSecret(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	clientID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	client, err := h.oauthClientService.ResetOAuthClientSecret(ctx, clientID.String())
+	if err != nil {
+		if errors.Is(err, apperror.ErrPublicClientSecret) {
+			return apperror.BadRequestError(err)
+		}
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToOAuthClientWithSecretResponse(http.StatusOK, client))
+}
+
+// DeleteOAuthClient - хэндлер удаления oauth-клиента. Выданные клиенту рефреш-токены перестают обновляться,
+// так как клиент больше не проходит аутентификацию на токен-эндпоинте
+func (h *Handler) DeleteOAuthClient(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	clientID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	err = h.oauthClientService.DeleteOAuthClientByID(ctx, clientID.String())
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, response.ViewResponse{Code: http.StatusOK})
+}
@@ -0,0 +1,30 @@
+package http
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/mapper"
+	"net/http"
+	"slices"
+)
+
+// UserInfo - хэндлер userinfo-эндпоинта: claims пользователя отдаются по скоупам access-токена
+func (h *Handler) UserInfo(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	claims := ctx.Value(config.ParamClaims).(entity.UserClaims)
+
+	scopes := entity.ParseScope(claims.Scope)
+	if claims.CallerType() != entity.CallerTypeUser || !slices.Contains(scopes, entity.ScopeOpenID) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+		return apperror.NewOAuthError(http.StatusForbidden, apperror.OAuthInsufficientScope, apperror.ErrInsufficientScope)
+	}
+
+	user, err := h.userService.GetUserByID(ctx, claims.Subject)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondJSON(w, http.StatusOK, mapper.MapToUserInfoResponse(user.ID, entity.NewUserInfo(user, scopes)))
+}
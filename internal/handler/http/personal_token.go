@@ -0,0 +1,134 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/mapper"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/validator"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// CreatePersonalToken - хэндлер создания персонального токена текущего пользователя
+func (h *Handler) CreatePersonalToken(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	selfUserID := ctx.Value(config.ParamID).(string)
+
+	var createToken model.PersonalTokenCreateRequest
+	defer func() {
+		err := r.Body.Close()
+		if err != nil {
+			logging.Error("error close request body")
+		}
+	}()
+
+	if err := json.NewDecoder(r.Body).Decode(&createToken); err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "json decode"))
+	}
+
+	err := validator.ValidatePersonalTokenCreate(createToken)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate personal token"))
+	}
+
+	token, err := h.personalTokenService.CreatePersonalToken(ctx, mapper.MapToEntityPersonalToken(createToken, selfUserID))
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccessCreate(w, mapper.MapToPersonalTokenWithSecretResponse(http.StatusCreated, token))
+}
+
+// GetPersonalTokens - хэндлер получения персональных токенов текущего пользователя
+func (h *Handler) GetPersonalTokens(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	selfUserID := ctx.Value(config.ParamID).(string)
+
+	tokens, err := h.personalTokenService.GetPersonalTokens(ctx, selfUserID)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToPersonalTokensResponse(http.StatusOK, tokens))
+}
+
+// GetPersonalTokenByID - хэндлер получения персонального токена текущего пользователя
+func (h *Handler) GetPersonalTokenByID(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	tokenID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	selfUserID := ctx.Value(config.ParamID).(string)
+
+	token, err := h.personalTokenService.GetPersonalTokenByID(ctx, tokenID.String(), selfUserID)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToPersonalTokenResponse(http.StatusOK, token))
+}
+
+// UpdatePersonalToken - хэндлер редактирования персонального токена текущего пользователя
+func (h *Handler) UpdatePersonalToken(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	tokenID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	selfUserID := ctx.Value(config.ParamID).(string)
+
+	var updateToken model.PersonalTokenUpdateRequest
+	defer func() {
+		errClose := r.Body.Close()
+		if errClose != nil {
+			logging.Error("error close request body")
+		}
+	}()
+
+	if errDecode := json.NewDecoder(r.Body).Decode(&updateToken); errDecode != nil {
+		return apperror.BadRequestError(errors.Wrap(errDecode, "json decode"))
+	}
+
+	err = validator.ValidatePersonalTokenUpdate(updateToken)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate personal token"))
+	}
+
+	token, err := h.personalTokenService.UpdatePersonalToken(ctx, mapper.MapToEntityPersonalTokenUpdate(tokenID.String(), selfUserID, updateToken))
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToPersonalTokenResponse(http.StatusOK, token))
+}
+
+// DeletePersonalToken - хэндлер отзыва персонального токена текущего пользователя
+func (h *Handler) DeletePersonalToken(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	tokenID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	selfUserID := ctx.Value(config.ParamID).(string)
+
+	err = h.personalTokenService.DeletePersonalToken(ctx, tokenID.String(), selfUserID)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, response.ViewResponse{Code: http.StatusOK})
+}
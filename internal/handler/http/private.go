@@ -2,12 +2,10 @@ package http
 
 import (
 	"encoding/json"
-	"fmt"
 	"github.com/GermanBogatov/auth-service/internal/common/apperror"
 	"github.com/GermanBogatov/auth-service/internal/common/helpers"
 	"github.com/GermanBogatov/auth-service/internal/common/response"
 	"github.com/GermanBogatov/auth-service/internal/config"
-	"github.com/GermanBogatov/auth-service/internal/entity"
 	"github.com/GermanBogatov/auth-service/internal/handler/http/mapper"
 	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
 	"github.com/GermanBogatov/auth-service/internal/handler/http/validator"
@@ -16,6 +14,7 @@ import (
 	"net/http"
 )
 
+// PrivateUpdateUser - хэндлер приватного редактирования пользователя, смена роли ограничена уровнем роли вызывающего
 func (h *Handler) PrivateUpdateUser(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 
@@ -24,14 +23,6 @@ func (h *Handler) PrivateUpdateUser(w http.ResponseWriter, r *http.Request) erro
 		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
 	}
 
-	selfUserID := ctx.Value(config.ParamID).(string)
-	role := ctx.Value(config.ParamRole).(string)
-
-	// только админу можно редактировать пользователей любых
-	if entity.RoleType(role) != entity.RoleAdmin && entity.RoleType(role) != entity.RoleSuperAdmin {
-		return apperror.BadRequestError(fmt.Errorf("user [%s] does not have rights to update user [%s]", selfUserID, userID))
-	}
-
 	var userUpdate model.UserUpdatePrivate
 	defer func() {
 		errClose := r.Body.Close()
@@ -44,7 +35,12 @@ func (h *Handler) PrivateUpdateUser(w http.ResponseWriter, r *http.Request) erro
 		return apperror.BadRequestError(errors.Wrap(errDecode, "json decode"))
 	}
 
-	err = validator.ValidateUserUpdatePrivate(userUpdate)
+	roles, err := h.roleService.GetRoles(ctx)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	err = validator.ValidateUserUpdatePrivate(userUpdate, roles)
 	if err != nil {
 		return apperror.BadRequestError(errors.Wrap(err, "validate user"))
 	}
@@ -52,10 +48,41 @@ func (h *Handler) PrivateUpdateUser(w http.ResponseWriter, r *http.Request) erro
 	user := mapper.MapToEntityUserUpdatePrivate(userUpdate)
 	user.ID = userID.String()
 
-	result, err := h.userService.UpdatePrivateUserByID(ctx, user)
+	result, err := h.userService.UpdatePrivateUserByID(ctx, callerActor(r), user)
 	if err != nil {
 		return apperror.InternalServerError(err)
 	}
 
+	// роль хранится в выпущенных токенах, поэтому после ее смены сессии пользователя отзываются
+	if user.Role != nil {
+		err = h.jwtService.RevokeAllSessions(ctx, result.ID)
+		if err != nil {
+			return apperror.InternalServerError(err)
+		}
+	}
+
+	if user.Email != nil && !result.EmailVerified {
+		h.emailVerificationService.SendVerification(ctx, result)
+	}
+
 	return response.RespondSuccess(w, mapper.MapToPrivateUserResponse(http.StatusOK, result))
 }
+
+// PrivateUnlockUser - хэндлер снятия блокировки входа пользователя после неудачных попыток
+func (h *Handler) PrivateUnlockUser(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	userID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	err = h.signInGuardService.Unlock(ctx, userID.String())
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	logging.Infof("sign-in lockout of user [%s] removed by [%s]", userID, callerActor(r).ID)
+
+	return response.RespondSuccess(w, response.ViewResponse{Code: http.StatusOK})
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/mapper"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/validator"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// CreateRole - хэндлер создания роли
+func (h *Handler) CreateRole(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var createRole model.RoleCreateRequest
+	defer func() {
+		err := r.Body.Close()
+		if err != nil {
+			logging.Error("error close request body")
+		}
+	}()
+
+	if err := json.NewDecoder(r.Body).Decode(&createRole); err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "json decode"))
+	}
+
+	err := validator.ValidateRoleCreate(createRole)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate role"))
+	}
+
+	role, err := h.roleService.CreateRole(ctx, callerActor(r), mapper.MapToEntityRole(createRole))
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccessCreate(w, mapper.MapToRoleResponse(http.StatusCreated, role))
+}
+
+// GetRoles - хэндлер получения ролей
+func (h *Handler) GetRoles(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	roles, err := h.roleService.GetRoles(ctx)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToRolesResponse(http.StatusOK, roles))
+}
+
+// GetRoleByName - хэндлер получения роли по названию
+func (h *Handler) GetRoleByName(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	name, err := helpers.GetStringFromPath(r, config.ParamRole)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get role from path"))
+	}
+
+	role, err := h.roleService.GetRoleByName(ctx, name)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToRoleResponse(http.StatusOK, role))
+}
+
+// UpdateRole - хэндлер редактирования роли
+func (h *Handler) UpdateRole(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	name, err := helpers.GetStringFromPath(r, config.ParamRole)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get role from path"))
+	}
+
+	var updateRole model.RoleUpdateRequest
+	defer func() {
+		errClose := r.Body.Close()
+		if errClose != nil {
+			logging.Error("error close request body")
+		}
+	}()
+
+	if errDecode := json.NewDecoder(r.Body).Decode(&updateRole); errDecode != nil {
+		return apperror.BadRequestError(errors.Wrap(errDecode, "json decode"))
+	}
+
+	err = validator.ValidateRoleUpdate(updateRole)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate role"))
+	}
+
+	role, err := h.roleService.UpdateRole(ctx, callerActor(r), mapper.MapToEntityRoleUpdate(name, updateRole))
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToRoleResponse(http.StatusOK, role))
+}
+
+// DeleteRole - хэндлер удаления роли, встроенные роли и роли, назначенные пользователям, не удаляются
+func (h *Handler) DeleteRole(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	name, err := helpers.GetStringFromPath(r, config.ParamRole)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get role from path"))
+	}
+
+	err = h.roleService.DeleteRole(ctx, callerActor(r), name)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, response.ViewResponse{Code: http.StatusOK})
+}
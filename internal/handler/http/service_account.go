@@ -0,0 +1,153 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/mapper"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/validator"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// CreateServiceAccount - хэндлер создания сервисного аккаунта
+func (h *Handler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	selfUserID := ctx.Value(config.ParamID).(string)
+
+	var createAccount model.ServiceAccountCreateRequest
+	defer func() {
+		err := r.Body.Close()
+		if err != nil {
+			logging.Error("error close request body")
+		}
+	}()
+
+	if err := json.NewDecoder(r.Body).Decode(&createAccount); err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "json decode"))
+	}
+
+	err := validator.ValidateServiceAccountCreate(createAccount)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate service account"))
+	}
+
+	ownerID := selfUserID
+	if createAccount.OwnerID != nil {
+		ownerID = *createAccount.OwnerID
+	}
+
+	account, err := h.serviceAccountService.CreateServiceAccount(ctx, mapper.MapToEntityServiceAccount(createAccount, ownerID))
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccessCreate(w, mapper.MapToServiceAccountWithSecretResponse(http.StatusCreated, account))
+}
+
+// GetServiceAccounts - хэндлер получения сервисных аккаунтов
+func (h *Handler) GetServiceAccounts(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	accounts, err := h.serviceAccountService.GetServiceAccounts(ctx)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToServiceAccountsResponse(http.StatusOK, accounts))
+}
+
+// GetServiceAccountByID - хэндлер получения сервисного аккаунта по идентификатору
+func (h *Handler) GetServiceAccountByID(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	accountID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	account, err := h.serviceAccountService.GetServiceAccountByID(ctx, accountID.String())
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToServiceAccountResponse(http.StatusOK, account))
+}
+
+// UpdateServiceAccount - хэндлер редактирования сервисного аккаунта
+func (h *Handler) UpdateServiceAccount(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	accountID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	var updateAccount model.ServiceAccountUpdateRequest
+	defer func() {
+		errClose := r.Body.Close()
+		if errClose != nil {
+			logging.Error("error close request body")
+		}
+	}()
+
+	if errDecode := json.NewDecoder(r.Body).Decode(&updateAccount); errDecode != nil {
+		return apperror.BadRequestError(errors.Wrap(errDecode, "json decode"))
+	}
+
+	err = validator.ValidateServiceAccountUpdate(updateAccount)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate service account"))
+	}
+
+	account, err := h.serviceAccountService.UpdateServiceAccount(ctx, mapper.MapToEntityServiceAccountUpdate(accountID.String(), updateAccount))
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToServiceAccountResponse(http.StatusOK, account))
+}
+
+// ResetServiceAccountSecret - хэндлер выпуска нового секрета сервисного аккаунта
+func (h *Handler) ResetServiceAccountSecret(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	accountID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	account, err := h.serviceAccountService.ResetServiceAccountSecret(ctx, accountID.String())
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToServiceAccountWithSecretResponse(http.StatusOK, account))
+}
+
+// DeleteServiceAccount - хэндлер удаления сервисного аккаунта, выпущенные ему токены отзываются
+func (h *Handler) DeleteServiceAccount(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	accountID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	err = h.serviceAccountService.DeleteServiceAccountByID(ctx, accountID.String())
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	err = h.jwtService.RevokeAllSessions(ctx, accountID.String())
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, response.ViewResponse{Code: http.StatusOK})
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/mapper"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// GetSessions - хэндлер получения сессий текущего пользователя
+func (h *Handler) GetSessions(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	claims := ctx.Value(config.ParamClaims).(entity.UserClaims)
+
+	sessions, err := h.sessionService.GetUserSessions(ctx, claims.Subject)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToSessionsResponse(http.StatusOK, sessions, claims.SessionID))
+}
+
+// DeleteSession - хэндлер завершения сессии текущего пользователя
+func (h *Handler) DeleteSession(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	sessionID, err := helpers.GetUuidFromPath(r, config.ParamSession)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	selfUserID := ctx.Value(config.ParamID).(string)
+
+	err = h.sessionService.RevokeUserSession(ctx, selfUserID, sessionID.String())
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, response.ViewResponse{Code: http.StatusOK})
+}
+
+// PrivateGetUserSessions - хэндлер получения сессий любого пользователя
+func (h *Handler) PrivateGetUserSessions(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	userID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	sessions, err := h.sessionService.GetUserSessions(ctx, userID.String())
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, mapper.MapToSessionsResponse(http.StatusOK, sessions, ""))
+}
+
+// PrivateDeleteUserSession - хэндлер завершения сессии любого пользователя
+func (h *Handler) PrivateDeleteUserSession(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	userID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	sessionID, err := helpers.GetUuidFromPath(r, config.ParamSession)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	err = h.sessionService.RevokeUserSession(ctx, userID.String(), sessionID.String())
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, response.ViewResponse{Code: http.StatusOK})
+}
+
+// PrivateDeleteUserSessions - хэндлер завершения всех сессий любого пользователя
+func (h *Handler) PrivateDeleteUserSessions(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	userID, err := helpers.GetUuidFromPath(r, config.ParamID)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
+	}
+
+	err = h.jwtService.RevokeAllSessions(ctx, userID.String())
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, response.ViewResponse{Code: http.StatusOK})
+}
@@ -2,11 +2,11 @@ package http
 
 import (
 	"encoding/json"
-	"fmt"
 	"github.com/GermanBogatov/auth-service/internal/common/apperror"
 	"github.com/GermanBogatov/auth-service/internal/common/helpers"
 	"github.com/GermanBogatov/auth-service/internal/common/response"
 	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
 	"github.com/GermanBogatov/auth-service/internal/handler/http/mapper"
 	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
 	"github.com/GermanBogatov/auth-service/internal/handler/http/validator"
@@ -38,7 +38,12 @@ func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) error {
 		return apperror.BadRequestError(err)
 	}
 
-	err = validator.ValidateRole(role)
+	roles, err := h.roleService.GetRoles(ctx)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	err = validator.ValidateRole(role, roles)
 	if err != nil {
 		return apperror.BadRequestError(err)
 	}
@@ -78,13 +83,6 @@ func (h *Handler) DeleteUserByID(w http.ResponseWriter, r *http.Request) error {
 		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
 	}
 
-	selfUserID := ctx.Value(config.ParamID).(string)
-
-	// пользователь может удалить только сам себя
-	if selfUserID != userID.String() {
-		return apperror.BadRequestError(fmt.Errorf("user [%s] does not have rights to delete user [%s]", selfUserID, userID))
-	}
-
 	err = h.userService.DeleteUserByID(ctx, userID.String())
 	if err != nil {
 		return apperror.InternalServerError(err)
@@ -101,13 +99,6 @@ func (h *Handler) UpdateUserByID(w http.ResponseWriter, r *http.Request) error {
 		return apperror.BadRequestError(errors.Wrap(err, "get uuid from path"))
 	}
 
-	selfUserID := ctx.Value(config.ParamID).(string)
-
-	// пользователь может редактировать только сам себя
-	if selfUserID != userID.String() {
-		return apperror.BadRequestError(fmt.Errorf("user [%s] does not have rights to update user [%s]", selfUserID, userID))
-	}
-
 	var userUpdate model.UserUpdate
 	defer func() {
 		errClose := r.Body.Close()
@@ -126,16 +117,51 @@ func (h *Handler) UpdateUserByID(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	user := mapper.MapToEntityUserUpdate(userUpdate)
-	user.ID = selfUserID
-	if userUpdate.Password != nil {
-		passwordHash := helpers.GeneratePasswordHash(*userUpdate.Password)
-		user.Password = &passwordHash
-	}
+	user.ID = userID.String()
 
 	result, err := h.userService.UpdateUserByID(ctx, user)
 	if err != nil {
 		return apperror.InternalServerError(err)
 	}
 
+	// новый email снова требует подтверждения
+	if user.Email != nil && !result.EmailVerified {
+		h.emailVerificationService.SendVerification(ctx, result)
+	}
+
 	return response.RespondSuccess(w, mapper.MapToUserResponse(http.StatusOK, result))
 }
+
+// ChangePassword - хэндлер смены пароля текущим пользователем по текущему паролю. Остальные сессии и персональные
+// токены пользователя отзываются, текущая сессия сохраняется
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	var request model.ChangePasswordRequest
+	defer func() {
+		errClose := r.Body.Close()
+		if errClose != nil {
+			logging.Error("error close request body")
+		}
+	}()
+
+	if errDecode := json.NewDecoder(r.Body).Decode(&request); errDecode != nil {
+		return apperror.BadRequestError(errors.Wrap(errDecode, "json decode"))
+	}
+
+	err := validator.ValidateChangePassword(request)
+	if err != nil {
+		return apperror.BadRequestError(errors.Wrap(err, "validate change password"))
+	}
+
+	selfUserID := ctx.Value(config.ParamID).(string)
+	claims := ctx.Value(config.ParamClaims).(entity.UserClaims)
+
+	err = h.signInGuardService.ChangePassword(ctx, selfUserID, claims.SessionID, request.CurrentPassword,
+		request.NewPassword)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondSuccess(w, response.ViewResponse{Code: http.StatusOK})
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChangePassword(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		guardErr       error
+		wantStatus     int
+		wantRetryAfter time.Duration
+	}{
+		{
+			name:       "changed",
+			body:       `{"currentPassword":"Correct-Horse-7","newPassword":"New-Password-8"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "empty current password",
+			body:       `{"newPassword":"New-Password-8"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "wrong current password",
+			body:       `{"currentPassword":"wrong","newPassword":"New-Password-8"}`,
+			guardErr:   errors.Wrap(apperror.ErrInvalidCurrentPassword, "userService.ChangePassword"),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:           "throttled",
+			body:           `{"currentPassword":"Correct-Horse-7","newPassword":"New-Password-8"}`,
+			guardErr:       apperror.NewSignInThrottledError(apperror.ErrTooManySignInAttempts, 3*time.Second),
+			wantStatus:     http.StatusTooManyRequests,
+			wantRetryAfter: 3 * time.Second,
+		},
+		{
+			name:       "new password violates policy",
+			body:       `{"currentPassword":"Correct-Horse-7","newPassword":"short"}`,
+			guardErr:   apperror.NewPasswordPolicyError([]string{"min_length"}),
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := &fakeSignInGuard{err: tt.guardErr}
+			h := &Handler{signInGuardService: guard}
+
+			ctx := context.WithValue(context.Background(), config.ParamID, "user-1")
+			ctx = context.WithValue(ctx, config.ParamClaims, entity.UserClaims{SessionID: "session-1"})
+			r := httptest.NewRequest(http.MethodPost, "/public/v1/me/password", strings.NewReader(tt.body)).
+				WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			err := h.ChangePassword(w, r)
+			if tt.wantStatus == http.StatusOK {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, w.Code)
+				// текущая сессия передается в сервис, чтобы пережить отзыв остальных
+				assert.Equal(t, "session-1", guard.sessionID)
+				return
+			}
+
+			var appErr *apperror.AppError
+			if !assert.ErrorAs(t, err, &appErr) {
+				return
+			}
+			assert.Equal(t, tt.wantStatus, appErr.StatusCode)
+			assert.Equal(t, tt.wantRetryAfter, appErr.RetryAfter)
+		})
+	}
+}
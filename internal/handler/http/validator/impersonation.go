@@ -0,0 +1,21 @@
+package validator
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateImpersonation - валидация запроса токена имперсонации, причина сохраняется в аудите
+func ValidateImpersonation(request model.ImpersonationRequest) error {
+	if strings.TrimSpace(request.Reason) == "" {
+		return apperror.ErrEmptyReason
+	}
+
+	if utf8.RuneCountInString(request.Reason) > maxReasonLength {
+		return apperror.ErrReasonTooLong
+	}
+
+	return nil
+}
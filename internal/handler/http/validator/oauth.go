@@ -0,0 +1,199 @@
+package validator
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+	"github.com/GermanBogatov/auth-service/pkg/pkce"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// maxNonceLength - максимальная длина nonce, значение хранится вместе с кодом авторизации
+const maxNonceLength = 255
+
+// ValidateOAuthClientCreate - валидация oauth-клиента при регистрации
+func ValidateOAuthClientCreate(client model.OAuthClientCreateRequest) error {
+	if strings.TrimSpace(client.Name) == "" {
+		return apperror.ErrEmptyName
+	}
+
+	err := ValidateRedirectURIs(client.RedirectURIs)
+	if err != nil {
+		return err
+	}
+
+	err = ValidateClientGrantTypes(client.GrantTypes)
+	if err != nil {
+		return err
+	}
+
+	err = ValidateScopes(client.Scopes)
+	if err != nil {
+		return err
+	}
+
+	return ValidateAudiences(client.Audiences)
+}
+
+// ValidateOAuthClientUpdate - валидация oauth-клиента при редактировании
+func ValidateOAuthClientUpdate(client model.OAuthClientUpdateRequest) error {
+	if client.Name == nil && client.RedirectURIs == nil && client.GrantTypes == nil && client.Scopes == nil &&
+		client.Audiences == nil {
+		return apperror.ErrAllFieldAreEmpty
+	}
+
+	if client.Name != nil && strings.TrimSpace(*client.Name) == "" {
+		return apperror.ErrEmptyName
+	}
+
+	if client.RedirectURIs != nil {
+		err := ValidateRedirectURIs(*client.RedirectURIs)
+		if err != nil {
+			return err
+		}
+	}
+
+	if client.GrantTypes != nil {
+		err := ValidateClientGrantTypes(*client.GrantTypes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if client.Scopes != nil {
+		err := ValidateScopes(*client.Scopes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if client.Audiences != nil {
+		return ValidateAudiences(*client.Audiences)
+	}
+
+	return nil
+}
+
+// ValidateRedirectURIs - валидация redirect_uri клиента (RFC 6749, раздел 3.1.2 и RFC 8252, раздел 7):
+// абсолютный uri без фрагмента, http допускается только для loopback-адресов
+func ValidateRedirectURIs(redirectURIs []string) error {
+	if len(redirectURIs) == 0 {
+		return apperror.ErrEmptyRedirectURIs
+	}
+
+	for _, redirectURI := range redirectURIs {
+		u, err := url.Parse(redirectURI)
+		if err != nil || !u.IsAbs() || u.Fragment != "" || strings.Contains(redirectURI, "#") {
+			return apperror.ErrInvalidRedirectURI
+		}
+
+		switch strings.ToLower(u.Scheme) {
+		case "https":
+			if u.Host == "" {
+				return apperror.ErrInvalidRedirectURI
+			}
+		case "http":
+			if !isLoopbackHost(u.Hostname()) {
+				return apperror.ErrInvalidRedirectURI
+			}
+		case "javascript", "data", "vbscript", "file":
+			return apperror.ErrInvalidRedirectURI
+		}
+	}
+
+	return nil
+}
+
+// isLoopbackHost - хост указывает на локальную машину
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ValidateClientGrantTypes - валидация грантов, доступных oauth-клиенту
+func ValidateClientGrantTypes(grantTypes []string) error {
+	if len(grantTypes) == 0 {
+		return apperror.ErrEmptyGrantTypes
+	}
+
+	for _, grantType := range grantTypes {
+		switch grantType {
+		case entity.GrantTypeAuthorizationCode, entity.GrantTypeRefreshToken:
+		default:
+			return apperror.ErrUnsupportedGrantType
+		}
+	}
+
+	return nil
+}
+
+// ValidateAuthorizeRequest - валидация запроса авторизации, PKCE с методом S256 обязателен для всех клиентов
+func ValidateAuthorizeRequest(request entity.AuthorizeRequest) error {
+	if request.ResponseType != entity.ResponseTypeCode {
+		return apperror.ErrUnsupportedResponseType
+	}
+
+	if request.CodeChallengeMethod != pkce.MethodS256 {
+		return apperror.ErrUnsupportedCodeChallengeMethod
+	}
+
+	if !pkce.ValidChallenge(request.CodeChallenge) {
+		return apperror.ErrInvalidCodeChallenge
+	}
+
+	if len(request.Nonce) > maxNonceLength {
+		return apperror.ErrNonceTooLong
+	}
+
+	return ValidateScopes(entity.ParseScope(request.Scope))
+}
+
+// ValidateCodeExchange - валидация параметров обмена кода авторизации на токены
+func ValidateCodeExchange(exchange entity.CodeExchange) error {
+	if exchange.Code == "" {
+		return apperror.ErrEmptyCode
+	}
+
+	if exchange.RedirectURI == "" {
+		return apperror.ErrRedirectURIMismatch
+	}
+
+	if !pkce.ValidVerifier(exchange.CodeVerifier) {
+		return apperror.ErrInvalidCodeVerifier
+	}
+
+	return nil
+}
+
+// ValidateTokenExchange - валидация запроса обмена токена: обменивается только access-токен на access-токен,
+// аудитория должна быть среди разрешенных в конфиге
+func ValidateTokenExchange(exchange entity.TokenExchange, audiences []string) error {
+	if exchange.SubjectToken == "" {
+		return apperror.ErrEmptySubjectToken
+	}
+
+	if exchange.SubjectTokenType != entity.TokenTypeAccessToken {
+		return apperror.ErrUnsupportedTokenType
+	}
+
+	if exchange.RequestedTokenType != "" && exchange.RequestedTokenType != entity.TokenTypeAccessToken {
+		return apperror.ErrUnsupportedTokenType
+	}
+
+	if exchange.Audience == "" {
+		return apperror.ErrEmptyAudience
+	}
+
+	if !slices.Contains(audiences, exchange.Audience) {
+		return apperror.ErrInvalidTarget
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package validator
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidateTokenExchange(t *testing.T) {
+	audiences := []string{"orders", "billing"}
+	valid := entity.TokenExchange{
+		SubjectToken:     "subject-token",
+		SubjectTokenType: entity.TokenTypeAccessToken,
+		Audience:         "orders",
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(exchange *entity.TokenExchange)
+		wantErr error
+	}{
+		{
+			name:   "valid",
+			modify: func(exchange *entity.TokenExchange) {},
+		},
+		{
+			name: "access token requested",
+			modify: func(exchange *entity.TokenExchange) {
+				exchange.RequestedTokenType = entity.TokenTypeAccessToken
+			},
+		},
+		{
+			name: "empty subject token",
+			modify: func(exchange *entity.TokenExchange) {
+				exchange.SubjectToken = ""
+			},
+			wantErr: apperror.ErrEmptySubjectToken,
+		},
+		{
+			name: "refresh token as subject",
+			modify: func(exchange *entity.TokenExchange) {
+				exchange.SubjectTokenType = "urn:ietf:params:oauth:token-type:refresh_token"
+			},
+			wantErr: apperror.ErrUnsupportedTokenType,
+		},
+		{
+			name: "id token as subject",
+			modify: func(exchange *entity.TokenExchange) {
+				exchange.SubjectTokenType = "urn:ietf:params:oauth:token-type:id_token"
+			},
+			wantErr: apperror.ErrUnsupportedTokenType,
+		},
+		{
+			name: "empty subject token type",
+			modify: func(exchange *entity.TokenExchange) {
+				exchange.SubjectTokenType = ""
+			},
+			wantErr: apperror.ErrUnsupportedTokenType,
+		},
+		{
+			name: "refresh token requested",
+			modify: func(exchange *entity.TokenExchange) {
+				exchange.RequestedTokenType = "urn:ietf:params:oauth:token-type:refresh_token"
+			},
+			wantErr: apperror.ErrUnsupportedTokenType,
+		},
+		{
+			name: "empty audience",
+			modify: func(exchange *entity.TokenExchange) {
+				exchange.Audience = ""
+			},
+			wantErr: apperror.ErrEmptyAudience,
+		},
+		{
+			name: "audience not in allow-list",
+			modify: func(exchange *entity.TokenExchange) {
+				exchange.Audience = "payments"
+			},
+			wantErr: apperror.ErrInvalidTarget,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exchange := valid
+			tt.modify(&exchange)
+
+			err := ValidateTokenExchange(exchange, audiences)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
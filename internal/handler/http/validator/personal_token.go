@@ -0,0 +1,57 @@
+package validator
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+	"slices"
+	"strings"
+)
+
+// maxPersonalTokenDays - максимальный срок действия персонального токена
+const maxPersonalTokenDays = 366
+
+// ValidatePersonalTokenCreate - валидация персонального токена при создании
+func ValidatePersonalTokenCreate(token model.PersonalTokenCreateRequest) error {
+	if strings.TrimSpace(token.Name) == "" {
+		return apperror.ErrEmptyName
+	}
+
+	if token.ExpiresInDays != nil && (*token.ExpiresInDays <= 0 || *token.ExpiresInDays > maxPersonalTokenDays) {
+		return apperror.ErrInvalidExpiresInDays
+	}
+
+	return ValidatePersonalTokenScopes(token.Scopes)
+}
+
+// ValidatePersonalTokenUpdate - валидация персонального токена при редактировании
+func ValidatePersonalTokenUpdate(token model.PersonalTokenUpdateRequest) error {
+	if token.Name == nil && token.Scopes == nil {
+		return apperror.ErrAllFieldAreEmpty
+	}
+
+	if token.Name != nil && strings.TrimSpace(*token.Name) == "" {
+		return apperror.ErrEmptyName
+	}
+
+	if token.Scopes != nil {
+		return ValidatePersonalTokenScopes(*token.Scopes)
+	}
+
+	return nil
+}
+
+// ValidatePersonalTokenScopes - валидация скоупов персонального токена: токен без скоупов бесполезен
+func ValidatePersonalTokenScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return apperror.ErrEmptyScopes
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(entity.PersonalTokenScopes, scope) {
+			return apperror.ErrInvalidScope
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package validator
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+	"regexp"
+	"slices"
+	"unicode/utf8"
+)
+
+// maxRoleDescriptionLength - ограничение длины описания роли
+const maxRoleDescriptionLength = 255
+
+// maxRoleLevel - наибольший уровень роли, уровни выше заняты встроенной ролью super-admin
+const maxRoleLevel = 99
+
+// roleNameRegexp - название роли хранится в токенах и в бд, поэтому ограничено простыми символами
+var roleNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9-]{0,63}$`)
+
+// ValidateRoleCreate - валидация роли при создании
+func ValidateRoleCreate(role model.RoleCreateRequest) error {
+	if !roleNameRegexp.MatchString(role.Name) {
+		return apperror.ErrInvalidRoleName
+	}
+
+	if utf8.RuneCountInString(role.Description) > maxRoleDescriptionLength {
+		return apperror.ErrInvalidRoleDescription
+	}
+
+	if role.Level < 0 || role.Level > maxRoleLevel {
+		return apperror.ErrInvalidRoleLevel
+	}
+
+	return ValidatePermissions(role.Permissions)
+}
+
+// ValidateRoleUpdate - валидация роли при редактировании
+func ValidateRoleUpdate(role model.RoleUpdateRequest) error {
+	if role.Description == nil && role.Permissions == nil && role.Level == nil {
+		return apperror.ErrAllFieldAreEmpty
+	}
+
+	if role.Level != nil && (*role.Level < 0 || *role.Level > maxRoleLevel) {
+		return apperror.ErrInvalidRoleLevel
+	}
+
+	if role.Description != nil && utf8.RuneCountInString(*role.Description) > maxRoleDescriptionLength {
+		return apperror.ErrInvalidRoleDescription
+	}
+
+	if role.Permissions != nil {
+		return ValidatePermissions(*role.Permissions)
+	}
+
+	return nil
+}
+
+// ValidatePermissions - валидация прав роли, выдавать можно только права, известные сервису
+func ValidatePermissions(permissions []string) error {
+	for _, permission := range permissions {
+		if !slices.Contains(entity.AllPermissions, entity.Permission(permission)) {
+			return apperror.ErrInvalidPermission
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package validator
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+	"github.com/google/uuid"
+	"strings"
+)
+
+// maxAudienceLength - максимальная длина аудитории клиента
+const maxAudienceLength = 255
+
+// ValidateServiceAccountCreate - валидация сервисного аккаунта при создании
+func ValidateServiceAccountCreate(account model.ServiceAccountCreateRequest) error {
+	if strings.TrimSpace(account.Name) == "" {
+		return apperror.ErrEmptyName
+	}
+
+	if account.OwnerID != nil {
+		_, err := uuid.Parse(*account.OwnerID)
+		if err != nil {
+			return apperror.ErrInvalidOwnerID
+		}
+	}
+
+	err := ValidateScopes(account.Scopes)
+	if err != nil {
+		return err
+	}
+
+	return ValidateAudiences(account.Audiences)
+}
+
+// ValidateServiceAccountUpdate - валидация сервисного аккаунта при редактировании
+func ValidateServiceAccountUpdate(account model.ServiceAccountUpdateRequest) error {
+	if account.Name == nil && account.Scopes == nil && account.Audiences == nil {
+		return apperror.ErrAllFieldAreEmpty
+	}
+
+	if account.Name != nil && strings.TrimSpace(*account.Name) == "" {
+		return apperror.ErrEmptyName
+	}
+
+	if account.Scopes != nil {
+		err := ValidateScopes(*account.Scopes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if account.Audiences != nil {
+		return ValidateAudiences(*account.Audiences)
+	}
+
+	return nil
+}
+
+// ValidateScopes - валидация скоупов по грамматике scope-token (RFC 6749, раздел 3.3)
+func ValidateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if scope == "" {
+			return apperror.ErrEmptyScope
+		}
+
+		for _, c := range scope {
+			if c < 0x21 || c > 0x7E || c == '"' || c == '\\' {
+				return apperror.ErrInvalidScope
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateAudiences - валидация дополнительных аудиторий клиента: непустые строки без пробелов и управляющих символов
+func ValidateAudiences(audiences []string) error {
+	for _, audience := range audiences {
+		if audience == "" || len(audience) > maxAudienceLength {
+			return apperror.ErrInvalidClientAudience
+		}
+
+		for _, c := range audience {
+			if c < 0x21 || c > 0x7E {
+				return apperror.ErrInvalidClientAudience
+			}
+		}
+	}
+
+	return nil
+}
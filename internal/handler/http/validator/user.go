@@ -6,8 +6,15 @@ import (
 	"github.com/GermanBogatov/auth-service/internal/entity"
 	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
 	"strings"
+	"unicode/utf8"
 )
 
+// maxDeviceNameLength - максимальная длина названия устройства сессии
+const maxDeviceNameLength = 100
+
+// maxReasonLength - максимальная длина причины, сохраняемой в аудите
+const maxReasonLength = 500
+
 // ValidateSignUpUser - валидация пользователя при регистрации
 func ValidateSignUpUser(user model.SignUpRequest) error {
 	if strings.TrimSpace(user.Name) == "" {
@@ -27,6 +34,15 @@ func ValidateSignUpUser(user model.SignUpRequest) error {
 		return apperror.ErrInvalidEmailFormat
 	}
 
+	return ValidateDeviceName(user.DeviceName)
+}
+
+// ValidateDeviceName - валидация названия устройства сессии
+func ValidateDeviceName(deviceName string) error {
+	if utf8.RuneCountInString(deviceName) > maxDeviceNameLength {
+		return apperror.ErrDeviceNameTooLong
+	}
+
 	return nil
 }
 
@@ -43,10 +59,6 @@ func ValidateUserUpdate(user model.UserUpdate) error {
 		return apperror.ErrEmptySurname
 	}
 
-	if user.Password != nil && strings.TrimSpace(*user.Password) == "" {
-		return apperror.ErrEmptyPassword
-	}
-
 	if user.Email != nil {
 		if strings.TrimSpace(*user.Email) == "" {
 			return apperror.ErrEmptyEmail
@@ -60,17 +72,25 @@ func ValidateUserUpdate(user model.UserUpdate) error {
 	return nil
 }
 
-// ValidateUserUpdatePrivate - валидация пользователя при приватном редактировании
-func ValidateUserUpdatePrivate(user model.UserUpdatePrivate) error {
+// ValidateUserUpdatePrivate - валидация пользователя при приватном редактировании, роль должна быть среди существующих,
+// смена роли сопровождается причиной для аудита
+func ValidateUserUpdatePrivate(user model.UserUpdatePrivate, roles []entity.Role) error {
 	if user.Name == nil && user.Surname == nil && user.Email == nil && user.Role == nil {
 		return apperror.ErrAllFieldAreEmpty
 	}
 
 	if user.Role != nil {
-		role := *user.Role
-		if entity.RoleType(role) != entity.RoleAdmin && entity.RoleType(role) != entity.RoleUser {
+		if !roleExists(*user.Role, roles) {
 			return apperror.ErrInvalidRoleType
 		}
+
+		if user.Reason == nil || strings.TrimSpace(*user.Reason) == "" {
+			return apperror.ErrEmptyRoleChangeReason
+		}
+
+		if utf8.RuneCountInString(*user.Reason) > maxReasonLength {
+			return apperror.ErrReasonTooLong
+		}
 	}
 
 	if user.Name != nil && strings.TrimSpace(*user.Name) == "" {
@@ -106,6 +126,63 @@ func ValidateSignInUser(user model.SignInRequest) error {
 		return apperror.ErrEmptyPassword
 	}
 
+	return ValidateDeviceName(user.DeviceName)
+}
+
+// ValidateForgotPassword - валидация запроса письма для сброса пароля
+func ValidateForgotPassword(request model.ForgotPasswordRequest) error {
+	if strings.TrimSpace(request.Email) == "" {
+		return apperror.ErrEmptyEmail
+	}
+	if !strings.Contains(request.Email, "@") {
+		return apperror.ErrInvalidEmailFormat
+	}
+
+	return nil
+}
+
+// ValidateResetPassword - валидация смены пароля по токену
+func ValidateResetPassword(request model.ResetPasswordRequest) error {
+	if strings.TrimSpace(request.Token) == "" {
+		return apperror.ErrEmptyToken
+	}
+	if strings.TrimSpace(request.Password) == "" {
+		return apperror.ErrEmptyPassword
+	}
+
+	return nil
+}
+
+// ValidateChangePassword - валидация смены пароля текущим пользователем
+func ValidateChangePassword(request model.ChangePasswordRequest) error {
+	if request.CurrentPassword == "" {
+		return apperror.ErrEmptyCurrentPassword
+	}
+	if strings.TrimSpace(request.NewPassword) == "" {
+		return apperror.ErrEmptyNewPassword
+	}
+
+	return nil
+}
+
+// ValidateVerifyEmail - валидация подтверждения email
+func ValidateVerifyEmail(request model.VerifyEmailRequest) error {
+	if strings.TrimSpace(request.Token) == "" {
+		return apperror.ErrEmptyToken
+	}
+
+	return nil
+}
+
+// ValidateResendEmailVerification - валидация повторной отправки письма подтверждения email
+func ValidateResendEmailVerification(request model.ResendEmailVerificationRequest) error {
+	if strings.TrimSpace(request.Email) == "" {
+		return apperror.ErrEmptyEmail
+	}
+	if !strings.Contains(request.Email, "@") {
+		return apperror.ErrInvalidEmailFormat
+	}
+
 	return nil
 }
 
@@ -129,16 +206,52 @@ func ValidateOrder(order string) error {
 	}
 }
 
-// ValidateRole - валидация роли
-func ValidateRole(role *string) error {
+// ValidateRole - валидация роли по списку существующих ролей
+func ValidateRole(role *string, roles []entity.Role) error {
 	if role == nil {
 		return nil
 	}
 
-	switch *role {
-	case string(entity.RoleUser), string(entity.RoleAdmin):
+	if !roleExists(*role, roles) {
+		return apperror.ErrInvalidParamRole
+	}
+
+	return nil
+}
+
+// roleExists - есть ли роль среди существующих
+func roleExists(name string, roles []entity.Role) bool {
+	for _, role := range roles {
+		if role.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTokenRequest - валидация запроса с токеном
+func ValidateTokenRequest(tokenRequest model.TokenRequest) error {
+	if strings.TrimSpace(tokenRequest.Token) == "" {
+		return apperror.ErrEmptyToken
+	}
+
+	switch tokenRequest.TokenTypeHint {
+	case "", entity.TokenTypeHintAccessToken, entity.TokenTypeHintRefreshToken:
+		return nil
+	default:
+		return apperror.ErrUnsupportedTokenType
+	}
+}
+
+// ValidateGrantType - валидация типа гранта токен-эндпоинта
+func ValidateGrantType(grantType string) error {
+	switch grantType {
+	case "":
+		return apperror.ErrEmptyGrantType
+	case entity.GrantTypeClientCredentials, entity.GrantTypeAuthorizationCode, entity.GrantTypeRefreshToken,
+		entity.GrantTypeTokenExchange:
 		return nil
 	default:
-		return apperror.ErrInvalidParamRole
+		return apperror.ErrUnsupportedGrantType
 	}
 }
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/response"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/handler/http/model"
+	"github.com/GermanBogatov/auth-service/pkg/pkce"
+	"net/http"
+)
+
+// JWKS - хэндлер публикации публичных ключей для проверки jwt-токенов
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+
+	set, err := h.jwtService.GetJWKS(ctx)
+	if err != nil {
+		return apperror.InternalServerError(err)
+	}
+
+	return response.RespondJSON(w, http.StatusOK, set)
+}
+
+// OpenIDConfiguration - хэндлер публикации метаданных OpenID-провайдера, адреса эндпоинтов строятся от issuer
+func (h *Handler) OpenIDConfiguration(w http.ResponseWriter, _ *http.Request) error {
+	issuer := h.cfg.OIDC.Issuer
+
+	return response.RespondJSON(w, http.StatusOK, model.OpenIDConfigurationResponse{
+		Issuer:                 issuer,
+		AuthorizationEndpoint:  issuer + oauth + "/authorize",
+		TokenEndpoint:          issuer + oauth + "/token",
+		UserInfoEndpoint:       issuer + oauth + "/userinfo",
+		JWKSURI:                issuer + wellKnown + "/jwks.json",
+		IntrospectionEndpoint:  issuer + integrationV1 + "/introspect",
+		ScopesSupported:        []string{entity.ScopeOpenID, entity.ScopeProfile, entity.ScopeEmail},
+		ResponseTypesSupported: []string{entity.ResponseTypeCode},
+		GrantTypesSupported: []string{
+			entity.GrantTypeAuthorizationCode,
+			entity.GrantTypeRefreshToken,
+			entity.GrantTypeClientCredentials,
+			entity.GrantTypeTokenExchange,
+		},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{h.cfg.Jwt.Algorithm},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{pkce.MethodS256},
+		ClaimsSupported: []string{
+			"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce",
+			"email", "name", "given_name", "family_name",
+		},
+	})
+}
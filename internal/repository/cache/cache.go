@@ -18,7 +18,34 @@ type ICache interface {
 	GetUser(ctx context.Context, key string) (entity.User, error)
 	Delete(ctx context.Context, key string) error
 	SetUser(ctx context.Context, key string, user entity.User) error
-	SetRefreshToken(ctx context.Context, key, userID string) error
+	SetRefreshToken(ctx context.Context, key string, token entity.RefreshToken) error
+	RotateRefreshToken(ctx context.Context, key string, rotated entity.RotatedRefreshToken) (entity.RefreshToken, error)
+	GetRotatedRefreshToken(ctx context.Context, key string) (entity.RotatedRefreshToken, error)
+	DeleteRefreshFamily(ctx context.Context, familyID string) error
+	GetRefreshToken(ctx context.Context, key string) (entity.RefreshToken, error)
+	DeleteUserRefreshFamilies(ctx context.Context, userID string) error
+	SetRevokedAccessToken(ctx context.Context, jti string, ttl time.Duration) error
+	SetUserTokensRevokedBefore(ctx context.Context, userID string, revokedBefore time.Time, exceptSessionID string, ttl time.Duration) error
+	GetAccessTokenRevocation(ctx context.Context, jti, userID, familyID string) (entity.TokenRevocation, error)
+	SetSession(ctx context.Context, session entity.Session) error
+	UpdateSession(ctx context.Context, session entity.Session) (bool, error)
+	GetSession(ctx context.Context, sessionID string) (entity.Session, error)
+	GetUserSessions(ctx context.Context, userID string) ([]entity.Session, error)
+	SetAuthorizationCode(ctx context.Context, code string, authCode entity.AuthorizationCode, ttl time.Duration) error
+	ConsumeAuthorizationCode(ctx context.Context, code string) (entity.AuthorizationCode, error)
+	SetFederatedLoginState(ctx context.Context, state string, loginState entity.FederatedLoginState, ttl time.Duration) error
+	ConsumeFederatedLoginState(ctx context.Context, state string) (entity.FederatedLoginState, error)
+	SetPasswordReset(ctx context.Context, tokenHash string, reset entity.PasswordReset, ttl time.Duration) error
+	GetPasswordReset(ctx context.Context, tokenHash string) (entity.PasswordReset, error)
+	ConsumePasswordReset(ctx context.Context, tokenHash string) (entity.PasswordReset, error)
+	SetPasswordResetSent(ctx context.Context, userID string, interval time.Duration) (bool, error)
+	SetEmailVerification(ctx context.Context, tokenHash string, verification entity.EmailVerification, ttl time.Duration) error
+	ConsumeEmailVerification(ctx context.Context, tokenHash string) (entity.EmailVerification, error)
+	SetEmailVerificationSent(ctx context.Context, userID string, interval time.Duration) (bool, error)
+	IncrSignInFailures(ctx context.Context, subject string, window time.Duration) (int64, error)
+	SetSignInLock(ctx context.Context, lock entity.SignInLock) error
+	GetSignInLocks(ctx context.Context, subjects ...string) ([]entity.SignInLock, error)
+	DeleteSignInFailures(ctx context.Context, subject string) error
 }
 
 var _ ICache = &Cache{}
@@ -118,18 +145,3 @@ func (c *Cache) SetUser(ctx context.Context, key string, user entity.User) error
 	metrics.IncRequestTotalDB(metrics.SetUserCache, metrics.OkStatus)
 	return nil
 }
-
-// SetRefreshToken - добавление рефреш токена в кэш.
-func (c *Cache) SetRefreshToken(ctx context.Context, key, userID string) error {
-	_, span := tracer.StartTrace(ctx, config.SpanCacheSetRefreshToken)
-	defer span.End()
-	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.SetRefreshTokenCache)()
-	_, err := c.client.Set(ctx, key, userID, c.refreshTTL).Result()
-	if err != nil {
-		metrics.IncRequestTotalDB(metrics.SetRefreshTokenCache, metrics.FailStatus)
-		return err
-	}
-
-	metrics.IncRequestTotalDB(metrics.SetRefreshTokenCache, metrics.OkStatus)
-	return nil
-}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	prefixEmailVerification     = "email-verification:"
+	prefixEmailVerificationSent = "email-verification-sent:"
+)
+
+// SetEmailVerification - сохранение токена подтверждения email по его хэшу на время ttl
+func (c *Cache) SetEmailVerification(ctx context.Context, tokenHash string, verification entity.EmailVerification,
+	ttl time.Duration) error {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheSetEmailVerification)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.SetEmailVerificationCache)()
+
+	data, errJson := json.Marshal(verification)
+	if errJson != nil {
+		return errJson
+	}
+
+	err := c.client.Set(ctx, prefixEmailVerification+tokenHash, string(data), ttl).Err()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.SetEmailVerificationCache, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.SetEmailVerificationCache, metrics.OkStatus)
+	return nil
+}
+
+// ConsumeEmailVerification - атомарное получение и удаление токена подтверждения email, токен одноразовый
+func (c *Cache) ConsumeEmailVerification(ctx context.Context, tokenHash string) (entity.EmailVerification, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheConsumeEmailVerification)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.ConsumeEmailVerificationCache)()
+
+	val, err := c.client.GetDel(ctx, prefixEmailVerification+tokenHash).Result()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.ConsumeEmailVerificationCache, metrics.FailStatus)
+		if errors.Is(err, redis.Nil) {
+			return entity.EmailVerification{}, apperror.ErrRedisNil
+		}
+		return entity.EmailVerification{}, err
+	}
+
+	var verification entity.EmailVerification
+	err = json.Unmarshal([]byte(val), &verification)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.ConsumeEmailVerificationCache, metrics.FailStatus)
+		return entity.EmailVerification{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.ConsumeEmailVerificationCache, metrics.OkStatus)
+	return verification, nil
+}
+
+// SetEmailVerificationSent - отметка об отправке письма подтверждения пользователю на время interval.
+// Возвращает false, если письмо уже отправлялось в течение интервала
+func (c *Cache) SetEmailVerificationSent(ctx context.Context, userID string, interval time.Duration) (bool, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheSetEmailVerificationSent)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.SetEmailVerificationSentCache)()
+
+	ok, err := c.client.SetNX(ctx, prefixEmailVerificationSent+userID, time.Now().UTC().Format(time.RFC3339), interval).Result()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.SetEmailVerificationSentCache, metrics.FailStatus)
+		return false, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.SetEmailVerificationSentCache, metrics.OkStatus)
+	return ok, nil
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const prefixFederatedLoginState = "federation-state:"
+
+// SetFederatedLoginState - сохранение состояния входа через внешний провайдер на время ttl
+func (c *Cache) SetFederatedLoginState(ctx context.Context, state string, loginState entity.FederatedLoginState, ttl time.Duration) error {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheSetFederatedLoginState)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.SetFederatedLoginStateCache)()
+
+	data, errJson := json.Marshal(loginState)
+	if errJson != nil {
+		return errJson
+	}
+
+	err := c.client.Set(ctx, prefixFederatedLoginState+state, string(data), ttl).Err()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.SetFederatedLoginStateCache, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.SetFederatedLoginStateCache, metrics.OkStatus)
+	return nil
+}
+
+// ConsumeFederatedLoginState - атомарное получение и удаление состояния входа, повторный callback с тем же state не пройдет
+func (c *Cache) ConsumeFederatedLoginState(ctx context.Context, state string) (entity.FederatedLoginState, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheConsumeFederatedLoginState)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.ConsumeFederatedLoginStateCache)()
+
+	val, err := c.client.GetDel(ctx, prefixFederatedLoginState+state).Result()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.ConsumeFederatedLoginStateCache, metrics.FailStatus)
+		if errors.Is(err, redis.Nil) {
+			return entity.FederatedLoginState{}, apperror.ErrRedisNil
+		}
+		return entity.FederatedLoginState{}, err
+	}
+
+	var loginState entity.FederatedLoginState
+	err = json.Unmarshal([]byte(val), &loginState)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.ConsumeFederatedLoginStateCache, metrics.FailStatus)
+		return entity.FederatedLoginState{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.ConsumeFederatedLoginStateCache, metrics.OkStatus)
+	return loginState, nil
+}
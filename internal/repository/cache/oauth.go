@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const prefixAuthorizationCode = "oauth-code:"
+
+// SetAuthorizationCode - сохранение кода авторизации на время ttl
+func (c *Cache) SetAuthorizationCode(ctx context.Context, code string, authCode entity.AuthorizationCode, ttl time.Duration) error {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheSetAuthorizationCode)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.SetAuthorizationCodeCache)()
+
+	data, errJson := json.Marshal(authCode)
+	if errJson != nil {
+		return errJson
+	}
+
+	err := c.client.Set(ctx, prefixAuthorizationCode+code, string(data), ttl).Err()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.SetAuthorizationCodeCache, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.SetAuthorizationCodeCache, metrics.OkStatus)
+	return nil
+}
+
+// ConsumeAuthorizationCode - атомарное получение и удаление кода авторизации, код можно обменять только один раз
+func (c *Cache) ConsumeAuthorizationCode(ctx context.Context, code string) (entity.AuthorizationCode, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheConsumeAuthorizationCode)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.ConsumeAuthorizationCodeCache)()
+
+	val, err := c.client.GetDel(ctx, prefixAuthorizationCode+code).Result()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.ConsumeAuthorizationCodeCache, metrics.FailStatus)
+		if errors.Is(err, redis.Nil) {
+			return entity.AuthorizationCode{}, apperror.ErrRedisNil
+		}
+		return entity.AuthorizationCode{}, err
+	}
+
+	var authCode entity.AuthorizationCode
+	err = json.Unmarshal([]byte(val), &authCode)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.ConsumeAuthorizationCodeCache, metrics.FailStatus)
+		return entity.AuthorizationCode{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.ConsumeAuthorizationCodeCache, metrics.OkStatus)
+	return authCode, nil
+}
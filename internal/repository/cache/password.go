@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	prefixPasswordReset     = "password-reset:"
+	prefixPasswordResetSent = "password-reset-sent:"
+)
+
+// SetPasswordReset - сохранение токена сброса пароля по его хэшу на время ttl
+func (c *Cache) SetPasswordReset(ctx context.Context, tokenHash string, reset entity.PasswordReset, ttl time.Duration) error {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheSetPasswordReset)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.SetPasswordResetCache)()
+
+	data, errJson := json.Marshal(reset)
+	if errJson != nil {
+		return errJson
+	}
+
+	err := c.client.Set(ctx, prefixPasswordReset+tokenHash, string(data), ttl).Err()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.SetPasswordResetCache, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.SetPasswordResetCache, metrics.OkStatus)
+	return nil
+}
+
+// GetPasswordReset - получение токена сброса пароля без погашения, чтобы проверить новый пароль до использования токена
+func (c *Cache) GetPasswordReset(ctx context.Context, tokenHash string) (entity.PasswordReset, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheGetPasswordReset)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.GetPasswordResetCache)()
+
+	val, err := c.client.Get(ctx, prefixPasswordReset+tokenHash).Result()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetPasswordResetCache, metrics.FailStatus)
+		if errors.Is(err, redis.Nil) {
+			return entity.PasswordReset{}, apperror.ErrRedisNil
+		}
+		return entity.PasswordReset{}, err
+	}
+
+	var reset entity.PasswordReset
+	err = json.Unmarshal([]byte(val), &reset)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetPasswordResetCache, metrics.FailStatus)
+		return entity.PasswordReset{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetPasswordResetCache, metrics.OkStatus)
+	return reset, nil
+}
+
+// ConsumePasswordReset - атомарное получение и удаление токена сброса пароля, токен можно использовать только один раз
+func (c *Cache) ConsumePasswordReset(ctx context.Context, tokenHash string) (entity.PasswordReset, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheConsumePasswordReset)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.ConsumePasswordResetCache)()
+
+	val, err := c.client.GetDel(ctx, prefixPasswordReset+tokenHash).Result()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.ConsumePasswordResetCache, metrics.FailStatus)
+		if errors.Is(err, redis.Nil) {
+			return entity.PasswordReset{}, apperror.ErrRedisNil
+		}
+		return entity.PasswordReset{}, err
+	}
+
+	var reset entity.PasswordReset
+	err = json.Unmarshal([]byte(val), &reset)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.ConsumePasswordResetCache, metrics.FailStatus)
+		return entity.PasswordReset{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.ConsumePasswordResetCache, metrics.OkStatus)
+	return reset, nil
+}
+
+// SetPasswordResetSent - отметка об отправке письма сброса пароля пользователю на время interval.
+// Возвращает false, если письмо уже отправлялось в течение интервала
+func (c *Cache) SetPasswordResetSent(ctx context.Context, userID string, interval time.Duration) (bool, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheSetPasswordResetSent)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.SetPasswordResetSentCache)()
+
+	ok, err := c.client.SetNX(ctx, prefixPasswordResetSent+userID, time.Now().UTC().Format(time.RFC3339), interval).Result()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.SetPasswordResetSentCache, metrics.FailStatus)
+		return false, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.SetPasswordResetSentCache, metrics.OkStatus)
+	return ok, nil
+}
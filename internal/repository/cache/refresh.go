@@ -0,0 +1,194 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	prefixRefreshToken   = "refresh:"
+	prefixRefreshRotated = "refresh-rotated:"
+	prefixRefreshFamily  = "refresh-family:"
+
+	prefixUserRefreshFamilies = "user-refresh-families:"
+
+	fieldRotatedToken  = "token"
+	fieldRotatedRecord = "rotated"
+)
+
+// rotateRefreshTokenScript - атомарно забирает рефреш-токен и оставляет вместо него запись о ротации,
+// чтобы повторное предъявление токена можно было отличить от несуществующего
+var rotateRefreshTokenScript = redis.NewScript(`
+local token = redis.call('GET', KEYS[1])
+if not token then
+	return false
+end
+redis.call('DEL', KEYS[1])
+redis.call('HSET', KEYS[2], 'token', token, 'rotated', ARGV[1])
+redis.call('PEXPIRE', KEYS[2], ARGV[2])
+return token
+`)
+
+// SetRefreshToken - добавление рефреш токена в кэш.
+func (c *Cache) SetRefreshToken(ctx context.Context, key string, token entity.RefreshToken) error {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheSetRefreshToken)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.SetRefreshTokenCache)()
+
+	data, errJson := json.Marshal(token)
+	if errJson != nil {
+		return errJson
+	}
+
+	_, err := c.client.Set(ctx, prefixRefreshToken+key, string(data), c.refreshTTL).Result()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.SetRefreshTokenCache, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.SetRefreshTokenCache, metrics.OkStatus)
+	return nil
+}
+
+// RotateRefreshToken - атомарное получение и удаление рефреш-токена с сохранением записи о его ротации
+func (c *Cache) RotateRefreshToken(ctx context.Context, key string, rotated entity.RotatedRefreshToken) (entity.RefreshToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheRotateRefreshToken)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.RotateRefreshTokenCache)()
+
+	record, errJson := json.Marshal(rotated)
+	if errJson != nil {
+		return entity.RefreshToken{}, errJson
+	}
+
+	val, err := rotateRefreshTokenScript.Run(ctx, c.client, []string{prefixRefreshToken + key, prefixRefreshRotated + key},
+		string(record), c.refreshTTL.Milliseconds()).Text()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.RotateRefreshTokenCache, metrics.FailStatus)
+		if errors.Is(err, redis.Nil) {
+			return entity.RefreshToken{}, apperror.ErrRedisNil
+		}
+		return entity.RefreshToken{}, err
+	}
+
+	var token entity.RefreshToken
+	err = json.Unmarshal([]byte(val), &token)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.RotateRefreshTokenCache, metrics.FailStatus)
+		return entity.RefreshToken{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.RotateRefreshTokenCache, metrics.OkStatus)
+	return token, nil
+}
+
+// GetRotatedRefreshToken - получение записи о ротации рефреш-токена
+func (c *Cache) GetRotatedRefreshToken(ctx context.Context, key string) (entity.RotatedRefreshToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheGetRotatedRefreshToken)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.GetRotatedRefreshTokenCache)()
+
+	values, err := c.client.HGetAll(ctx, prefixRefreshRotated+key).Result()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetRotatedRefreshTokenCache, metrics.FailStatus)
+		return entity.RotatedRefreshToken{}, err
+	}
+
+	if len(values) == 0 {
+		metrics.IncRequestTotalDB(metrics.GetRotatedRefreshTokenCache, metrics.OkStatus)
+		return entity.RotatedRefreshToken{}, apperror.ErrRedisNil
+	}
+
+	var rotated entity.RotatedRefreshToken
+	err = json.Unmarshal([]byte(values[fieldRotatedRecord]), &rotated)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetRotatedRefreshTokenCache, metrics.FailStatus)
+		return entity.RotatedRefreshToken{}, err
+	}
+
+	err = json.Unmarshal([]byte(values[fieldRotatedToken]), &rotated.RefreshToken)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetRotatedRefreshTokenCache, metrics.FailStatus)
+		return entity.RotatedRefreshToken{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetRotatedRefreshTokenCache, metrics.OkStatus)
+	return rotated, nil
+}
+
+// DeleteRefreshFamily - отзыв семейства рефреш-токенов
+func (c *Cache) DeleteRefreshFamily(ctx context.Context, familyID string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheDeleteRefreshFamily)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.DeleteRefreshFamilyCache)()
+
+	err := c.client.Del(ctx, prefixRefreshFamily+familyID).Err()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.DeleteRefreshFamilyCache, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.DeleteRefreshFamilyCache, metrics.OkStatus)
+	return nil
+}
+
+// GetRefreshToken - получение рефреш-токена без его погашения
+func (c *Cache) GetRefreshToken(ctx context.Context, key string) (entity.RefreshToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheGetRefreshToken)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.GetRefreshTokenCache)()
+
+	val, err := c.client.Get(ctx, prefixRefreshToken+key).Result()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetRefreshTokenCache, metrics.FailStatus)
+		if errors.Is(err, redis.Nil) {
+			return entity.RefreshToken{}, apperror.ErrRedisNil
+		}
+		return entity.RefreshToken{}, err
+	}
+
+	var token entity.RefreshToken
+	err = json.Unmarshal([]byte(val), &token)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetRefreshTokenCache, metrics.FailStatus)
+		return entity.RefreshToken{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetRefreshTokenCache, metrics.OkStatus)
+	return token, nil
+}
+
+// DeleteUserRefreshFamilies - отзыв всех семейств рефреш-токенов пользователя
+func (c *Cache) DeleteUserRefreshFamilies(ctx context.Context, userID string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheDeleteUserRefreshFamilies)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.DeleteUserRefreshFamiliesCache)()
+
+	families, err := c.client.SMembers(ctx, prefixUserRefreshFamilies+userID).Result()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.DeleteUserRefreshFamiliesCache, metrics.FailStatus)
+		return err
+	}
+
+	keys := make([]string, 0, len(families)+1)
+	for _, familyID := range families {
+		keys = append(keys, prefixRefreshFamily+familyID)
+	}
+	keys = append(keys, prefixUserRefreshFamilies+userID)
+
+	err = c.client.Del(ctx, keys...).Err()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.DeleteUserRefreshFamiliesCache, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.DeleteUserRefreshFamiliesCache, metrics.OkStatus)
+	return nil
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	prefixRevokedAccessToken = "revoked-jti:"
+	prefixRevokedBefore      = "revoked-before:"
+
+	// revokedBeforeSeparator - разделитель момента отзыва и исключенной сессии
+	revokedBeforeSeparator = ":"
+)
+
+// SetRevokedAccessToken - добавление jti access-токена в denylist до истечения токена
+func (c *Cache) SetRevokedAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheSetRevokedAccessToken)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.SetRevokedAccessTokenCache)()
+
+	err := c.client.Set(ctx, prefixRevokedAccessToken+jti, 1, ttl).Err()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.SetRevokedAccessTokenCache, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.SetRevokedAccessTokenCache, metrics.OkStatus)
+	return nil
+}
+
+// SetUserTokensRevokedBefore - отзыв всех access-токенов пользователя, выпущенных до revokedBefore, кроме токенов сессии
+// exceptSessionID. Момент отзыва и сессия хранятся одним значением, чтобы проверка токена оставалась одним запросом
+func (c *Cache) SetUserTokensRevokedBefore(ctx context.Context, userID string, revokedBefore time.Time, exceptSessionID string,
+	ttl time.Duration) error {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheSetUserTokensRevokedBefore)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.SetUserTokensRevokedBeforeCache)()
+
+	value := strconv.FormatInt(revokedBefore.UnixMicro(), 10)
+	if exceptSessionID != "" {
+		value += revokedBeforeSeparator + exceptSessionID
+	}
+
+	err := c.client.Set(ctx, prefixRevokedBefore+userID, value, ttl).Err()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.SetUserTokensRevokedBeforeCache, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.SetUserTokensRevokedBeforeCache, metrics.OkStatus)
+	return nil
+}
+
+// GetAccessTokenRevocation - получение состояния отзыва access-токена одним запросом
+func (c *Cache) GetAccessTokenRevocation(ctx context.Context, jti, userID, familyID string) (entity.TokenRevocation, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheGetAccessTokenRevocation)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.GetAccessTokenRevocationCache)()
+
+	keys := []string{prefixRevokedAccessToken + jti, prefixRevokedBefore + userID}
+	if familyID != "" {
+		keys = append(keys, prefixRefreshFamily+familyID)
+	}
+
+	values, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetAccessTokenRevocationCache, metrics.FailStatus)
+		return entity.TokenRevocation{}, err
+	}
+
+	revocation := entity.TokenRevocation{
+		Revoked: values[0] != nil,
+	}
+
+	if value, ok := values[1].(string); ok {
+		revokedBefore, exceptSessionID, _ := strings.Cut(value, revokedBeforeSeparator)
+		unixMicro, errParse := strconv.ParseInt(revokedBefore, 10, 64)
+		if errParse != nil {
+			metrics.IncRequestTotalDB(metrics.GetAccessTokenRevocationCache, metrics.FailStatus)
+			return entity.TokenRevocation{}, errParse
+		}
+		t := time.UnixMicro(unixMicro)
+		revocation.RevokedBefore = &t
+		revocation.RevokedBeforeExceptSessionID = exceptSessionID
+	}
+
+	if familyID != "" {
+		revocation.FamilyRevoked = values[2] == nil
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetAccessTokenRevocationCache, metrics.OkStatus)
+	return revocation, nil
+}
@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// SetSession - создание сессии (семейства рефреш-токенов) с привязкой к пользователю
+func (c *Cache) SetSession(ctx context.Context, session entity.Session) error {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheSetSession)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.SetSessionCache)()
+
+	data, errJson := json.Marshal(session)
+	if errJson != nil {
+		return errJson
+	}
+
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, prefixRefreshFamily+session.ID, string(data), c.refreshTTL)
+		pipe.SAdd(ctx, prefixUserRefreshFamilies+session.UserID, session.ID)
+		pipe.Expire(ctx, prefixUserRefreshFamilies+session.UserID, c.refreshTTL)
+		return nil
+	})
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.SetSessionCache, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.SetSessionCache, metrics.OkStatus)
+	return nil
+}
+
+// UpdateSession - обновление и продление существующей сессии. Возвращает false, если сессия уже завершена
+func (c *Cache) UpdateSession(ctx context.Context, session entity.Session) (bool, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheUpdateSession)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.UpdateSessionCache)()
+
+	data, errJson := json.Marshal(session)
+	if errJson != nil {
+		return false, errJson
+	}
+
+	var updated *redis.BoolCmd
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		// XX не дает воскресить сессию, завершенную параллельным запросом
+		updated = pipe.SetXX(ctx, prefixRefreshFamily+session.ID, string(data), c.refreshTTL)
+		pipe.Expire(ctx, prefixUserRefreshFamilies+session.UserID, c.refreshTTL)
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		metrics.IncRequestTotalDB(metrics.UpdateSessionCache, metrics.FailStatus)
+		return false, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.UpdateSessionCache, metrics.OkStatus)
+	return updated.Val(), nil
+}
+
+// GetSession - получение сессии по идентификатору
+func (c *Cache) GetSession(ctx context.Context, sessionID string) (entity.Session, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheGetSession)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.GetSessionCache)()
+
+	val, err := c.client.Get(ctx, prefixRefreshFamily+sessionID).Result()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetSessionCache, metrics.FailStatus)
+		if errors.Is(err, redis.Nil) {
+			return entity.Session{}, apperror.ErrRedisNil
+		}
+		return entity.Session{}, err
+	}
+
+	var session entity.Session
+	err = json.Unmarshal([]byte(val), &session)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetSessionCache, metrics.FailStatus)
+		return entity.Session{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetSessionCache, metrics.OkStatus)
+	return session, nil
+}
+
+// GetUserSessions - получение активных сессий пользователя, завершенные сессии вычищаются из индекса
+func (c *Cache) GetUserSessions(ctx context.Context, userID string) ([]entity.Session, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheGetUserSessions)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.GetUserSessionsCache)()
+
+	sessionIDs, err := c.client.SMembers(ctx, prefixUserRefreshFamilies+userID).Result()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetUserSessionsCache, metrics.FailStatus)
+		return nil, err
+	}
+
+	sessions := make([]entity.Session, 0, len(sessionIDs))
+	if len(sessionIDs) == 0 {
+		metrics.IncRequestTotalDB(metrics.GetUserSessionsCache, metrics.OkStatus)
+		return sessions, nil
+	}
+
+	keys := make([]string, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		keys = append(keys, prefixRefreshFamily+sessionID)
+	}
+
+	values, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetUserSessionsCache, metrics.FailStatus)
+		return nil, err
+	}
+
+	stale := make([]any, 0)
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			stale = append(stale, sessionIDs[i])
+			continue
+		}
+
+		var session entity.Session
+		err = json.Unmarshal([]byte(data), &session)
+		if err != nil {
+			metrics.IncRequestTotalDB(metrics.GetUserSessionsCache, metrics.FailStatus)
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	if len(stale) > 0 {
+		err = c.client.SRem(ctx, prefixUserRefreshFamilies+userID, stale...).Err()
+		if err != nil {
+			metrics.IncRequestTotalDB(metrics.GetUserSessionsCache, metrics.FailStatus)
+			return nil, err
+		}
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetUserSessionsCache, metrics.OkStatus)
+	return sessions, nil
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	prefixSignInFailures = "sign-in-failures:"
+	prefixSignInLock     = "sign-in-lock:"
+)
+
+// signInLockKinds - виды ограничений хранятся под разными ключами, чтобы задержка не перезаписала блокировку
+var signInLockKinds = []string{entity.SignInLockLockout, entity.SignInLockDelay}
+
+// incrSignInFailuresScript - увеличивает счетчик ошибок входа. Срок жизни задает только первая ошибка,
+// чтобы окно подсчета не продлевалось каждой следующей попыткой
+var incrSignInFailuresScript = redis.NewScript(`
+local failures = redis.call('INCR', KEYS[1])
+if failures == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return failures
+`)
+
+// IncrSignInFailures - учет неудачной попытки входа, возвращает число ошибок в текущем окне
+func (c *Cache) IncrSignInFailures(ctx context.Context, subject string, window time.Duration) (int64, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheIncrSignInFailures)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.IncrSignInFailuresCache)()
+
+	failures, err := incrSignInFailuresScript.Run(ctx, c.client, []string{prefixSignInFailures + subject},
+		window.Milliseconds()).Int64()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.IncrSignInFailuresCache, metrics.FailStatus)
+		return 0, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.IncrSignInFailuresCache, metrics.OkStatus)
+	return failures, nil
+}
+
+// SetSignInLock - ограничение входа на время lock.TTL, по истечении ограничение снимается само
+func (c *Cache) SetSignInLock(ctx context.Context, lock entity.SignInLock) error {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheSetSignInLock)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.SetSignInLockCache)()
+
+	err := c.client.Set(ctx, signInLockKey(lock.Kind, lock.Subject), time.Now().UTC().Format(time.RFC3339), lock.TTL).Err()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.SetSignInLockCache, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.SetSignInLockCache, metrics.OkStatus)
+	return nil
+}
+
+// GetSignInLocks - действующие ограничения входа перечисленных учетных записей и ip
+func (c *Cache) GetSignInLocks(ctx context.Context, subjects ...string) ([]entity.SignInLock, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheGetSignInLocks)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.GetSignInLocksCache)()
+
+	candidates := make([]entity.SignInLock, 0, len(subjects)*len(signInLockKinds))
+	cmds := make([]*redis.DurationCmd, 0, cap(candidates))
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, subject := range subjects {
+			for _, kind := range signInLockKinds {
+				candidates = append(candidates, entity.SignInLock{Subject: subject, Kind: kind})
+				cmds = append(cmds, pipe.PTTL(ctx, signInLockKey(kind, subject)))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetSignInLocksCache, metrics.FailStatus)
+		return nil, err
+	}
+
+	locks := make([]entity.SignInLock, 0)
+	for i, cmd := range cmds {
+		// отсутствующий ключ дает отрицательный ttl
+		if ttl := cmd.Val(); ttl > 0 {
+			candidates[i].TTL = ttl
+			locks = append(locks, candidates[i])
+		}
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetSignInLocksCache, metrics.OkStatus)
+	return locks, nil
+}
+
+// DeleteSignInFailures - сброс счетчика ошибок и всех ограничений входа
+func (c *Cache) DeleteSignInFailures(ctx context.Context, subject string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanCacheDeleteSignInFailures)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Cache, metrics.DeleteSignInFailuresCache)()
+
+	keys := []string{prefixSignInFailures + subject}
+	for _, kind := range signInLockKinds {
+		keys = append(keys, signInLockKey(kind, subject))
+	}
+
+	err := c.client.Del(ctx, keys...).Err()
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.DeleteSignInFailuresCache, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.DeleteSignInFailuresCache, metrics.OkStatus)
+	return nil
+}
+
+// signInLockKey - ключ ограничения входа
+func signInLockKey(kind, subject string) string {
+	return prefixSignInLock + kind + ":" + subject
+}
@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/GermanBogatov/auth-service/pkg/postgresql"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+)
+
+var _ IUserIdentity = &UserIdentity{}
+
+type IUserIdentity interface {
+	GetUserByIdentity(ctx context.Context, provider, subject string) (entity.User, error)
+	CreateUserIdentity(ctx context.Context, identity entity.UserIdentity) error
+	CreateUserWithIdentity(ctx context.Context, user entity.User, identity entity.UserIdentity) error
+}
+
+type UserIdentity struct {
+	client postgresql.Client
+}
+
+func NewUserIdentity(client postgresql.Client) IUserIdentity {
+	return &UserIdentity{
+		client: client,
+	}
+}
+
+const insertUserIdentityQuery = `
+	INSERT INTO user_identities
+    	(provider,subject,user_id,email,created_date)
+    VALUES
+		($1,$2,$3,$4,$5);
+		`
+
+// GetUserByIdentity - получение пользователя, привязанного к учетной записи внешнего провайдера
+func (u *UserIdentity) GetUserByIdentity(ctx context.Context, provider, subject string) (entity.User, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresGetUserByIdentity)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetUserByIdentityDb)()
+
+	q := `
+		SELECT u.id,u.name,u.surname,u.email,u.password,u.role,u.email_verified,u.created_date,u.updated_date
+		FROM user_identities i
+		JOIN users u ON u.id = i.user_id
+		WHERE i.provider=$1 AND i.subject=$2;
+		`
+
+	var user entity.User
+	err := u.client.QueryRow(ctx, q, provider, subject).Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password,
+		&user.Role, &user.EmailVerified, &user.CreatedDate, &user.UpdatedDate)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetUserByIdentityDb, metrics.FailStatus)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.User{}, apperror.ErrUserIdentityNotFound
+		}
+		return entity.User{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetUserByIdentityDb, metrics.OkStatus)
+	return user, nil
+}
+
+// CreateUserIdentity - привязка учетной записи внешнего провайдера к существующему пользователю
+func (u *UserIdentity) CreateUserIdentity(ctx context.Context, identity entity.UserIdentity) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresCreateUserIdentity)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.CreateUserIdentityDb)()
+
+	_, err := u.client.Exec(ctx, insertUserIdentityQuery, identity.Provider, identity.Subject, identity.UserID, identity.Email,
+		identity.CreatedDate)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.CreateUserIdentityDb, metrics.FailStatus)
+		return identityError(err)
+	}
+
+	metrics.IncRequestTotalDB(metrics.CreateUserIdentityDb, metrics.OkStatus)
+	return nil
+}
+
+// CreateUserWithIdentity - создание пользователя вместе с привязкой учетной записи внешнего провайдера
+func (u *UserIdentity) CreateUserWithIdentity(ctx context.Context, user entity.User, identity entity.UserIdentity) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresCreateUserWithIdentity)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.CreateUserWithIdentityDb)()
+
+	err := u.createUserWithIdentity(ctx, user, identity)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.CreateUserWithIdentityDb, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.CreateUserWithIdentityDb, metrics.OkStatus)
+	return nil
+}
+
+func (u *UserIdentity) createUserWithIdentity(ctx context.Context, user entity.User, identity entity.UserIdentity) error {
+	tx, err := u.client.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "begin")
+	}
+
+	defer func() {
+		errRollback := tx.Rollback(ctx)
+		if errRollback != nil && !errors.Is(errRollback, pgx.ErrTxClosed) {
+			logging.Errorf("error rollback create user with identity: %s", errRollback)
+		}
+	}()
+
+	_, err = tx.Exec(ctx, `
+	INSERT INTO users
+    	(id,name,surname,email,password,role,email_verified,created_date)
+    VALUES
+		($1,$2,$3,$4,$5,$6,$7,$8);`,
+		user.ID, user.Name, user.Surname, user.Email, user.Password, user.Role, user.EmailVerified, user.CreatedDate)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return apperror.ErrUserIsExistWithEmail
+		}
+		return errors.Wrap(err, "insert user")
+	}
+
+	_, err = tx.Exec(ctx, insertUserIdentityQuery, identity.Provider, identity.Subject, identity.UserID, identity.Email,
+		identity.CreatedDate)
+	if err != nil {
+		return identityError(err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// identityError - учетная запись провайдера уже привязана, либо у пользователя уже есть учетная запись этого провайдера
+func identityError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return apperror.ErrUserIdentityExists
+	}
+	return err
+}
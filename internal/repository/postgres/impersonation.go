@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/postgresql"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+)
+
+var _ IImpersonation = &Impersonation{}
+
+type IImpersonation interface {
+	CreateImpersonation(ctx context.Context, impersonation entity.Impersonation) error
+}
+
+type Impersonation struct {
+	client postgresql.Client
+}
+
+func NewImpersonation(client postgresql.Client) IImpersonation {
+	return &Impersonation{
+		client: client,
+	}
+}
+
+// CreateImpersonation - запись аудита выдачи токена имперсонации
+func (i *Impersonation) CreateImpersonation(ctx context.Context, impersonation entity.Impersonation) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresCreateImpersonation)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.CreateImpersonationDb)()
+
+	q := `
+	INSERT INTO user_impersonations
+    	(id,user_id,actor_id,reason,expires_date,created_date)
+    VALUES
+		($1,$2,$3,$4,$5,$6);
+		`
+
+	_, err := i.client.Exec(ctx, q, impersonation.ID, impersonation.UserID, impersonation.ActorID, impersonation.Reason,
+		impersonation.ExpiresDate, impersonation.CreatedDate)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.CreateImpersonationDb, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.CreateImpersonationDb, metrics.OkStatus)
+	return nil
+}
@@ -0,0 +1,217 @@
+package postgres
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/GermanBogatov/auth-service/pkg/postgresql"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// keyRotationLockID - идентификатор advisory-блокировки на ротацию ключей между инстансами
+const keyRotationLockID = 7301
+
+var _ IKey = &Key{}
+
+type IKey interface {
+	CreateKey(ctx context.Context, key entity.SigningKey) error
+	GetKeys(ctx context.Context) ([]entity.SigningKey, error)
+	RotateKeys(ctx context.Context, rotation entity.KeyRotation) (bool, error)
+	RetireKey(ctx context.Context, id string, expiresDate time.Time) (entity.KeyStatus, error)
+}
+
+type Key struct {
+	client postgresql.Client
+}
+
+func NewKey(client postgresql.Client) IKey {
+	return &Key{
+		client: client,
+	}
+}
+
+// CreateKey - создание ключа подписи
+func (k *Key) CreateKey(ctx context.Context, key entity.SigningKey) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresCreateKey)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.CreateKeyDb)()
+
+	q := `
+	INSERT INTO signing_keys
+    	(id,algorithm,private_key,status,created_date,activated_date)
+    VALUES
+		($1,$2,$3,$4,$5,$6);
+		`
+
+	_, err := k.client.Exec(ctx, q, key.ID, key.Algorithm, key.EncryptedKey, key.Status, key.CreatedDate, key.ActivatedDate)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.CreateKeyDb, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.CreateKeyDb, metrics.OkStatus)
+	return nil
+}
+
+// GetKeys - получение ключей, пригодных для подписи или проверки
+func (k *Key) GetKeys(ctx context.Context) ([]entity.SigningKey, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresGetKeys)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetKeysDb)()
+
+	q := `
+		SELECT id,algorithm,private_key,status,created_date,activated_date,retired_date,expires_date
+		FROM signing_keys
+		WHERE status <> 'retired' OR expires_date > $1
+		ORDER BY created_date DESC;
+		`
+
+	rows, err := k.client.Query(ctx, q, time.Now().UTC())
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetKeysDb, metrics.FailStatus)
+		return nil, err
+	}
+
+	defer rows.Close()
+	keys := make([]entity.SigningKey, 0)
+	for rows.Next() {
+		var key entity.SigningKey
+		errScan := rows.Scan(&key.ID, &key.Algorithm, &key.EncryptedKey, &key.Status, &key.CreatedDate, &key.ActivatedDate, &key.RetiredDate, &key.ExpiresDate)
+		if errScan != nil {
+			metrics.IncRequestTotalDB(metrics.GetKeysDb, metrics.FailStatus)
+			return nil, errScan
+		}
+		keys = append(keys, key)
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetKeysDb, metrics.OkStatus)
+	return keys, nil
+}
+
+// RotateKeys - ротация ключей: активный выводится, следующий становится активным, создается новый следующий.
+// Возвращает false, если ротация не потребовалась (ее уже выполнил другой инстанс)
+func (k *Key) RotateKeys(ctx context.Context, rotation entity.KeyRotation) (bool, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresRotateKeys)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.RotateKeysDb)()
+
+	rotated, err := k.rotateKeys(ctx, rotation)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.RotateKeysDb, metrics.FailStatus)
+		return false, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.RotateKeysDb, metrics.OkStatus)
+	return rotated, nil
+}
+
+func (k *Key) rotateKeys(ctx context.Context, rotation entity.KeyRotation) (bool, error) {
+	tx, err := k.client.Begin(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "begin")
+	}
+
+	defer func() {
+		errRollback := tx.Rollback(ctx)
+		if errRollback != nil && !errors.Is(errRollback, pgx.ErrTxClosed) {
+			logging.Errorf("error rollback rotate keys: %s", errRollback)
+		}
+	}()
+
+	_, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1);`, keyRotationLockID)
+	if err != nil {
+		return false, errors.Wrap(err, "advisory lock")
+	}
+
+	if rotation.ActivatedBefore != nil {
+		var activatedDate time.Time
+		err = tx.QueryRow(ctx, `SELECT activated_date FROM signing_keys WHERE status = 'active';`).Scan(&activatedDate)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return false, errors.Wrap(err, "select active key")
+		}
+		if err == nil && activatedDate.After(*rotation.ActivatedBefore) {
+			return false, nil
+		}
+	}
+
+	now := time.Now().UTC()
+
+	_, err = tx.Exec(ctx, `
+		UPDATE signing_keys SET status = 'retired', retired_date = $1, expires_date = $2
+		WHERE status = 'active';`, now, rotation.RetiredUntil)
+	if err != nil {
+		return false, errors.Wrap(err, "retire active key")
+	}
+
+	tag, err := tx.Exec(ctx, `UPDATE signing_keys SET status = 'active', activated_date = $1 WHERE status = 'next';`, now)
+	if err != nil {
+		return false, errors.Wrap(err, "activate next key")
+	}
+
+	insertQuery := `
+	INSERT INTO signing_keys
+    	(id,algorithm,private_key,status,created_date,activated_date)
+    VALUES
+		($1,$2,$3,$4,$5,$6);
+		`
+
+	if tag.RowsAffected() == 0 {
+		_, err = tx.Exec(ctx, insertQuery, rotation.Active.ID, rotation.Active.Algorithm, rotation.Active.EncryptedKey,
+			entity.KeyStatusActive, now, now)
+		if err != nil {
+			return false, errors.Wrap(err, "insert active key")
+		}
+	}
+
+	_, err = tx.Exec(ctx, insertQuery, rotation.Next.ID, rotation.Next.Algorithm, rotation.Next.EncryptedKey,
+		entity.KeyStatusNext, now, nil)
+	if err != nil {
+		return false, errors.Wrap(err, "insert next key")
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM signing_keys WHERE status = 'retired' AND expires_date <= $1;`, now)
+	if err != nil {
+		return false, errors.Wrap(err, "delete expired keys")
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "commit")
+	}
+
+	return true, nil
+}
+
+// RetireKey - вывод ключа из оборота, ключ проверяет подписи до expiresDate. Возвращает прежний статус ключа
+func (k *Key) RetireKey(ctx context.Context, id string, expiresDate time.Time) (entity.KeyStatus, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresRetireKey)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.RetireKeyDb)()
+
+	q := `
+		UPDATE signing_keys AS k
+		SET status = 'retired', retired_date = COALESCE(k.retired_date, $1), expires_date = LEAST(COALESCE(k.expires_date, $2), $2)
+		FROM signing_keys AS prev
+		WHERE k.id = $3 AND prev.id = k.id
+		RETURNING prev.status;
+		`
+
+	var status entity.KeyStatus
+	err := k.client.QueryRow(ctx, q, time.Now().UTC(), expiresDate, id).Scan(&status)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.RetireKeyDb, metrics.FailStatus)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", apperror.ErrKeyNotFound
+		}
+		return "", err
+	}
+
+	metrics.IncRequestTotalDB(metrics.RetireKeyDb, metrics.OkStatus)
+	return status, nil
+}
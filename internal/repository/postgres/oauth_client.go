@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/postgresql"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+var _ IOAuthClient = &OAuthClient{}
+
+type IOAuthClient interface {
+	CreateOAuthClient(ctx context.Context, client entity.OAuthClient) error
+	GetOAuthClientByID(ctx context.Context, id string) (entity.OAuthClient, error)
+	GetOAuthClients(ctx context.Context) ([]entity.OAuthClient, error)
+	UpdateOAuthClient(ctx context.Context, clientUpdate entity.OAuthClientUpdate) (entity.OAuthClient, error)
+	UpdateOAuthClientSecret(ctx context.Context, id, secretHash string) error
+	DeleteOAuthClientByID(ctx context.Context, id string) error
+}
+
+type OAuthClient struct {
+	client postgresql.Client
+}
+
+func NewOAuthClient(client postgresql.Client) IOAuthClient {
+	return &OAuthClient{
+		client: client,
+	}
+}
+
+// CreateOAuthClient - регистрация oauth-клиента
+func (o *OAuthClient) CreateOAuthClient(ctx context.Context, client entity.OAuthClient) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresCreateOAuthClient)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.CreateOAuthClientDb)()
+
+	q := `
+	INSERT INTO oauth_clients
+    	(id,name,secret_hash,public,redirect_uris,grant_types,scopes,audiences,created_date)
+    VALUES
+		($1,$2,$3,$4,$5,$6,$7,$8,$9);
+		`
+
+	_, err := o.client.Exec(ctx, q, client.ID, client.Name, client.SecretHash, client.Public, client.RedirectURIs,
+		client.GrantTypes, client.Scopes, client.Audiences, client.CreatedDate)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.CreateOAuthClientDb, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.CreateOAuthClientDb, metrics.OkStatus)
+	return nil
+}
+
+// GetOAuthClientByID - получение oauth-клиента по идентификатору
+func (o *OAuthClient) GetOAuthClientByID(ctx context.Context, id string) (entity.OAuthClient, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresGetOAuthClientByID)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetOAuthClientByIDDb)()
+
+	q := `
+		SELECT id,name,secret_hash,public,redirect_uris,grant_types,scopes,audiences,created_date,updated_date
+		FROM oauth_clients
+		WHERE id=$1;
+		`
+
+	var client entity.OAuthClient
+	err := o.client.QueryRow(ctx, q, id).Scan(&client.ID, &client.Name, &client.SecretHash, &client.Public, &client.RedirectURIs,
+		&client.GrantTypes, &client.Scopes, &client.Audiences, &client.CreatedDate, &client.UpdatedDate)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetOAuthClientByIDDb, metrics.FailStatus)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.OAuthClient{}, apperror.ErrOAuthClientNotFound
+		}
+		return entity.OAuthClient{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetOAuthClientByIDDb, metrics.OkStatus)
+	return client, nil
+}
+
+// GetOAuthClients - получение oauth-клиентов
+func (o *OAuthClient) GetOAuthClients(ctx context.Context) ([]entity.OAuthClient, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresGetOAuthClients)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetOAuthClientsDb)()
+
+	q := `
+		SELECT id,name,secret_hash,public,redirect_uris,grant_types,scopes,audiences,created_date,updated_date
+		FROM oauth_clients
+		ORDER BY created_date DESC;
+		`
+
+	rows, err := o.client.Query(ctx, q)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetOAuthClientsDb, metrics.FailStatus)
+		return nil, err
+	}
+
+	defer rows.Close()
+	clients := make([]entity.OAuthClient, 0)
+	for rows.Next() {
+		var client entity.OAuthClient
+		errScan := rows.Scan(&client.ID, &client.Name, &client.SecretHash, &client.Public, &client.RedirectURIs,
+			&client.GrantTypes, &client.Scopes, &client.Audiences, &client.CreatedDate, &client.UpdatedDate)
+		if errScan != nil {
+			metrics.IncRequestTotalDB(metrics.GetOAuthClientsDb, metrics.FailStatus)
+			return nil, errScan
+		}
+		clients = append(clients, client)
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetOAuthClientsDb, metrics.OkStatus)
+	return clients, nil
+}
+
+// UpdateOAuthClient - редактирование oauth-клиента
+func (o *OAuthClient) UpdateOAuthClient(ctx context.Context, clientUpdate entity.OAuthClientUpdate) (entity.OAuthClient, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresUpdateOAuthClient)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.UpdateOAuthClientDb)()
+
+	query, args := prepareQueryUpdateOAuthClient(clientUpdate)
+	var client entity.OAuthClient
+	err := o.client.QueryRow(ctx, query, args...).Scan(&client.ID, &client.Name, &client.SecretHash, &client.Public, &client.RedirectURIs,
+		&client.GrantTypes, &client.Scopes, &client.Audiences, &client.CreatedDate, &client.UpdatedDate)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.UpdateOAuthClientDb, metrics.FailStatus)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.OAuthClient{}, apperror.ErrOAuthClientNotFound
+		}
+		return entity.OAuthClient{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.UpdateOAuthClientDb, metrics.OkStatus)
+	return client, nil
+}
+
+// prepareQueryUpdateOAuthClient - подготовка запроса для обновления oauth-клиента
+func prepareQueryUpdateOAuthClient(client entity.OAuthClientUpdate) (string, []interface{}) {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+	if client.Name != nil {
+		setValues = append(setValues, fmt.Sprintf("name=$%d", argId))
+		args = append(args, *client.Name)
+		argId++
+	}
+
+	if client.RedirectURIs != nil {
+		setValues = append(setValues, fmt.Sprintf("redirect_uris=$%d", argId))
+		args = append(args, *client.RedirectURIs)
+		argId++
+	}
+
+	if client.GrantTypes != nil {
+		setValues = append(setValues, fmt.Sprintf("grant_types=$%d", argId))
+		args = append(args, *client.GrantTypes)
+		argId++
+	}
+
+	if client.Scopes != nil {
+		setValues = append(setValues, fmt.Sprintf("scopes=$%d", argId))
+		args = append(args, *client.Scopes)
+		argId++
+	}
+
+	if client.Audiences != nil {
+		setValues = append(setValues, fmt.Sprintf("audiences=$%d", argId))
+		args = append(args, *client.Audiences)
+		argId++
+	}
+
+	setValues = append(setValues, fmt.Sprintf("updated_date=$%d", argId))
+	args = append(args, time.Now().UTC())
+	argId++
+
+	setQuery := strings.Join(setValues, ", ")
+	args = append(args, client.ID)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id=$%v RETURNING id,name,secret_hash,public,redirect_uris,grant_types,scopes,audiences,created_date,updated_date;",
+		"oauth_clients", setQuery, argId)
+	return query, args
+}
+
+// UpdateOAuthClientSecret - замена секрета конфиденциального oauth-клиента
+func (o *OAuthClient) UpdateOAuthClientSecret(ctx context.Context, id, secretHash string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresUpdateOAuthClientSecret)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.UpdateOAuthClientSecretDb)()
+
+	q := `
+		UPDATE oauth_clients SET secret_hash=$1, updated_date=$2
+		WHERE id=$3 AND NOT public;
+		`
+
+	tag, err := o.client.Exec(ctx, q, secretHash, time.Now().UTC(), id)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.UpdateOAuthClientSecretDb, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.UpdateOAuthClientSecretDb, metrics.OkStatus)
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrOAuthClientNotFound
+	}
+	return nil
+}
+
+// DeleteOAuthClientByID - удаление oauth-клиента
+func (o *OAuthClient) DeleteOAuthClientByID(ctx context.Context, id string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresDeleteOAuthClientByID)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.DeleteOAuthClientByIDDb)()
+
+	q := `
+	DELETE FROM oauth_clients
+    WHERE id=$1;`
+
+	tag, err := o.client.Exec(ctx, q, id)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.DeleteOAuthClientByIDDb, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.DeleteOAuthClientByIDDb, metrics.OkStatus)
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrOAuthClientNotFound
+	}
+	return nil
+}
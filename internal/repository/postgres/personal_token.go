@@ -0,0 +1,250 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/postgresql"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+var _ IPersonalToken = &PersonalToken{}
+
+type IPersonalToken interface {
+	CreatePersonalToken(ctx context.Context, token entity.PersonalToken) error
+	GetPersonalTokens(ctx context.Context, userID string) ([]entity.PersonalToken, error)
+	GetPersonalTokenByID(ctx context.Context, id, userID string) (entity.PersonalToken, error)
+	GetPersonalTokenByHash(ctx context.Context, tokenHash string) (entity.PersonalToken, error)
+	UpdatePersonalToken(ctx context.Context, tokenUpdate entity.PersonalTokenUpdate) (entity.PersonalToken, error)
+	UpdatePersonalTokenLastUsed(ctx context.Context, id, ip string, usedDate time.Time) error
+	DeletePersonalToken(ctx context.Context, id, userID string) error
+}
+
+type PersonalToken struct {
+	client postgresql.Client
+}
+
+func NewPersonalToken(client postgresql.Client) IPersonalToken {
+	return &PersonalToken{
+		client: client,
+	}
+}
+
+const personalTokenColumns = "id,user_id,name,token_hash,token_prefix,scopes,expires_date,last_used_date,last_used_ip,created_date,updated_date"
+
+// scanPersonalToken - чтение персонального токена из строки результата
+func scanPersonalToken(row pgx.Row) (entity.PersonalToken, error) {
+	var token entity.PersonalToken
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenHash, &token.TokenPrefix, &token.Scopes, &token.ExpiresDate,
+		&token.LastUsedDate, &token.LastUsedIP, &token.CreatedDate, &token.UpdatedDate)
+	return token, err
+}
+
+// CreatePersonalToken - создание персонального токена
+func (p *PersonalToken) CreatePersonalToken(ctx context.Context, token entity.PersonalToken) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresCreatePersonalToken)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.CreatePersonalTokenDb)()
+
+	q := `
+	INSERT INTO personal_tokens
+    	(id,user_id,name,token_hash,token_prefix,scopes,expires_date,created_date)
+    VALUES
+		($1,$2,$3,$4,$5,$6,$7,$8);
+		`
+
+	_, err := p.client.Exec(ctx, q, token.ID, token.UserID, token.Name, token.TokenHash, token.TokenPrefix, token.Scopes,
+		token.ExpiresDate, token.CreatedDate)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.CreatePersonalTokenDb, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.CreatePersonalTokenDb, metrics.OkStatus)
+	return nil
+}
+
+// GetPersonalTokens - получение персональных токенов пользователя
+func (p *PersonalToken) GetPersonalTokens(ctx context.Context, userID string) ([]entity.PersonalToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresGetPersonalTokens)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetPersonalTokensDb)()
+
+	q := fmt.Sprintf(`
+		SELECT %s
+		FROM personal_tokens
+		WHERE user_id=$1
+		ORDER BY created_date DESC;
+		`, personalTokenColumns)
+
+	rows, err := p.client.Query(ctx, q, userID)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetPersonalTokensDb, metrics.FailStatus)
+		return nil, err
+	}
+
+	defer rows.Close()
+	tokens := make([]entity.PersonalToken, 0)
+	for rows.Next() {
+		token, errScan := scanPersonalToken(rows)
+		if errScan != nil {
+			metrics.IncRequestTotalDB(metrics.GetPersonalTokensDb, metrics.FailStatus)
+			return nil, errScan
+		}
+		tokens = append(tokens, token)
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetPersonalTokensDb, metrics.OkStatus)
+	return tokens, nil
+}
+
+// GetPersonalTokenByID - получение персонального токена пользователя по идентификатору
+func (p *PersonalToken) GetPersonalTokenByID(ctx context.Context, id, userID string) (entity.PersonalToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresGetPersonalTokenByID)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetPersonalTokenByIDDb)()
+
+	q := fmt.Sprintf(`
+		SELECT %s
+		FROM personal_tokens
+		WHERE id=$1 AND user_id=$2;
+		`, personalTokenColumns)
+
+	token, err := scanPersonalToken(p.client.QueryRow(ctx, q, id, userID))
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetPersonalTokenByIDDb, metrics.FailStatus)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.PersonalToken{}, apperror.ErrPersonalTokenNotFound
+		}
+		return entity.PersonalToken{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetPersonalTokenByIDDb, metrics.OkStatus)
+	return token, nil
+}
+
+// GetPersonalTokenByHash - получение персонального токена по хэшу
+func (p *PersonalToken) GetPersonalTokenByHash(ctx context.Context, tokenHash string) (entity.PersonalToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresGetPersonalTokenByHash)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetPersonalTokenByHashDb)()
+
+	q := fmt.Sprintf(`
+		SELECT %s
+		FROM personal_tokens
+		WHERE token_hash=$1;
+		`, personalTokenColumns)
+
+	token, err := scanPersonalToken(p.client.QueryRow(ctx, q, tokenHash))
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetPersonalTokenByHashDb, metrics.FailStatus)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.PersonalToken{}, apperror.ErrPersonalTokenNotFound
+		}
+		return entity.PersonalToken{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetPersonalTokenByHashDb, metrics.OkStatus)
+	return token, nil
+}
+
+// UpdatePersonalToken - редактирование персонального токена пользователя
+func (p *PersonalToken) UpdatePersonalToken(ctx context.Context, tokenUpdate entity.PersonalTokenUpdate) (entity.PersonalToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresUpdatePersonalToken)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.UpdatePersonalTokenDb)()
+
+	query, args := prepareQueryUpdatePersonalToken(tokenUpdate)
+	token, err := scanPersonalToken(p.client.QueryRow(ctx, query, args...))
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.UpdatePersonalTokenDb, metrics.FailStatus)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.PersonalToken{}, apperror.ErrPersonalTokenNotFound
+		}
+		return entity.PersonalToken{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.UpdatePersonalTokenDb, metrics.OkStatus)
+	return token, nil
+}
+
+// prepareQueryUpdatePersonalToken - подготовка запроса для обновления персонального токена
+func prepareQueryUpdatePersonalToken(token entity.PersonalTokenUpdate) (string, []interface{}) {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+	if token.Name != nil {
+		setValues = append(setValues, fmt.Sprintf("name=$%d", argId))
+		args = append(args, *token.Name)
+		argId++
+	}
+
+	if token.Scopes != nil {
+		setValues = append(setValues, fmt.Sprintf("scopes=$%d", argId))
+		args = append(args, *token.Scopes)
+		argId++
+	}
+
+	setValues = append(setValues, fmt.Sprintf("updated_date=$%d", argId))
+	args = append(args, time.Now().UTC())
+	argId++
+
+	setQuery := strings.Join(setValues, ", ")
+	args = append(args, token.ID, token.UserID)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id=$%v AND user_id=$%v RETURNING %s;", "personal_tokens", setQuery, argId,
+		argId+1, personalTokenColumns)
+	return query, args
+}
+
+// UpdatePersonalTokenLastUsed - фиксация использования токена. Чтобы не писать в базу на каждый запрос,
+// время обновляется не чаще раза в минуту, если не сменился ip-адрес
+func (p *PersonalToken) UpdatePersonalTokenLastUsed(ctx context.Context, id, ip string, usedDate time.Time) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresUpdatePersonalTokenLastUsed)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.UpdatePersonalTokenLastUsedDb)()
+
+	q := `
+		UPDATE personal_tokens SET last_used_date=$1, last_used_ip=$2
+		WHERE id=$3 AND (last_used_date IS NULL OR last_used_date < $4 OR last_used_ip IS DISTINCT FROM $2);
+		`
+
+	_, err := p.client.Exec(ctx, q, usedDate, ip, id, usedDate.Add(-time.Minute))
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.UpdatePersonalTokenLastUsedDb, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.UpdatePersonalTokenLastUsedDb, metrics.OkStatus)
+	return nil
+}
+
+// DeletePersonalToken - удаление персонального токена пользователя
+func (p *PersonalToken) DeletePersonalToken(ctx context.Context, id, userID string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresDeletePersonalToken)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.DeletePersonalTokenDb)()
+
+	q := `
+	DELETE FROM personal_tokens
+    WHERE id=$1 AND user_id=$2;`
+
+	tag, err := p.client.Exec(ctx, q, id, userID)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.DeletePersonalTokenDb, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.DeletePersonalTokenDb, metrics.OkStatus)
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrPersonalTokenNotFound
+	}
+	return nil
+}
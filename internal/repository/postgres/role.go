@@ -0,0 +1,235 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/postgresql"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+const roleColumns = "name,description,permissions,level,built_in,created_date,updated_date"
+
+var _ IRole = &Role{}
+
+type IRole interface {
+	CreateRole(ctx context.Context, role entity.Role) error
+	GetRoles(ctx context.Context) ([]entity.Role, error)
+	GetRoleByName(ctx context.Context, name string) (entity.Role, error)
+	UpdateRole(ctx context.Context, roleUpdate entity.RoleUpdate) (entity.Role, error)
+	DeleteRole(ctx context.Context, name string) error
+}
+
+type Role struct {
+	client postgresql.Client
+}
+
+func NewRole(client postgresql.Client) IRole {
+	return &Role{
+		client: client,
+	}
+}
+
+// CreateRole - создание роли
+func (r *Role) CreateRole(ctx context.Context, role entity.Role) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresCreateRole)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.CreateRoleDb)()
+
+	q := `
+	INSERT INTO roles
+    	(name,description,permissions,level,built_in,created_date)
+    VALUES
+		($1,$2,$3,$4,$5,$6);
+		`
+
+	_, err := r.client.Exec(ctx, q, role.Name, role.Description, permissionsToStrings(role.Permissions), role.Level, role.BuiltIn,
+		role.CreatedDate)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.CreateRoleDb, metrics.FailStatus)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return apperror.ErrRoleExists
+		}
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.CreateRoleDb, metrics.OkStatus)
+	return nil
+}
+
+// GetRoles - получение ролей
+func (r *Role) GetRoles(ctx context.Context) ([]entity.Role, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresGetRoles)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetRolesDb)()
+
+	q := fmt.Sprintf(`
+		SELECT %s
+		FROM roles
+		ORDER BY level DESC, name;
+		`, roleColumns)
+
+	rows, err := r.client.Query(ctx, q)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetRolesDb, metrics.FailStatus)
+		return nil, err
+	}
+
+	defer rows.Close()
+	roles := make([]entity.Role, 0)
+	for rows.Next() {
+		role, errScan := scanRole(rows)
+		if errScan != nil {
+			metrics.IncRequestTotalDB(metrics.GetRolesDb, metrics.FailStatus)
+			return nil, errScan
+		}
+		roles = append(roles, role)
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetRolesDb, metrics.OkStatus)
+	return roles, nil
+}
+
+// GetRoleByName - получение роли по названию
+func (r *Role) GetRoleByName(ctx context.Context, name string) (entity.Role, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresGetRoleByName)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetRoleByNameDb)()
+
+	q := fmt.Sprintf(`
+		SELECT %s
+		FROM roles
+		WHERE name=$1;
+		`, roleColumns)
+
+	role, err := scanRole(r.client.QueryRow(ctx, q, name))
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetRoleByNameDb, metrics.FailStatus)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Role{}, apperror.ErrRoleNotFound
+		}
+		return entity.Role{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetRoleByNameDb, metrics.OkStatus)
+	return role, nil
+}
+
+// UpdateRole - редактирование роли
+func (r *Role) UpdateRole(ctx context.Context, roleUpdate entity.RoleUpdate) (entity.Role, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresUpdateRole)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.UpdateRoleDb)()
+
+	query, args := prepareQueryUpdateRole(roleUpdate)
+	role, err := scanRole(r.client.QueryRow(ctx, query, args...))
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.UpdateRoleDb, metrics.FailStatus)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.Role{}, apperror.ErrRoleNotFound
+		}
+		return entity.Role{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.UpdateRoleDb, metrics.OkStatus)
+	return role, nil
+}
+
+// prepareQueryUpdateRole - подготовка запроса для обновления роли
+func prepareQueryUpdateRole(role entity.RoleUpdate) (string, []interface{}) {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+	if role.Description != nil {
+		setValues = append(setValues, fmt.Sprintf("description=$%d", argId))
+		args = append(args, *role.Description)
+		argId++
+	}
+
+	if role.Permissions != nil {
+		setValues = append(setValues, fmt.Sprintf("permissions=$%d", argId))
+		args = append(args, permissionsToStrings(*role.Permissions))
+		argId++
+	}
+
+	if role.Level != nil {
+		setValues = append(setValues, fmt.Sprintf("level=$%d", argId))
+		args = append(args, *role.Level)
+		argId++
+	}
+
+	setValues = append(setValues, fmt.Sprintf("updated_date=$%d", argId))
+	args = append(args, time.Now().UTC())
+	argId++
+
+	setQuery := strings.Join(setValues, ", ")
+	args = append(args, role.Name)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE name=$%v RETURNING %s;", "roles", setQuery, argId, roleColumns)
+	return query, args
+}
+
+// DeleteRole - удаление роли, встроенные роли и роли, назначенные пользователям, не удаляются
+func (r *Role) DeleteRole(ctx context.Context, name string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresDeleteRole)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.DeleteRoleDb)()
+
+	q := `
+	DELETE FROM roles
+    WHERE name=$1 AND NOT built_in;`
+
+	tag, err := r.client.Exec(ctx, q, name)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.DeleteRoleDb, metrics.FailStatus)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return apperror.ErrRoleInUse
+		}
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.DeleteRoleDb, metrics.OkStatus)
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrRoleNotFound
+	}
+	return nil
+}
+
+// scanRole - чтение роли из строки результата
+func scanRole(row pgx.Row) (entity.Role, error) {
+	var (
+		role        entity.Role
+		permissions []string
+	)
+	err := row.Scan(&role.Name, &role.Description, &permissions, &role.Level, &role.BuiltIn, &role.CreatedDate, &role.UpdatedDate)
+	if err != nil {
+		return entity.Role{}, err
+	}
+
+	role.Permissions = make(entity.Permissions, 0, len(permissions))
+	for _, permission := range permissions {
+		role.Permissions = append(role.Permissions, entity.Permission(permission))
+	}
+
+	return role, nil
+}
+
+// permissionsToStrings - права в виде массива строк для записи в бд
+func permissionsToStrings(permissions entity.Permissions) []string {
+	result := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		result = append(result, string(permission))
+	}
+	return result
+}
@@ -0,0 +1,232 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/postgresql"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+var _ IServiceAccount = &ServiceAccount{}
+
+type IServiceAccount interface {
+	CreateServiceAccount(ctx context.Context, account entity.ServiceAccount) error
+	GetServiceAccountByID(ctx context.Context, id string) (entity.ServiceAccount, error)
+	GetServiceAccounts(ctx context.Context) ([]entity.ServiceAccount, error)
+	UpdateServiceAccount(ctx context.Context, accountUpdate entity.ServiceAccountUpdate) (entity.ServiceAccount, error)
+	UpdateServiceAccountSecret(ctx context.Context, id, secretHash string) error
+	DeleteServiceAccountByID(ctx context.Context, id string) error
+}
+
+type ServiceAccount struct {
+	client postgresql.Client
+}
+
+func NewServiceAccount(client postgresql.Client) IServiceAccount {
+	return &ServiceAccount{
+		client: client,
+	}
+}
+
+// CreateServiceAccount - создание сервисного аккаунта
+func (s *ServiceAccount) CreateServiceAccount(ctx context.Context, account entity.ServiceAccount) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresCreateServiceAccount)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.CreateServiceAccountDb)()
+
+	q := `
+	INSERT INTO service_accounts
+    	(id,name,owner_id,secret_hash,scopes,audiences,created_date)
+    VALUES
+		($1,$2,$3,$4,$5,$6,$7);
+		`
+
+	_, err := s.client.Exec(ctx, q, account.ID, account.Name, account.OwnerID, account.SecretHash, account.Scopes, account.Audiences,
+		account.CreatedDate)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.CreateServiceAccountDb, metrics.FailStatus)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return apperror.ErrUserNotFound
+		}
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.CreateServiceAccountDb, metrics.OkStatus)
+	return nil
+}
+
+// GetServiceAccountByID - получение сервисного аккаунта по идентификатору
+func (s *ServiceAccount) GetServiceAccountByID(ctx context.Context, id string) (entity.ServiceAccount, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresGetServiceAccountByID)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetServiceAccountByIDDb)()
+
+	q := `
+		SELECT id,name,owner_id,secret_hash,scopes,audiences,created_date,updated_date
+		FROM service_accounts
+		WHERE id=$1;
+		`
+
+	var account entity.ServiceAccount
+	err := s.client.QueryRow(ctx, q, id).Scan(&account.ID, &account.Name, &account.OwnerID, &account.SecretHash, &account.Scopes, &account.Audiences,
+		&account.CreatedDate, &account.UpdatedDate)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetServiceAccountByIDDb, metrics.FailStatus)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.ServiceAccount{}, apperror.ErrServiceAccountNotFound
+		}
+		return entity.ServiceAccount{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetServiceAccountByIDDb, metrics.OkStatus)
+	return account, nil
+}
+
+// GetServiceAccounts - получение сервисных аккаунтов
+func (s *ServiceAccount) GetServiceAccounts(ctx context.Context) ([]entity.ServiceAccount, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresGetServiceAccounts)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetServiceAccountsDb)()
+
+	q := `
+		SELECT id,name,owner_id,secret_hash,scopes,audiences,created_date,updated_date
+		FROM service_accounts
+		ORDER BY created_date DESC;
+		`
+
+	rows, err := s.client.Query(ctx, q)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetServiceAccountsDb, metrics.FailStatus)
+		return nil, err
+	}
+
+	defer rows.Close()
+	accounts := make([]entity.ServiceAccount, 0)
+	for rows.Next() {
+		var account entity.ServiceAccount
+		errScan := rows.Scan(&account.ID, &account.Name, &account.OwnerID, &account.SecretHash, &account.Scopes, &account.Audiences,
+			&account.CreatedDate, &account.UpdatedDate)
+		if errScan != nil {
+			metrics.IncRequestTotalDB(metrics.GetServiceAccountsDb, metrics.FailStatus)
+			return nil, errScan
+		}
+		accounts = append(accounts, account)
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetServiceAccountsDb, metrics.OkStatus)
+	return accounts, nil
+}
+
+// UpdateServiceAccount - редактирование сервисного аккаунта
+func (s *ServiceAccount) UpdateServiceAccount(ctx context.Context, accountUpdate entity.ServiceAccountUpdate) (entity.ServiceAccount, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresUpdateServiceAccount)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.UpdateServiceAccountDb)()
+
+	query, args := prepareQueryUpdateServiceAccount(accountUpdate)
+	var account entity.ServiceAccount
+	err := s.client.QueryRow(ctx, query, args...).Scan(&account.ID, &account.Name, &account.OwnerID, &account.SecretHash, &account.Scopes, &account.Audiences,
+		&account.CreatedDate, &account.UpdatedDate)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.UpdateServiceAccountDb, metrics.FailStatus)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.ServiceAccount{}, apperror.ErrServiceAccountNotFound
+		}
+		return entity.ServiceAccount{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.UpdateServiceAccountDb, metrics.OkStatus)
+	return account, nil
+}
+
+// prepareQueryUpdateServiceAccount - подготовка запроса для обновления сервисного аккаунта
+func prepareQueryUpdateServiceAccount(account entity.ServiceAccountUpdate) (string, []interface{}) {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+	if account.Name != nil {
+		setValues = append(setValues, fmt.Sprintf("name=$%d", argId))
+		args = append(args, *account.Name)
+		argId++
+	}
+
+	if account.Scopes != nil {
+		setValues = append(setValues, fmt.Sprintf("scopes=$%d", argId))
+		args = append(args, *account.Scopes)
+		argId++
+	}
+
+	if account.Audiences != nil {
+		setValues = append(setValues, fmt.Sprintf("audiences=$%d", argId))
+		args = append(args, *account.Audiences)
+		argId++
+	}
+
+	setValues = append(setValues, fmt.Sprintf("updated_date=$%d", argId))
+	args = append(args, time.Now().UTC())
+	argId++
+
+	setQuery := strings.Join(setValues, ", ")
+	args = append(args, account.ID)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id=$%v RETURNING id,name,owner_id,secret_hash,scopes,audiences,created_date,updated_date;", "service_accounts", setQuery, argId)
+	return query, args
+}
+
+// UpdateServiceAccountSecret - замена секрета сервисного аккаунта
+func (s *ServiceAccount) UpdateServiceAccountSecret(ctx context.Context, id, secretHash string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresUpdateServiceAccountSecret)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.UpdateServiceAccountSecretDb)()
+
+	q := `
+		UPDATE service_accounts SET secret_hash=$1, updated_date=$2
+		WHERE id=$3;
+		`
+
+	tag, err := s.client.Exec(ctx, q, secretHash, time.Now().UTC(), id)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.UpdateServiceAccountSecretDb, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.UpdateServiceAccountSecretDb, metrics.OkStatus)
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrServiceAccountNotFound
+	}
+	return nil
+}
+
+// DeleteServiceAccountByID - удаление сервисного аккаунта
+func (s *ServiceAccount) DeleteServiceAccountByID(ctx context.Context, id string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresDeleteServiceAccountByID)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.DeleteServiceAccountByIDDb)()
+
+	q := `
+	DELETE FROM service_accounts
+    WHERE id=$1;`
+
+	tag, err := s.client.Exec(ctx, q, id)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.DeleteServiceAccountByIDDb, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.DeleteServiceAccountByIDDb, metrics.OkStatus)
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrServiceAccountNotFound
+	}
+	return nil
+}
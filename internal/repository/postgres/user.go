@@ -7,8 +7,10 @@ import (
 	"github.com/GermanBogatov/auth-service/internal/common/metrics"
 	"github.com/GermanBogatov/auth-service/internal/config"
 	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
 	"github.com/GermanBogatov/auth-service/pkg/postgresql"
 	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/google/uuid"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -22,20 +24,26 @@ var _ IUser = &User{}
 type IUser interface {
 	CreateUser(ctx context.Context, user entity.User) error
 	GetUserByID(ctx context.Context, id string) (entity.User, error)
-	GetUserByEmailAndPassword(ctx context.Context, email, password string) (entity.User, error)
+	UpdateUserPasswordHash(ctx context.Context, id, currentHash, newHash string) error
+	GetUserByEmail(ctx context.Context, email string) (entity.User, error)
 	DeleteUserByID(ctx context.Context, id string) error
 	UpdateUserByID(ctx context.Context, userUpdate entity.UserUpdate) (entity.User, error)
 	GetUsers(ctx context.Context, filter entity.Filter) ([]entity.User, error)
-	UpdatePrivateUserByID(ctx context.Context, userUpdate entity.UserUpdatePrivate) (entity.User, error)
+	UpdatePrivateUserByID(ctx context.Context, userUpdate entity.UserUpdatePrivate, roleChange *entity.RoleChange) (entity.User, error)
+	GetPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error)
+	VerifyEmail(ctx context.Context, id, email string) error
 }
 
 type User struct {
-	client postgresql.Client
+	client              postgresql.Client
+	passwordHistorySize int
 }
 
-func NewUser(client postgresql.Client) IUser {
+// NewUser - репозиторий пользователей, при смене пароля хранится passwordHistorySize предыдущих хэшей
+func NewUser(client postgresql.Client, passwordHistorySize int) IUser {
 	return &User{
-		client: client,
+		client:              client,
+		passwordHistorySize: passwordHistorySize,
 	}
 }
 
@@ -47,12 +55,13 @@ func (u *User) CreateUser(ctx context.Context, user entity.User) error {
 
 	q := `
 	INSERT INTO users 
-    	(id,name,surname,email,password,role,created_date) 
+    	(id,name,surname,email,password,role,email_verified,created_date) 
     VALUES 
-		($1,$2,$3,$4,$5,$6,$7);
+		($1,$2,$3,$4,$5,$6,$7,$8);
 		`
 
-	_, err := u.client.Exec(ctx, q, user.ID, user.Name, user.Surname, user.Email, user.Password, user.Role, user.CreatedDate)
+	_, err := u.client.Exec(ctx, q, user.ID, user.Name, user.Surname, user.Email, user.Password, user.Role, user.EmailVerified,
+		user.CreatedDate)
 	if err != nil {
 		metrics.IncRequestTotalDB(metrics.CreateUserDb, metrics.FailStatus)
 		var pgErr *pgconn.PgError
@@ -68,29 +77,76 @@ func (u *User) CreateUser(ctx context.Context, user entity.User) error {
 	return nil
 }
 
-// GetUserByEmailAndPassword - получение пользователя по емайл и паролю
-func (u *User) GetUserByEmailAndPassword(ctx context.Context, email, password string) (entity.User, error) {
-	_, span := tracer.StartTrace(ctx, config.SpanPostgresGetUserByEmailAndPassword)
+// UpdateUserPasswordHash - замена хэша пароля на пересчитанный. Хэш меняется, только если пароль не сменили
+// параллельно, иначе обновление молча пропускается
+func (u *User) UpdateUserPasswordHash(ctx context.Context, id, currentHash, newHash string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresUpdateUserPasswordHash)
 	defer span.End()
-	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetUserByEmailAndPasswordDb)()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.UpdateUserPasswordHashDb)()
 
 	q := `
-		SELECT id,name,surname,email,password,role,created_date,updated_date 
+		UPDATE users SET password=$1
+		WHERE id=$2 AND password=$3;
+		`
+
+	_, err := u.client.Exec(ctx, q, newHash, id, currentHash)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.UpdateUserPasswordHashDb, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.UpdateUserPasswordHashDb, metrics.OkStatus)
+	return nil
+}
+
+// VerifyEmail - подтверждение email пользователя. Подтверждается только email, на который отправлялось письмо:
+// если пользователь успел его сменить, возвращается ErrUserNotFound
+func (u *User) VerifyEmail(ctx context.Context, id, email string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresVerifyEmail)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.VerifyEmailDb)()
+
+	q := `
+		UPDATE users SET email_verified=TRUE, updated_date=$1
+		WHERE id=$2 AND email=$3;
+		`
+
+	tag, err := u.client.Exec(ctx, q, time.Now().UTC(), id, email)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.VerifyEmailDb, metrics.FailStatus)
+		return err
+	}
+
+	metrics.IncRequestTotalDB(metrics.VerifyEmailDb, metrics.OkStatus)
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrUserNotFound
+	}
+	return nil
+}
+
+// GetUserByEmail - получение пользователя по емайл
+func (u *User) GetUserByEmail(ctx context.Context, email string) (entity.User, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresGetUserByEmail)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetUserByEmailDb)()
+
+	q := `
+		SELECT id,name,surname,email,password,role,email_verified,created_date,updated_date 
 		FROM users
-		WHERE email=$1 AND password=$2;	
+		WHERE email=$1;	
 		`
 
 	var user entity.User
-	err := u.client.QueryRow(ctx, q, email, password).Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password, &user.Role, &user.CreatedDate, &user.UpdatedDate)
+	err := u.client.QueryRow(ctx, q, email).Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password, &user.Role, &user.EmailVerified, &user.CreatedDate, &user.UpdatedDate)
 	if err != nil {
-		metrics.IncRequestTotalDB(metrics.GetUserByEmailAndPasswordDb, metrics.FailStatus)
+		metrics.IncRequestTotalDB(metrics.GetUserByEmailDb, metrics.FailStatus)
 		if errors.Is(err, pgx.ErrNoRows) {
 			return entity.User{}, apperror.ErrUserNotFound
 		}
 		return entity.User{}, err
 	}
 
-	metrics.IncRequestTotalDB(metrics.GetUserByEmailAndPasswordDb, metrics.OkStatus)
+	metrics.IncRequestTotalDB(metrics.GetUserByEmailDb, metrics.OkStatus)
 	return user, nil
 }
 
@@ -101,13 +157,13 @@ func (u *User) GetUserByID(ctx context.Context, id string) (entity.User, error)
 	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetUserByIDDb)()
 
 	q := `
-		SELECT id,name,surname,email,password,role,created_date,updated_date 
+		SELECT id,name,surname,email,password,role,email_verified,created_date,updated_date 
 		FROM users
 		WHERE id=$1;	
 		`
 
 	var user entity.User
-	err := u.client.QueryRow(ctx, q, id).Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password, &user.Role, &user.CreatedDate, &user.UpdatedDate)
+	err := u.client.QueryRow(ctx, q, id).Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password, &user.Role, &user.EmailVerified, &user.CreatedDate, &user.UpdatedDate)
 	if err != nil {
 		metrics.IncRequestTotalDB(metrics.GetUserByIDDb, metrics.FailStatus)
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -120,16 +176,13 @@ func (u *User) GetUserByID(ctx context.Context, id string) (entity.User, error)
 	return user, nil
 }
 
+// DeleteUserByID - удаление пользователя по идентификатору, последний суперадминистратор не удаляется
 func (u *User) DeleteUserByID(ctx context.Context, id string) error {
 	_, span := tracer.StartTrace(ctx, config.SpanPostgresDeleteUserByID)
 	defer span.End()
 	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.DeleteUserByIDDb)()
 
-	q := `
-	DELETE FROM users 
-    WHERE id=$1;`
-
-	_, err := u.client.Exec(ctx, q, id)
+	err := u.deleteUserByID(ctx, id)
 	if err != nil {
 		metrics.IncRequestTotalDB(metrics.DeleteUserByIDDb, metrics.FailStatus)
 		return err
@@ -139,15 +192,54 @@ func (u *User) DeleteUserByID(ctx context.Context, id string) error {
 	return nil
 }
 
+func (u *User) deleteUserByID(ctx context.Context, id string) error {
+	tx, err := u.client.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "begin")
+	}
+
+	defer func() {
+		errRollback := tx.Rollback(ctx)
+		if errRollback != nil && !errors.Is(errRollback, pgx.ErrTxClosed) {
+			logging.Errorf("error rollback delete user: %s", errRollback)
+		}
+	}()
+
+	err = checkLastSuperAdmin(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+	DELETE FROM users 
+    WHERE id=$1;`, id)
+	if err != nil {
+		return errors.Wrap(err, "delete user")
+	}
+
+	return tx.Commit(ctx)
+}
+
 // UpdateUserByID - редактирование пользователя
 func (u *User) UpdateUserByID(ctx context.Context, userUpdate entity.UserUpdate) (entity.User, error) {
 	_, span := tracer.StartTrace(ctx, config.SpanPostgresUpdateUserByID)
 	defer span.End()
 	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.UpdateUserByIDDb)()
 
+	if userUpdate.Password != nil {
+		user, err := u.updateUserWithPasswordByID(ctx, userUpdate)
+		if err != nil {
+			metrics.IncRequestTotalDB(metrics.UpdateUserByIDDb, metrics.FailStatus)
+			return entity.User{}, err
+		}
+
+		metrics.IncRequestTotalDB(metrics.UpdateUserByIDDb, metrics.OkStatus)
+		return user, nil
+	}
+
 	query, args := prepareQueryUpdate(userUpdate)
 	var user entity.User
-	err := u.client.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password, &user.Role, &user.CreatedDate, &user.UpdatedDate)
+	err := u.client.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password, &user.Role, &user.EmailVerified, &user.CreatedDate, &user.UpdatedDate)
 	if err != nil {
 		metrics.IncRequestTotalDB(metrics.UpdateUserByIDDb, metrics.FailStatus)
 		var pgErr *pgconn.PgError
@@ -164,6 +256,116 @@ func (u *User) UpdateUserByID(ctx context.Context, userUpdate entity.UserUpdate)
 	return user, nil
 }
 
+// updateUserWithPasswordByID - редактирование со сменой пароля: прежний хэш в той же транзакции уходит в историю,
+// история обрезается до passwordHistorySize последних хэшей, персональные токены пользователя удаляются
+func (u *User) updateUserWithPasswordByID(ctx context.Context, userUpdate entity.UserUpdate) (entity.User, error) {
+	tx, err := u.client.Begin(ctx)
+	if err != nil {
+		return entity.User{}, errors.Wrap(err, "begin")
+	}
+
+	defer func() {
+		errRollback := tx.Rollback(ctx)
+		if errRollback != nil && !errors.Is(errRollback, pgx.ErrTxClosed) {
+			logging.Errorf("error rollback update user password: %s", errRollback)
+		}
+	}()
+
+	var previousHash string
+	err = tx.QueryRow(ctx, `
+		SELECT password
+		FROM users
+		WHERE id=$1
+		FOR UPDATE;`, userUpdate.ID).Scan(&previousHash)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.User{}, apperror.ErrUserNotFound
+		}
+		return entity.User{}, errors.Wrap(err, "select password")
+	}
+
+	query, args := prepareQueryUpdate(userUpdate)
+	var user entity.User
+	err = tx.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password, &user.Role, &user.EmailVerified, &user.CreatedDate, &user.UpdatedDate)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return entity.User{}, apperror.ErrUserIsExistWithEmail
+		}
+		return entity.User{}, errors.Wrap(err, "update user")
+	}
+
+	if u.passwordHistorySize > 0 && previousHash != "" {
+		_, err = tx.Exec(ctx, `
+		INSERT INTO password_history
+			(id,user_id,password_hash,created_date)
+		VALUES
+			($1,$2,$3,$4);`,
+			uuid.New().String(), user.ID, previousHash, time.Now().UTC())
+		if err != nil {
+			return entity.User{}, errors.Wrap(err, "insert password history")
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM password_history
+		WHERE user_id=$1 AND id NOT IN (
+			SELECT id
+			FROM password_history
+			WHERE user_id=$1
+			ORDER BY created_date DESC
+			LIMIT $2
+		);`, user.ID, u.passwordHistorySize)
+	if err != nil {
+		return entity.User{}, errors.Wrap(err, "trim password history")
+	}
+
+	// персональные токены выпущены под старым паролем и не должны переживать его смену
+	_, err = tx.Exec(ctx, `
+		DELETE FROM personal_tokens
+		WHERE user_id=$1;`, user.ID)
+	if err != nil {
+		return entity.User{}, errors.Wrap(err, "delete personal tokens")
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return entity.User{}, errors.Wrap(err, "commit")
+	}
+
+	return user, nil
+}
+
+// GetPasswordHistory - предыдущие хэши пароля пользователя, начиная с последнего
+func (u *User) GetPasswordHistory(ctx context.Context, userID string, limit int) ([]string, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanPostgresGetPasswordHistory)
+	defer span.End()
+	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetPasswordHistoryDb)()
+
+	q := `
+		SELECT password_hash
+		FROM password_history
+		WHERE user_id=$1
+		ORDER BY created_date DESC
+		LIMIT $2;
+		`
+
+	rows, err := u.client.Query(ctx, q, userID, limit)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetPasswordHistoryDb, metrics.FailStatus)
+		return nil, err
+	}
+
+	hashes, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.GetPasswordHistoryDb, metrics.FailStatus)
+		return nil, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.GetPasswordHistoryDb, metrics.OkStatus)
+	return hashes, nil
+}
+
 // prepareQueryUpdate - подготовка запроса для обновления пользователя
 func prepareQueryUpdate(user entity.UserUpdate) (string, []interface{}) {
 	setValues := make([]string, 0)
@@ -182,7 +384,8 @@ func prepareQueryUpdate(user entity.UserUpdate) (string, []interface{}) {
 	}
 
 	if user.Email != nil {
-		setValues = append(setValues, fmt.Sprintf("email=$%d", argId))
+		// подтверждение сохраняется, только если email не изменился: правая часть видит значения до обновления
+		setValues = append(setValues, fmt.Sprintf("email=$%d", argId), fmt.Sprintf("email_verified=(email_verified AND email=$%d)", argId))
 		args = append(args, *user.Email)
 		argId++
 	}
@@ -200,7 +403,7 @@ func prepareQueryUpdate(user entity.UserUpdate) (string, []interface{}) {
 	setQuery := strings.Join(setValues, ", ")
 	args = append(args, user.ID)
 
-	query := fmt.Sprintf("UPDATE %s SET %s WHERE id=$%v RETURNING id,name,surname,email,password,role,created_date,updated_date;", "users", setQuery, argId)
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id=$%v RETURNING id,name,surname,email,password,role,email_verified,created_date,updated_date;", "users", setQuery, argId)
 	return query, args
 }
 
@@ -209,23 +412,27 @@ func (u *User) GetUsers(ctx context.Context, filter entity.Filter) ([]entity.Use
 	_, span := tracer.StartTrace(ctx, config.SpanPostgresGetUsers)
 	defer span.End()
 	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.GetUsersDb)()
-	var q string
+	var (
+		q    string
+		args []interface{}
+	)
 	if filter.Role == nil {
 		q = fmt.Sprintf(`
-			SELECT id,name,surname,email,password,role,created_date,updated_date
+			SELECT id,name,surname,email,password,role,email_verified,created_date,updated_date
 			FROM users
 			ORDER BY %s %s
 			OFFSET %v LIMIT %v;`, filter.Order, filter.Sort, filter.Offset, filter.Limit)
 	} else {
 		q = fmt.Sprintf(`
-			SELECT id,name,surname,email,password,role,created_date,updated_date
+			SELECT id,name,surname,email,password,role,email_verified,created_date,updated_date
 			FROM users
-			WHERE role = '%s'
+			WHERE role = $1
 			ORDER BY %s %s
-			OFFSET %v LIMIT %v;`, *filter.Role, filter.Order, filter.Sort, filter.Offset, filter.Limit)
+			OFFSET %v LIMIT %v;`, filter.Order, filter.Sort, filter.Offset, filter.Limit)
+		args = append(args, string(*filter.Role))
 	}
 
-	rows, err := u.client.Query(ctx, q)
+	rows, err := u.client.Query(ctx, q, args...)
 	if err != nil {
 		metrics.IncRequestTotalDB(metrics.GetUsersDb, metrics.FailStatus)
 		return nil, err
@@ -235,7 +442,7 @@ func (u *User) GetUsers(ctx context.Context, filter entity.Filter) ([]entity.Use
 	users := make([]entity.User, 0, filter.Limit)
 	for rows.Next() {
 		var user entity.User
-		errScan := rows.Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password, &user.Role, &user.CreatedDate, &user.UpdatedDate)
+		errScan := rows.Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password, &user.Role, &user.EmailVerified, &user.CreatedDate, &user.UpdatedDate)
 		if errScan != nil {
 			metrics.IncRequestTotalDB(metrics.GetUsersDb, metrics.FailStatus)
 			return nil, err
@@ -247,31 +454,102 @@ func (u *User) GetUsers(ctx context.Context, filter entity.Filter) ([]entity.Use
 	return users, nil
 }
 
-// UpdatePrivateUserByID - приватное редактирование пользователя
-func (u *User) UpdatePrivateUserByID(ctx context.Context, userUpdate entity.UserUpdatePrivate) (entity.User, error) {
+// UpdatePrivateUserByID - приватное редактирование пользователя. Смена роли пишется в аудит в той же транзакции,
+// последний суперадминистратор не может лишиться роли
+func (u *User) UpdatePrivateUserByID(ctx context.Context, userUpdate entity.UserUpdatePrivate, roleChange *entity.RoleChange) (entity.User, error) {
 	_, span := tracer.StartTrace(ctx, config.SpanPostgresUpdatePrivateUserByID)
 	defer span.End()
 	defer metrics.ObserveRequestDurationPerMethodDB(metrics.Postgres, metrics.UpdatePrivateUserByIDDb)()
 
+	user, err := u.updatePrivateUserByID(ctx, userUpdate, roleChange)
+	if err != nil {
+		metrics.IncRequestTotalDB(metrics.UpdatePrivateUserByIDDb, metrics.FailStatus)
+		return entity.User{}, err
+	}
+
+	metrics.IncRequestTotalDB(metrics.UpdatePrivateUserByIDDb, metrics.OkStatus)
+	return user, nil
+}
+
+func (u *User) updatePrivateUserByID(ctx context.Context, userUpdate entity.UserUpdatePrivate, roleChange *entity.RoleChange) (entity.User, error) {
+	tx, err := u.client.Begin(ctx)
+	if err != nil {
+		return entity.User{}, errors.Wrap(err, "begin")
+	}
+
+	defer func() {
+		errRollback := tx.Rollback(ctx)
+		if errRollback != nil && !errors.Is(errRollback, pgx.ErrTxClosed) {
+			logging.Errorf("error rollback update private user: %s", errRollback)
+		}
+	}()
+
+	if roleChange != nil && roleChange.NewRole != entity.RoleSuperAdmin {
+		err = checkLastSuperAdmin(ctx, tx, userUpdate.ID)
+		if err != nil {
+			return entity.User{}, err
+		}
+	}
+
 	query, args := prepareQueryUpdatePrivate(userUpdate)
 	var user entity.User
-	err := u.client.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password, &user.Role, &user.CreatedDate, &user.UpdatedDate)
+	err = tx.QueryRow(ctx, query, args...).Scan(&user.ID, &user.Name, &user.Surname, &user.Email, &user.Password, &user.Role, &user.EmailVerified, &user.CreatedDate, &user.UpdatedDate)
 	if err != nil {
-		metrics.IncRequestTotalDB(metrics.UpdateUserByIDDb, metrics.FailStatus)
 		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			if pgErr.Code == pgerrcode.UniqueViolation {
-				return entity.User{}, apperror.ErrUserIsExistWithEmail
-			}
-			return entity.User{}, err
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return entity.User{}, apperror.ErrUserIsExistWithEmail
 		}
-		return entity.User{}, err
+		if errors.Is(err, pgx.ErrNoRows) {
+			return entity.User{}, apperror.ErrUserNotFound
+		}
+		return entity.User{}, errors.Wrap(err, "update user")
+	}
+
+	if roleChange != nil {
+		_, err = tx.Exec(ctx, `
+		INSERT INTO user_role_changes
+			(id,user_id,actor_id,old_role,new_role,reason,created_date)
+		VALUES
+			($1,$2,$3,$4,$5,$6,$7);`,
+			roleChange.ID, roleChange.UserID, roleChange.ActorID, roleChange.OldRole, roleChange.NewRole, roleChange.Reason,
+			roleChange.CreatedDate)
+		if err != nil {
+			return entity.User{}, errors.Wrap(err, "insert role change")
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return entity.User{}, errors.Wrap(err, "commit")
 	}
 
-	metrics.IncRequestTotalDB(metrics.UpdateUserByIDDb, metrics.OkStatus)
 	return user, nil
 }
 
+// checkLastSuperAdmin - пользователь не последний суперадминистратор. Суперадминистраторы блокируются до конца
+// транзакции, поэтому параллельные понижения не могут оставить сервис без суперадминистратора
+func checkLastSuperAdmin(ctx context.Context, tx pgx.Tx, userID string) error {
+	rows, err := tx.Query(ctx, `
+		SELECT id
+		FROM users
+		WHERE role=$1
+		FOR UPDATE;`, entity.RoleSuperAdmin)
+	if err != nil {
+		return errors.Wrap(err, "select super-admins")
+	}
+
+	superAdminIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return errors.Wrap(err, "collect super-admins")
+	}
+
+	if entity.IsLastSuperAdmin(superAdminIDs, userID) {
+		return apperror.ErrLastSuperAdmin
+	}
+
+	return nil
+}
+
 // prepareQueryUpdate - подготовка запроса для обновления пользователя
 func prepareQueryUpdatePrivate(user entity.UserUpdatePrivate) (string, []interface{}) {
 	setValues := make([]string, 0)
@@ -290,7 +568,8 @@ func prepareQueryUpdatePrivate(user entity.UserUpdatePrivate) (string, []interfa
 	}
 
 	if user.Email != nil {
-		setValues = append(setValues, fmt.Sprintf("email=$%d", argId))
+		// подтверждение сохраняется, только если email не изменился: правая часть видит значения до обновления
+		setValues = append(setValues, fmt.Sprintf("email=$%d", argId), fmt.Sprintf("email_verified=(email_verified AND email=$%d)", argId))
 		args = append(args, *user.Email)
 		argId++
 	}
@@ -308,6 +587,6 @@ func prepareQueryUpdatePrivate(user entity.UserUpdatePrivate) (string, []interfa
 	setQuery := strings.Join(setValues, ", ")
 	args = append(args, user.ID)
 
-	query := fmt.Sprintf("UPDATE %s SET %s WHERE id=$%v RETURNING id,name,surname,email,password,role,created_date,updated_date;", "users", setQuery, argId)
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id=$%v RETURNING id,name,surname,email,password,role,email_verified,created_date,updated_date;", "users", setQuery, argId)
 	return query, args
 }
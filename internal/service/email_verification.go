@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/repository/cache"
+	"github.com/GermanBogatov/auth-service/internal/repository/postgres"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/GermanBogatov/auth-service/pkg/mailer"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// emailVerificationMailTimeout - время на выдачу токена и отправку письма в фоне
+const emailVerificationMailTimeout = time.Minute
+
+var _ IEmailVerification = &EmailVerification{}
+
+type IEmailVerification interface {
+	SendVerification(ctx context.Context, user entity.User)
+	ResendVerification(ctx context.Context, email string)
+	VerifyEmail(ctx context.Context, token string) error
+}
+
+type EmailVerification struct {
+	userRepo       postgres.IUser
+	cache          cache.ICache
+	mailer         mailer.Mailer
+	tokenTTL       time.Duration
+	resendInterval time.Duration
+	linkURL        string
+}
+
+// NewEmailVerification - подтверждение email по одноразовым токенам из письма. linkURL - страница подтверждения,
+// токен добавляется к ней параметром token
+func NewEmailVerification(userRepo postgres.IUser, cache cache.ICache, mailer mailer.Mailer, tokenTTLSec, resendIntervalSec int,
+	linkURL string) IEmailVerification {
+	return &EmailVerification{
+		userRepo:       userRepo,
+		cache:          cache,
+		mailer:         mailer,
+		tokenTTL:       time.Duration(tokenTTLSec) * time.Second,
+		resendInterval: time.Duration(resendIntervalSec) * time.Second,
+		linkURL:        linkURL,
+	}
+}
+
+// SendVerification - отправка письма подтверждения на текущий email пользователя после регистрации или смены email.
+// Письмо отправляется в фоне, ошибки только логируются: пользователь может запросить письмо повторно
+func (e *EmailVerification) SendVerification(ctx context.Context, user entity.User) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceSendEmailVerification)
+	defer span.End()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), emailVerificationMailTimeout)
+		defer cancel()
+
+		// отметка об отправке ограничивает повторную отправку сразу после регистрации
+		_, err := e.cache.SetEmailVerificationSent(ctx, user.ID, e.resendInterval)
+		if err != nil {
+			logging.Errorf("error set email verification sent of user [%s]: %v", user.ID, err)
+		}
+
+		err = e.sendVerificationToken(ctx, user)
+		if err != nil {
+			logging.Errorf("error send email verification token: %v", err)
+		}
+	}()
+}
+
+// ResendVerification - повторная отправка письма подтверждения не чаще интервала. Результат клиенту не сообщается,
+// чтобы по ответу нельзя было узнать, зарегистрирован ли email и подтвержден ли он
+func (e *EmailVerification) ResendVerification(ctx context.Context, email string) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceResendEmailVerification)
+	defer span.End()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), emailVerificationMailTimeout)
+		defer cancel()
+
+		err := e.resendVerificationToken(ctx, email)
+		if err != nil && !errors.Is(err, apperror.ErrUserNotFound) {
+			logging.Errorf("error resend email verification token: %v", err)
+		}
+	}()
+}
+
+// resendVerificationToken - повторная отправка письма пользователю с неподтвержденным email
+func (e *EmailVerification) resendVerificationToken(ctx context.Context, email string) error {
+	user, err := e.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return errors.Wrap(err, "userRepo.GetUserByEmail")
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	ok, err := e.cache.SetEmailVerificationSent(ctx, user.ID, e.resendInterval)
+	if err != nil {
+		return errors.Wrap(err, "cache.SetEmailVerificationSent")
+	}
+	if !ok {
+		logging.Infof("email verification for user [%s] was sent recently, resend skipped", user.ID)
+		return nil
+	}
+
+	return e.sendVerificationToken(ctx, user)
+}
+
+// sendVerificationToken - выдача токена подтверждения и отправка письма со ссылкой
+func (e *EmailVerification) sendVerificationToken(ctx context.Context, user entity.User) error {
+	token, err := helpers.GenerateSecret()
+	if err != nil {
+		return err
+	}
+
+	err = e.cache.SetEmailVerification(ctx, helpers.HashSecret(token), entity.EmailVerification{
+		UserID: user.ID,
+		Email:  user.Email,
+	}, e.tokenTTL)
+	if err != nil {
+		return errors.Wrap(err, "cache.SetEmailVerification")
+	}
+
+	link, err := helpers.AddQueryParam(e.linkURL, "token", token)
+	if err != nil {
+		return err
+	}
+
+	err = e.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Подтверждение email",
+		Text: fmt.Sprintf("Для подтверждения email перейдите по ссылке: %s\n\nСсылка действует %d ч. Если вы не регистрировались, проигнорируйте это письмо.",
+			link, int(e.tokenTTL.Hours())),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "send mail to user [%s]", user.ID)
+	}
+
+	return nil
+}
+
+// VerifyEmail - подтверждение email по токену. Токен гасится сразу и подтверждает только тот email,
+// на который было отправлено письмо
+func (e *EmailVerification) VerifyEmail(ctx context.Context, token string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceVerifyEmail)
+	defer span.End()
+
+	verification, err := e.cache.ConsumeEmailVerification(ctx, helpers.HashSecret(token))
+	if err != nil {
+		if errors.Is(err, apperror.ErrRedisNil) {
+			return apperror.ErrInvalidEmailVerificationToken
+		}
+		return errors.Wrap(err, "cache.ConsumeEmailVerification")
+	}
+
+	err = e.userRepo.VerifyEmail(ctx, verification.UserID, verification.Email)
+	if err != nil {
+		if errors.Is(err, apperror.ErrUserNotFound) {
+			return errors.Wrap(apperror.ErrInvalidEmailVerificationToken, "email changed after token was issued")
+		}
+		return errors.Wrap(err, "userRepo.VerifyEmail")
+	}
+
+	// пользователь в кэше хранится вместе с признаком подтверждения, по которому выпускаются токены
+	err = e.cache.Delete(ctx, verification.UserID)
+	if err != nil {
+		logging.Errorf("error delete cached user [%s]: %v", verification.UserID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,575 @@
+package service
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/repository/cache"
+	"github.com/GermanBogatov/auth-service/internal/repository/postgres"
+	"github.com/GermanBogatov/auth-service/pkg/jwks"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	err := logging.InitLogging(&logging.Config{Output: io.Discard, SystemName: "test", Env: "test"})
+	if err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+// fakeCache - кэш в памяти для тестов сервисов. Не нужные тестам методы не реализованы: вызов встроенного
+// интерфейса паникует
+type fakeCache struct {
+	cache.ICache
+
+	mu             sync.Mutex
+	users          map[string]entity.User
+	refreshTokens  map[string]entity.RefreshToken
+	rotatedTokens  map[string]entity.RotatedRefreshToken
+	sessions       map[string]entity.Session
+	revokedBefore  map[string]entity.TokenRevocation
+	signInFailures map[string]int64
+	signInLocks    map[string]entity.SignInLock
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{
+		users:          make(map[string]entity.User),
+		refreshTokens:  make(map[string]entity.RefreshToken),
+		rotatedTokens:  make(map[string]entity.RotatedRefreshToken),
+		sessions:       make(map[string]entity.Session),
+		revokedBefore:  make(map[string]entity.TokenRevocation),
+		signInFailures: make(map[string]int64),
+		signInLocks:    make(map[string]entity.SignInLock),
+	}
+}
+
+func (c *fakeCache) GetUser(_ context.Context, key string) (entity.User, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	user, ok := c.users[key]
+	if !ok {
+		return entity.User{}, apperror.ErrRedisNil
+	}
+	return user, nil
+}
+
+func (c *fakeCache) SetUser(_ context.Context, key string, user entity.User) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.users[key] = user
+	return nil
+}
+
+func (c *fakeCache) SetRefreshToken(_ context.Context, key string, token entity.RefreshToken) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.refreshTokens[key] = token
+	return nil
+}
+
+func (c *fakeCache) GetRefreshToken(_ context.Context, key string) (entity.RefreshToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	token, ok := c.refreshTokens[key]
+	if !ok {
+		return entity.RefreshToken{}, apperror.ErrRedisNil
+	}
+	return token, nil
+}
+
+func (c *fakeCache) RotateRefreshToken(_ context.Context, key string, rotated entity.RotatedRefreshToken) (entity.RefreshToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	token, ok := c.refreshTokens[key]
+	if !ok {
+		return entity.RefreshToken{}, apperror.ErrRedisNil
+	}
+	delete(c.refreshTokens, key)
+	rotated.RefreshToken = token
+	c.rotatedTokens[key] = rotated
+	return token, nil
+}
+
+func (c *fakeCache) GetRotatedRefreshToken(_ context.Context, key string) (entity.RotatedRefreshToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rotated, ok := c.rotatedTokens[key]
+	if !ok {
+		return entity.RotatedRefreshToken{}, apperror.ErrRedisNil
+	}
+	return rotated, nil
+}
+
+func (c *fakeCache) DeleteRefreshFamily(_ context.Context, familyID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.sessions, familyID)
+	return nil
+}
+
+func (c *fakeCache) DeleteUserRefreshFamilies(_ context.Context, userID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, session := range c.sessions {
+		if session.UserID == userID {
+			delete(c.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (c *fakeCache) SetSession(_ context.Context, session entity.Session) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sessions[session.ID] = session
+	return nil
+}
+
+func (c *fakeCache) UpdateSession(_ context.Context, session entity.Session) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.sessions[session.ID]; !ok {
+		return false, nil
+	}
+	c.sessions[session.ID] = session
+	return true, nil
+}
+
+func (c *fakeCache) GetSession(_ context.Context, sessionID string) (entity.Session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	session, ok := c.sessions[sessionID]
+	if !ok {
+		return entity.Session{}, apperror.ErrRedisNil
+	}
+	return session, nil
+}
+
+func (c *fakeCache) GetUserSessions(_ context.Context, userID string) ([]entity.Session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sessions := make([]entity.Session, 0)
+	for _, session := range c.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (c *fakeCache) SetUserTokensRevokedBefore(_ context.Context, userID string, revokedBefore time.Time,
+	exceptSessionID string, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// кэш хранит момент отзыва в микросекундах
+	revokedBefore = revokedBefore.Truncate(time.Microsecond)
+	c.revokedBefore[userID] = entity.TokenRevocation{RevokedBefore: &revokedBefore, RevokedBeforeExceptSessionID: exceptSessionID}
+	return nil
+}
+
+func (c *fakeCache) GetAccessTokenRevocation(_ context.Context, _, userID, familyID string) (entity.TokenRevocation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	revocation := c.revokedBefore[userID]
+	if familyID != "" {
+		_, ok := c.sessions[familyID]
+		revocation.FamilyRevoked = !ok
+	}
+	return revocation, nil
+}
+
+func (c *fakeCache) IncrSignInFailures(_ context.Context, subject string, _ time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.signInFailures[subject]++
+	return c.signInFailures[subject], nil
+}
+
+func (c *fakeCache) SetSignInLock(_ context.Context, lock entity.SignInLock) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.signInLocks[lock.Kind+":"+lock.Subject] = lock
+	return nil
+}
+
+func (c *fakeCache) GetSignInLocks(_ context.Context, subjects ...string) ([]entity.SignInLock, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	locks := make([]entity.SignInLock, 0)
+	for _, subject := range subjects {
+		for _, kind := range []string{entity.SignInLockLockout, entity.SignInLockDelay} {
+			if lock, ok := c.signInLocks[kind+":"+subject]; ok {
+				locks = append(locks, lock)
+			}
+		}
+	}
+	return locks, nil
+}
+
+func (c *fakeCache) DeleteSignInFailures(_ context.Context, subject string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.signInFailures, subject)
+	delete(c.signInLocks, entity.SignInLockLockout+":"+subject)
+	delete(c.signInLocks, entity.SignInLockDelay+":"+subject)
+	return nil
+}
+
+// expireSignInDelays - истечение задержек входа, блокировки остаются
+func (c *fakeCache) expireSignInDelays() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, lock := range c.signInLocks {
+		if lock.Kind == entity.SignInLockDelay {
+			delete(c.signInLocks, key)
+		}
+	}
+}
+
+// fakeKeyRing - один ключ подписи, созданный при старте теста
+type fakeKeyRing struct {
+	IKeyRing
+
+	key entity.SigningKey
+}
+
+func newFakeKeyRing(t *testing.T) *fakeKeyRing {
+	t.Helper()
+
+	privateKey, err := jwks.GenerateKey(jwks.AlgES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &fakeKeyRing{key: entity.SigningKey{ID: "test-key", Algorithm: jwks.AlgES256, PrivateKey: privateKey}}
+}
+
+func (k *fakeKeyRing) GetSigningKey(_ context.Context) (entity.SigningKey, error) {
+	return k.key, nil
+}
+
+func (k *fakeKeyRing) GetVerificationKey(_ context.Context, kid string) (entity.SigningKey, error) {
+	if kid != k.key.ID {
+		return entity.SigningKey{}, apperror.ErrUnknownKeyID
+	}
+	return k.key, nil
+}
+
+// fakeKeyRepo - ключи подписи в памяти с семантикой ротации хранилища
+type fakeKeyRepo struct {
+	postgres.IKey
+
+	mu   sync.Mutex
+	keys []entity.SigningKey
+}
+
+func (r *fakeKeyRepo) CreateKey(_ context.Context, key entity.SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *fakeKeyRepo) GetKeys(_ context.Context) ([]entity.SigningKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	keys := make([]entity.SigningKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		if key.Status != entity.KeyStatusRetired || key.ExpiresDate.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (r *fakeKeyRepo) RotateKeys(_ context.Context, rotation entity.KeyRotation) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	activeIdx, nextIdx := -1, -1
+	for i, key := range r.keys {
+		switch key.Status {
+		case entity.KeyStatusActive:
+			activeIdx = i
+		case entity.KeyStatusNext:
+			nextIdx = i
+		}
+	}
+
+	if rotation.ActivatedBefore != nil && activeIdx >= 0 && r.keys[activeIdx].ActivatedDate.After(*rotation.ActivatedBefore) {
+		return false, nil
+	}
+
+	if activeIdx >= 0 {
+		retiredUntil := rotation.RetiredUntil
+		r.keys[activeIdx].Status = entity.KeyStatusRetired
+		r.keys[activeIdx].RetiredDate = &now
+		r.keys[activeIdx].ExpiresDate = &retiredUntil
+	}
+
+	if nextIdx >= 0 {
+		r.keys[nextIdx].Status = entity.KeyStatusActive
+		r.keys[nextIdx].ActivatedDate = &now
+	} else {
+		active := rotation.Active
+		active.Status = entity.KeyStatusActive
+		active.ActivatedDate = &now
+		r.keys = append(r.keys, active)
+	}
+
+	next := rotation.Next
+	next.Status = entity.KeyStatusNext
+	r.keys = append(r.keys, next)
+	return true, nil
+}
+
+func (r *fakeKeyRepo) RetireKey(_ context.Context, id string, expiresDate time.Time) (entity.KeyStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, key := range r.keys {
+		if key.ID != id {
+			continue
+		}
+		if key.ExpiresDate == nil || key.ExpiresDate.After(expiresDate) {
+			r.keys[i].ExpiresDate = &expiresDate
+		}
+		r.keys[i].Status = entity.KeyStatusRetired
+		return key.Status, nil
+	}
+	return "", apperror.ErrKeyNotFound
+}
+
+// setActivatedDate - сдвиг даты активации активного ключа, чтобы проверить плановую ротацию
+func (r *fakeKeyRepo) setActivatedDate(activatedDate time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, key := range r.keys {
+		if key.Status == entity.KeyStatusActive {
+			r.keys[i].ActivatedDate = &activatedDate
+		}
+	}
+}
+
+// fakeUserRepo - пользователи в памяти
+type fakeUserRepo struct {
+	postgres.IUser
+
+	users map[string]entity.User
+	// roleChanges - записи аудита, переданные вместе с приватным редактированием
+	roleChanges []*entity.RoleChange
+}
+
+func newFakeUserRepo(users ...entity.User) *fakeUserRepo {
+	repo := &fakeUserRepo{users: make(map[string]entity.User)}
+	for _, user := range users {
+		repo.users[user.ID] = user
+	}
+	return repo
+}
+
+func (r *fakeUserRepo) GetUserByID(_ context.Context, id string) (entity.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return entity.User{}, apperror.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepo) UpdateUserByID(_ context.Context, userUpdate entity.UserUpdate) (entity.User, error) {
+	user, ok := r.users[userUpdate.ID]
+	if !ok {
+		return entity.User{}, apperror.ErrUserNotFound
+	}
+	if userUpdate.Password != nil {
+		user.Password = *userUpdate.Password
+	}
+	r.users[user.ID] = user
+	return user, nil
+}
+
+func (r *fakeUserRepo) UpdatePrivateUserByID(_ context.Context, userUpdate entity.UserUpdatePrivate,
+	roleChange *entity.RoleChange) (entity.User, error) {
+	user, ok := r.users[userUpdate.ID]
+	if !ok {
+		return entity.User{}, apperror.ErrUserNotFound
+	}
+	if roleChange != nil && roleChange.NewRole != entity.RoleSuperAdmin && entity.IsLastSuperAdmin(r.superAdminIDs(), user.ID) {
+		return entity.User{}, apperror.ErrLastSuperAdmin
+	}
+	if userUpdate.Role != nil {
+		user.Role = *userUpdate.Role
+	}
+	r.users[user.ID] = user
+	r.roleChanges = append(r.roleChanges, roleChange)
+	return user, nil
+}
+
+func (r *fakeUserRepo) DeleteUserByID(_ context.Context, id string) error {
+	if entity.IsLastSuperAdmin(r.superAdminIDs(), id) {
+		return apperror.ErrLastSuperAdmin
+	}
+	delete(r.users, id)
+	return nil
+}
+
+func (r *fakeUserRepo) superAdminIDs() []string {
+	ids := make([]string, 0)
+	for _, user := range r.users {
+		if user.Role == entity.RoleSuperAdmin {
+			ids = append(ids, user.ID)
+		}
+	}
+	return ids
+}
+
+// fakeRoleRepo - роли в памяти по названию
+type fakeRoleRepo struct {
+	postgres.IRole
+
+	roles map[string]entity.Role
+}
+
+func newFakeRoleRepo(roles ...entity.Role) *fakeRoleRepo {
+	repo := &fakeRoleRepo{roles: make(map[string]entity.Role)}
+	for _, role := range roles {
+		repo.roles[role.Name] = role
+	}
+	return repo
+}
+
+func (r *fakeRoleRepo) GetRoleByName(_ context.Context, name string) (entity.Role, error) {
+	role, ok := r.roles[name]
+	if !ok {
+		return entity.Role{}, apperror.ErrRoleNotFound
+	}
+	return role, nil
+}
+
+// fakeImpersonationRepo - записи аудита имперсонации в памяти
+type fakeImpersonationRepo struct {
+	postgres.IImpersonation
+
+	impersonations []entity.Impersonation
+	err            error
+}
+
+func (r *fakeImpersonationRepo) CreateImpersonation(_ context.Context, impersonation entity.Impersonation) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.impersonations = append(r.impersonations, impersonation)
+	return nil
+}
+
+// fakePersonalTokenRepo - персональные токены в памяти по хэшу
+type fakePersonalTokenRepo struct {
+	postgres.IPersonalToken
+
+	tokens map[string]entity.PersonalToken
+}
+
+func newFakePersonalTokenRepo(tokens ...entity.PersonalToken) *fakePersonalTokenRepo {
+	repo := &fakePersonalTokenRepo{tokens: make(map[string]entity.PersonalToken)}
+	for _, token := range tokens {
+		repo.tokens[token.TokenHash] = token
+	}
+	return repo
+}
+
+func (r *fakePersonalTokenRepo) GetPersonalTokenByHash(_ context.Context, tokenHash string) (entity.PersonalToken, error) {
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return entity.PersonalToken{}, apperror.ErrPersonalTokenNotFound
+	}
+	return token, nil
+}
+
+func (r *fakePersonalTokenRepo) UpdatePersonalTokenLastUsed(_ context.Context, id, ip string, usedDate time.Time) error {
+	for hash, token := range r.tokens {
+		if token.ID == id {
+			token.LastUsedIP = &ip
+			token.LastUsedDate = &usedDate
+			r.tokens[hash] = token
+		}
+	}
+	return nil
+}
+
+// fakeUserService - пользователи с паролями в открытом виде
+type fakeUserService struct {
+	IUser
+
+	users     map[string]entity.User
+	passwords map[string]string
+	// checks - число проверок пароля, по нему видно, что заблокированный вход до проверки не доходит
+	checks int
+}
+
+func newFakeUserService(users ...entity.User) *fakeUserService {
+	service := &fakeUserService{users: make(map[string]entity.User), passwords: make(map[string]string)}
+	for _, user := range users {
+		service.users[user.ID] = user
+		service.passwords[user.ID] = user.Password
+	}
+	return service
+}
+
+func (u *fakeUserService) GetUserByID(_ context.Context, id string) (entity.User, error) {
+	user, ok := u.users[id]
+	if !ok {
+		return entity.User{}, apperror.ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (u *fakeUserService) GetUserByEmailAndPassword(_ context.Context, email, password string) (entity.User, error) {
+	u.checks++
+	for _, user := range u.users {
+		if user.Email == email && u.passwords[user.ID] == password {
+			return user, nil
+		}
+	}
+	return entity.User{}, apperror.ErrUserNotFound
+}
+
+func (u *fakeUserService) ChangePassword(_ context.Context, userID, _, currentPassword, newPassword string) error {
+	u.checks++
+	if u.passwords[userID] != currentPassword {
+		return apperror.ErrInvalidCurrentPassword
+	}
+	u.passwords[userID] = newPassword
+	return nil
+}
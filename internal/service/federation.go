@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/repository/cache"
+	"github.com/GermanBogatov/auth-service/internal/repository/postgres"
+	"github.com/GermanBogatov/auth-service/pkg/oidc"
+	"github.com/GermanBogatov/auth-service/pkg/pkce"
+	"github.com/GermanBogatov/auth-service/pkg/pwhash"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"net/http"
+	"time"
+)
+
+const (
+	// federationCallbackPath - адрес возврата с провайдера, должен совпадать с роутом хэндлера
+	federationCallbackPath = "/public/v1/auth/sso/%s/callback"
+	// providerRequestTimeout - таймаут запросов к провайдеру
+	providerRequestTimeout = 10 * time.Second
+)
+
+var defaultFederationScopes = []string{entity.ScopeOpenID, entity.ScopeEmail, entity.ScopeProfile}
+
+var _ IFederation = &Federation{}
+
+type IFederation interface {
+	GetProviders() []entity.FederatedProvider
+	BeginLogin(ctx context.Context, providerName, deviceName string) (string, error)
+	CompleteLogin(ctx context.Context, providerName, state, code string) (entity.User, entity.FederatedLoginState, error)
+}
+
+type Federation struct {
+	userRepo       postgres.IUser
+	identityRepo   postgres.IUserIdentity
+	cache          cache.ICache
+	passwordHasher *pwhash.Hasher
+	providers      []entity.FederatedProvider
+	clients        map[string]*oidc.Provider
+	stateTTL       time.Duration
+}
+
+func NewFederation(userRepo postgres.IUser, identityRepo postgres.IUserIdentity, cache cache.ICache,
+	passwordHasher *pwhash.Hasher, providers config.FederationProviders, stateTTLSec int, issuer string) IFederation {
+	httpClient := &http.Client{Timeout: providerRequestTimeout}
+
+	federation := &Federation{
+		userRepo:       userRepo,
+		identityRepo:   identityRepo,
+		cache:          cache,
+		passwordHasher: passwordHasher,
+		providers:      make([]entity.FederatedProvider, 0, len(providers)),
+		clients:        make(map[string]*oidc.Provider, len(providers)),
+		stateTTL:       time.Duration(stateTTLSec) * time.Second,
+	}
+
+	for _, provider := range providers {
+		scopes := provider.Scopes
+		if len(scopes) == 0 {
+			scopes = defaultFederationScopes
+		}
+		displayName := provider.DisplayName
+		if displayName == "" {
+			displayName = provider.Name
+		}
+
+		federation.providers = append(federation.providers, entity.FederatedProvider{
+			Name:        provider.Name,
+			DisplayName: displayName,
+		})
+		federation.clients[provider.Name] = oidc.NewProvider(oidc.Config{
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  issuer + fmt.Sprintf(federationCallbackPath, provider.Name),
+			Scopes:       scopes,
+		}, httpClient)
+	}
+
+	return federation
+}
+
+// GetProviders - список провайдеров, через которые доступен вход
+func (f *Federation) GetProviders() []entity.FederatedProvider {
+	return f.providers
+}
+
+// BeginLogin - начало входа через внешний провайдер: сохраняет state, nonce и PKCE, возвращает адрес провайдера
+func (f *Federation) BeginLogin(ctx context.Context, providerName, deviceName string) (string, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceBeginFederatedLogin)
+	defer span.End()
+
+	provider, ok := f.clients[providerName]
+	if !ok {
+		return "", apperror.ErrProviderNotFound
+	}
+
+	state, err := helpers.GenerateSecret()
+	if err != nil {
+		return "", errors.Wrap(err, "helpers.GenerateSecret")
+	}
+	nonce, err := helpers.GenerateSecret()
+	if err != nil {
+		return "", errors.Wrap(err, "helpers.GenerateSecret")
+	}
+	codeVerifier, err := helpers.GenerateSecret()
+	if err != nil {
+		return "", errors.Wrap(err, "helpers.GenerateSecret")
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, pkce.Challenge(codeVerifier))
+	if err != nil {
+		return "", errors.Wrap(err, "provider.AuthCodeURL")
+	}
+
+	err = f.cache.SetFederatedLoginState(ctx, state, entity.FederatedLoginState{
+		CreatedDate:  time.Now().UTC(),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		DeviceName:   deviceName,
+	}, f.stateTTL)
+	if err != nil {
+		return "", errors.Wrap(err, "cache.SetFederatedLoginState")
+	}
+
+	return authURL, nil
+}
+
+// CompleteLogin - завершение входа через внешний провайдер: обмен кода, проверка id_token и поиск,
+// привязка или создание пользователя
+func (f *Federation) CompleteLogin(ctx context.Context, providerName, state, code string) (entity.User, entity.FederatedLoginState, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceCompleteFederatedLogin)
+	defer span.End()
+
+	provider, ok := f.clients[providerName]
+	if !ok {
+		return entity.User{}, entity.FederatedLoginState{}, apperror.ErrProviderNotFound
+	}
+
+	loginState, err := f.cache.ConsumeFederatedLoginState(ctx, state)
+	if err != nil {
+		if errors.Is(err, apperror.ErrRedisNil) {
+			return entity.User{}, entity.FederatedLoginState{}, apperror.ErrInvalidFederatedState
+		}
+		return entity.User{}, entity.FederatedLoginState{}, errors.Wrap(err, "cache.ConsumeFederatedLoginState")
+	}
+
+	if loginState.Provider != providerName {
+		return entity.User{}, entity.FederatedLoginState{}, apperror.ErrInvalidFederatedState
+	}
+
+	token, err := provider.Exchange(ctx, code, loginState.CodeVerifier)
+	if err != nil {
+		return entity.User{}, entity.FederatedLoginState{}, errors.Wrapf(apperror.ErrFederatedLoginFailed, "provider.Exchange: %s", err)
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, token.IDToken, loginState.Nonce)
+	if err != nil {
+		return entity.User{}, entity.FederatedLoginState{}, errors.Wrapf(apperror.ErrFederatedLoginFailed, "provider.VerifyIDToken: %s", err)
+	}
+
+	user, err := f.provisionUser(ctx, newFederatedUser(providerName, claims))
+	if err != nil {
+		return entity.User{}, entity.FederatedLoginState{}, err
+	}
+
+	return user, loginState, nil
+}
+
+// provisionUser - поиск пользователя по учетной записи провайдера. Если привязки нет, учетная запись
+// привязывается к пользователю с тем же email (только если email подтвердили и провайдер, и сам пользователь),
+// либо создается новый пользователь
+func (f *Federation) provisionUser(ctx context.Context, federatedUser entity.FederatedUser) (entity.User, error) {
+	user, err := f.identityRepo.GetUserByIdentity(ctx, federatedUser.Provider, federatedUser.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, apperror.ErrUserIdentityNotFound) {
+		return entity.User{}, errors.Wrap(err, "identityRepo.GetUserByIdentity")
+	}
+
+	if federatedUser.Email == "" {
+		return entity.User{}, apperror.ErrFederatedEmailRequired
+	}
+
+	user, err = f.userRepo.GetUserByEmail(ctx, federatedUser.Email)
+	switch {
+	case err == nil:
+		if !federatedUser.EmailVerified {
+			return entity.User{}, apperror.ErrFederatedEmailNotVerified
+		}
+		// неподтвержденную учетную запись мог заранее зарегистрировать на чужой email злоумышленник: после привязки
+		// он сохранил бы вход по своему паролю в учетную запись владельца email
+		if !user.EmailVerified {
+			return entity.User{}, apperror.ErrFederatedAccountNotVerified
+		}
+
+		err = f.identityRepo.CreateUserIdentity(ctx, entity.NewUserIdentity(federatedUser, user.ID))
+		if err != nil {
+			return entity.User{}, errors.Wrap(err, "identityRepo.CreateUserIdentity")
+		}
+
+		return user, nil
+	case errors.Is(err, apperror.ErrUserNotFound):
+	default:
+		return entity.User{}, errors.Wrap(err, "userRepo.GetUserByEmail")
+	}
+
+	// пароль случайный: войти по паролю можно будет только после его смены
+	password, err := helpers.GenerateSecret()
+	if err != nil {
+		return entity.User{}, errors.Wrap(err, "helpers.GenerateSecret")
+	}
+
+	passwordHash, err := f.passwordHasher.Hash(password)
+	if err != nil {
+		return entity.User{}, errors.Wrap(err, "passwordHasher.Hash")
+	}
+
+	user = entity.User{
+		Name:          federatedUser.Name,
+		Surname:       federatedUser.Surname,
+		Email:         federatedUser.Email,
+		EmailVerified: federatedUser.EmailVerified,
+	}
+	user.GenerateID()
+	user.SetPasswordHash(passwordHash)
+	user.GenerateCreatedDate()
+	user.AddRoleUser()
+
+	err = f.identityRepo.CreateUserWithIdentity(ctx, user, entity.NewUserIdentity(federatedUser, user.ID))
+	if err != nil {
+		return entity.User{}, errors.Wrap(err, "identityRepo.CreateUserWithIdentity")
+	}
+
+	return user, nil
+}
+
+// newFederatedUser - данные пользователя из проверенного id_token провайдера
+func newFederatedUser(providerName string, claims oidc.Claims) entity.FederatedUser {
+	name := claims.GivenName
+	if name == "" {
+		name = claims.Name
+	}
+
+	return entity.FederatedUser{
+		Provider:      providerName,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		Name:          name,
+		Surname:       claims.FamilyName,
+		EmailVerified: claims.EmailVerified,
+	}
+}
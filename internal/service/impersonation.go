@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/repository/postgres"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var _ IImpersonation = &Impersonation{}
+
+type IImpersonation interface {
+	Impersonate(ctx context.Context, actor entity.Actor, userID, reason string) (entity.ImpersonationToken, error)
+}
+
+type Impersonation struct {
+	impersonationRepo postgres.IImpersonation
+	userRepo          postgres.IUser
+	roleRepo          postgres.IRole
+	jwtService        IJWT
+	ttl               time.Duration
+}
+
+func NewImpersonation(impersonationRepo postgres.IImpersonation, userRepo postgres.IUser, roleRepo postgres.IRole, jwtService IJWT,
+	ttlSec int) IImpersonation {
+	return &Impersonation{
+		impersonationRepo: impersonationRepo,
+		userRepo:          userRepo,
+		roleRepo:          roleRepo,
+		jwtService:        jwtService,
+		ttl:               time.Duration(ttlSec) * time.Second,
+	}
+}
+
+// Impersonate - выдача короткоживущего токена пользователя администратору. Пользователь должен быть ниже роли
+// вызывающего и не иметь привилегированных прав. Аудит пишется до выпуска токена, чтобы неучтенных токенов не было
+func (i *Impersonation) Impersonate(ctx context.Context, actor entity.Actor, userID, reason string) (entity.ImpersonationToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceImpersonate)
+	defer span.End()
+
+	user, err := i.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return entity.ImpersonationToken{}, errors.Wrap(err, "userRepo.GetUserByID")
+	}
+
+	actorRole, err := i.roleRepo.GetRoleByName(ctx, string(actor.Role))
+	if err != nil {
+		return entity.ImpersonationToken{}, errors.Wrap(err, "roleRepo.GetRoleByName")
+	}
+
+	userRole, err := i.roleRepo.GetRoleByName(ctx, string(user.Role))
+	if err != nil {
+		return entity.ImpersonationToken{}, errors.Wrap(err, "roleRepo.GetRoleByName")
+	}
+
+	if !actorRole.Outranks(userRole.Level) || userRole.Permissions.Privileged() {
+		return entity.ImpersonationToken{}, errors.Wrapf(apperror.ErrImpersonationNotAllowed,
+			"caller with role [%s] cannot impersonate user with role [%s]", actorRole.Name, userRole.Name)
+	}
+
+	impersonation := entity.NewImpersonation(user.ID, actor.ID, reason, i.ttl)
+	err = i.impersonationRepo.CreateImpersonation(ctx, impersonation)
+	if err != nil {
+		return entity.ImpersonationToken{}, errors.Wrap(err, "impersonationRepo.CreateImpersonation")
+	}
+
+	accessToken, err := i.jwtService.GenerateImpersonationToken(ctx, user, impersonation)
+	if err != nil {
+		return entity.ImpersonationToken{}, errors.Wrap(err, "jwtService.GenerateImpersonationToken")
+	}
+
+	return entity.ImpersonationToken{
+		Impersonation: impersonation,
+		AccessToken:   accessToken,
+	}, nil
+}
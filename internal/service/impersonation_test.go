@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const testImpersonationTTLSec = 600
+
+// impersonationRoles - роли теста, auditor ниже support, но с привилегированным правом
+var impersonationRoles = append([]entity.Role{
+	{Name: "auditor", Level: 10, Permissions: entity.Permissions{entity.PermissionSessionsReadAny}},
+}, testRoles...)
+
+func TestImpersonate(t *testing.T) {
+	superAdmin := entity.User{ID: "super-admin-1", Role: entity.RoleSuperAdmin}
+	admin := entity.User{ID: "admin-1", Role: entity.RoleAdmin}
+	otherAdmin := entity.User{ID: "admin-2", Role: entity.RoleAdmin}
+	support := entity.User{ID: "support-1", Role: "support"}
+	auditor := entity.User{ID: "auditor-1", Role: "auditor"}
+	user := entity.User{ID: "user-1", Email: "ivan.petrov@example.com", Role: entity.RoleUser}
+
+	tests := []struct {
+		name    string
+		actor   entity.User
+		target  string
+		wantErr error
+	}{
+		{
+			name:   "support impersonates user",
+			actor:  support,
+			target: user.ID,
+		},
+		{
+			name:   "admin impersonates support",
+			actor:  admin,
+			target: support.ID,
+		},
+		{
+			name:   "super-admin impersonates admin",
+			actor:  superAdmin,
+			target: admin.ID,
+		},
+		{
+			name:    "same level",
+			actor:   admin,
+			target:  otherAdmin.ID,
+			wantErr: apperror.ErrImpersonationNotAllowed,
+		},
+		{
+			name:    "higher level",
+			actor:   support,
+			target:  admin.ID,
+			wantErr: apperror.ErrImpersonationNotAllowed,
+		},
+		{
+			name:    "self",
+			actor:   admin,
+			target:  admin.ID,
+			wantErr: apperror.ErrImpersonationNotAllowed,
+		},
+		{
+			name:    "privileged role below caller",
+			actor:   support,
+			target:  auditor.ID,
+			wantErr: apperror.ErrImpersonationNotAllowed,
+		},
+		{
+			name:    "unknown user",
+			actor:   admin,
+			target:  "missing",
+			wantErr: apperror.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			jwtService, _ := newTestJWT(t)
+			impersonationRepo := &fakeImpersonationRepo{}
+			impersonationService := NewImpersonation(impersonationRepo,
+				newFakeUserRepo(superAdmin, admin, otherAdmin, support, auditor, user),
+				newFakeRoleRepo(impersonationRoles...), jwtService, testImpersonationTTLSec)
+
+			token, err := impersonationService.Impersonate(ctx, entity.Actor{ID: tt.actor.ID, Role: tt.actor.Role},
+				tt.target, "ticket-42")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, token.AccessToken)
+				assert.Empty(t, impersonationRepo.impersonations)
+				return
+			}
+			require.NoError(t, err)
+
+			// запись аудита совпадает с выданным токеном
+			require.Len(t, impersonationRepo.impersonations, 1)
+			audit := impersonationRepo.impersonations[0]
+			assert.Equal(t, token.Impersonation, audit)
+			assert.Equal(t, tt.target, audit.UserID)
+			assert.Equal(t, tt.actor.ID, audit.ActorID)
+			assert.Equal(t, "ticket-42", audit.Reason)
+			assert.Equal(t, testImpersonationTTLSec*time.Second, audit.ExpiresDate.Sub(audit.CreatedDate))
+
+			claims, err := jwtService.ParseAccessToken(ctx, token.AccessToken)
+			require.NoError(t, err)
+			assert.Equal(t, tt.target, claims.Subject)
+			assert.Equal(t, audit.ID, claims.ID)
+			require.NotNil(t, claims.Actor)
+			assert.Equal(t, tt.actor.ID, claims.Actor.Subject)
+			assert.Empty(t, claims.SessionID)
+			assert.WithinDuration(t, audit.ExpiresDate, claims.ExpiresAt.Time, time.Second)
+		})
+	}
+}
+
+func TestImpersonateAuditFailure(t *testing.T) {
+	errAudit := errors.New("audit unavailable")
+	jwtService, _ := newTestJWT(t)
+	impersonationService := NewImpersonation(&fakeImpersonationRepo{err: errAudit},
+		newFakeUserRepo(entity.User{ID: "user-1", Role: entity.RoleUser}), newFakeRoleRepo(testRoles...), jwtService,
+		testImpersonationTTLSec)
+
+	token, err := impersonationService.Impersonate(context.Background(),
+		entity.Actor{ID: "admin-1", Role: entity.RoleAdmin}, "user-1", "ticket-42")
+	assert.ErrorIs(t, err, errAudit)
+	assert.Empty(t, token.AccessToken)
+}
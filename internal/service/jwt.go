@@ -7,122 +7,806 @@ import (
 	"github.com/GermanBogatov/auth-service/internal/entity"
 	"github.com/GermanBogatov/auth-service/internal/repository/cache"
 	"github.com/GermanBogatov/auth-service/internal/repository/postgres"
+	"github.com/GermanBogatov/auth-service/pkg/claims"
+	"github.com/GermanBogatov/auth-service/pkg/jwks"
 	"github.com/GermanBogatov/auth-service/pkg/logging"
 	"github.com/GermanBogatov/auth-service/pkg/tracer"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
-	"github.com/redis/go-redis/v9"
+	"slices"
+	"strings"
 	"time"
 )
 
 var _ IJWT = &JWT{}
 
+func init() {
+	// iat, nbf и exp выпускаются с точностью до микросекунды (RFC 7519 допускает дробные NumericDate): с точностью
+	// до секунды токены, выпущенные в одну секунду с отзывом токенов пользователя, нельзя отличить до и после отзыва.
+	// Миллисекунд недостаточно: при разборе дробь секунд проходит через float64 и может потерять последнюю единицу
+	jwt.TimePrecision = time.Microsecond
+}
+
 type JWT struct {
-	userRepo postgres.IUser
-	cache    cache.ICache
-	secret   string
-	jwtTTL   time.Duration
+	userRepo   postgres.IUser
+	cache      cache.ICache
+	keyRing    IKeyRing
+	jwtTTL     time.Duration
+	refreshTTL time.Duration
+	reuseGrace time.Duration
+	leeway     time.Duration
+	// claimsPipeline - обогащение access-токенов пользователей дополнительными claims
+	claimsPipeline *claims.Pipeline
+	// issuer - iss id_token, accessIssuer - iss access-токенов
+	issuer       string
+	accessIssuer string
+	// audience - аудитория access-токенов, которые принимает api самого сервиса
+	audience string
+	// emailVerificationMode - claim или block для пользователей с неподтвержденным email
+	emailVerificationMode string
 }
 
-func NewJWT(userRepo postgres.IUser, cache cache.ICache, secret string, jwtTTL int) IJWT {
+func NewJWT(userRepo postgres.IUser, cache cache.ICache, keyRing IKeyRing, claimsPipeline *claims.Pipeline,
+	jwtTTL, refreshTTL, reuseGraceSec, leewaySec int, issuer, accessIssuer, audience, emailVerificationMode string) IJWT {
 	return &JWT{
-		userRepo: userRepo,
-		cache:    cache,
-		secret:   secret,
-		jwtTTL:   time.Duration(jwtTTL) * time.Second,
+		userRepo:              userRepo,
+		cache:                 cache,
+		keyRing:               keyRing,
+		claimsPipeline:        claimsPipeline,
+		jwtTTL:                time.Duration(jwtTTL) * time.Second,
+		refreshTTL:            time.Duration(refreshTTL) * time.Second,
+		reuseGrace:            time.Duration(reuseGraceSec) * time.Second,
+		leeway:                time.Duration(leewaySec) * time.Second,
+		issuer:                issuer,
+		accessIssuer:          accessIssuer,
+		audience:              audience,
+		emailVerificationMode: emailVerificationMode,
 	}
 }
 
 type IJWT interface {
-	UpdateRefreshToken(ctx context.Context, refreshToken string) (string, string, error)
-	GenerateAccessAndRefreshTokens(ctx context.Context, user entity.User) (string, string, error)
+	UpdateRefreshToken(ctx context.Context, refreshToken string, client entity.ClientInfo) (string, string, error)
+	GenerateAccessAndRefreshTokens(ctx context.Context, user entity.User, client entity.ClientInfo) (string, string, error)
+	ParseAccessToken(ctx context.Context, accessToken string) (entity.UserClaims, error)
+	GetJWKS(ctx context.Context) (jwks.Set, error)
+	RevokeSession(ctx context.Context, claims entity.UserClaims) error
+	RevokeAllSessions(ctx context.Context, userID string) error
+	RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) error
+	RevokeToken(ctx context.Context, token, tokenTypeHint string) error
+	Introspect(ctx context.Context, token, tokenTypeHint string) (entity.TokenIntrospection, error)
+	GenerateClientCredentialsToken(ctx context.Context, account entity.ServiceAccount, scopes []string) (entity.OAuthToken, error)
+	GenerateIDToken(ctx context.Context, request entity.IDTokenRequest) (string, error)
+	GenerateImpersonationToken(ctx context.Context, user entity.User, impersonation entity.Impersonation) (string, error)
+	ExchangeToken(ctx context.Context, account entity.ServiceAccount, exchange entity.TokenExchange) (entity.OAuthToken, error)
 }
 
-// UpdateRefreshToken - обновление рефреш токена
-func (j *JWT) UpdateRefreshToken(ctx context.Context, refreshToken string) (string, string, error) {
+// UpdateRefreshToken - ротация рефреш-токена: старый токен атомарно погашается, взамен выдается новый из того же семейства.
+// Повторное предъявление погашенного токена отзывает все семейство, кроме параллельных запросов того же клиента в grace-окне:
+// им выдается только access-токен, рефреш-токен преемника получает лишь запрос, погасивший токен
+func (j *JWT) UpdateRefreshToken(ctx context.Context, refreshToken string, client entity.ClientInfo) (string, string, error) {
 	_, span := tracer.StartTrace(ctx, config.SpanServiceUpdateRefreshToken)
 	defer span.End()
 
-	userID, err := j.cache.Get(ctx, refreshToken)
+	// токен привязан к клиенту, которому выдан: чужой клиент не должен погасить его ротацией
+	current, err := j.cache.GetRefreshToken(ctx, refreshToken)
+	if err != nil && !errors.Is(err, apperror.ErrRedisNil) {
+		return "", "", errors.Wrap(err, "cache.GetRefreshToken")
+	}
+	if err == nil && current.ClientID != client.ClientID {
+		return "", "", apperror.ErrRefreshTokenNotFound
+	}
+
+	successor := uuid.New().String()
+	token, err := j.cache.RotateRefreshToken(ctx, refreshToken, entity.RotatedRefreshToken{
+		RotatedDate: time.Now().UTC(),
+		Fingerprint: client.Fingerprint(),
+	})
+	if err != nil {
+		if errors.Is(err, apperror.ErrRedisNil) {
+			return j.handleRotatedRefreshToken(ctx, refreshToken, client)
+		}
+		return "", "", errors.Wrap(err, "cache.RotateRefreshToken")
+	}
+
+	session, err := j.cache.GetSession(ctx, token.FamilyID)
 	if err != nil {
-		if errors.Is(err, redis.Nil) {
+		if errors.Is(err, apperror.ErrRedisNil) {
 			return "", "", apperror.ErrRefreshTokenNotFound
 		}
-		return "", "", errors.Wrap(err, "cache.Get")
+		return "", "", errors.Wrap(err, "cache.GetSession")
 	}
 
-	var (
-		user    entity.User
-		errUser error
-	)
-	user, errUser = j.cache.GetUser(ctx, userID)
-	if errUser != nil {
-		if errors.Is(errUser, redis.Nil) {
-			user, errUser = j.userRepo.GetUserByID(ctx, userID)
-			if errUser != nil {
-				return "", "", errors.Wrap(errUser, "userRepo.GetUserByID")
-			}
-		} else {
-			return "", "", errors.Wrap(errUser, "cache.GetUser")
+	user, err := j.getUser(ctx, token.UserID)
+	if err != nil {
+		return "", "", err
+	}
+
+	accessToken, err := j.generateAccessToken(ctx, user, session)
+	if err != nil {
+		return "", "", errors.Wrap(err, "generateAccessToken")
+	}
+
+	session.Touch(client)
+	active, err := j.cache.UpdateSession(ctx, session)
+	if err != nil {
+		return "", "", errors.Wrap(err, "cache.UpdateSession")
+	}
+	if !active {
+		return "", "", apperror.ErrRefreshTokenNotFound
+	}
+
+	err = j.storeRefreshToken(ctx, successor, session)
+	if err != nil {
+		return "", "", errors.Wrap(err, "storeRefreshToken")
+	}
+
+	return accessToken, successor, nil
+}
+
+// handleRotatedRefreshToken - обработка повторного предъявления уже погашенного рефреш-токена
+func (j *JWT) handleRotatedRefreshToken(ctx context.Context, refreshToken string, client entity.ClientInfo) (string, string, error) {
+	rotated, err := j.cache.GetRotatedRefreshToken(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, apperror.ErrRedisNil) {
+			return "", "", apperror.ErrRefreshTokenNotFound
+		}
+		return "", "", errors.Wrap(err, "cache.GetRotatedRefreshToken")
+	}
+
+	session, err := j.cache.GetSession(ctx, rotated.FamilyID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrRedisNil) {
+			return "", "", apperror.ErrRefreshTokenNotFound
+		}
+		return "", "", errors.Wrap(err, "cache.GetSession")
+	}
+
+	// параллельные обновления одного клиента: отпечаток подделывается, поэтому выдается только новый access-токен
+	// в рамках сессии, а преемник остается у запроса, погасившего токен. Украденный токен не дает продлить сессию
+	if time.Since(rotated.RotatedDate) <= j.reuseGrace && rotated.Fingerprint == client.Fingerprint() &&
+		rotated.ClientID == client.ClientID {
+		user, errUser := j.getUser(ctx, rotated.UserID)
+		if errUser != nil {
+			return "", "", errUser
+		}
+
+		accessToken, errToken := j.generateAccessToken(ctx, user, session)
+		if errToken != nil {
+			return "", "", errors.Wrap(errToken, "generateAccessToken")
 		}
+
+		return accessToken, "", nil
+	}
+
+	logging.Warnf("security event: reuse of rotated refresh token detected, revoking token family [%s] of user [%s] (ip [%s], user agent [%s])",
+		rotated.FamilyID, rotated.UserID, client.IP, client.UserAgent)
+
+	err = j.cache.DeleteRefreshFamily(ctx, rotated.FamilyID)
+	if err != nil {
+		return "", "", errors.Wrap(err, "cache.DeleteRefreshFamily")
+	}
+
+	return "", "", apperror.ErrRefreshTokenReused
+}
+
+// getUser - получение пользователя из кэша, либо из бд при его отсутствии
+func (j *JWT) getUser(ctx context.Context, userID string) (entity.User, error) {
+	user, err := j.cache.GetUser(ctx, userID)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, apperror.ErrRedisNil) {
+		return entity.User{}, errors.Wrap(err, "cache.GetUser")
+	}
+
+	user, err = j.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return entity.User{}, errors.Wrap(err, "userRepo.GetUserByID")
+	}
+
+	return user, nil
+}
+
+// GenerateAccessAndRefreshTokens - генерация токенов, рефреш-токен открывает новое семейство (сессию клиента)
+func (j *JWT) GenerateAccessAndRefreshTokens(ctx context.Context, user entity.User, client entity.ClientInfo) (string, string, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGenerateAccessAndRefreshTokens)
+	defer span.End()
+
+	session := entity.NewSession(uuid.New().String(), user.ID, client)
+	accessToken, err := j.generateAccessToken(ctx, user, session)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = j.cache.SetSession(ctx, session)
+	if err != nil {
+		return "", "", errors.Wrap(err, "cache.SetSession")
+	}
+
+	refreshToken := uuid.New().String()
+	err = j.storeRefreshToken(ctx, refreshToken, session)
+	if err != nil {
+		return "", "", errors.Wrap(err, "storeRefreshToken")
 	}
 
 	go func() {
-		ctxDel, cancel := context.WithTimeout(context.Background(), time.Duration(60)*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(60)*time.Second)
 		defer cancel()
-		errDelete := j.cache.Delete(ctxDel, refreshToken)
-		if errDelete != nil {
-			logging.Errorf("error deleting refresh token [%s]: %v", refreshToken, errDelete)
+
+		errSet := j.cache.SetUser(ctx, user.ID, user)
+		if errSet != nil {
+			logging.Errorf("error set user [%s]: %v", user.ID, errSet)
 		}
 	}()
 
-	newAccessToken, newRefreshToken, err := j.GenerateAccessAndRefreshTokens(ctx, user)
+	return accessToken, refreshToken, nil
+}
+
+// generateAccessToken - генерация подписанного access-токена в рамках сессии. Дополнительные claims собираются
+// заново при каждом выпуске, поэтому после обновления токена отражают текущий профиль и права
+func (j *JWT) generateAccessToken(ctx context.Context, user entity.User, session entity.Session) (string, error) {
+	// проверка при каждом выпуске, а не только при входе: после смены email действующие сессии тоже останавливаются
+	var emailVerified *bool
+	if !user.EmailVerified {
+		if j.emailVerificationMode == config.EmailVerificationModeBlock {
+			return "", apperror.ErrEmailNotVerified
+		}
+		emailVerified = &user.EmailVerified
+	}
+
+	key, err := j.keyRing.GetSigningKey(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "keyRing.GetSigningKey")
+	}
+
+	extra, err := j.claimsPipeline.Enrich(ctx, claims.Subject{
+		ID:       user.ID,
+		Name:     user.Name,
+		Surname:  user.Surname,
+		Email:    user.Email,
+		Role:     string(user.Role),
+		ClientID: session.ClientID,
+	})
 	if err != nil {
-		return "", "", errors.Wrap(err, "GenerateAccessAndRefreshTokens")
+		return "", errors.Wrap(err, "claimsPipeline.Enrich")
 	}
 
-	return newAccessToken, newRefreshToken, nil
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), entity.UserClaims{
+		RegisteredClaims: j.accessClaims(uuid.New().String(), user.ID, j.audiences(session.Audiences), now, now.Add(j.jwtTTL)),
+		Email:            user.Email,
+		Role:             string(user.Role),
+		SessionID:        session.ID,
+		ClientID:         session.ClientID,
+		Scope:            session.Scope,
+		EmailVerified:    emailVerified,
+		Extra:            extra,
+	})
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.PrivateKey)
 }
 
-// GenerateAccessAndRefreshTokens - генерация токенов
-func (j *JWT) GenerateAccessAndRefreshTokens(ctx context.Context, user entity.User) (string, string, error) {
-	_, span := tracer.StartTrace(ctx, config.SpanServiceGenerateAccessAndRefreshTokens)
+// GenerateClientCredentialsToken - выпуск access-токена сервисного аккаунта (grant_type=client_credentials).
+// Рефреш-токен не выдается: клиент всегда может запросить новый токен по своим учетным данным
+func (j *JWT) GenerateClientCredentialsToken(ctx context.Context, account entity.ServiceAccount, scopes []string) (entity.OAuthToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGenerateClientCredentialsToken)
+	defer span.End()
+
+	key, err := j.keyRing.GetSigningKey(ctx)
+	if err != nil {
+		return entity.OAuthToken{}, errors.Wrap(err, "keyRing.GetSigningKey")
+	}
+
+	scope := entity.FormatScope(scopes)
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), entity.UserClaims{
+		RegisteredClaims: j.accessClaims(uuid.New().String(), account.ID, j.audiences(account.Audiences), now, now.Add(j.jwtTTL)),
+		ClientID:         account.ID,
+		Scope:            scope,
+	})
+	token.Header["kid"] = key.ID
+
+	accessToken, err := token.SignedString(key.PrivateKey)
+	if err != nil {
+		return entity.OAuthToken{}, errors.Wrap(err, "token.SignedString")
+	}
+
+	return entity.OAuthToken{
+		AccessToken: accessToken,
+		TokenType:   entity.TokenTypeBearer,
+		Scope:       scope,
+		ExpiresIn:   int(j.jwtTTL.Seconds()),
+	}, nil
+}
+
+// GenerateIDToken - выпуск id_token для oauth-клиента. Claims пользователя включаются по выданным скоупам
+func (j *JWT) GenerateIDToken(ctx context.Context, request entity.IDTokenRequest) (string, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGenerateIDToken)
 	defer span.End()
 
-	key := []byte(j.secret)
+	key, err := j.keyRing.GetSigningKey(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "keyRing.GetSigningKey")
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, entity.UserClaims{
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), entity.IDTokenClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
-			ID:        user.ID,
-			Audience:  jwt.ClaimStrings{"users"},
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.jwtTTL)),
+			Issuer:    j.issuer,
+			Subject:   request.User.ID,
+			Audience:  jwt.ClaimStrings{request.ClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.jwtTTL)),
 		},
+		AuthTime: jwt.NewNumericDate(request.AuthTime),
+		Nonce:    request.Nonce,
+		UserInfo: entity.NewUserInfo(request.User, request.Scopes),
+	})
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.PrivateKey)
+}
+
+// GenerateImpersonationToken - выпуск access-токена пользователя с claim act администратора (RFC 8693).
+// Токен не привязан к сессии и не продлевается, jti совпадает с записью аудита
+func (j *JWT) GenerateImpersonationToken(ctx context.Context, user entity.User, impersonation entity.Impersonation) (string, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGenerateImpersonationToken)
+	defer span.End()
+
+	key, err := j.keyRing.GetSigningKey(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "keyRing.GetSigningKey")
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), entity.UserClaims{
+		RegisteredClaims: j.accessClaims(impersonation.ID, user.ID, j.audiences(nil), impersonation.CreatedDate,
+			impersonation.ExpiresDate),
 		Email: user.Email,
 		Role:  string(user.Role),
+		Actor: &entity.ActorClaim{Subject: impersonation.ActorID},
+	})
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.PrivateKey)
+}
+
+// ExchangeToken - обмен токена пользователя на токен для другого сервиса (RFC 8693). Аудитория сужается до запрошенной,
+// скоупы - до подмножества скоупов исходного токена, вызывающий сервис попадает в claim act. Токен остается в сессии
+// исходного токена, отзывается вместе с ней и живет не дольше исходного
+func (j *JWT) ExchangeToken(ctx context.Context, account entity.ServiceAccount, exchange entity.TokenExchange) (entity.OAuthToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceExchangeToken)
+	defer span.End()
+
+	subject, err := j.verifyAccessToken(ctx, exchange.SubjectToken)
+	if err != nil {
+		return entity.OAuthToken{}, errors.Wrap(apperror.ErrInvalidSubjectToken, err.Error())
+	}
+
+	err = j.checkAccessToken(ctx, subject)
+	if err != nil {
+		if errors.Is(err, apperror.ErrMalformedToken) || errors.Is(err, apperror.ErrTokenRevoked) {
+			return entity.OAuthToken{}, errors.Wrap(apperror.ErrInvalidSubjectToken, err.Error())
+		}
+		return entity.OAuthToken{}, err
+	}
+
+	if subject.CallerType() == entity.CallerTypeService {
+		return entity.OAuthToken{}, errors.Wrap(apperror.ErrInvalidSubjectToken, "subject token does not belong to a user")
+	}
+
+	scopes, err := exchangedScopes(entity.ParseScope(subject.Scope), exchange.Scopes, account.Scopes)
+	if err != nil {
+		return entity.OAuthToken{}, err
+	}
+
+	key, err := j.keyRing.GetSigningKey(ctx)
+	if err != nil {
+		return entity.OAuthToken{}, errors.Wrap(err, "keyRing.GetSigningKey")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(j.jwtTTL)
+	if subject.ExpiresAt.Time.Before(expiresAt) {
+		expiresAt = subject.ExpiresAt.Time
+	}
+
+	scope := entity.FormatScope(scopes)
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), entity.UserClaims{
+		RegisteredClaims: j.accessClaims(uuid.New().String(), subject.Subject, jwt.ClaimStrings{exchange.Audience}, now, expiresAt),
+		Email:            subject.Email,
+		Role:             subject.Role,
+		SessionID:        subject.SessionID,
+		ClientID:         account.ID,
+		Scope:            scope,
+		Actor:            &entity.ActorClaim{Subject: account.ID, Actor: subject.Actor},
 	})
+	token.Header["kid"] = key.ID
 
-	accessToken, err := token.SignedString(key)
+	accessToken, err := token.SignedString(key.PrivateKey)
 	if err != nil {
-		return "", "", err
+		return entity.OAuthToken{}, errors.Wrap(err, "token.SignedString")
 	}
 
-	refreshToken := uuid.New().String()
+	return entity.OAuthToken{
+		AccessToken:     accessToken,
+		TokenType:       entity.TokenTypeBearer,
+		Scope:           scope,
+		IssuedTokenType: entity.TokenTypeAccessToken,
+		ExpiresIn:       int(expiresAt.Sub(now).Seconds()),
+	}, nil
+}
 
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(60)*time.Second)
-		defer cancel()
+// exchangedScopes - скоупы обмененного токена. Запрошенный скоуп должен быть известен сервису, разрешен сервисному
+// аккаунту и входить в скоупы исходного токена. Токен без скоупов выдан самим сервисом и не ограничен: для него
+// остаются только первые две проверки
+func exchangedScopes(granted, requested, accountScopes []string) ([]string, error) {
+	if len(requested) == 0 {
+		return granted, nil
+	}
 
-		errSet := j.cache.SetRefreshToken(ctx, refreshToken, user.ID)
-		if errSet != nil {
-			logging.Errorf("error set refresh token [%s]: %v", refreshToken, errSet)
+	for _, scope := range requested {
+		if !slices.Contains(entity.ExchangeScopes, scope) {
+			return nil, errors.Wrapf(apperror.ErrScopeNotAllowed, "unknown scope [%s]", scope)
+		}
+		if !slices.Contains(accountScopes, scope) {
+			return nil, errors.Wrapf(apperror.ErrScopeNotAllowed, "scope [%s] is not allowed to service account", scope)
+		}
+		if len(granted) != 0 && !slices.Contains(granted, scope) {
+			return nil, errors.Wrapf(apperror.ErrScopeNotAllowed, "scope [%s] is not granted to subject token", scope)
 		}
+	}
 
-		errSet = j.cache.SetUser(ctx, user.ID, user)
-		if errSet != nil {
-			logging.Errorf("error set user [%s]: %v", user.ID, errSet)
+	return requested, nil
+}
+
+// accessClaims - зарегистрированные claims access-токена, токен действует с момента выпуска
+func (j *JWT) accessClaims(id, subject string, audience jwt.ClaimStrings, issuedAt, expiresAt time.Time) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		ID:        id,
+		Issuer:    j.accessIssuer,
+		Subject:   subject,
+		Audience:  audience,
+		IssuedAt:  jwt.NewNumericDate(issuedAt),
+		NotBefore: jwt.NewNumericDate(issuedAt),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+}
+
+// audiences - аудитория access-токена: api самого сервиса и дополнительные аудитории клиента
+func (j *JWT) audiences(extra []string) jwt.ClaimStrings {
+	audiences := jwt.ClaimStrings{j.audience}
+	for _, audience := range extra {
+		if !slices.Contains(audiences, audience) {
+			audiences = append(audiences, audience)
 		}
-	}()
+	}
+	return audiences
+}
+
+// storeRefreshToken - сохранение рефреш-токена семейства сессии
+func (j *JWT) storeRefreshToken(ctx context.Context, refreshToken string, session entity.Session) error {
+	err := j.cache.SetRefreshToken(ctx, refreshToken, entity.RefreshToken{
+		CreatedDate: time.Now().UTC(),
+		UserID:      session.UserID,
+		FamilyID:    session.ID,
+		ClientID:    session.ClientID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "cache.SetRefreshToken")
+	}
+
+	return nil
+}
+
+// ParseAccessToken - проверка подписи access-токена ключом из kid, издателя, аудитории, срока действия и отзыва
+func (j *JWT) ParseAccessToken(ctx context.Context, accessToken string) (entity.UserClaims, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceParseAccessToken)
+	defer span.End()
+
+	claims, err := j.verifyAccessToken(ctx, accessToken)
+	if err != nil {
+		return entity.UserClaims{}, err
+	}
+
+	// токены, выданные обменом для других сервисов, api самого сервиса не принимает
+	if !slices.Contains(claims.Audience, j.audience) {
+		return entity.UserClaims{}, errors.Wrapf(apperror.ErrInvalidAudience, "audience %v", []string(claims.Audience))
+	}
+
+	err = j.checkAccessToken(ctx, claims)
+	if err != nil {
+		return entity.UserClaims{}, err
+	}
+
+	return claims, nil
+}
+
+// checkAccessToken - проверка обязательных claims и отзыва access-токена с уже проверенной подписью
+func (j *JWT) checkAccessToken(ctx context.Context, claims entity.UserClaims) error {
+	if claims.Subject == "" || claims.ID == "" || claims.IssuedAt == nil || claims.ExpiresAt == nil {
+		return apperror.ErrMalformedToken
+	}
+
+	revocation, err := j.cache.GetAccessTokenRevocation(ctx, claims.ID, claims.Subject, claims.SessionID)
+	if err != nil {
+		return errors.Wrap(err, "cache.GetAccessTokenRevocation")
+	}
+
+	if revocation.IsRevoked(claims.IssuedAt.Time, claims.SessionID) {
+		return apperror.ErrTokenRevoked
+	}
+
+	return nil
+}
+
+// verifyAccessToken - проверка подписи, издателя и срока действия access-токена. Аудитория здесь не проверяется:
+// интроспекция и обмен принимают токены любых аудиторий. Сроки сверяются с допуском на расхождение часов
+func (j *JWT) verifyAccessToken(ctx context.Context, accessToken string) (entity.UserClaims, error) {
+	token, err := jwt.ParseWithClaims(accessToken, &entity.UserClaims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, apperror.ErrUnknownKeyID
+		}
+
+		key, errKey := j.keyRing.GetVerificationKey(ctx, kid)
+		if errKey != nil {
+			return nil, errKey
+		}
+
+		if token.Method.Alg() != key.Algorithm {
+			return nil, apperror.ErrInvalidSigningMethod
+		}
+
+		return key.PublicKey(), nil
+	}, jwt.WithValidMethods([]string{jwks.AlgRS256, jwks.AlgES256, jwks.AlgEdDSA}), jwt.WithIssuer(j.accessIssuer),
+		jwt.WithIssuedAt(), jwt.WithLeeway(j.leeway))
+	if err != nil {
+		switch {
+		case errors.Is(err, jwt.ErrTokenExpired):
+			return entity.UserClaims{}, apperror.ErrTokenIsInspired
+		case errors.Is(err, jwt.ErrTokenNotValidYet) || errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+			return entity.UserClaims{}, apperror.ErrTokenNotYetValid
+		case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+			return entity.UserClaims{}, apperror.ErrInvalidIssuer
+		}
+		return entity.UserClaims{}, errors.Wrap(err, apperror.ErrMalformedToken.Error())
+	}
+
+	claims, ok := token.Claims.(*entity.UserClaims)
+	if !ok || !token.Valid {
+		return entity.UserClaims{}, apperror.ErrMalformedToken
+	}
+
+	return *claims, nil
+}
+
+// RevokeSession - завершение текущей сессии: access-токен попадает в denylist, семейство рефреш-токенов отзывается
+func (j *JWT) RevokeSession(ctx context.Context, claims entity.UserClaims) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceRevokeSession)
+	defer span.End()
+
+	err := j.revokeAccessToken(ctx, claims)
+	if err != nil {
+		return err
+	}
+
+	if claims.SessionID == "" {
+		return nil
+	}
+
+	err = j.cache.DeleteRefreshFamily(ctx, claims.SessionID)
+	if err != nil {
+		return errors.Wrap(err, "cache.DeleteRefreshFamily")
+	}
+
+	return nil
+}
+
+// RevokeAllSessions - завершение всех сессий пользователя
+func (j *JWT) RevokeAllSessions(ctx context.Context, userID string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceRevokeAllSessions)
+	defer span.End()
+
+	err := j.cache.SetUserTokensRevokedBefore(ctx, userID, time.Now(), "", j.jwtTTL)
+	if err != nil {
+		return errors.Wrap(err, "cache.SetUserTokensRevokedBefore")
+	}
+
+	err = j.cache.DeleteUserRefreshFamilies(ctx, userID)
+	if err != nil {
+		return errors.Wrap(err, "cache.DeleteUserRefreshFamilies")
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions - завершение всех сессий пользователя, кроме текущей. Вместе с семействами рефреш-токенов
+// отзываются и access-токены пользователя вне сессий (имперсонация, обмен). Без текущей сессии завершаются все
+func (j *JWT) RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceRevokeOtherSessions)
+	defer span.End()
+
+	if currentSessionID == "" {
+		return j.RevokeAllSessions(ctx, userID)
+	}
+
+	err := j.cache.SetUserTokensRevokedBefore(ctx, userID, time.Now(), currentSessionID, j.jwtTTL)
+	if err != nil {
+		return errors.Wrap(err, "cache.SetUserTokensRevokedBefore")
+	}
+
+	sessions, err := j.cache.GetUserSessions(ctx, userID)
+	if err != nil {
+		return errors.Wrap(err, "cache.GetUserSessions")
+	}
+
+	for _, session := range sessions {
+		if session.ID == currentSessionID {
+			continue
+		}
+
+		err = j.cache.DeleteRefreshFamily(ctx, session.ID)
+		if err != nil {
+			return errors.Wrapf(err, "cache.DeleteRefreshFamily [%s]", session.ID)
+		}
+	}
+
+	return nil
+}
+
+// RevokeToken - отзыв токена по RFC 7009. Неизвестные и уже недействительные токены не считаются ошибкой
+func (j *JWT) RevokeToken(ctx context.Context, token, tokenTypeHint string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceRevokeToken)
+	defer span.End()
+
+	// рефреш-токен непрозрачный, access-токен - jwt из трех частей
+	isJWT := strings.Count(token, ".") == 2
+	if tokenTypeHint == entity.TokenTypeHintRefreshToken || !isJWT {
+		refreshToken, err := j.cache.GetRefreshToken(ctx, token)
+		if err == nil {
+			errDelete := j.cache.DeleteRefreshFamily(ctx, refreshToken.FamilyID)
+			if errDelete != nil {
+				return errors.Wrap(errDelete, "cache.DeleteRefreshFamily")
+			}
+			return nil
+		}
+		if !errors.Is(err, apperror.ErrRedisNil) {
+			return errors.Wrap(err, "cache.GetRefreshToken")
+		}
+		if !isJWT {
+			return nil
+		}
+	}
+
+	claims, err := j.verifyAccessToken(ctx, token)
+	if err != nil {
+		logging.Debugf("revoke token: skip invalid access token: %s", err)
+		return nil
+	}
+
+	return j.revokeAccessToken(ctx, claims)
+}
+
+// revokeAccessToken - добавление access-токена в denylist до истечения его срока действия
+func (j *JWT) revokeAccessToken(ctx context.Context, claims entity.UserClaims) error {
+	if claims.ExpiresAt == nil {
+		return apperror.ErrMalformedToken
+	}
+
+	ttl := time.Until(claims.ExpiresAt.Time)
+	if ttl <= 0 {
+		return nil
+	}
+
+	err := j.cache.SetRevokedAccessToken(ctx, claims.ID, ttl)
+	if err != nil {
+		return errors.Wrap(err, "cache.SetRevokedAccessToken")
+	}
+
+	return nil
+}
+
+// GetJWKS - получение публичных ключей для проверки токенов сторонними сервисами
+func (j *JWT) GetJWKS(ctx context.Context) (jwks.Set, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGetJWKS)
+	defer span.End()
+
+	set, err := j.keyRing.GetJWKS(ctx)
+	if err != nil {
+		return jwks.Set{}, errors.Wrap(err, "keyRing.GetJWKS")
+	}
+
+	return set, nil
+}
+
+// Introspect - интроспекция токена по RFC 7662. Недействительный, отозванный или неизвестный токен
+// возвращается как неактивный без ошибки
+func (j *JWT) Introspect(ctx context.Context, token, tokenTypeHint string) (entity.TokenIntrospection, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceIntrospect)
+	defer span.End()
+
+	isJWT := strings.Count(token, ".") == 2
+	if tokenTypeHint == entity.TokenTypeHintRefreshToken || !isJWT {
+		introspection, err := j.introspectRefreshToken(ctx, token)
+		if err != nil || introspection.Active || !isJWT {
+			return introspection, err
+		}
+	}
+
+	return j.introspectAccessToken(ctx, token)
+}
+
+// introspectAccessToken - интроспекция access-токена
+func (j *JWT) introspectAccessToken(ctx context.Context, token string) (entity.TokenIntrospection, error) {
+	claims, err := j.verifyAccessToken(ctx, token)
+	if err != nil {
+		logging.Debugf("introspect: inactive access token: %s", err)
+		return entity.TokenIntrospection{}, nil
+	}
+
+	err = j.checkAccessToken(ctx, claims)
+	if err != nil {
+		if errors.Is(err, apperror.ErrMalformedToken) || errors.Is(err, apperror.ErrTokenRevoked) {
+			return entity.TokenIntrospection{}, nil
+		}
+		return entity.TokenIntrospection{}, err
+	}
+
+	return entity.TokenIntrospection{
+		ExpiresAt: &claims.ExpiresAt.Time,
+		IssuedAt:  &claims.IssuedAt.Time,
+		Active:    true,
+		TokenType: entity.TokenTypeHintAccessToken,
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		Role:      claims.Role,
+		ID:        claims.ID,
+		SessionID: claims.SessionID,
+		ClientID:  claims.ClientID,
+		Scope:     claims.Scope,
+		Actor:     claims.Actor,
+	}, nil
+}
+
+// introspectRefreshToken - интроспекция рефреш-токена, данные пользователя берутся на момент запроса
+func (j *JWT) introspectRefreshToken(ctx context.Context, token string) (entity.TokenIntrospection, error) {
+	refreshToken, err := j.cache.GetRefreshToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, apperror.ErrRedisNil) {
+			return entity.TokenIntrospection{}, nil
+		}
+		return entity.TokenIntrospection{}, errors.Wrap(err, "cache.GetRefreshToken")
+	}
+
+	session, err := j.cache.GetSession(ctx, refreshToken.FamilyID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrRedisNil) {
+			return entity.TokenIntrospection{}, nil
+		}
+		return entity.TokenIntrospection{}, errors.Wrap(err, "cache.GetSession")
+	}
+
+	user, err := j.getUser(ctx, refreshToken.UserID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrUserNotFound) {
+			return entity.TokenIntrospection{}, nil
+		}
+		return entity.TokenIntrospection{}, err
+	}
 
-	return accessToken, refreshToken, err
+	expiresAt := refreshToken.CreatedDate.Add(j.refreshTTL)
+	return entity.TokenIntrospection{
+		ExpiresAt: &expiresAt,
+		IssuedAt:  &refreshToken.CreatedDate,
+		Active:    true,
+		TokenType: entity.TokenTypeHintRefreshToken,
+		Subject:   user.ID,
+		Email:     user.Email,
+		Role:      string(user.Role),
+		SessionID: session.ID,
+		ClientID:  session.ClientID,
+		Scope:     session.Scope,
+	}, nil
 }
@@ -0,0 +1,489 @@
+package service
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/claims"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const testReuseGraceSec = 10
+
+var (
+	testUser   = entity.User{ID: "user-1", Email: "ivan.petrov@example.com", EmailVerified: true}
+	testClient = entity.ClientInfo{IP: "10.0.0.1", UserAgent: "test-agent"}
+)
+
+func newTestJWT(t *testing.T) (IJWT, *fakeCache) {
+	t.Helper()
+
+	fake := newFakeCache()
+	fake.users[testUser.ID] = testUser
+
+	jwtService := NewJWT(nil, fake, newFakeKeyRing(t), claims.NewPipeline(0, nil), 300, 3600, testReuseGraceSec, 0,
+		"https://auth.example.com", "https://auth.example.com", "auth-service", config.EmailVerificationModeClaim)
+
+	return jwtService, fake
+}
+
+func TestUpdateRefreshTokenRotation(t *testing.T) {
+	ctx := context.Background()
+	jwtService, _ := newTestJWT(t)
+
+	_, refreshToken, err := jwtService.GenerateAccessAndRefreshTokens(ctx, testUser, testClient)
+	require.NoError(t, err)
+
+	accessToken, successor, err := jwtService.UpdateRefreshToken(ctx, refreshToken, testClient)
+	require.NoError(t, err)
+	assert.NotEmpty(t, accessToken)
+	assert.NotEmpty(t, successor)
+	assert.NotEqual(t, refreshToken, successor)
+
+	accessToken, next, err := jwtService.UpdateRefreshToken(ctx, successor, testClient)
+	require.NoError(t, err)
+	assert.NotEmpty(t, accessToken)
+	assert.NotEmpty(t, next)
+}
+
+func TestUpdateRefreshTokenUnknown(t *testing.T) {
+	jwtService, _ := newTestJWT(t)
+
+	_, _, err := jwtService.UpdateRefreshToken(context.Background(), "unknown", testClient)
+	assert.ErrorIs(t, err, apperror.ErrRefreshTokenNotFound)
+}
+
+func TestUpdateRefreshTokenOtherClient(t *testing.T) {
+	ctx := context.Background()
+	jwtService, _ := newTestJWT(t)
+
+	_, refreshToken, err := jwtService.GenerateAccessAndRefreshTokens(ctx, testUser, testClient)
+	require.NoError(t, err)
+
+	client := testClient
+	client.ClientID = "other-client"
+	_, _, err = jwtService.UpdateRefreshToken(ctx, refreshToken, client)
+	assert.ErrorIs(t, err, apperror.ErrRefreshTokenNotFound)
+
+	// токен не погашен чужим клиентом и остается у владельца
+	_, successor, err := jwtService.UpdateRefreshToken(ctx, refreshToken, testClient)
+	require.NoError(t, err)
+	assert.NotEmpty(t, successor)
+}
+
+func TestUpdateRefreshTokenReplayInGraceWindow(t *testing.T) {
+	ctx := context.Background()
+	jwtService, _ := newTestJWT(t)
+
+	_, refreshToken, err := jwtService.GenerateAccessAndRefreshTokens(ctx, testUser, testClient)
+	require.NoError(t, err)
+
+	_, successor, err := jwtService.UpdateRefreshToken(ctx, refreshToken, testClient)
+	require.NoError(t, err)
+
+	// параллельный запрос того же клиента получает только access-токен, преемник ему не выдается
+	accessToken, replayed, err := jwtService.UpdateRefreshToken(ctx, refreshToken, testClient)
+	require.NoError(t, err)
+	assert.NotEmpty(t, accessToken)
+	assert.Empty(t, replayed)
+
+	// семейство не отозвано: преемник продолжает работать
+	_, next, err := jwtService.UpdateRefreshToken(ctx, successor, testClient)
+	require.NoError(t, err)
+	assert.NotEmpty(t, next)
+}
+
+func TestUpdateRefreshTokenReuse(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  entity.ClientInfo
+		rotated time.Duration
+	}{
+		{
+			name:   "other user agent in grace window",
+			client: entity.ClientInfo{IP: testClient.IP, UserAgent: "other-agent"},
+		},
+		{
+			name:   "other ip in grace window",
+			client: entity.ClientInfo{IP: "10.0.0.2", UserAgent: testClient.UserAgent},
+		},
+		{
+			name:    "same client after grace window",
+			client:  testClient,
+			rotated: -(testReuseGraceSec + 1) * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			jwtService, fake := newTestJWT(t)
+
+			_, refreshToken, err := jwtService.GenerateAccessAndRefreshTokens(ctx, testUser, testClient)
+			require.NoError(t, err)
+
+			_, successor, err := jwtService.UpdateRefreshToken(ctx, refreshToken, testClient)
+			require.NoError(t, err)
+
+			rotated := fake.rotatedTokens[refreshToken]
+			rotated.RotatedDate = rotated.RotatedDate.Add(tt.rotated)
+			fake.rotatedTokens[refreshToken] = rotated
+
+			_, _, err = jwtService.UpdateRefreshToken(ctx, refreshToken, tt.client)
+			assert.ErrorIs(t, err, apperror.ErrRefreshTokenReused)
+			assert.NotContains(t, fake.sessions, rotated.FamilyID)
+
+			// повторное предъявление отзывает все семейство вместе с преемником
+			_, _, err = jwtService.UpdateRefreshToken(ctx, successor, testClient)
+			assert.ErrorIs(t, err, apperror.ErrRefreshTokenNotFound)
+		})
+	}
+}
+
+func TestParseAccessTokenRevokedBefore(t *testing.T) {
+	ctx := context.Background()
+	jwtService, fake := newTestJWT(t)
+
+	// отзыв в середине секунды: токены той же секунды различаются по миллисекундам iat
+	cutoff := time.Now().Add(-time.Minute).Truncate(time.Second).Add(500 * time.Millisecond)
+	err := fake.SetUserTokensRevokedBefore(ctx, testUser.ID, cutoff, "", time.Hour)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		wantErr  error
+	}{
+		{
+			name:     "issued in same second before revocation",
+			issuedAt: cutoff.Add(-time.Millisecond),
+			wantErr:  apperror.ErrTokenRevoked,
+		},
+		{
+			name:     "issued at revocation",
+			issuedAt: cutoff,
+			wantErr:  apperror.ErrTokenRevoked,
+		},
+		{
+			name:     "issued in same second after revocation",
+			issuedAt: cutoff.Add(time.Millisecond),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// токен имперсонации не привязан к сессии и отзывается только моментом отзыва токенов пользователя
+			token, err := jwtService.GenerateImpersonationToken(ctx, testUser, entity.Impersonation{
+				ID:          tt.name,
+				ActorID:     "admin-1",
+				CreatedDate: tt.issuedAt,
+				ExpiresDate: tt.issuedAt.Add(time.Hour),
+			})
+			require.NoError(t, err)
+
+			claims, err := jwtService.ParseAccessToken(ctx, token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.WithinDuration(t, tt.issuedAt, claims.IssuedAt.Time, time.Microsecond)
+		})
+	}
+}
+
+func TestExchangeToken(t *testing.T) {
+	account := entity.ServiceAccount{
+		ID:     "account-1",
+		Scopes: []string{entity.ScopeTokenExchange, entity.ScopeOpenID, entity.PersonalScopeUsersRead},
+	}
+	scopedClient := entity.ClientInfo{IP: testClient.IP, UserAgent: testClient.UserAgent, ClientID: "client-1",
+		Scope: "openid users:read sessions:read"}
+
+	tests := []struct {
+		name      string
+		client    entity.ClientInfo
+		requested []string
+		wantScope string
+		wantErr   error
+	}{
+		{
+			name:      "narrowed scoped token",
+			client:    scopedClient,
+			requested: []string{entity.PersonalScopeUsersRead},
+			wantScope: "users:read",
+		},
+		{
+			name:      "scoped token without requested scopes",
+			client:    scopedClient,
+			wantScope: "openid users:read sessions:read",
+		},
+		{
+			name:      "scope not granted to subject token",
+			client:    entity.ClientInfo{ClientID: "client-1", Scope: "openid"},
+			requested: []string{entity.PersonalScopeUsersRead},
+			wantErr:   apperror.ErrScopeNotAllowed,
+		},
+		{
+			name:      "scope not allowed to service account",
+			client:    scopedClient,
+			requested: []string{entity.PersonalScopeSessionsRead},
+			wantErr:   apperror.ErrScopeNotAllowed,
+		},
+		{
+			name:      "unrestricted token narrowed",
+			client:    testClient,
+			requested: []string{entity.ScopeOpenID, entity.PersonalScopeUsersRead},
+			wantScope: "openid users:read",
+		},
+		{
+			name:      "unrestricted token with scope not allowed to service account",
+			client:    testClient,
+			requested: []string{entity.PersonalScopeAdmin},
+			wantErr:   apperror.ErrScopeNotAllowed,
+		},
+		{
+			name:      "unrestricted token with unknown scope",
+			client:    testClient,
+			requested: []string{"orders:write"},
+			wantErr:   apperror.ErrScopeNotAllowed,
+		},
+		{
+			name:      "service account capability scope",
+			client:    testClient,
+			requested: []string{entity.ScopeTokenExchange},
+			wantErr:   apperror.ErrScopeNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			jwtService, _ := newTestJWT(t)
+
+			subjectToken, _, err := jwtService.GenerateAccessAndRefreshTokens(ctx, testUser, tt.client)
+			require.NoError(t, err)
+
+			token, err := jwtService.ExchangeToken(ctx, account, entity.TokenExchange{
+				SubjectToken:     subjectToken,
+				SubjectTokenType: entity.TokenTypeAccessToken,
+				Audience:         "orders",
+				Scopes:           tt.requested,
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantScope, token.Scope)
+			assert.Equal(t, entity.TokenTypeAccessToken, token.IssuedTokenType)
+		})
+	}
+}
+
+func TestExchangeTokenInvalidSubject(t *testing.T) {
+	ctx := context.Background()
+	jwtService, fake := newTestJWT(t)
+	account := entity.ServiceAccount{ID: "account-1", Scopes: []string{entity.ScopeTokenExchange}}
+
+	serviceToken, err := jwtService.GenerateClientCredentialsToken(ctx, account, nil)
+	require.NoError(t, err)
+
+	revokedToken, _, err := jwtService.GenerateAccessAndRefreshTokens(ctx, testUser, testClient)
+	require.NoError(t, err)
+	revoked, err := jwtService.ParseAccessToken(ctx, revokedToken)
+	require.NoError(t, err)
+	err = fake.DeleteRefreshFamily(ctx, revoked.SessionID)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		subjectToken string
+	}{
+		{
+			name:         "service account token",
+			subjectToken: serviceToken.AccessToken,
+		},
+		{
+			name:         "revoked token",
+			subjectToken: revokedToken,
+		},
+		{
+			name:         "not a jwt",
+			subjectToken: "pat_0123456789",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jwtService.ExchangeToken(ctx, account, entity.TokenExchange{
+				SubjectToken:     tt.subjectToken,
+				SubjectTokenType: entity.TokenTypeAccessToken,
+				Audience:         "orders",
+			})
+			assert.ErrorIs(t, err, apperror.ErrInvalidSubjectToken)
+		})
+	}
+}
+
+func TestParseAccessTokenClaims(t *testing.T) {
+	ctx := context.Background()
+	keyRing := newFakeKeyRing(t)
+	// допуск на расхождение часов 30 секунд
+	jwtService := NewJWT(nil, newFakeCache(), keyRing, claims.NewPipeline(0, nil), 300, 3600, testReuseGraceSec, 30,
+		"https://auth.example.com", "https://auth.example.com", "auth-service", config.EmailVerificationModeClaim)
+
+	now := time.Now()
+	valid := func() entity.UserClaims {
+		return entity.UserClaims{RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "token-1",
+			Issuer:    "https://auth.example.com",
+			Subject:   testUser.ID,
+			Audience:  jwt.ClaimStrings{"auth-service"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		}}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(claims *entity.UserClaims)
+		kid     string
+		wantErr error
+	}{
+		{
+			name:   "valid",
+			modify: func(claims *entity.UserClaims) {},
+		},
+		{
+			name: "service among several audiences",
+			modify: func(claims *entity.UserClaims) {
+				claims.Audience = jwt.ClaimStrings{"orders", "auth-service"}
+			},
+		},
+		{
+			name: "audience of other service",
+			modify: func(claims *entity.UserClaims) {
+				claims.Audience = jwt.ClaimStrings{"orders"}
+			},
+			wantErr: apperror.ErrInvalidAudience,
+		},
+		{
+			name: "without audience",
+			modify: func(claims *entity.UserClaims) {
+				claims.Audience = nil
+			},
+			wantErr: apperror.ErrInvalidAudience,
+		},
+		{
+			name: "other issuer",
+			modify: func(claims *entity.UserClaims) {
+				claims.Issuer = "https://evil.example.com"
+			},
+			wantErr: apperror.ErrInvalidIssuer,
+		},
+		{
+			name: "expired within leeway",
+			modify: func(claims *entity.UserClaims) {
+				claims.ExpiresAt = jwt.NewNumericDate(now.Add(-10 * time.Second))
+			},
+		},
+		{
+			name: "expired beyond leeway",
+			modify: func(claims *entity.UserClaims) {
+				claims.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute))
+			},
+			wantErr: apperror.ErrTokenIsInspired,
+		},
+		{
+			name: "issued in future within leeway",
+			modify: func(claims *entity.UserClaims) {
+				claims.IssuedAt = jwt.NewNumericDate(now.Add(10 * time.Second))
+				claims.NotBefore = claims.IssuedAt
+			},
+		},
+		{
+			name: "issued in future beyond leeway",
+			modify: func(claims *entity.UserClaims) {
+				claims.IssuedAt = jwt.NewNumericDate(now.Add(time.Minute))
+			},
+			wantErr: apperror.ErrTokenNotYetValid,
+		},
+		{
+			name: "not valid yet beyond leeway",
+			modify: func(claims *entity.UserClaims) {
+				claims.NotBefore = jwt.NewNumericDate(now.Add(time.Minute))
+			},
+			wantErr: apperror.ErrTokenNotYetValid,
+		},
+		{
+			name: "without jti",
+			modify: func(claims *entity.UserClaims) {
+				claims.ID = ""
+			},
+			wantErr: apperror.ErrMalformedToken,
+		},
+		{
+			name: "without iat",
+			modify: func(claims *entity.UserClaims) {
+				claims.IssuedAt = nil
+			},
+			wantErr: apperror.ErrMalformedToken,
+		},
+		{
+			name:    "unknown kid",
+			modify:  func(claims *entity.UserClaims) {},
+			kid:     "rotated-away",
+			wantErr: apperror.ErrUnknownKeyID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenClaims := valid()
+			tt.modify(&tokenClaims)
+
+			kid := keyRing.key.ID
+			if tt.kid != "" {
+				kid = tt.kid
+			}
+			token := jwt.NewWithClaims(jwt.GetSigningMethod(keyRing.key.Algorithm), tokenClaims)
+			token.Header["kid"] = kid
+			accessToken, err := token.SignedString(keyRing.key.PrivateKey)
+			require.NoError(t, err)
+
+			parsed, err := jwtService.ParseAccessToken(ctx, accessToken)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testUser.ID, parsed.Subject)
+		})
+	}
+}
+
+func TestParseAccessTokenUnsignedRejected(t *testing.T) {
+	jwtService, _ := newTestJWT(t)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodNone, entity.UserClaims{RegisteredClaims: jwt.RegisteredClaims{
+		ID:        "token-1",
+		Issuer:    "https://auth.example.com",
+		Subject:   testUser.ID,
+		Audience:  jwt.ClaimStrings{"auth-service"},
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}})
+	token.Header["kid"] = "test-key"
+	accessToken, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	_, err = jwtService.ParseAccessToken(context.Background(), accessToken)
+	assert.Error(t, err)
+}
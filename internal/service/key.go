@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/repository/postgres"
+	"github.com/GermanBogatov/auth-service/pkg/cipher"
+	"github.com/GermanBogatov/auth-service/pkg/jwks"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+// minReloadInterval - минимальный интервал перечитывания ключей при встрече неизвестного kid
+const minReloadInterval = 5 * time.Second
+
+var _ IKeyRing = &KeyRing{}
+
+type IKeyRing interface {
+	GetSigningKey(ctx context.Context) (entity.SigningKey, error)
+	GetVerificationKey(ctx context.Context, kid string) (entity.SigningKey, error)
+	GetJWKS(ctx context.Context) (jwks.Set, error)
+	GetKeys(ctx context.Context) ([]entity.SigningKey, error)
+	Init(ctx context.Context) error
+	RotateKeys(ctx context.Context) error
+	RetireKey(ctx context.Context, kid string, immediately bool) error
+	StartRotation(ctx context.Context)
+}
+
+type KeyRing struct {
+	keyRepo          postgres.IKey
+	cipher           *cipher.AESGCM
+	algorithm        string
+	rotationInterval time.Duration
+	retiredGrace     time.Duration
+	refreshInterval  time.Duration
+
+	mu       sync.RWMutex
+	keys     []entity.SigningKey
+	loadedAt time.Time
+}
+
+func NewKeyRing(keyRepo postgres.IKey, cipher *cipher.AESGCM, algorithm string, rotationIntervalHour,
+	retiredGraceHour, refreshIntervalSec int) IKeyRing {
+	return &KeyRing{
+		keyRepo:          keyRepo,
+		cipher:           cipher,
+		algorithm:        algorithm,
+		rotationInterval: time.Duration(rotationIntervalHour) * time.Hour,
+		retiredGrace:     time.Duration(retiredGraceHour) * time.Hour,
+		refreshInterval:  time.Duration(refreshIntervalSec) * time.Second,
+	}
+}
+
+// Init - загрузка ключей, при пустом хранилище создаются активный и следующий ключи
+func (k *KeyRing) Init(ctx context.Context) error {
+	err := k.reload(ctx)
+	if err != nil {
+		return errors.Wrap(err, "reload")
+	}
+
+	if _, ok := k.findByStatus(entity.KeyStatusActive); ok {
+		if _, ok = k.findByStatus(entity.KeyStatusNext); ok {
+			return nil
+		}
+	}
+
+	logging.Info("signing keys are incomplete, rotating...")
+	return k.RotateKeys(ctx)
+}
+
+// GetSigningKey - получение ключа, которым подписываются новые токены
+func (k *KeyRing) GetSigningKey(ctx context.Context) (entity.SigningKey, error) {
+	key, ok := k.findByStatus(entity.KeyStatusActive)
+	if ok {
+		return key, nil
+	}
+
+	err := k.reload(ctx)
+	if err != nil {
+		return entity.SigningKey{}, errors.Wrap(err, "reload")
+	}
+
+	key, ok = k.findByStatus(entity.KeyStatusActive)
+	if !ok {
+		return entity.SigningKey{}, apperror.ErrActiveKeyNotFound
+	}
+
+	return key, nil
+}
+
+// GetVerificationKey - получение ключа для проверки подписи по kid
+func (k *KeyRing) GetVerificationKey(ctx context.Context, kid string) (entity.SigningKey, error) {
+	key, ok := k.findByID(kid)
+	if ok {
+		return key, nil
+	}
+
+	// ключ мог появиться на другом инстансе после ротации
+	k.mu.RLock()
+	stale := time.Since(k.loadedAt) > minReloadInterval
+	k.mu.RUnlock()
+	if !stale {
+		return entity.SigningKey{}, apperror.ErrUnknownKeyID
+	}
+
+	err := k.reload(ctx)
+	if err != nil {
+		return entity.SigningKey{}, errors.Wrap(err, "reload")
+	}
+
+	key, ok = k.findByID(kid)
+	if !ok {
+		return entity.SigningKey{}, apperror.ErrUnknownKeyID
+	}
+
+	return key, nil
+}
+
+// GetJWKS - получение набора публичных ключей для публикации (следующий, активный и выведенные в grace-периоде)
+func (k *KeyRing) GetJWKS(_ context.Context) (jwks.Set, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	set := jwks.Set{Keys: make([]jwks.Key, 0, len(k.keys))}
+	for _, key := range k.keys {
+		if !isVerificationKey(key) {
+			continue
+		}
+
+		jwk, err := jwks.NewKey(key.ID, key.Algorithm, key.PublicKey())
+		if err != nil {
+			return jwks.Set{}, errors.Wrap(err, "jwks.NewKey")
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set, nil
+}
+
+// GetKeys - получение всех ключей из хранилища
+func (k *KeyRing) GetKeys(ctx context.Context) ([]entity.SigningKey, error) {
+	err := k.reload(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "reload")
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	keys := make([]entity.SigningKey, len(k.keys))
+	copy(keys, k.keys)
+	return keys, nil
+}
+
+// RotateKeys - внеплановая ротация ключей
+func (k *KeyRing) RotateKeys(ctx context.Context) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceRotateKeys)
+	defer span.End()
+
+	_, err := k.rotate(ctx, nil)
+	return err
+}
+
+// RetireKey - вывод ключа из оборота. Активный ключ выводится через ротацию,
+// при immediately ключ сразу перестает проверять подписи
+func (k *KeyRing) RetireKey(ctx context.Context, kid string, immediately bool) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceRetireKey)
+	defer span.End()
+
+	err := k.reload(ctx)
+	if err != nil {
+		return errors.Wrap(err, "reload")
+	}
+
+	if key, ok := k.findByID(kid); ok && key.Status == entity.KeyStatusActive {
+		_, err = k.rotate(ctx, nil)
+		if err != nil {
+			return errors.Wrap(err, "rotate")
+		}
+		if !immediately {
+			return nil
+		}
+	}
+
+	expiresDate := time.Now().UTC().Add(k.retiredGrace)
+	if immediately {
+		expiresDate = time.Now().UTC()
+	}
+
+	status, err := k.keyRepo.RetireKey(ctx, kid, expiresDate)
+	if err != nil {
+		return errors.Wrap(err, "keyRepo.RetireKey")
+	}
+
+	// выведенный следующий ключ нужно заменить новым
+	if status == entity.KeyStatusNext {
+		next, errGenerate := k.generateKey(entity.KeyStatusNext)
+		if errGenerate != nil {
+			return errors.Wrap(errGenerate, "generate next key")
+		}
+
+		err = k.keyRepo.CreateKey(ctx, next)
+		if err != nil {
+			return errors.Wrap(err, "keyRepo.CreateKey")
+		}
+	}
+
+	return k.reload(ctx)
+}
+
+// StartRotation - периодическое обновление ключей из хранилища и плановая ротация
+func (k *KeyRing) StartRotation(ctx context.Context) {
+	ticker := time.NewTicker(k.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			activatedBefore := time.Now().UTC().Add(-k.rotationInterval)
+			rotated, err := k.rotate(ctx, &activatedBefore)
+			if err != nil {
+				logging.Errorf("error rotate signing keys: %s", err)
+				continue
+			}
+			if rotated {
+				logging.Info("signing keys rotated")
+			}
+		}
+	}
+}
+
+// rotate - ротация ключей, при activatedBefore выполняется только если активный ключ старше этого момента
+func (k *KeyRing) rotate(ctx context.Context, activatedBefore *time.Time) (bool, error) {
+	if activatedBefore != nil {
+		// без обращения к генерации ключей, если ротация явно не нужна
+		if key, ok := k.findByStatus(entity.KeyStatusActive); ok && key.ActivatedDate != nil && key.ActivatedDate.After(*activatedBefore) {
+			return false, k.reload(ctx)
+		}
+	}
+
+	next, err := k.generateKey(entity.KeyStatusNext)
+	if err != nil {
+		return false, errors.Wrap(err, "generate next key")
+	}
+
+	active, err := k.generateKey(entity.KeyStatusActive)
+	if err != nil {
+		return false, errors.Wrap(err, "generate active key")
+	}
+
+	rotated, err := k.keyRepo.RotateKeys(ctx, entity.KeyRotation{
+		Next:            next,
+		Active:          active,
+		RetiredUntil:    time.Now().UTC().Add(k.retiredGrace),
+		ActivatedBefore: activatedBefore,
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "keyRepo.RotateKeys")
+	}
+
+	return rotated, k.reload(ctx)
+}
+
+// reload - перечитывание ключей из хранилища
+func (k *KeyRing) reload(ctx context.Context) error {
+	stored, err := k.keyRepo.GetKeys(ctx)
+	if err != nil {
+		return errors.Wrap(err, "keyRepo.GetKeys")
+	}
+
+	keys := make([]entity.SigningKey, 0, len(stored))
+	for _, key := range stored {
+		data, errDecrypt := k.cipher.Decrypt(key.EncryptedKey)
+		if errDecrypt != nil {
+			logging.Errorf("error decrypt signing key [%s]: %s", key.ID, errDecrypt)
+			continue
+		}
+
+		key.PrivateKey, err = jwks.ParsePrivateKeyPEM(data)
+		if err != nil {
+			logging.Errorf("error parse signing key [%s]: %s", key.ID, err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.loadedAt = time.Now()
+	k.mu.Unlock()
+
+	return nil
+}
+
+// generateKey - генерация и шифрование нового ключа
+func (k *KeyRing) generateKey(status entity.KeyStatus) (entity.SigningKey, error) {
+	privateKey, err := jwks.GenerateKey(k.algorithm)
+	if err != nil {
+		return entity.SigningKey{}, errors.Wrap(err, "jwks.GenerateKey")
+	}
+
+	key, err := NewSigningKey(k.algorithm, privateKey)
+	if err != nil {
+		return entity.SigningKey{}, err
+	}
+
+	data, err := jwks.EncodePrivateKeyPEM(privateKey)
+	if err != nil {
+		return entity.SigningKey{}, errors.Wrap(err, "jwks.EncodePrivateKeyPEM")
+	}
+
+	key.EncryptedKey, err = k.cipher.Encrypt(data)
+	if err != nil {
+		return entity.SigningKey{}, errors.Wrap(err, "cipher.Encrypt")
+	}
+
+	key.Status = status
+	key.CreatedDate = time.Now().UTC()
+	return key, nil
+}
+
+func (k *KeyRing) findByStatus(status entity.KeyStatus) (entity.SigningKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.Status == status {
+			return key, true
+		}
+	}
+	return entity.SigningKey{}, false
+}
+
+func (k *KeyRing) findByID(kid string) (entity.SigningKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.ID == kid && isVerificationKey(key) {
+			return key, true
+		}
+	}
+	return entity.SigningKey{}, false
+}
+
+// isVerificationKey - ключ еще может проверять подписи
+func isVerificationKey(key entity.SigningKey) bool {
+	if key.Status != entity.KeyStatusRetired {
+		return true
+	}
+	return key.ExpiresDate != nil && key.ExpiresDate.After(time.Now().UTC())
+}
+
+// NewSigningKey - сборка ключа подписи, kid вычисляется как отпечаток публичного ключа
+func NewSigningKey(alg string, privateKey crypto.Signer) (entity.SigningKey, error) {
+	err := jwks.ValidateKey(alg, privateKey)
+	if err != nil {
+		return entity.SigningKey{}, errors.Wrap(err, "jwks.ValidateKey")
+	}
+
+	kid, err := jwks.Thumbprint(privateKey.Public())
+	if err != nil {
+		return entity.SigningKey{}, errors.Wrap(err, "jwks.Thumbprint")
+	}
+
+	return entity.SigningKey{
+		PrivateKey: privateKey,
+		ID:         kid,
+		Algorithm:  alg,
+	}, nil
+}
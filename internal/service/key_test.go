@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/cipher"
+	"github.com/GermanBogatov/auth-service/pkg/claims"
+	"github.com/GermanBogatov/auth-service/pkg/jwks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const testKeyEncryptionKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func newTestKeyRing(t *testing.T, keyRepo *fakeKeyRepo) *KeyRing {
+	t.Helper()
+
+	keyCipher, err := cipher.NewAESGCM(testKeyEncryptionKey)
+	require.NoError(t, err)
+
+	keyRing := NewKeyRing(keyRepo, keyCipher, jwks.AlgES256, 24, 1, 60).(*KeyRing)
+	require.NoError(t, keyRing.Init(context.Background()))
+	return keyRing
+}
+
+// jwksKeyIDs - kid ключей, опубликованных в jwks
+func jwksKeyIDs(t *testing.T, keyRing IKeyRing) []string {
+	t.Helper()
+
+	set, err := keyRing.GetJWKS(context.Background())
+	require.NoError(t, err)
+
+	ids := make([]string, 0, len(set.Keys))
+	for _, key := range set.Keys {
+		ids = append(ids, key.Kid)
+	}
+	return ids
+}
+
+func TestKeyRingInit(t *testing.T) {
+	ctx := context.Background()
+	keyRepo := &fakeKeyRepo{}
+	keyRing := newTestKeyRing(t, keyRepo)
+
+	require.Len(t, keyRepo.keys, 2)
+	active, err := keyRing.GetSigningKey(ctx)
+	require.NoError(t, err)
+	next, ok := keyRing.findByStatus(entity.KeyStatusNext)
+	require.True(t, ok)
+	assert.NotEqual(t, active.ID, next.ID)
+	assert.ElementsMatch(t, []string{active.ID, next.ID}, jwksKeyIDs(t, keyRing))
+
+	// kid - отпечаток публичного ключа
+	kid, err := jwks.Thumbprint(active.PublicKey())
+	require.NoError(t, err)
+	assert.Equal(t, kid, active.ID)
+
+	// повторная инициализация не создает новых ключей
+	require.NoError(t, newTestKeyRing(t, keyRepo).Init(ctx))
+	assert.Len(t, keyRepo.keys, 2)
+}
+
+func TestKeyRingRotateKeys(t *testing.T) {
+	ctx := context.Background()
+	keyRing := newTestKeyRing(t, &fakeKeyRepo{})
+
+	before, err := keyRing.GetSigningKey(ctx)
+	require.NoError(t, err)
+	next, _ := keyRing.findByStatus(entity.KeyStatusNext)
+
+	require.NoError(t, keyRing.RotateKeys(ctx))
+
+	// следующий ключ становится активным, прежний активный проверяет подписи в grace-периоде
+	after, err := keyRing.GetSigningKey(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, next.ID, after.ID)
+
+	retired, err := keyRing.GetVerificationKey(ctx, before.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.KeyStatusRetired, retired.Status)
+
+	newNext, ok := keyRing.findByStatus(entity.KeyStatusNext)
+	require.True(t, ok)
+	assert.ElementsMatch(t, []string{before.ID, after.ID, newNext.ID}, jwksKeyIDs(t, keyRing))
+}
+
+func TestKeyRingScheduledRotation(t *testing.T) {
+	tests := []struct {
+		name          string
+		activatedAgo  time.Duration
+		wantRotated   bool
+		wantNewActive bool
+	}{
+		{
+			name:         "active key is fresh",
+			activatedAgo: time.Hour,
+		},
+		{
+			name:          "active key is older than rotation interval",
+			activatedAgo:  25 * time.Hour,
+			wantRotated:   true,
+			wantNewActive: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			keyRepo := &fakeKeyRepo{}
+			keyRing := newTestKeyRing(t, keyRepo)
+			before, err := keyRing.GetSigningKey(ctx)
+			require.NoError(t, err)
+
+			keyRepo.setActivatedDate(time.Now().UTC().Add(-tt.activatedAgo))
+			require.NoError(t, keyRing.reload(ctx))
+
+			activatedBefore := time.Now().UTC().Add(-keyRing.rotationInterval)
+			rotated, err := keyRing.rotate(ctx, &activatedBefore)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRotated, rotated)
+
+			after, err := keyRing.GetSigningKey(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantNewActive, before.ID != after.ID)
+		})
+	}
+}
+
+func TestKeyRingRetireKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      entity.KeyStatus
+		kid         string
+		immediately bool
+		// wantVerifies - выведенный ключ еще проверяет подписи
+		wantVerifies bool
+		wantErr      error
+	}{
+		{
+			name:         "active key with grace",
+			status:       entity.KeyStatusActive,
+			wantVerifies: true,
+		},
+		{
+			name:        "active key immediately",
+			status:      entity.KeyStatusActive,
+			immediately: true,
+		},
+		{
+			name:         "next key with grace",
+			status:       entity.KeyStatusNext,
+			wantVerifies: true,
+		},
+		{
+			name:        "next key immediately",
+			status:      entity.KeyStatusNext,
+			immediately: true,
+		},
+		{
+			name:    "unknown key",
+			kid:     "missing",
+			wantErr: apperror.ErrKeyNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			keyRing := newTestKeyRing(t, &fakeKeyRepo{})
+
+			kid := tt.kid
+			if tt.status != "" {
+				key, ok := keyRing.findByStatus(tt.status)
+				require.True(t, ok)
+				kid = key.ID
+			}
+
+			err := keyRing.RetireKey(ctx, kid, tt.immediately)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			// после вывода любого ключа есть и активный, и следующий, отличные от выведенного
+			active, err := keyRing.GetSigningKey(ctx)
+			require.NoError(t, err)
+			assert.NotEqual(t, kid, active.ID)
+			next, ok := keyRing.findByStatus(entity.KeyStatusNext)
+			require.True(t, ok)
+			assert.NotEqual(t, kid, next.ID)
+
+			// проверка с перечитыванием хранилища, как на инстансе, не выполнявшем вывод
+			keyRing.loadedAt = time.Time{}
+			_, err = keyRing.GetVerificationKey(ctx, kid)
+			if tt.wantVerifies {
+				assert.NoError(t, err)
+				assert.Contains(t, jwksKeyIDs(t, keyRing), kid)
+			} else {
+				assert.ErrorIs(t, err, apperror.ErrUnknownKeyID)
+				assert.NotContains(t, jwksKeyIDs(t, keyRing), kid)
+			}
+		})
+	}
+}
+
+func TestKeyRingGetVerificationKeyReload(t *testing.T) {
+	ctx := context.Background()
+	keyRepo := &fakeKeyRepo{}
+	keyRing := newTestKeyRing(t, keyRepo)
+	otherInstance := newTestKeyRing(t, keyRepo)
+
+	// ротация на другом инстансе: новый следующий ключ еще не известен первому
+	require.NoError(t, otherInstance.RotateKeys(ctx))
+	next, ok := otherInstance.findByStatus(entity.KeyStatusNext)
+	require.True(t, ok)
+
+	// ключи перечитаны недавно: неизвестный kid отклоняется без обращения к хранилищу
+	_, err := keyRing.GetVerificationKey(ctx, next.ID)
+	assert.ErrorIs(t, err, apperror.ErrUnknownKeyID)
+
+	keyRing.loadedAt = time.Now().Add(-2 * minReloadInterval)
+	key, err := keyRing.GetVerificationKey(ctx, next.ID)
+	require.NoError(t, err)
+	assert.Equal(t, next.ID, key.ID)
+
+	_, err = keyRing.GetVerificationKey(ctx, "missing")
+	assert.ErrorIs(t, err, apperror.ErrUnknownKeyID)
+}
+
+func TestKeyRingSkipsUndecryptableKeys(t *testing.T) {
+	ctx := context.Background()
+	keyRepo := &fakeKeyRepo{}
+	keyRing := newTestKeyRing(t, keyRepo)
+	active, err := keyRing.GetSigningKey(ctx)
+	require.NoError(t, err)
+
+	expiresDate := time.Now().UTC().Add(time.Hour)
+	require.NoError(t, keyRepo.CreateKey(ctx, entity.SigningKey{
+		ID:           "corrupted",
+		Algorithm:    jwks.AlgES256,
+		Status:       entity.KeyStatusRetired,
+		EncryptedKey: []byte("not encrypted"),
+		ExpiresDate:  &expiresDate,
+	}))
+
+	keys, err := keyRing.GetKeys(ctx)
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+	_, err = keyRing.GetVerificationKey(ctx, "corrupted")
+	assert.ErrorIs(t, err, apperror.ErrUnknownKeyID)
+	_, err = keyRing.GetVerificationKey(ctx, active.ID)
+	assert.NoError(t, err)
+}
+
+func TestAccessTokenAcrossKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	keyRepo := &fakeKeyRepo{}
+	keyRing := newTestKeyRing(t, keyRepo)
+
+	fake := newFakeCache()
+	fake.users[testUser.ID] = testUser
+	jwtService := NewJWT(nil, fake, keyRing, claims.NewPipeline(0, nil), 300, 3600, testReuseGraceSec, 0,
+		"https://auth.example.com", "https://auth.example.com", "auth-service", config.EmailVerificationModeClaim)
+
+	before, _, err := jwtService.GenerateAccessAndRefreshTokens(ctx, testUser, testClient)
+	require.NoError(t, err)
+
+	require.NoError(t, keyRing.RotateKeys(ctx))
+	after, _, err := jwtService.GenerateAccessAndRefreshTokens(ctx, testUser, testClient)
+	require.NoError(t, err)
+
+	// токены, подписанные до ротации, проверяются выведенным ключом до конца grace-периода
+	_, err = jwtService.ParseAccessToken(ctx, before)
+	assert.NoError(t, err)
+	_, err = jwtService.ParseAccessToken(ctx, after)
+	assert.NoError(t, err)
+
+	keys, err := keyRing.GetKeys(ctx)
+	require.NoError(t, err)
+	for _, key := range keys {
+		if key.Status == entity.KeyStatusRetired {
+			require.NoError(t, keyRing.RetireKey(ctx, key.ID, true))
+		}
+	}
+
+	_, err = jwtService.ParseAccessToken(ctx, before)
+	assert.ErrorIs(t, err, apperror.ErrUnknownKeyID)
+	_, err = jwtService.ParseAccessToken(ctx, after)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/repository/cache"
+	"github.com/GermanBogatov/auth-service/internal/repository/postgres"
+	"github.com/GermanBogatov/auth-service/pkg/pkce"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"slices"
+	"time"
+)
+
+var _ IOAuth = &OAuth{}
+
+type IOAuth interface {
+	CreateAuthorizationCode(ctx context.Context, request entity.AuthorizeRequest, userID string) (string, error)
+	ExchangeAuthorizationCode(ctx context.Context, client entity.OAuthClient, exchange entity.CodeExchange, info entity.ClientInfo) (entity.OAuthToken, error)
+	RefreshToken(ctx context.Context, client entity.OAuthClient, refreshToken string, info entity.ClientInfo) (entity.OAuthToken, error)
+}
+
+type OAuth struct {
+	userRepo   postgres.IUser
+	cache      cache.ICache
+	jwtService IJWT
+	codeTTL    time.Duration
+	jwtTTL     time.Duration
+}
+
+func NewOAuth(userRepo postgres.IUser, cache cache.ICache, jwtService IJWT, codeTTLSec, jwtTTL int) IOAuth {
+	return &OAuth{
+		userRepo:   userRepo,
+		cache:      cache,
+		jwtService: jwtService,
+		codeTTL:    time.Duration(codeTTLSec) * time.Second,
+		jwtTTL:     time.Duration(jwtTTL) * time.Second,
+	}
+}
+
+// CreateAuthorizationCode - выпуск кода авторизации для уже проверенного запроса авторизации
+func (o *OAuth) CreateAuthorizationCode(ctx context.Context, request entity.AuthorizeRequest, userID string) (string, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceCreateAuthorizationCode)
+	defer span.End()
+
+	code, err := helpers.GenerateSecret()
+	if err != nil {
+		return "", errors.Wrap(err, "helpers.GenerateSecret")
+	}
+
+	now := time.Now().UTC()
+	err = o.cache.SetAuthorizationCode(ctx, code, entity.AuthorizationCode{
+		CreatedDate:   now,
+		AuthTime:      now,
+		ClientID:      request.ClientID,
+		UserID:        userID,
+		RedirectURI:   request.RedirectURI,
+		Scope:         request.Scope,
+		CodeChallenge: request.CodeChallenge,
+		Nonce:         request.Nonce,
+	}, o.codeTTL)
+	if err != nil {
+		return "", errors.Wrap(err, "cache.SetAuthorizationCode")
+	}
+
+	return code, nil
+}
+
+// ExchangeAuthorizationCode - обмен кода авторизации на токены (grant_type=authorization_code).
+// Код погашается до проверок, поэтому неудачная попытка обмена его тоже сжигает
+func (o *OAuth) ExchangeAuthorizationCode(ctx context.Context, client entity.OAuthClient, exchange entity.CodeExchange,
+	info entity.ClientInfo) (entity.OAuthToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceExchangeAuthorizationCode)
+	defer span.End()
+
+	authCode, err := o.cache.ConsumeAuthorizationCode(ctx, exchange.Code)
+	if err != nil {
+		if errors.Is(err, apperror.ErrRedisNil) {
+			return entity.OAuthToken{}, apperror.ErrInvalidAuthorizationCode
+		}
+		return entity.OAuthToken{}, errors.Wrap(err, "cache.ConsumeAuthorizationCode")
+	}
+
+	if authCode.ClientID != client.ID {
+		return entity.OAuthToken{}, apperror.ErrInvalidAuthorizationCode
+	}
+	if authCode.RedirectURI != exchange.RedirectURI {
+		return entity.OAuthToken{}, apperror.ErrRedirectURIMismatch
+	}
+	if !pkce.Verify(exchange.CodeVerifier, authCode.CodeChallenge) {
+		return entity.OAuthToken{}, apperror.ErrInvalidCodeVerifier
+	}
+
+	user, err := o.userRepo.GetUserByID(ctx, authCode.UserID)
+	if err != nil {
+		return entity.OAuthToken{}, errors.Wrap(err, "userRepo.GetUserByID")
+	}
+
+	info.ClientID = client.ID
+	info.Scope = authCode.Scope
+	info.Audiences = client.Audiences
+	info.DeviceName = client.Name
+
+	accessToken, refreshToken, err := o.jwtService.GenerateAccessAndRefreshTokens(ctx, user, info)
+	if err != nil {
+		return entity.OAuthToken{}, errors.Wrap(err, "jwtService.GenerateAccessAndRefreshTokens")
+	}
+
+	// рефреш-токен отдается только клиентам, которым разрешен соответствующий грант
+	if !client.HasGrantType(entity.GrantTypeRefreshToken) {
+		refreshToken = ""
+	}
+
+	var idToken string
+	scopes := entity.ParseScope(authCode.Scope)
+	if slices.Contains(scopes, entity.ScopeOpenID) {
+		idToken, err = o.jwtService.GenerateIDToken(ctx, entity.IDTokenRequest{
+			AuthTime: authCode.AuthTime,
+			User:     user,
+			ClientID: client.ID,
+			Nonce:    authCode.Nonce,
+			Scopes:   scopes,
+		})
+		if err != nil {
+			return entity.OAuthToken{}, errors.Wrap(err, "jwtService.GenerateIDToken")
+		}
+	}
+
+	return entity.OAuthToken{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		IDToken:      idToken,
+		TokenType:    entity.TokenTypeBearer,
+		Scope:        authCode.Scope,
+		ExpiresIn:    int(o.jwtTTL.Seconds()),
+	}, nil
+}
+
+// RefreshToken - ротация рефреш-токена, выданного oauth-клиенту (grant_type=refresh_token)
+func (o *OAuth) RefreshToken(ctx context.Context, client entity.OAuthClient, refreshToken string, info entity.ClientInfo) (entity.OAuthToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceRefreshOAuthToken)
+	defer span.End()
+
+	info.ClientID = client.ID
+	accessToken, newRefreshToken, err := o.jwtService.UpdateRefreshToken(ctx, refreshToken, info)
+	if err != nil {
+		return entity.OAuthToken{}, errors.Wrap(err, "jwtService.UpdateRefreshToken")
+	}
+
+	return entity.OAuthToken{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+		TokenType:    entity.TokenTypeBearer,
+		ExpiresIn:    int(o.jwtTTL.Seconds()),
+	}, nil
+}
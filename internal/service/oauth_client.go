@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/repository/postgres"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var _ IOAuthClient = &OAuthClient{}
+
+type IOAuthClient interface {
+	CreateOAuthClient(ctx context.Context, client entity.OAuthClient) (entity.OAuthClient, error)
+	GetOAuthClientByID(ctx context.Context, id string) (entity.OAuthClient, error)
+	GetOAuthClients(ctx context.Context) ([]entity.OAuthClient, error)
+	UpdateOAuthClient(ctx context.Context, clientUpdate entity.OAuthClientUpdate) (entity.OAuthClient, error)
+	ResetOAuthClientSecret(ctx context.Context, id string) (entity.OAuthClient, error)
+	DeleteOAuthClientByID(ctx context.Context, id string) error
+	Authenticate(ctx context.Context, clientID, clientSecret string) (entity.OAuthClient, error)
+}
+
+type OAuthClient struct {
+	oauthClientRepo postgres.IOAuthClient
+}
+
+func NewOAuthClient(oauthClientRepo postgres.IOAuthClient) IOAuthClient {
+	return &OAuthClient{
+		oauthClientRepo: oauthClientRepo,
+	}
+}
+
+// CreateOAuthClient - регистрация oauth-клиента, конфиденциальному клиенту выпускается секрет
+func (o *OAuthClient) CreateOAuthClient(ctx context.Context, client entity.OAuthClient) (entity.OAuthClient, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceCreateOAuthClient)
+	defer span.End()
+
+	client.GenerateID()
+	client.GenerateCreatedDate()
+
+	if !client.Public {
+		secret, err := helpers.GenerateSecret()
+		if err != nil {
+			return entity.OAuthClient{}, errors.Wrap(err, "helpers.GenerateSecret")
+		}
+
+		secretHash := helpers.HashSecret(secret)
+		client.Secret = secret
+		client.SecretHash = &secretHash
+	}
+
+	err := o.oauthClientRepo.CreateOAuthClient(ctx, client)
+	if err != nil {
+		return entity.OAuthClient{}, errors.Wrap(err, "oauthClientRepo.CreateOAuthClient")
+	}
+
+	return client, nil
+}
+
+// GetOAuthClientByID - получение oauth-клиента по идентификатору
+func (o *OAuthClient) GetOAuthClientByID(ctx context.Context, id string) (entity.OAuthClient, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGetOAuthClientByID)
+	defer span.End()
+
+	client, err := o.oauthClientRepo.GetOAuthClientByID(ctx, id)
+	if err != nil {
+		return entity.OAuthClient{}, errors.Wrap(err, "oauthClientRepo.GetOAuthClientByID")
+	}
+
+	return client, nil
+}
+
+// GetOAuthClients - получение oauth-клиентов
+func (o *OAuthClient) GetOAuthClients(ctx context.Context) ([]entity.OAuthClient, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGetOAuthClients)
+	defer span.End()
+
+	clients, err := o.oauthClientRepo.GetOAuthClients(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "oauthClientRepo.GetOAuthClients")
+	}
+
+	return clients, nil
+}
+
+// UpdateOAuthClient - редактирование oauth-клиента
+func (o *OAuthClient) UpdateOAuthClient(ctx context.Context, clientUpdate entity.OAuthClientUpdate) (entity.OAuthClient, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceUpdateOAuthClient)
+	defer span.End()
+
+	client, err := o.oauthClientRepo.UpdateOAuthClient(ctx, clientUpdate)
+	if err != nil {
+		return entity.OAuthClient{}, errors.Wrap(err, "oauthClientRepo.UpdateOAuthClient")
+	}
+
+	return client, nil
+}
+
+// ResetOAuthClientSecret - выпуск нового секрета конфиденциального клиента, старый секрет перестает действовать
+func (o *OAuthClient) ResetOAuthClientSecret(ctx context.Context, id string) (entity.OAuthClient, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceResetOAuthClientSecret)
+	defer span.End()
+
+	client, err := o.oauthClientRepo.GetOAuthClientByID(ctx, id)
+	if err != nil {
+		return entity.OAuthClient{}, errors.Wrap(err, "oauthClientRepo.GetOAuthClientByID")
+	}
+	if client.Public {
+		return entity.OAuthClient{}, apperror.ErrPublicClientSecret
+	}
+
+	secret, err := helpers.GenerateSecret()
+	if err != nil {
+		return entity.OAuthClient{}, errors.Wrap(err, "helpers.GenerateSecret")
+	}
+
+	secretHash := helpers.HashSecret(secret)
+	err = o.oauthClientRepo.UpdateOAuthClientSecret(ctx, id, secretHash)
+	if err != nil {
+		return entity.OAuthClient{}, errors.Wrap(err, "oauthClientRepo.UpdateOAuthClientSecret")
+	}
+
+	client.Secret = secret
+	client.SecretHash = &secretHash
+	return client, nil
+}
+
+// DeleteOAuthClientByID - удаление oauth-клиента
+func (o *OAuthClient) DeleteOAuthClientByID(ctx context.Context, id string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceDeleteOAuthClientByID)
+	defer span.End()
+
+	err := o.oauthClientRepo.DeleteOAuthClientByID(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "oauthClientRepo.DeleteOAuthClientByID")
+	}
+
+	return nil
+}
+
+// Authenticate - аутентификация oauth-клиента. Публичный клиент предъявляет только client_id,
+// конфиденциальный - еще и секрет. Неизвестный клиент и неверный секрет неразличимы
+func (o *OAuthClient) Authenticate(ctx context.Context, clientID, clientSecret string) (entity.OAuthClient, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceAuthenticateOAuthClient)
+	defer span.End()
+
+	_, err := uuid.Parse(clientID)
+	if err != nil {
+		return entity.OAuthClient{}, apperror.ErrInvalidClient
+	}
+
+	client, err := o.oauthClientRepo.GetOAuthClientByID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrOAuthClientNotFound) {
+			return entity.OAuthClient{}, apperror.ErrInvalidClient
+		}
+		return entity.OAuthClient{}, errors.Wrap(err, "oauthClientRepo.GetOAuthClientByID")
+	}
+
+	if client.Public {
+		if clientSecret != "" {
+			return entity.OAuthClient{}, apperror.ErrInvalidClient
+		}
+		return client, nil
+	}
+
+	if client.SecretHash == nil ||
+		subtle.ConstantTimeCompare([]byte(*client.SecretHash), []byte(helpers.HashSecret(clientSecret))) != 1 {
+		return entity.OAuthClient{}, apperror.ErrInvalidClient
+	}
+
+	return client, nil
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/repository/cache"
+	"github.com/GermanBogatov/auth-service/internal/repository/postgres"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/GermanBogatov/auth-service/pkg/mailer"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// passwordResetMailTimeout - время на выдачу токена и отправку письма в фоне
+const passwordResetMailTimeout = time.Minute
+
+var _ IPasswordReset = &PasswordReset{}
+
+type IPasswordReset interface {
+	ForgotPassword(ctx context.Context, email string)
+	ResetPassword(ctx context.Context, token, password string) error
+}
+
+type PasswordReset struct {
+	userRepo       postgres.IUser
+	cache          cache.ICache
+	userService    IUser
+	jwtService     IJWT
+	mailer         mailer.Mailer
+	tokenTTL       time.Duration
+	resendInterval time.Duration
+	linkURL        string
+}
+
+// NewPasswordReset - сброс пароля по одноразовым токенам из письма. linkURL - страница смены пароля, токен
+// добавляется к ней параметром token, без нее в письмо попадает сам токен
+func NewPasswordReset(userRepo postgres.IUser, cache cache.ICache, userService IUser, jwtService IJWT, mailer mailer.Mailer,
+	tokenTTLSec, resendIntervalSec int, linkURL string) IPasswordReset {
+	return &PasswordReset{
+		userRepo:       userRepo,
+		cache:          cache,
+		userService:    userService,
+		jwtService:     jwtService,
+		mailer:         mailer,
+		tokenTTL:       time.Duration(tokenTTLSec) * time.Second,
+		resendInterval: time.Duration(resendIntervalSec) * time.Second,
+		linkURL:        linkURL,
+	}
+}
+
+// ForgotPassword - выдача токена сброса пароля и отправка письма. Работа выполняется в фоне, а ошибки только
+// логируются: по времени и результату ответа нельзя узнать, зарегистрирован ли email
+func (p *PasswordReset) ForgotPassword(ctx context.Context, email string) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceForgotPassword)
+	defer span.End()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), passwordResetMailTimeout)
+		defer cancel()
+
+		err := p.sendResetToken(ctx, email)
+		if err != nil && !errors.Is(err, apperror.ErrUserNotFound) {
+			logging.Errorf("error send password reset token: %v", err)
+		}
+	}()
+}
+
+// sendResetToken - выдача токена пользователю с указанным email и отправка письма со ссылкой не чаще интервала
+func (p *PasswordReset) sendResetToken(ctx context.Context, email string) error {
+	user, err := p.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return errors.Wrap(err, "userRepo.GetUserByEmail")
+	}
+
+	// повторные запросы в пределах интервала не засыпают ящик пользователя письмами и не плодят действующие токены
+	ok, err := p.cache.SetPasswordResetSent(ctx, user.ID, p.resendInterval)
+	if err != nil {
+		return errors.Wrap(err, "cache.SetPasswordResetSent")
+	}
+	if !ok {
+		logging.Infof("password reset for user [%s] was sent recently, resend skipped", user.ID)
+		return nil
+	}
+
+	token, err := helpers.GenerateSecret()
+	if err != nil {
+		return err
+	}
+
+	err = p.cache.SetPasswordReset(ctx, helpers.HashSecret(token), entity.PasswordReset{
+		UserID:              user.ID,
+		PasswordFingerprint: helpers.HashSecret(user.Password),
+	}, p.tokenTTL)
+	if err != nil {
+		return errors.Wrap(err, "cache.SetPasswordReset")
+	}
+
+	text := fmt.Sprintf("Код для смены пароля: %s", token)
+	if p.linkURL != "" {
+		link, errLink := helpers.AddQueryParam(p.linkURL, "token", token)
+		if errLink != nil {
+			return errLink
+		}
+		text = fmt.Sprintf("Для смены пароля перейдите по ссылке: %s", link)
+	}
+
+	err = p.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Восстановление пароля",
+		Text: fmt.Sprintf("%s\n\nСсылка действует %d мин. Если вы не запрашивали смену пароля, проигнорируйте это письмо.",
+			text, int(p.tokenTTL.Minutes())),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "send mail to user [%s]", user.ID)
+	}
+
+	return nil
+}
+
+// ResetPassword - смена пароля по токену. Новый пароль проверяется по политике до погашения токена, чтобы
+// отклоненный пароль не сжигал токен. После смены завершаются все сессии пользователя
+func (p *PasswordReset) ResetPassword(ctx context.Context, token, password string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceResetPassword)
+	defer span.End()
+
+	tokenHash := helpers.HashSecret(token)
+	reset, err := p.cache.GetPasswordReset(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, apperror.ErrRedisNil) {
+			return apperror.ErrInvalidPasswordResetToken
+		}
+		return errors.Wrap(err, "cache.GetPasswordReset")
+	}
+
+	user, err := p.userRepo.GetUserByID(ctx, reset.UserID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrUserNotFound) {
+			return apperror.ErrInvalidPasswordResetToken
+		}
+		return errors.Wrap(err, "userRepo.GetUserByID")
+	}
+	if helpers.HashSecret(user.Password) != reset.PasswordFingerprint {
+		return errors.Wrap(apperror.ErrInvalidPasswordResetToken, "password changed after token was issued")
+	}
+
+	err = p.userService.ValidatePassword(ctx, user, password)
+	if err != nil {
+		return err
+	}
+
+	// токен гасится атомарно: из параллельных запросов с одним токеном пароль сменит только один
+	_, err = p.cache.ConsumePasswordReset(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, apperror.ErrRedisNil) {
+			return apperror.ErrInvalidPasswordResetToken
+		}
+		return errors.Wrap(err, "cache.ConsumePasswordReset")
+	}
+
+	passwordHash, err := p.userService.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.userRepo.UpdateUserByID(ctx, entity.UserUpdate{
+		Password:       &passwordHash,
+		UserUpdateBase: entity.UserUpdateBase{ID: user.ID},
+	})
+	if err != nil {
+		return errors.Wrap(err, "userRepo.UpdateUserByID")
+	}
+
+	err = p.jwtService.RevokeAllSessions(ctx, user.ID)
+	if err != nil {
+		return errors.Wrap(err, "jwtService.RevokeAllSessions")
+	}
+
+	return nil
+}
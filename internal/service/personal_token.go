@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/repository/postgres"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"time"
+)
+
+var _ IPersonalToken = &PersonalToken{}
+
+type IPersonalToken interface {
+	CreatePersonalToken(ctx context.Context, token entity.PersonalToken) (entity.PersonalToken, error)
+	GetPersonalTokens(ctx context.Context, userID string) ([]entity.PersonalToken, error)
+	GetPersonalTokenByID(ctx context.Context, id, userID string) (entity.PersonalToken, error)
+	UpdatePersonalToken(ctx context.Context, tokenUpdate entity.PersonalTokenUpdate) (entity.PersonalToken, error)
+	DeletePersonalToken(ctx context.Context, id, userID string) error
+	Authenticate(ctx context.Context, token, ip string) (entity.PersonalToken, entity.User, error)
+}
+
+type PersonalToken struct {
+	personalTokenRepo postgres.IPersonalToken
+	userRepo          postgres.IUser
+}
+
+func NewPersonalToken(personalTokenRepo postgres.IPersonalToken, userRepo postgres.IUser) IPersonalToken {
+	return &PersonalToken{
+		personalTokenRepo: personalTokenRepo,
+		userRepo:          userRepo,
+	}
+}
+
+// CreatePersonalToken - создание персонального токена, токен в открытом виде возвращается только здесь
+func (p *PersonalToken) CreatePersonalToken(ctx context.Context, token entity.PersonalToken) (entity.PersonalToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceCreatePersonalToken)
+	defer span.End()
+
+	secret, err := helpers.GenerateSecret()
+	if err != nil {
+		return entity.PersonalToken{}, errors.Wrap(err, "helpers.GenerateSecret")
+	}
+
+	plain := entity.PersonalTokenPrefix + secret
+	token.GenerateID()
+	token.GenerateCreatedDate()
+	token.SetToken(plain, helpers.HashSecret(plain))
+
+	err = p.personalTokenRepo.CreatePersonalToken(ctx, token)
+	if err != nil {
+		return entity.PersonalToken{}, errors.Wrap(err, "personalTokenRepo.CreatePersonalToken")
+	}
+
+	return token, nil
+}
+
+// GetPersonalTokens - получение персональных токенов пользователя
+func (p *PersonalToken) GetPersonalTokens(ctx context.Context, userID string) ([]entity.PersonalToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGetPersonalTokens)
+	defer span.End()
+
+	tokens, err := p.personalTokenRepo.GetPersonalTokens(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "personalTokenRepo.GetPersonalTokens")
+	}
+
+	return tokens, nil
+}
+
+// GetPersonalTokenByID - получение персонального токена пользователя по идентификатору
+func (p *PersonalToken) GetPersonalTokenByID(ctx context.Context, id, userID string) (entity.PersonalToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGetPersonalTokenByID)
+	defer span.End()
+
+	token, err := p.personalTokenRepo.GetPersonalTokenByID(ctx, id, userID)
+	if err != nil {
+		return entity.PersonalToken{}, errors.Wrap(err, "personalTokenRepo.GetPersonalTokenByID")
+	}
+
+	return token, nil
+}
+
+// UpdatePersonalToken - редактирование названия и скоупов персонального токена
+func (p *PersonalToken) UpdatePersonalToken(ctx context.Context, tokenUpdate entity.PersonalTokenUpdate) (entity.PersonalToken, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceUpdatePersonalToken)
+	defer span.End()
+
+	token, err := p.personalTokenRepo.UpdatePersonalToken(ctx, tokenUpdate)
+	if err != nil {
+		return entity.PersonalToken{}, errors.Wrap(err, "personalTokenRepo.UpdatePersonalToken")
+	}
+
+	return token, nil
+}
+
+// DeletePersonalToken - удаление (отзыв) персонального токена пользователя
+func (p *PersonalToken) DeletePersonalToken(ctx context.Context, id, userID string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceDeletePersonalToken)
+	defer span.End()
+
+	err := p.personalTokenRepo.DeletePersonalToken(ctx, id, userID)
+	if err != nil {
+		return errors.Wrap(err, "personalTokenRepo.DeletePersonalToken")
+	}
+
+	return nil
+}
+
+// Authenticate - проверка персонального токена из заголовка Authorization, фиксирует время и ip использования
+func (p *PersonalToken) Authenticate(ctx context.Context, token, ip string) (entity.PersonalToken, entity.User, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceAuthenticatePersonalToken)
+	defer span.End()
+
+	// токен имеет высокую энтропию, поэтому поиск по хэшу не раскрывает его через время ответа
+	personalToken, err := p.personalTokenRepo.GetPersonalTokenByHash(ctx, helpers.HashSecret(token))
+	if err != nil {
+		if errors.Is(err, apperror.ErrPersonalTokenNotFound) {
+			return entity.PersonalToken{}, entity.User{}, apperror.ErrInvalidPersonalToken
+		}
+		return entity.PersonalToken{}, entity.User{}, errors.Wrap(err, "personalTokenRepo.GetPersonalTokenByHash")
+	}
+
+	now := time.Now().UTC()
+	if personalToken.IsExpired(now) {
+		return entity.PersonalToken{}, entity.User{}, apperror.ErrInvalidPersonalToken
+	}
+
+	// роль берется у пользователя на момент запроса: понижение прав сразу действует и на его токены
+	user, err := p.userRepo.GetUserByID(ctx, personalToken.UserID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrUserNotFound) {
+			return entity.PersonalToken{}, entity.User{}, apperror.ErrInvalidPersonalToken
+		}
+		return entity.PersonalToken{}, entity.User{}, errors.Wrap(err, "userRepo.GetUserByID")
+	}
+
+	// неудачная запись времени использования не должна блокировать запрос
+	err = p.personalTokenRepo.UpdatePersonalTokenLastUsed(ctx, personalToken.ID, ip, now)
+	if err != nil {
+		logging.Errorf("error update personal token [%s] last used: %s", personalToken.ID, err)
+	}
+
+	return personalToken, user, nil
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPersonalTokenAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	expired := time.Now().Add(-time.Minute)
+	valid := time.Now().Add(time.Hour)
+
+	tokens := newFakePersonalTokenRepo(
+		entity.PersonalToken{ID: "pat-1", UserID: testUser.ID, TokenHash: helpers.HashSecret("valid"), ExpiresDate: &valid,
+			Scopes: []string{entity.PersonalScopeUsersRead}},
+		entity.PersonalToken{ID: "pat-2", UserID: testUser.ID, TokenHash: helpers.HashSecret("expired"), ExpiresDate: &expired},
+		entity.PersonalToken{ID: "pat-3", UserID: "deleted-user", TokenHash: helpers.HashSecret("orphan")},
+	)
+	personalTokenService := NewPersonalToken(tokens, newFakeUserRepo(testUser))
+
+	personalToken, user, err := personalTokenService.Authenticate(ctx, "valid", testClient.IP)
+	require.NoError(t, err)
+	assert.Equal(t, "pat-1", personalToken.ID)
+	assert.Equal(t, testUser.ID, user.ID)
+	require.NotNil(t, tokens.tokens[helpers.HashSecret("valid")].LastUsedIP)
+	assert.Equal(t, testClient.IP, *tokens.tokens[helpers.HashSecret("valid")].LastUsedIP)
+
+	for _, token := range []string{"unknown", "expired", "orphan"} {
+		t.Run(token, func(t *testing.T) {
+			_, _, err := personalTokenService.Authenticate(ctx, token, testClient.IP)
+			assert.ErrorIs(t, err, apperror.ErrInvalidPersonalToken)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/repository/postgres"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+var _ IRole = &Role{}
+
+type IRole interface {
+	Init(ctx context.Context) error
+	CreateRole(ctx context.Context, actor entity.Actor, role entity.Role) (entity.Role, error)
+	GetRoles(ctx context.Context) ([]entity.Role, error)
+	GetRoleByName(ctx context.Context, name string) (entity.Role, error)
+	UpdateRole(ctx context.Context, actor entity.Actor, roleUpdate entity.RoleUpdate) (entity.Role, error)
+	DeleteRole(ctx context.Context, actor entity.Actor, name string) error
+	GetPermissions(ctx context.Context, role string) (entity.Permissions, error)
+}
+
+// Role - роли пользователей. Права ролей проверяются на каждом запросе, поэтому держатся в памяти
+// и перечитываются из бд не реже refreshInterval, изменения на других инстансах видны с этой задержкой
+type Role struct {
+	roleRepo        postgres.IRole
+	refreshInterval time.Duration
+
+	mu       sync.RWMutex
+	roles    []entity.Role
+	loadedAt time.Time
+}
+
+func NewRole(roleRepo postgres.IRole, refreshIntervalSec int) IRole {
+	return &Role{
+		roleRepo:        roleRepo,
+		refreshInterval: time.Duration(refreshIntervalSec) * time.Second,
+	}
+}
+
+// Init - загрузка ролей при старте
+func (r *Role) Init(ctx context.Context) error {
+	return r.reload(ctx)
+}
+
+// CreateRole - создание роли ниже роли вызывающего и только с его правами
+func (r *Role) CreateRole(ctx context.Context, actor entity.Actor, role entity.Role) (entity.Role, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceCreateRole)
+	defer span.End()
+
+	actorRole, err := r.roleRepo.GetRoleByName(ctx, string(actor.Role))
+	if err != nil {
+		return entity.Role{}, errors.Wrap(err, "roleRepo.GetRoleByName")
+	}
+
+	err = checkRoleChange(actorRole, role.Name, role.Level, role.Permissions)
+	if err != nil {
+		return entity.Role{}, err
+	}
+
+	role.BuiltIn = false
+	role.GenerateCreatedDate()
+
+	err = r.roleRepo.CreateRole(ctx, role)
+	if err != nil {
+		return entity.Role{}, errors.Wrap(err, "roleRepo.CreateRole")
+	}
+
+	r.invalidate()
+	return role, nil
+}
+
+// GetRoles - получение ролей
+func (r *Role) GetRoles(ctx context.Context) ([]entity.Role, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGetRoles)
+	defer span.End()
+
+	roles, err := r.roleRepo.GetRoles(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "roleRepo.GetRoles")
+	}
+
+	return roles, nil
+}
+
+// GetRoleByName - получение роли по названию
+func (r *Role) GetRoleByName(ctx context.Context, name string) (entity.Role, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGetRoleByName)
+	defer span.End()
+
+	role, err := r.roleRepo.GetRoleByName(ctx, name)
+	if err != nil {
+		return entity.Role{}, errors.Wrap(err, "roleRepo.GetRoleByName")
+	}
+
+	return role, nil
+}
+
+// UpdateRole - редактирование роли ниже роли вызывающего, права пользователей с этой ролью меняются без перевыпуска
+// токенов. Уровень встроенных ролей не меняется
+func (r *Role) UpdateRole(ctx context.Context, actor entity.Actor, roleUpdate entity.RoleUpdate) (entity.Role, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceUpdateRole)
+	defer span.End()
+
+	actorRole, err := r.roleRepo.GetRoleByName(ctx, string(actor.Role))
+	if err != nil {
+		return entity.Role{}, errors.Wrap(err, "roleRepo.GetRoleByName")
+	}
+
+	current, err := r.roleRepo.GetRoleByName(ctx, roleUpdate.Name)
+	if err != nil {
+		return entity.Role{}, errors.Wrap(err, "roleRepo.GetRoleByName")
+	}
+	if current.BuiltIn && roleUpdate.Level != nil && *roleUpdate.Level != current.Level {
+		return entity.Role{}, apperror.ErrBuiltInRole
+	}
+
+	level, permissions := current.Level, entity.Permissions(nil)
+	if roleUpdate.Level != nil {
+		level = max(level, *roleUpdate.Level)
+	}
+	if roleUpdate.Permissions != nil {
+		permissions = *roleUpdate.Permissions
+	}
+
+	err = checkRoleChange(actorRole, current.Name, level, permissions)
+	if err != nil {
+		return entity.Role{}, err
+	}
+
+	role, err := r.roleRepo.UpdateRole(ctx, roleUpdate)
+	if err != nil {
+		return entity.Role{}, errors.Wrap(err, "roleRepo.UpdateRole")
+	}
+
+	r.invalidate()
+	return role, nil
+}
+
+// DeleteRole - удаление роли ниже роли вызывающего. Встроенные роли и роли, назначенные пользователям, не удаляются
+func (r *Role) DeleteRole(ctx context.Context, actor entity.Actor, name string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceDeleteRole)
+	defer span.End()
+
+	role, err := r.roleRepo.GetRoleByName(ctx, name)
+	if err != nil {
+		return errors.Wrap(err, "roleRepo.GetRoleByName")
+	}
+	if role.BuiltIn {
+		return apperror.ErrBuiltInRole
+	}
+
+	actorRole, err := r.roleRepo.GetRoleByName(ctx, string(actor.Role))
+	if err != nil {
+		return errors.Wrap(err, "roleRepo.GetRoleByName")
+	}
+
+	err = checkRoleChange(actorRole, role.Name, role.Level, nil)
+	if err != nil {
+		return err
+	}
+
+	err = r.roleRepo.DeleteRole(ctx, name)
+	if err != nil {
+		return errors.Wrap(err, "roleRepo.DeleteRole")
+	}
+
+	r.invalidate()
+	return nil
+}
+
+// GetPermissions - права роли, у неизвестной роли прав нет
+func (r *Role) GetPermissions(ctx context.Context, role string) (entity.Permissions, error) {
+	roles, err := r.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stored := range roles {
+		if stored.Name == role {
+			return stored.Permissions, nil
+		}
+	}
+
+	return entity.Permissions{}, nil
+}
+
+// checkRoleChange - вызывающий может менять только роли ниже своей и выдавать им только свои права,
+// иначе через роль можно было бы получить права выше собственных
+func checkRoleChange(actorRole entity.Role, name string, level int, permissions entity.Permissions) error {
+	if !actorRole.Outranks(level) {
+		return errors.Wrapf(apperror.ErrRoleEscalation, "caller with role [%s] cannot manage role [%s] of level [%d]",
+			actorRole.Name, name, level)
+	}
+
+	if actorRole.Name != string(entity.RoleSuperAdmin) && !actorRole.Permissions.Has(permissions...) {
+		return errors.Wrapf(apperror.ErrRoleEscalation, "caller with role [%s] cannot grant permissions it does not have",
+			actorRole.Name)
+	}
+
+	return nil
+}
+
+// snapshot - роли из памяти, устаревший набор перечитывается из бд
+func (r *Role) snapshot(ctx context.Context) ([]entity.Role, error) {
+	r.mu.RLock()
+	roles, stale := r.roles, time.Since(r.loadedAt) > r.refreshInterval
+	r.mu.RUnlock()
+
+	if !stale {
+		return roles, nil
+	}
+
+	err := r.reload(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "reload")
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.roles, nil
+}
+
+func (r *Role) reload(ctx context.Context) error {
+	roles, err := r.roleRepo.GetRoles(ctx)
+	if err != nil {
+		return errors.Wrap(err, "roleRepo.GetRoles")
+	}
+
+	r.mu.Lock()
+	r.roles = roles
+	r.loadedAt = time.Now()
+	r.mu.Unlock()
+
+	return nil
+}
+
+// invalidate - сброс ролей в памяти после изменения, следующий запрос перечитает их из бд
+func (r *Role) invalidate() {
+	r.mu.Lock()
+	r.loadedAt = time.Time{}
+	r.mu.Unlock()
+}
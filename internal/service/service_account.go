@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/repository/postgres"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+var _ IServiceAccount = &ServiceAccount{}
+
+type IServiceAccount interface {
+	CreateServiceAccount(ctx context.Context, account entity.ServiceAccount) (entity.ServiceAccount, error)
+	GetServiceAccountByID(ctx context.Context, id string) (entity.ServiceAccount, error)
+	GetServiceAccounts(ctx context.Context) ([]entity.ServiceAccount, error)
+	UpdateServiceAccount(ctx context.Context, accountUpdate entity.ServiceAccountUpdate) (entity.ServiceAccount, error)
+	ResetServiceAccountSecret(ctx context.Context, id string) (entity.ServiceAccount, error)
+	DeleteServiceAccountByID(ctx context.Context, id string) error
+	Authenticate(ctx context.Context, clientID, clientSecret string) (entity.ServiceAccount, error)
+}
+
+type ServiceAccount struct {
+	serviceAccountRepo postgres.IServiceAccount
+}
+
+func NewServiceAccount(serviceAccountRepo postgres.IServiceAccount) IServiceAccount {
+	return &ServiceAccount{
+		serviceAccountRepo: serviceAccountRepo,
+	}
+}
+
+// CreateServiceAccount - создание сервисного аккаунта, секрет в открытом виде возвращается только здесь
+func (s *ServiceAccount) CreateServiceAccount(ctx context.Context, account entity.ServiceAccount) (entity.ServiceAccount, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceCreateServiceAccount)
+	defer span.End()
+
+	secret, err := helpers.GenerateSecret()
+	if err != nil {
+		return entity.ServiceAccount{}, errors.Wrap(err, "helpers.GenerateSecret")
+	}
+
+	account.GenerateID()
+	account.GenerateCreatedDate()
+	account.Secret = secret
+	account.SecretHash = helpers.HashSecret(secret)
+
+	err = s.serviceAccountRepo.CreateServiceAccount(ctx, account)
+	if err != nil {
+		return entity.ServiceAccount{}, errors.Wrap(err, "serviceAccountRepo.CreateServiceAccount")
+	}
+
+	return account, nil
+}
+
+// GetServiceAccountByID - получение сервисного аккаунта по идентификатору
+func (s *ServiceAccount) GetServiceAccountByID(ctx context.Context, id string) (entity.ServiceAccount, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGetServiceAccountByID)
+	defer span.End()
+
+	account, err := s.serviceAccountRepo.GetServiceAccountByID(ctx, id)
+	if err != nil {
+		return entity.ServiceAccount{}, errors.Wrap(err, "serviceAccountRepo.GetServiceAccountByID")
+	}
+
+	return account, nil
+}
+
+// GetServiceAccounts - получение сервисных аккаунтов
+func (s *ServiceAccount) GetServiceAccounts(ctx context.Context) ([]entity.ServiceAccount, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGetServiceAccounts)
+	defer span.End()
+
+	accounts, err := s.serviceAccountRepo.GetServiceAccounts(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "serviceAccountRepo.GetServiceAccounts")
+	}
+
+	return accounts, nil
+}
+
+// UpdateServiceAccount - редактирование сервисного аккаунта
+func (s *ServiceAccount) UpdateServiceAccount(ctx context.Context, accountUpdate entity.ServiceAccountUpdate) (entity.ServiceAccount, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceUpdateServiceAccount)
+	defer span.End()
+
+	account, err := s.serviceAccountRepo.UpdateServiceAccount(ctx, accountUpdate)
+	if err != nil {
+		return entity.ServiceAccount{}, errors.Wrap(err, "serviceAccountRepo.UpdateServiceAccount")
+	}
+
+	return account, nil
+}
+
+// ResetServiceAccountSecret - выпуск нового секрета сервисного аккаунта, старый секрет перестает действовать
+func (s *ServiceAccount) ResetServiceAccountSecret(ctx context.Context, id string) (entity.ServiceAccount, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceResetServiceAccountSecret)
+	defer span.End()
+
+	secret, err := helpers.GenerateSecret()
+	if err != nil {
+		return entity.ServiceAccount{}, errors.Wrap(err, "helpers.GenerateSecret")
+	}
+
+	err = s.serviceAccountRepo.UpdateServiceAccountSecret(ctx, id, helpers.HashSecret(secret))
+	if err != nil {
+		return entity.ServiceAccount{}, errors.Wrap(err, "serviceAccountRepo.UpdateServiceAccountSecret")
+	}
+
+	account, err := s.serviceAccountRepo.GetServiceAccountByID(ctx, id)
+	if err != nil {
+		return entity.ServiceAccount{}, errors.Wrap(err, "serviceAccountRepo.GetServiceAccountByID")
+	}
+	account.Secret = secret
+
+	return account, nil
+}
+
+// DeleteServiceAccountByID - удаление сервисного аккаунта
+func (s *ServiceAccount) DeleteServiceAccountByID(ctx context.Context, id string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceDeleteServiceAccountByID)
+	defer span.End()
+
+	err := s.serviceAccountRepo.DeleteServiceAccountByID(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "serviceAccountRepo.DeleteServiceAccountByID")
+	}
+
+	return nil
+}
+
+// Authenticate - проверка учетных данных клиента. Неизвестный клиент и неверный секрет неразличимы
+func (s *ServiceAccount) Authenticate(ctx context.Context, clientID, clientSecret string) (entity.ServiceAccount, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceAuthenticateServiceAccount)
+	defer span.End()
+
+	_, err := uuid.Parse(clientID)
+	if err != nil {
+		return entity.ServiceAccount{}, apperror.ErrInvalidClient
+	}
+
+	account, err := s.serviceAccountRepo.GetServiceAccountByID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrServiceAccountNotFound) {
+			return entity.ServiceAccount{}, apperror.ErrInvalidClient
+		}
+		return entity.ServiceAccount{}, errors.Wrap(err, "serviceAccountRepo.GetServiceAccountByID")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(account.SecretHash), []byte(helpers.HashSecret(clientSecret))) != 1 {
+		return entity.ServiceAccount{}, apperror.ErrInvalidClient
+	}
+
+	return account, nil
+}
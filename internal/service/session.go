@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/repository/cache"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+var _ ISession = &Session{}
+
+type ISession interface {
+	GetUserSessions(ctx context.Context, userID string) ([]entity.Session, error)
+	RevokeUserSession(ctx context.Context, userID, sessionID string) error
+}
+
+type Session struct {
+	cache cache.ICache
+}
+
+func NewSession(cache cache.ICache) ISession {
+	return &Session{
+		cache: cache,
+	}
+}
+
+// GetUserSessions - получение активных сессий пользователя, последние использованные идут первыми
+func (s *Session) GetUserSessions(ctx context.Context, userID string) ([]entity.Session, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGetUserSessions)
+	defer span.End()
+
+	sessions, err := s.cache.GetUserSessions(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "cache.GetUserSessions")
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedDate.After(sessions[j].LastUsedDate)
+	})
+
+	return sessions, nil
+}
+
+// RevokeUserSession - завершение сессии пользователя: отзывается семейство рефреш-токенов,
+// а вместе с ним и выпущенные в сессии access-токены
+func (s *Session) RevokeUserSession(ctx context.Context, userID, sessionID string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceRevokeUserSession)
+	defer span.End()
+
+	session, err := s.cache.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, apperror.ErrRedisNil) {
+			return apperror.ErrSessionNotFound
+		}
+		return errors.Wrap(err, "cache.GetSession")
+	}
+
+	// чужая сессия для пользователя неотличима от несуществующей
+	if session.UserID != userID {
+		return apperror.ErrSessionNotFound
+	}
+
+	err = s.cache.DeleteRefreshFamily(ctx, sessionID)
+	if err != nil {
+		return errors.Wrap(err, "cache.DeleteRefreshFamily")
+	}
+
+	return nil
+}
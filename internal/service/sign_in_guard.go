@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/metrics"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/internal/repository/cache"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/GermanBogatov/auth-service/pkg/tracer"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// области счетчиков неудачных попыток входа
+const (
+	signInSubjectEmail = "email:"
+	signInSubjectIP    = "ip:"
+	// signInSubjectUser - проверка текущего пароля при его смене уже вошедшим пользователем
+	signInSubjectUser = "user:"
+)
+
+var _ ISignInGuard = &SignInGuard{}
+
+type ISignInGuard interface {
+	SignIn(ctx context.Context, email, password, ip string) (entity.User, error)
+	ChangePassword(ctx context.Context, userID, sessionID, currentPassword, newPassword string) error
+	Unlock(ctx context.Context, userID string) error
+}
+
+type SignInGuard struct {
+	cache              cache.ICache
+	userService        IUser
+	failureWindow      time.Duration
+	delayThreshold     int64
+	baseDelay          time.Duration
+	maxDelay           time.Duration
+	lockoutThreshold   int64
+	ipLockoutThreshold int64
+	lockoutDuration    time.Duration
+}
+
+// NewSignInGuard - защита входа по паролю от перебора: неудачные попытки считаются по email и по ip клиента,
+// после порогов вход замедляется и временно блокируется
+func NewSignInGuard(cache cache.ICache, userService IUser, guard config.SignInGuard) ISignInGuard {
+	return &SignInGuard{
+		cache:              cache,
+		userService:        userService,
+		failureWindow:      time.Duration(guard.FailureWindowSec) * time.Second,
+		delayThreshold:     int64(guard.DelayThreshold),
+		baseDelay:          time.Duration(guard.BaseDelaySec) * time.Second,
+		maxDelay:           time.Duration(guard.MaxDelaySec) * time.Second,
+		lockoutThreshold:   int64(guard.LockoutThreshold),
+		ipLockoutThreshold: int64(guard.IPLockoutThreshold),
+		lockoutDuration:    time.Duration(guard.LockoutDurationSec) * time.Second,
+	}
+}
+
+// SignIn - проверка email и пароля с учетом ограничений. Пока действует ограничение, пароль не проверяется.
+// Ошибки считаются и для незарегистрированных email, иначе по блокировке можно было бы узнать, есть ли учетная запись
+func (s *SignInGuard) SignIn(ctx context.Context, email, password, ip string) (entity.User, error) {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGuardedSignIn)
+	defer span.End()
+
+	emailSubject := signInSubjectEmail + strings.ToLower(strings.TrimSpace(email))
+	ipSubject := signInSubjectIP + ip
+
+	err := s.checkLocks(ctx, emailSubject, emailSubject, ipSubject)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	user, err := s.userService.GetUserByEmailAndPassword(ctx, email, password)
+	if err != nil {
+		if errors.Is(err, apperror.ErrUserNotFound) {
+			s.registerFailure(ctx, emailSubject, ipSubject)
+		}
+		return entity.User{}, err
+	}
+
+	// счетчик ip не сбрасывается: иначе перебор по многим учетным записям можно было бы прерывать входом в свою
+	err = s.cache.DeleteSignInFailures(ctx, emailSubject)
+	if err != nil {
+		logging.Errorf("error reset sign-in failures of user [%s]: %v", user.ID, err)
+	}
+
+	return user, nil
+}
+
+// ChangePassword - смена пароля с учетом ограничений. Неверный текущий пароль считается ошибкой по пользователю:
+// иначе с украденным access-токеном пароль можно было бы перебирать без ограничений входа
+func (s *SignInGuard) ChangePassword(ctx context.Context, userID, sessionID, currentPassword, newPassword string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceGuardedChangePassword)
+	defer span.End()
+
+	userSubject := signInSubjectUser + userID
+
+	err := s.checkLocks(ctx, "", userSubject)
+	if err != nil {
+		return err
+	}
+
+	err = s.userService.ChangePassword(ctx, userID, sessionID, currentPassword, newPassword)
+	if err != nil {
+		if errors.Is(err, apperror.ErrInvalidCurrentPassword) {
+			metrics.IncSignInFailures(metrics.SignInScopeUser)
+			failures, errIncr := s.cache.IncrSignInFailures(ctx, userSubject, s.failureWindow)
+			if errIncr != nil {
+				logging.Errorf("error count sign-in failure: %v", errIncr)
+			} else {
+				s.lockAccount(ctx, userSubject, failures, metrics.SignInScopeUser)
+			}
+		}
+		return err
+	}
+
+	err = s.cache.DeleteSignInFailures(ctx, userSubject)
+	if err != nil {
+		logging.Errorf("error reset sign-in failures of user [%s]: %v", userID, err)
+	}
+
+	return nil
+}
+
+// checkLocks - действующие ограничения по субъектам: блокировка accountSubject дает ErrAccountLocked, остальные
+// блокировки и задержки - ErrTooManySignInAttempts, клиенту возвращается время до снятия самого долгого ограничения
+func (s *SignInGuard) checkLocks(ctx context.Context, accountSubject string, subjects ...string) error {
+	locks, err := s.cache.GetSignInLocks(ctx, subjects...)
+	if err != nil {
+		return errors.Wrap(err, "cache.GetSignInLocks")
+	}
+	if len(locks) == 0 {
+		return nil
+	}
+
+	var retryAfter time.Duration
+	reason := apperror.ErrTooManySignInAttempts
+	for _, lock := range locks {
+		retryAfter = max(retryAfter, lock.TTL)
+		if lock.Subject == accountSubject && lock.Kind == entity.SignInLockLockout {
+			reason = apperror.ErrAccountLocked
+		}
+	}
+
+	if errors.Is(reason, apperror.ErrAccountLocked) {
+		metrics.IncSignInRejected(metrics.SignInRejectLocked)
+	} else {
+		metrics.IncSignInRejected(metrics.SignInRejectThrottled)
+	}
+
+	return apperror.NewSignInThrottledError(reason, retryAfter)
+}
+
+// registerFailure - учет неудачной попытки и выставление ограничений по порогам. Ошибки учета только логируются,
+// ответ на неверный пароль от них не зависит
+func (s *SignInGuard) registerFailure(ctx context.Context, emailSubject, ipSubject string) {
+	metrics.IncSignInFailures(metrics.SignInScopeEmail)
+	failures, err := s.cache.IncrSignInFailures(ctx, emailSubject, s.failureWindow)
+	if err != nil {
+		logging.Errorf("error count sign-in failure: %v", err)
+	} else {
+		s.lockAccount(ctx, emailSubject, failures, metrics.SignInScopeEmail)
+	}
+
+	metrics.IncSignInFailures(metrics.SignInScopeIP)
+	failures, err = s.cache.IncrSignInFailures(ctx, ipSubject, s.failureWindow)
+	if err != nil {
+		logging.Errorf("error count sign-in failure: %v", err)
+	} else if failures >= s.ipLockoutThreshold {
+		s.setLock(ctx, entity.SignInLock{Subject: ipSubject, Kind: entity.SignInLockLockout, TTL: s.lockoutDuration})
+		if failures == s.ipLockoutThreshold {
+			metrics.IncSignInLockouts(metrics.SignInScopeIP)
+			logging.Infof("sign-in from [%s] locked after %d failed attempts", ipSubject, failures)
+		}
+	}
+}
+
+// lockAccount - блокировка учетной записи после порога или задержка, удваивающаяся с каждой ошибкой после порога задержек
+func (s *SignInGuard) lockAccount(ctx context.Context, subject string, failures int64, scope metrics.SignInScope) {
+	if failures >= s.lockoutThreshold {
+		s.setLock(ctx, entity.SignInLock{Subject: subject, Kind: entity.SignInLockLockout, TTL: s.lockoutDuration})
+		if failures == s.lockoutThreshold {
+			metrics.IncSignInLockouts(scope)
+			logging.Infof("sign-in to [%s] locked after %d failed attempts", subject, failures)
+		}
+		return
+	}
+
+	if failures >= s.delayThreshold {
+		delay := s.maxDelay
+		// сдвиг ограничен, чтобы задержка не переполнилась до сравнения с максимумом
+		if shift := failures - s.delayThreshold; shift < 32 {
+			delay = min(s.baseDelay<<shift, s.maxDelay)
+		}
+		s.setLock(ctx, entity.SignInLock{Subject: subject, Kind: entity.SignInLockDelay, TTL: delay})
+	}
+}
+
+// setLock - выставление ограничения входа с логированием ошибки
+func (s *SignInGuard) setLock(ctx context.Context, lock entity.SignInLock) {
+	err := s.cache.SetSignInLock(ctx, lock)
+	if err != nil {
+		logging.Errorf("error set sign-in %s of [%s]: %v", lock.Kind, lock.Subject, err)
+	}
+}
+
+// Unlock - снятие администратором блокировки и задержек входа в учетную запись и смены пароля вместе со счетчиками
+// ошибок. Ограничения по ip не снимаются: за ними может стоять перебор других учетных записей
+func (s *SignInGuard) Unlock(ctx context.Context, userID string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceUnlockSignIn)
+	defer span.End()
+
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return errors.Wrap(err, "userService.GetUserByID")
+	}
+
+	for _, subject := range []string{signInSubjectEmail + strings.ToLower(strings.TrimSpace(user.Email)), signInSubjectUser + user.ID} {
+		err = s.cache.DeleteSignInFailures(ctx, subject)
+		if err != nil {
+			return errors.Wrap(err, "cache.DeleteSignInFailures")
+		}
+	}
+
+	metrics.IncSignInUnlocks()
+	return nil
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/config"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	testPassword = "Correct-Horse-7"
+	testIP       = "10.0.0.1"
+)
+
+var testGuard = config.SignInGuard{
+	FailureWindowSec:   900,
+	DelayThreshold:     3,
+	BaseDelaySec:       1,
+	MaxDelaySec:        3,
+	LockoutThreshold:   6,
+	IPLockoutThreshold: 8,
+	LockoutDurationSec: 900,
+}
+
+func newTestSignInGuard() (ISignInGuard, *fakeCache, *fakeUserService) {
+	user := testUser
+	user.Password = testPassword
+
+	fake := newFakeCache()
+	users := newFakeUserService(user)
+
+	return NewSignInGuard(fake, users, testGuard), fake, users
+}
+
+// assertThrottled - вход отклонен ограничением с ожидаемой причиной и временем до повтора
+func assertThrottled(t *testing.T, err error, reason error, retryAfter time.Duration) {
+	t.Helper()
+
+	var throttledErr *apperror.SignInThrottledError
+	require.True(t, errors.As(err, &throttledErr), "unexpected error: %v", err)
+	assert.ErrorIs(t, err, reason)
+	assert.Equal(t, retryAfter, throttledErr.RetryAfter)
+}
+
+func TestSignInGuardDelays(t *testing.T) {
+	ctx := context.Background()
+	guard, fake, users := newTestSignInGuard()
+
+	// до порога задержек ошибки только считаются
+	for i := 0; i < testGuard.DelayThreshold-1; i++ {
+		_, err := guard.SignIn(ctx, testUser.Email, "wrong", testIP)
+		assert.ErrorIs(t, err, apperror.ErrUserNotFound)
+	}
+
+	// задержка удваивается с каждой ошибкой после порога и ограничена максимумом
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		_, err := guard.SignIn(ctx, testUser.Email, "wrong", testIP)
+		assert.ErrorIs(t, err, apperror.ErrUserNotFound)
+
+		checks := users.checks
+		_, err = guard.SignIn(ctx, testUser.Email, testPassword, testIP)
+		assertThrottled(t, err, apperror.ErrTooManySignInAttempts, delay)
+		assert.Equal(t, checks, users.checks, "password must not be checked while delayed")
+
+		fake.expireSignInDelays()
+	}
+
+	// успешный вход сбрасывает счетчик email, но не ip
+	user, err := guard.SignIn(ctx, testUser.Email, testPassword, testIP)
+	require.NoError(t, err)
+	assert.Equal(t, testUser.ID, user.ID)
+	assert.NotContains(t, fake.signInFailures, signInSubjectEmail+testUser.Email)
+	assert.Equal(t, int64(testGuard.DelayThreshold+2), fake.signInFailures[signInSubjectIP+testIP])
+}
+
+func TestSignInGuardLockout(t *testing.T) {
+	ctx := context.Background()
+	guard, fake, _ := newTestSignInGuard()
+
+	for i := 0; i < testGuard.LockoutThreshold; i++ {
+		fake.expireSignInDelays()
+		_, err := guard.SignIn(ctx, " Ivan.Petrov@example.com ", "wrong", strconv.Itoa(i))
+		assert.ErrorIs(t, err, apperror.ErrUserNotFound)
+	}
+
+	// блокировка учетной записи не зависит от ip и регистра email
+	fake.expireSignInDelays()
+	_, err := guard.SignIn(ctx, testUser.Email, testPassword, "10.0.0.2")
+	assertThrottled(t, err, apperror.ErrAccountLocked, time.Duration(testGuard.LockoutDurationSec)*time.Second)
+}
+
+func TestSignInGuardIPLockout(t *testing.T) {
+	ctx := context.Background()
+	guard, _, _ := newTestSignInGuard()
+
+	// перебор разных учетных записей с одного ip: счетчики email порогов не достигают
+	for i := 0; i < testGuard.IPLockoutThreshold; i++ {
+		_, err := guard.SignIn(ctx, "user"+strconv.Itoa(i)+"@example.com", "wrong", testIP)
+		assert.ErrorIs(t, err, apperror.ErrUserNotFound)
+	}
+
+	_, err := guard.SignIn(ctx, testUser.Email, testPassword, testIP)
+	assertThrottled(t, err, apperror.ErrTooManySignInAttempts, time.Duration(testGuard.LockoutDurationSec)*time.Second)
+
+	_, err = guard.SignIn(ctx, testUser.Email, testPassword, "10.0.0.2")
+	require.NoError(t, err)
+}
+
+func TestSignInGuardChangePassword(t *testing.T) {
+	ctx := context.Background()
+	guard, fake, users := newTestSignInGuard()
+
+	for i := 0; i < testGuard.DelayThreshold; i++ {
+		err := guard.ChangePassword(ctx, testUser.ID, "session-1", "wrong", "New-Password-8")
+		assert.ErrorIs(t, err, apperror.ErrInvalidCurrentPassword)
+	}
+
+	checks := users.checks
+	err := guard.ChangePassword(ctx, testUser.ID, "session-1", testPassword, "New-Password-8")
+	assertThrottled(t, err, apperror.ErrTooManySignInAttempts, time.Second)
+	assert.Equal(t, checks, users.checks, "password must not be checked while delayed")
+
+	// ошибки смены пароля не ограничивают вход
+	_, err = guard.SignIn(ctx, testUser.Email, testPassword, testIP)
+	require.NoError(t, err)
+
+	fake.expireSignInDelays()
+	err = guard.ChangePassword(ctx, testUser.ID, "session-1", testPassword, "New-Password-8")
+	require.NoError(t, err)
+	assert.NotContains(t, fake.signInFailures, signInSubjectUser+testUser.ID)
+}
+
+func TestSignInGuardChangePasswordLockout(t *testing.T) {
+	ctx := context.Background()
+	guard, fake, _ := newTestSignInGuard()
+
+	for i := 0; i < testGuard.LockoutThreshold; i++ {
+		fake.expireSignInDelays()
+		err := guard.ChangePassword(ctx, testUser.ID, "session-1", "wrong", "New-Password-8")
+		assert.ErrorIs(t, err, apperror.ErrInvalidCurrentPassword)
+	}
+
+	fake.expireSignInDelays()
+	err := guard.ChangePassword(ctx, testUser.ID, "session-1", testPassword, "New-Password-8")
+	assertThrottled(t, err, apperror.ErrTooManySignInAttempts, time.Duration(testGuard.LockoutDurationSec)*time.Second)
+}
+
+func TestSignInGuardUnlock(t *testing.T) {
+	ctx := context.Background()
+	guard, fake, _ := newTestSignInGuard()
+
+	for i := 0; i < testGuard.LockoutThreshold; i++ {
+		fake.expireSignInDelays()
+		_, err := guard.SignIn(ctx, testUser.Email, "wrong", testIP)
+		assert.ErrorIs(t, err, apperror.ErrUserNotFound)
+		err = guard.ChangePassword(ctx, testUser.ID, "session-1", "wrong", "New-Password-8")
+		assert.ErrorIs(t, err, apperror.ErrInvalidCurrentPassword)
+	}
+
+	err := guard.Unlock(ctx, testUser.ID)
+	require.NoError(t, err)
+
+	// ограничения по ip администратор не снимает
+	assert.Contains(t, fake.signInFailures, signInSubjectIP+testIP)
+
+	_, err = guard.SignIn(ctx, testUser.Email, testPassword, "10.0.0.2")
+	require.NoError(t, err)
+
+	err = guard.ChangePassword(ctx, testUser.ID, "session-1", testPassword, "New-Password-8")
+	require.NoError(t, err)
+
+	err = guard.Unlock(ctx, "unknown")
+	assert.ErrorIs(t, err, apperror.ErrUserNotFound)
+}
+
+func TestSignInGuardStoredLocks(t *testing.T) {
+	ctx := context.Background()
+	guard, fake, _ := newTestSignInGuard()
+
+	// блокировка учетной записи важнее задержки по ip, время до повтора - по самому долгому ограничению
+	err := fake.SetSignInLock(ctx, entity.SignInLock{Subject: signInSubjectEmail + testUser.Email,
+		Kind: entity.SignInLockLockout, TTL: time.Minute})
+	require.NoError(t, err)
+	err = fake.SetSignInLock(ctx, entity.SignInLock{Subject: signInSubjectIP + testIP,
+		Kind: entity.SignInLockDelay, TTL: time.Hour})
+	require.NoError(t, err)
+
+	_, err = guard.SignIn(ctx, testUser.Email, testPassword, testIP)
+	assertThrottled(t, err, apperror.ErrAccountLocked, time.Hour)
+}
@@ -2,9 +2,15 @@ package service
 
 import (
 	"context"
+	"crypto/subtle"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/common/helpers"
 	"github.com/GermanBogatov/auth-service/internal/config"
 	"github.com/GermanBogatov/auth-service/internal/entity"
 	"github.com/GermanBogatov/auth-service/internal/repository/postgres"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/GermanBogatov/auth-service/pkg/pwhash"
+	"github.com/GermanBogatov/auth-service/pkg/pwpolicy"
 	"github.com/GermanBogatov/auth-service/pkg/tracer"
 	"github.com/pkg/errors"
 )
@@ -16,19 +22,35 @@ type IUser interface {
 	GetUserByID(ctx context.Context, id string) (entity.User, error)
 	GetUsers(ctx context.Context, filter entity.Filter) ([]entity.User, error)
 	GetUserByEmailAndPassword(ctx context.Context, email, password string) (entity.User, error)
+	HashPassword(password string) (string, error)
+	ValidatePassword(ctx context.Context, user entity.User, password string) error
+	ChangePassword(ctx context.Context, userID, sessionID, currentPassword, newPassword string) error
 	DeleteUserByID(ctx context.Context, id string) error
 	UpdateUserByID(ctx context.Context, userUpdate entity.UserUpdate) (entity.User, error)
 
-	UpdatePrivateUserByID(ctx context.Context, userUpdate entity.UserUpdatePrivate) (entity.User, error)
+	UpdatePrivateUserByID(ctx context.Context, actor entity.Actor, userUpdate entity.UserUpdatePrivate) (entity.User, error)
 }
 
 type User struct {
-	userRepo postgres.IUser
+	userRepo            postgres.IUser
+	roleRepo            postgres.IRole
+	jwtService          IJWT
+	passwordHasher      *pwhash.Hasher
+	passwordPolicy      *pwpolicy.Policy
+	passwordHistorySize int
+	legacySalt          string
 }
 
-func NewUser(client postgres.IUser) IUser {
+func NewUser(client postgres.IUser, roleRepo postgres.IRole, jwtService IJWT, passwordHasher *pwhash.Hasher,
+	passwordPolicy *pwpolicy.Policy, passwordHistorySize int, legacySalt string) IUser {
 	return &User{
-		userRepo: client,
+		userRepo:            client,
+		roleRepo:            roleRepo,
+		jwtService:          jwtService,
+		passwordHasher:      passwordHasher,
+		passwordPolicy:      passwordPolicy,
+		passwordHistorySize: passwordHistorySize,
+		legacySalt:          legacySalt,
 	}
 }
 
@@ -71,19 +93,169 @@ func (u *User) DeleteUserByID(ctx context.Context, id string) error {
 	return nil
 }
 
-// GetUserByEmailAndPassword - получение пользователя по майлу и паролю
+// GetUserByEmailAndPassword - получение пользователя по майлу и паролю. Пароль сверяется с хэшем в сервисе,
+// хэш устаревшего формата или с устаревшими параметрами пересчитывается после успешного входа
 func (u *User) GetUserByEmailAndPassword(ctx context.Context, email, password string) (entity.User, error) {
 	_, span := tracer.StartTrace(ctx, config.SpanServiceGetUserByEmailAndPassword)
 	defer span.End()
 
-	user, err := u.userRepo.GetUserByEmailAndPassword(ctx, email, password)
+	user, err := u.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return entity.User{}, errors.Wrap(err, "userRepo.GetUserByEmailAndPassword")
+		if errors.Is(err, apperror.ErrUserNotFound) {
+			// хэшируем впустую, чтобы по времени ответа нельзя было отличить неизвестный email от неверного пароля
+			_, _ = u.passwordHasher.Hash(password)
+		}
+		return entity.User{}, errors.Wrap(err, "userRepo.GetUserByEmail")
+	}
+
+	ok, rehash, err := u.verifyPassword(password, user.Password)
+	if err != nil {
+		return entity.User{}, errors.Wrapf(err, "verify password of user [%s]", user.ID)
+	}
+	if !ok {
+		return entity.User{}, apperror.ErrUserNotFound
+	}
+
+	if rehash {
+		hash, errHash := u.passwordHasher.Hash(password)
+		if errHash == nil {
+			errHash = u.userRepo.UpdateUserPasswordHash(ctx, user.ID, user.Password, hash)
+		}
+		// вход не должен зависеть от пересчета хэша, он повторится при следующем входе
+		if errHash != nil {
+			logging.Errorf("error rehash password of user [%s]: %v", user.ID, errHash)
+		} else {
+			user.Password = hash
+		}
 	}
 
 	return user, nil
 }
 
+// HashPassword - хэш пароля текущим алгоритмом с уникальной солью
+func (u *User) HashPassword(password string) (string, error) {
+	hash, err := u.passwordHasher.Hash(password)
+	if err != nil {
+		return "", errors.Wrap(err, "passwordHasher.Hash")
+	}
+
+	return hash, nil
+}
+
+// ValidatePassword - проверка нового пароля по политике. Для существующего пользователя (есть хэш пароля) пароль
+// не должен совпадать с текущим и предыдущими из истории
+func (u *User) ValidatePassword(ctx context.Context, user entity.User, password string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceValidatePassword)
+	defer span.End()
+
+	violations := u.passwordPolicy.Check(password, user.Email, user.Name, user.Surname)
+	// история проверяется хэшированием, поэтому только для пароля, прошедшего остальные правила
+	if len(violations) == 0 && user.Password != "" && u.passwordHistorySize > 0 {
+		reused, err := u.isPasswordReused(ctx, user, password)
+		if err != nil {
+			return errors.Wrap(err, "check password history")
+		}
+		if reused {
+			violations = append(violations, pwpolicy.ViolationReused)
+		}
+	}
+
+	if len(violations) != 0 {
+		return apperror.NewPasswordPolicyError(violations)
+	}
+
+	return nil
+}
+
+// ChangePassword - смена пароля самим пользователем. Новый пароль проверяется по политике только после сверки
+// текущего, чтобы без знания текущего пароля нельзя было перебирать историю паролей. Все сессии пользователя, кроме
+// текущей sessionID, завершаются, персональные токены удаляются вместе со сменой пароля
+func (u *User) ChangePassword(ctx context.Context, userID, sessionID, currentPassword, newPassword string) error {
+	_, span := tracer.StartTrace(ctx, config.SpanServiceChangePassword)
+	defer span.End()
+
+	user, err := u.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return errors.Wrap(err, "userRepo.GetUserByID")
+	}
+
+	ok, _, err := u.verifyPassword(currentPassword, user.Password)
+	if err != nil {
+		return errors.Wrapf(err, "verify password of user [%s]", user.ID)
+	}
+	if !ok {
+		return apperror.ErrInvalidCurrentPassword
+	}
+
+	err = u.ValidatePassword(ctx, user, newPassword)
+	if err != nil {
+		return err
+	}
+
+	passwordHash, err := u.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	// сессии завершаются до записи пароля: при сбое отзыва пароль остается прежним и запрос можно повторить,
+	// а не получить новый пароль при живых сессиях со старым
+	err = u.jwtService.RevokeOtherSessions(ctx, user.ID, sessionID)
+	if err != nil {
+		return errors.Wrap(err, "jwtService.RevokeOtherSessions")
+	}
+
+	_, err = u.userRepo.UpdateUserByID(ctx, entity.UserUpdate{
+		Password:       &passwordHash,
+		UserUpdateBase: entity.UserUpdateBase{ID: user.ID},
+	})
+	if err != nil {
+		return errors.Wrap(err, "userRepo.UpdateUserByID")
+	}
+
+	return nil
+}
+
+// isPasswordReused - пароль совпадает с текущим или одним из предыдущих. Хэш, который не удается проверить
+// (например, с удаленной версией перца), пропускается
+func (u *User) isPasswordReused(ctx context.Context, user entity.User, password string) (bool, error) {
+	history, err := u.userRepo.GetPasswordHistory(ctx, user.ID, u.passwordHistorySize)
+	if err != nil {
+		return false, errors.Wrap(err, "userRepo.GetPasswordHistory")
+	}
+
+	for _, hash := range append([]string{user.Password}, history...) {
+		ok, _, errVerify := u.verifyPassword(password, hash)
+		if errVerify != nil {
+			logging.Errorf("error verify password history of user [%s]: %v", user.ID, errVerify)
+			continue
+		}
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// verifyPassword - проверка пароля и необходимости пересчитать хэш. Устаревший хэш sha256 с общей солью
+// проверяется отдельно и пересчитывается всегда, без заданной соли такие хэши не проверяются
+func (u *User) verifyPassword(password, hash string) (bool, bool, error) {
+	if !pwhash.IsSupported(hash) {
+		if u.legacySalt == "" {
+			return false, false, nil
+		}
+		legacy := helpers.GenerateLegacyPasswordHash(password, u.legacySalt)
+		return subtle.ConstantTimeCompare([]byte(legacy), []byte(hash)) == 1, true, nil
+	}
+
+	ok, err := u.passwordHasher.Verify(password, hash)
+	if err != nil {
+		return false, false, err
+	}
+
+	return ok, u.passwordHasher.NeedsRehash(hash), nil
+}
+
 // UpdateUserByID - обновление пользователя
 func (u *User) UpdateUserByID(ctx context.Context, userUpdate entity.UserUpdate) (entity.User, error) {
 	_, span := tracer.StartTrace(ctx, config.SpanServiceUpdateUserByID)
@@ -110,12 +282,49 @@ func (u *User) GetUsers(ctx context.Context, filter entity.Filter) ([]entity.Use
 	return users, nil
 }
 
-// UpdatePrivateUserByID - приватное обновление пользователя
-func (u *User) UpdatePrivateUserByID(ctx context.Context, userUpdate entity.UserUpdatePrivate) (entity.User, error) {
+// UpdatePrivateUserByID - приватное обновление пользователя. Вызывающий редактирует только пользователей с ролью
+// ниже своей и выдает только роли ниже своей, смена роли сохраняется в аудите
+func (u *User) UpdatePrivateUserByID(ctx context.Context, actor entity.Actor, userUpdate entity.UserUpdatePrivate) (entity.User, error) {
 	_, span := tracer.StartTrace(ctx, config.SpanServiceUpdatePrivateUserByID)
 	defer span.End()
 
-	user, err := u.userRepo.UpdatePrivateUserByID(ctx, userUpdate)
+	target, err := u.userRepo.GetUserByID(ctx, userUpdate.ID)
+	if err != nil {
+		return entity.User{}, errors.Wrap(err, "userRepo.GetUserByID")
+	}
+
+	actorRole, err := u.roleRepo.GetRoleByName(ctx, string(actor.Role))
+	if err != nil {
+		return entity.User{}, errors.Wrap(err, "roleRepo.GetRoleByName")
+	}
+
+	targetRole, err := u.roleRepo.GetRoleByName(ctx, string(target.Role))
+	if err != nil {
+		return entity.User{}, errors.Wrap(err, "roleRepo.GetRoleByName")
+	}
+
+	if !actorRole.Outranks(targetRole.Level) {
+		return entity.User{}, errors.Wrapf(apperror.ErrRoleEscalation, "caller with role [%s] cannot modify user with role [%s]",
+			actorRole.Name, targetRole.Name)
+	}
+
+	var roleChange *entity.RoleChange
+	if userUpdate.Role != nil && *userUpdate.Role != target.Role {
+		newRole, errRole := u.roleRepo.GetRoleByName(ctx, string(*userUpdate.Role))
+		if errRole != nil {
+			return entity.User{}, errors.Wrap(errRole, "roleRepo.GetRoleByName")
+		}
+
+		if !actorRole.Outranks(newRole.Level) {
+			return entity.User{}, errors.Wrapf(apperror.ErrRoleEscalation, "caller with role [%s] cannot grant role [%s]",
+				actorRole.Name, newRole.Name)
+		}
+
+		change := entity.NewRoleChange(target.ID, actor.ID, target.Role, *userUpdate.Role, userUpdate.Reason)
+		roleChange = &change
+	}
+
+	user, err := u.userRepo.UpdatePrivateUserByID(ctx, userUpdate, roleChange)
 	if err != nil {
 		return entity.User{}, errors.Wrap(err, "userRepo.UpdatePrivateUserByID")
 	}
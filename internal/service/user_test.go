@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"github.com/GermanBogatov/auth-service/internal/common/apperror"
+	"github.com/GermanBogatov/auth-service/internal/entity"
+	"github.com/GermanBogatov/auth-service/pkg/pwhash"
+	"github.com/GermanBogatov/auth-service/pkg/pwpolicy"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const testNewPassword = "New-Password-8"
+
+var errCacheUnavailable = errors.New("cache unavailable")
+
+// failingRevocationCache - кэш, в котором отзыв токенов пользователя не удается
+type failingRevocationCache struct {
+	*fakeCache
+}
+
+func (c failingRevocationCache) SetUserTokensRevokedBefore(_ context.Context, _ string, _ time.Time, _ string, _ time.Duration) error {
+	return errCacheUnavailable
+}
+
+func newTestUserService(t *testing.T, jwtService IJWT) (IUser, *fakeUserRepo, *pwhash.Hasher) {
+	t.Helper()
+
+	hasher := pwhash.NewHasher(pwhash.Params{Algorithm: pwhash.AlgorithmBcrypt, BcryptCost: 4})
+	passwordHash, err := hasher.Hash(testPassword)
+	require.NoError(t, err)
+
+	user := testUser
+	user.Password = passwordHash
+	userRepo := newFakeUserRepo(user)
+
+	return NewUser(userRepo, nil, jwtService, hasher, pwpolicy.NewPolicy(pwpolicy.Rules{MinLength: 8}, nil), 0, ""),
+		userRepo, hasher
+}
+
+func TestUserChangePasswordRevokesOtherSessions(t *testing.T) {
+	ctx := context.Background()
+	jwtService, _ := newTestJWT(t)
+	userService, userRepo, hasher := newTestUserService(t, jwtService)
+
+	currentAccess, currentRefresh, err := jwtService.GenerateAccessAndRefreshTokens(ctx, testUser, testClient)
+	require.NoError(t, err)
+	otherAccess, otherRefresh, err := jwtService.GenerateAccessAndRefreshTokens(ctx, testUser, testClient)
+	require.NoError(t, err)
+	impersonationToken, err := jwtService.GenerateImpersonationToken(ctx, testUser, entity.Impersonation{
+		ID:          "impersonation-1",
+		ActorID:     "admin-1",
+		CreatedDate: time.Now(),
+		ExpiresDate: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	current, err := jwtService.ParseAccessToken(ctx, currentAccess)
+	require.NoError(t, err)
+
+	err = userService.ChangePassword(ctx, testUser.ID, current.SessionID, testPassword, testNewPassword)
+	require.NoError(t, err)
+
+	ok, err := hasher.Verify(testNewPassword, userRepo.users[testUser.ID].Password)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// текущая сессия продолжает работать
+	_, err = jwtService.ParseAccessToken(ctx, currentAccess)
+	require.NoError(t, err)
+	_, _, err = jwtService.UpdateRefreshToken(ctx, currentRefresh, testClient)
+	require.NoError(t, err)
+
+	// остальные сессии и токены вне сессий отозваны
+	_, err = jwtService.ParseAccessToken(ctx, otherAccess)
+	assert.ErrorIs(t, err, apperror.ErrTokenRevoked)
+	_, _, err = jwtService.UpdateRefreshToken(ctx, otherRefresh, testClient)
+	assert.ErrorIs(t, err, apperror.ErrRefreshTokenNotFound)
+	_, err = jwtService.ParseAccessToken(ctx, impersonationToken)
+	assert.ErrorIs(t, err, apperror.ErrTokenRevoked)
+}
+
+func TestUserChangePasswordWithoutSession(t *testing.T) {
+	ctx := context.Background()
+	jwtService, _ := newTestJWT(t)
+	userService, _, _ := newTestUserService(t, jwtService)
+
+	accessToken, _, err := jwtService.GenerateAccessAndRefreshTokens(ctx, testUser, testClient)
+	require.NoError(t, err)
+
+	// токен вне сессии не может сохранить ни одну из сессий пользователя
+	err = userService.ChangePassword(ctx, testUser.ID, "", testPassword, testNewPassword)
+	require.NoError(t, err)
+
+	_, err = jwtService.ParseAccessToken(ctx, accessToken)
+	assert.ErrorIs(t, err, apperror.ErrTokenRevoked)
+}
+
+func TestUserChangePasswordRejected(t *testing.T) {
+	tests := []struct {
+		name            string
+		currentPassword string
+		newPassword     string
+		failRevocation  bool
+		wantErr         error
+	}{
+		{
+			name:            "wrong current password",
+			currentPassword: "wrong",
+			newPassword:     testNewPassword,
+			wantErr:         apperror.ErrInvalidCurrentPassword,
+		},
+		{
+			name:            "new password violates policy",
+			currentPassword: testPassword,
+			newPassword:     "short",
+			wantErr:         apperror.ErrPasswordPolicy,
+		},
+		{
+			name:            "revocation failed",
+			currentPassword: testPassword,
+			newPassword:     testNewPassword,
+			failRevocation:  true,
+			wantErr:         errCacheUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			jwtService, fake := newTestJWT(t)
+
+			otherAccess, _, err := jwtService.GenerateAccessAndRefreshTokens(ctx, testUser, testClient)
+			require.NoError(t, err)
+
+			userJWT := jwtService
+			if tt.failRevocation {
+				userJWT = NewJWT(nil, failingRevocationCache{fake}, newFakeKeyRing(t), nil, 300, 3600, testReuseGraceSec, 0,
+					"", "", "", "")
+			}
+			userService, userRepo, hasher := newTestUserService(t, userJWT)
+
+			err = userService.ChangePassword(ctx, testUser.ID, "session-1", tt.currentPassword, tt.newPassword)
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			// пароль не меняется, сессии остаются
+			ok, err := hasher.Verify(testPassword, userRepo.users[testUser.ID].Password)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			_, err = jwtService.ParseAccessToken(ctx, otherAccess)
+			require.NoError(t, err)
+		})
+	}
+}
+
+var testRoles = []entity.Role{
+	{Name: string(entity.RoleUser), Level: 0},
+	{Name: "support", Level: 30},
+	{Name: string(entity.RoleAdmin), Level: 50},
+	{Name: string(entity.RoleSuperAdmin), Level: 100},
+}
+
+func newTestRoleUsers(users ...entity.User) (IUser, *fakeUserRepo) {
+	userRepo := newFakeUserRepo(users...)
+	return NewUser(userRepo, newFakeRoleRepo(testRoles...), nil, nil, nil, 0, ""), userRepo
+}
+
+func TestUserUpdatePrivateUserByIDRoleChange(t *testing.T) {
+	rolePtr := func(role entity.RoleType) *entity.RoleType {
+		return &role
+	}
+
+	superAdmin := entity.User{ID: "super-admin-1", Role: entity.RoleSuperAdmin}
+	otherSuperAdmin := entity.User{ID: "super-admin-2", Role: entity.RoleSuperAdmin}
+	admin := entity.User{ID: "admin-1", Role: entity.RoleAdmin}
+	otherAdmin := entity.User{ID: "admin-2", Role: entity.RoleAdmin}
+	support := entity.User{ID: "support-1", Role: "support"}
+	user := entity.User{ID: "user-1", Role: entity.RoleUser}
+
+	tests := []struct {
+		name    string
+		users   []entity.User
+		actor   entity.User
+		target  entity.User
+		role    *entity.RoleType
+		wantErr error
+	}{
+		{
+			name:   "admin promotes user to support",
+			users:  []entity.User{admin, user},
+			actor:  admin,
+			target: user,
+			role:   rolePtr("support"),
+		},
+		{
+			name:   "admin demotes support",
+			users:  []entity.User{admin, support},
+			actor:  admin,
+			target: support,
+			role:   rolePtr(entity.RoleUser),
+		},
+		{
+			name:   "super-admin promotes admin to super-admin",
+			users:  []entity.User{superAdmin, admin},
+			actor:  superAdmin,
+			target: admin,
+			role:   rolePtr(entity.RoleSuperAdmin),
+		},
+		{
+			name:   "super-admin demotes another super-admin",
+			users:  []entity.User{superAdmin, otherSuperAdmin},
+			actor:  superAdmin,
+			target: otherSuperAdmin,
+			role:   rolePtr(entity.RoleAdmin),
+		},
+		{
+			name:    "admin grants own role",
+			users:   []entity.User{admin, user},
+			actor:   admin,
+			target:  user,
+			role:    rolePtr(entity.RoleAdmin),
+			wantErr: apperror.ErrRoleEscalation,
+		},
+		{
+			name:    "admin grants super-admin",
+			users:   []entity.User{admin, user},
+			actor:   admin,
+			target:  user,
+			role:    rolePtr(entity.RoleSuperAdmin),
+			wantErr: apperror.ErrRoleEscalation,
+		},
+		{
+			name:    "admin modifies admin of same level",
+			users:   []entity.User{admin, otherAdmin},
+			actor:   admin,
+			target:  otherAdmin,
+			role:    rolePtr(entity.RoleUser),
+			wantErr: apperror.ErrRoleEscalation,
+		},
+		{
+			name:    "admin demotes super-admin",
+			users:   []entity.User{superAdmin, admin},
+			actor:   admin,
+			target:  superAdmin,
+			role:    rolePtr(entity.RoleUser),
+			wantErr: apperror.ErrRoleEscalation,
+		},
+		{
+			name:    "admin promotes self",
+			users:   []entity.User{admin},
+			actor:   admin,
+			target:  admin,
+			role:    rolePtr(entity.RoleSuperAdmin),
+			wantErr: apperror.ErrRoleEscalation,
+		},
+		{
+			name:    "support promotes self",
+			users:   []entity.User{support},
+			actor:   support,
+			target:  support,
+			role:    rolePtr(entity.RoleAdmin),
+			wantErr: apperror.ErrRoleEscalation,
+		},
+		{
+			name:    "last super-admin demotes self",
+			users:   []entity.User{superAdmin, admin},
+			actor:   superAdmin,
+			target:  superAdmin,
+			role:    rolePtr(entity.RoleAdmin),
+			wantErr: apperror.ErrLastSuperAdmin,
+		},
+		{
+			name:   "super-admin demotes self while another remains",
+			users:  []entity.User{superAdmin, otherSuperAdmin},
+			actor:  superAdmin,
+			target: superAdmin,
+			role:   rolePtr(entity.RoleAdmin),
+		},
+		{
+			name:    "unknown role",
+			users:   []entity.User{superAdmin, user},
+			actor:   superAdmin,
+			target:  user,
+			role:    rolePtr("owner"),
+			wantErr: apperror.ErrRoleNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, userRepo := newTestRoleUsers(tt.users...)
+
+			result, err := userService.UpdatePrivateUserByID(context.Background(),
+				entity.Actor{ID: tt.actor.ID, Role: tt.actor.Role},
+				entity.UserUpdatePrivate{Role: tt.role, Reason: "test", UserUpdateBase: entity.UserUpdateBase{ID: tt.target.ID}})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Equal(t, tt.target.Role, userRepo.users[tt.target.ID].Role)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, *tt.role, result.Role)
+
+			// смена роли уходит в аудит вместе с редактированием
+			require.Len(t, userRepo.roleChanges, 1)
+			require.NotNil(t, userRepo.roleChanges[0])
+			assert.Equal(t, tt.actor.ID, userRepo.roleChanges[0].ActorID)
+			assert.Equal(t, tt.target.Role, userRepo.roleChanges[0].OldRole)
+			assert.Equal(t, *tt.role, userRepo.roleChanges[0].NewRole)
+		})
+	}
+}
+
+func TestUserUpdatePrivateUserByIDWithoutRoleChange(t *testing.T) {
+	admin := entity.User{ID: "admin-1", Role: entity.RoleAdmin}
+	user := entity.User{ID: "user-1", Role: entity.RoleUser}
+	userService, userRepo := newTestRoleUsers(admin, user)
+
+	// та же роль не считается сменой и не пишется в аудит
+	role := entity.RoleUser
+	_, err := userService.UpdatePrivateUserByID(context.Background(), entity.Actor{ID: admin.ID, Role: admin.Role},
+		entity.UserUpdatePrivate{Role: &role, UserUpdateBase: entity.UserUpdateBase{ID: user.ID}})
+	require.NoError(t, err)
+	require.Len(t, userRepo.roleChanges, 1)
+	assert.Nil(t, userRepo.roleChanges[0])
+}
+
+func TestUserDeleteLastSuperAdmin(t *testing.T) {
+	superAdmin := entity.User{ID: "super-admin-1", Role: entity.RoleSuperAdmin}
+	otherSuperAdmin := entity.User{ID: "super-admin-2", Role: entity.RoleSuperAdmin}
+
+	userService, userRepo := newTestRoleUsers(superAdmin)
+	err := userService.DeleteUserByID(context.Background(), superAdmin.ID)
+	assert.ErrorIs(t, err, apperror.ErrLastSuperAdmin)
+	assert.Contains(t, userRepo.users, superAdmin.ID)
+
+	userService, userRepo = newTestRoleUsers(superAdmin, otherSuperAdmin)
+	err = userService.DeleteUserByID(context.Background(), superAdmin.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, userRepo.users, superAdmin.ID)
+}
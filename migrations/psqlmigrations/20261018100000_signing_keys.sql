@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TYPE keyStatus AS ENUM (
+    'next',
+    'active',
+    'retired'
+    );
+
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id                  VARCHAR(64) NOT NULL PRIMARY KEY,
+    algorithm           VARCHAR(16) NOT NULL,
+    private_key         BYTEA NOT NULL,
+    status              keyStatus NOT NULL,
+    created_date        TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    activated_date      TIMESTAMP WITHOUT TIME ZONE DEFAULT NULL,
+    retired_date        TIMESTAMP WITHOUT TIME ZONE DEFAULT NULL,
+    expires_date        TIMESTAMP WITHOUT TIME ZONE DEFAULT NULL
+);
+
+-- в любой момент времени может быть только один активный и один следующий ключ
+CREATE UNIQUE INDEX IF NOT EXISTS idx_signing_keys_status_unique
+    ON signing_keys(status) WHERE status IN ('next', 'active');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_signing_keys_status_unique;
+DROP TABLE signing_keys;
+DROP TYPE keyStatus;
+-- +goose StatementEnd
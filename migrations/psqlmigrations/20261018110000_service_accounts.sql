@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS service_accounts (
+    id                  UUID NOT NULL PRIMARY KEY,
+    name                VARCHAR(255) NOT NULL,
+    owner_id            UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    secret_hash         VARCHAR(64) NOT NULL,
+    scopes              TEXT[] NOT NULL DEFAULT '{}',
+    created_date        TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    updated_date        TIMESTAMP WITHOUT TIME ZONE DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_accounts_owner_id
+    ON service_accounts(owner_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_service_accounts_owner_id;
+DROP TABLE service_accounts;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id                  UUID NOT NULL PRIMARY KEY,
+    name                VARCHAR(255) NOT NULL,
+    secret_hash         VARCHAR(64) DEFAULT NULL,
+    public              BOOLEAN NOT NULL DEFAULT FALSE,
+    redirect_uris       TEXT[] NOT NULL DEFAULT '{}',
+    grant_types         TEXT[] NOT NULL DEFAULT '{}',
+    scopes              TEXT[] NOT NULL DEFAULT '{}',
+    created_date        TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    updated_date        TIMESTAMP WITHOUT TIME ZONE DEFAULT NULL,
+    -- у публичных клиентов (spa, мобильные приложения) секрета нет
+    CONSTRAINT oauth_clients_secret_check CHECK (public = (secret_hash IS NULL))
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE oauth_clients;
+-- +goose StatementEnd
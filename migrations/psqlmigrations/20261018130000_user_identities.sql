@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    provider            VARCHAR(64) NOT NULL,
+    subject             VARCHAR(255) NOT NULL,
+    user_id             UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email               VARCHAR(255) NOT NULL DEFAULT '',
+    created_date        TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    PRIMARY KEY (provider, subject)
+);
+
+-- у пользователя не больше одной учетной записи каждого провайдера
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_user_id_provider
+    ON user_identities(user_id, provider);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_user_identities_user_id_provider;
+DROP TABLE user_identities;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS personal_tokens (
+    id                  UUID NOT NULL PRIMARY KEY,
+    user_id             UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name                VARCHAR(255) NOT NULL,
+    token_hash          VARCHAR(64) NOT NULL,
+    token_prefix        VARCHAR(16) NOT NULL,
+    scopes              TEXT[] NOT NULL DEFAULT '{}',
+    expires_date        TIMESTAMP WITHOUT TIME ZONE DEFAULT NULL,
+    last_used_date      TIMESTAMP WITHOUT TIME ZONE DEFAULT NULL,
+    last_used_ip        VARCHAR(64) DEFAULT NULL,
+    created_date        TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    updated_date        TIMESTAMP WITHOUT TIME ZONE DEFAULT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_personal_tokens_token_hash
+    ON personal_tokens(token_hash);
+
+CREATE INDEX IF NOT EXISTS idx_personal_tokens_user_id
+    ON personal_tokens(user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_personal_tokens_user_id;
+DROP INDEX idx_personal_tokens_token_hash;
+DROP TABLE personal_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS roles (
+    name                VARCHAR(64) NOT NULL PRIMARY KEY,
+    description         VARCHAR(255) NOT NULL DEFAULT '',
+    permissions         TEXT[] NOT NULL DEFAULT '{}',
+    built_in            BOOLEAN NOT NULL DEFAULT FALSE,
+    created_date        TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    updated_date        TIMESTAMP WITHOUT TIME ZONE DEFAULT NULL
+);
+
+INSERT INTO roles (name,description,permissions,built_in,created_date) VALUES
+    ('user','Пользователь','{users:read,users:update:self,users:delete:self,sessions:read:self,sessions:delete:self,tokens:manage:self}',TRUE,current_timestamp),
+    ('admin','Администратор','{users:read,users:update:self,users:delete:self,sessions:read:self,sessions:delete:self,tokens:manage:self,users:update:any,sessions:read:any,sessions:delete:any,service-accounts:manage,oauth-clients:manage,roles:manage}',TRUE,current_timestamp),
+    ('super-admin','Суперадминистратор','{users:read,users:update:self,users:delete:self,sessions:read:self,sessions:delete:self,tokens:manage:self,users:update:any,sessions:read:any,sessions:delete:any,service-accounts:manage,oauth-clients:manage,roles:manage}',TRUE,current_timestamp);
+
+ALTER TABLE users ALTER COLUMN role TYPE VARCHAR(64) USING role::TEXT;
+ALTER TABLE users ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(name);
+DROP TYPE roleType;
+
+CREATE INDEX IF NOT EXISTS idx_users_role
+    ON users(role);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE TYPE roleType AS ENUM (
+    'super-admin',
+    'admin',
+    'user'
+    );
+
+DROP INDEX idx_users_role;
+ALTER TABLE users DROP CONSTRAINT fk_users_role;
+UPDATE users SET role='user' WHERE role NOT IN ('super-admin','admin','user');
+ALTER TABLE users ALTER COLUMN role TYPE roleType USING role::roleType;
+DROP TABLE roles;
+-- +goose StatementEnd
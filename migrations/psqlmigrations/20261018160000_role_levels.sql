@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS level INT NOT NULL DEFAULT 0;
+UPDATE roles SET level=50 WHERE name='admin';
+UPDATE roles SET level=100 WHERE name='super-admin';
+
+CREATE TABLE IF NOT EXISTS user_role_changes (
+    id                  UUID NOT NULL PRIMARY KEY,
+    user_id             UUID NOT NULL,
+    actor_id            UUID NOT NULL,
+    old_role            VARCHAR(64) NOT NULL,
+    new_role            VARCHAR(64) NOT NULL,
+    reason              VARCHAR(500) NOT NULL,
+    created_date        TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_role_changes_user_id
+    ON user_role_changes(user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_role_changes;
+ALTER TABLE roles DROP COLUMN level;
+-- +goose StatementEnd
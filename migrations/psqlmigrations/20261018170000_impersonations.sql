@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS user_impersonations (
+    id                  UUID NOT NULL PRIMARY KEY,
+    user_id             UUID NOT NULL,
+    actor_id            UUID NOT NULL,
+    reason              VARCHAR(500) NOT NULL,
+    expires_date        TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+    created_date        TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_impersonations_user_id
+    ON user_impersonations(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_impersonations_actor_id
+    ON user_impersonations(actor_id);
+
+UPDATE roles SET permissions = array_append(permissions, 'users:impersonate')
+WHERE name IN ('admin','super-admin') AND NOT ('users:impersonate' = ANY(permissions));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE roles SET permissions = array_remove(permissions, 'users:impersonate');
+DROP TABLE user_impersonations;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS audiences TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE service_accounts ADD COLUMN IF NOT EXISTS audiences TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE service_accounts DROP COLUMN audiences;
+ALTER TABLE oauth_clients DROP COLUMN audiences;
+-- +goose StatementEnd
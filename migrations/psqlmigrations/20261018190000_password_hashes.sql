@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- пароль сверяется в сервисе после выборки по email, индекс по хэшу больше не используется
+DROP INDEX IF EXISTS idx_users_email_password;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_users_email_password
+    ON users(email,password);
+-- +goose StatementEnd
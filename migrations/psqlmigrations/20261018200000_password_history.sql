@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS password_history (
+    id                  UUID NOT NULL PRIMARY KEY,
+    user_id             UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash       TEXT NOT NULL,
+    created_date        TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user_id_created_date
+    ON password_history(user_id, created_date DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_password_history_user_id_created_date;
+DROP TABLE password_history;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- пользователи, зарегистрированные до появления подтверждения email, считаются подтвержденными,
+-- иначе режим блокировки входа закроет им доступ
+UPDATE users SET email_verified = TRUE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN email_verified;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+
+UPDATE roles SET permissions = array_append(permissions, 'password:change:self')
+WHERE 'users:update:self' = ANY(permissions) AND NOT ('password:change:self' = ANY(permissions));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE roles SET permissions = array_remove(permissions, 'password:change:self');
+-- +goose StatementEnd
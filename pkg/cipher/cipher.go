@@ -0,0 +1,65 @@
+package cipher
+
+import (
+	"crypto/aes"
+	stdcipher "crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"io"
+)
+
+var ErrInvalidCiphertext = errors.New("invalid ciphertext")
+
+// AESGCM - симметричное шифрование AES-256-GCM, nonce хранится в начале шифротекста
+type AESGCM struct {
+	aead stdcipher.AEAD
+}
+
+// NewAESGCM - создание шифратора из ключа в hex (32 байта)
+func NewAESGCM(hexKey string) (*AESGCM, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode key")
+	}
+	if len(key) != 32 {
+		return nil, errors.New("key must be 32 bytes")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "aes.NewCipher")
+	}
+
+	aead, err := stdcipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "cipher.NewGCM")
+	}
+
+	return &AESGCM{aead: aead}, nil
+}
+
+// Encrypt - шифрование данных
+func (c *AESGCM) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "generate nonce")
+	}
+
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt - расшифровка данных
+func (c *AESGCM) Decrypt(ciphertext []byte) ([]byte, error) {
+	nonceSize := c.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, ErrInvalidCiphertext
+	}
+
+	plaintext, err := c.aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], nil)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidCiphertext, err.Error())
+	}
+
+	return plaintext, nil
+}
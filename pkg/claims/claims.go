@@ -0,0 +1,193 @@
+// Package claims - конвейер обогащения access-токенов дополнительными claims
+package claims
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+const (
+	EnricherProfile     = "profile"
+	EnricherPermissions = "permissions"
+	EnricherStatic      = "static"
+)
+
+var (
+	ErrReservedClaim  = errors.New("claim is reserved")
+	ErrClaimsTooLarge = errors.New("claims exceed size limit")
+)
+
+// Registered - зарегистрированные claims jwt (RFC 7519, раздел 4.1)
+var Registered = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti"}
+
+// Claims - дополнительные claims токена
+type Claims map[string]any
+
+// Subject - данные, по которым обогащается токен
+type Subject struct {
+	ID      string
+	Name    string
+	Surname string
+	Email   string
+	Role    string
+	// ClientID - oauth-клиент, которому выдается токен, пустой для входа в сам сервис
+	ClientID string
+}
+
+// Enricher - источник дополнительных claims. Обогатитель пишет только свои claims, конфликты и размер проверяет конвейер
+type Enricher interface {
+	Name() string
+	Enrich(ctx context.Context, subject Subject, claims Claims) error
+}
+
+// Pipeline - последовательный вызов обогатителей. При совпадении имен побеждает обогатитель, вызванный позже
+type Pipeline struct {
+	enrichers []Enricher
+	reserved  map[string]struct{}
+	maxSize   int
+}
+
+// NewPipeline - конвейер с ограничением размера claims в json и именами, которые обогатители перекрыть не могут
+func NewPipeline(maxSize int, reserved []string, enrichers ...Enricher) *Pipeline {
+	return &Pipeline{
+		enrichers: enrichers,
+		reserved:  reservedSet(reserved),
+		maxSize:   maxSize,
+	}
+}
+
+// Enrich - сбор дополнительных claims для субъекта
+func (p *Pipeline) Enrich(ctx context.Context, subject Subject) (Claims, error) {
+	result := make(Claims)
+	for _, enricher := range p.enrichers {
+		claims := make(Claims)
+		err := enricher.Enrich(ctx, subject, claims)
+		if err != nil {
+			return nil, errors.Wrapf(err, "enricher [%s]", enricher.Name())
+		}
+
+		for name, value := range claims {
+			if _, ok := p.reserved[name]; ok || name == "" {
+				return nil, errors.Wrapf(ErrReservedClaim, "enricher [%s] claim [%s]", enricher.Name(), name)
+			}
+			result[name] = value
+		}
+	}
+
+	if len(result) == 0 {
+		return result, nil
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal claims")
+	}
+	if len(data) > p.maxSize {
+		return nil, errors.Wrapf(ErrClaimsTooLarge, "%d bytes, limit %d", len(data), p.maxSize)
+	}
+
+	return result, nil
+}
+
+// Profile - профиль пользователя в стандартных claims OpenID Connect Core 1.0, раздел 5.1
+type Profile struct{}
+
+func (Profile) Name() string {
+	return EnricherProfile
+}
+
+func (Profile) Enrich(_ context.Context, subject Subject, claims Claims) error {
+	if subject.Name != "" {
+		claims["given_name"] = subject.Name
+	}
+	if subject.Surname != "" {
+		claims["family_name"] = subject.Surname
+	}
+
+	name := strings.TrimSpace(subject.Name + " " + subject.Surname)
+	if name != "" {
+		claims["name"] = name
+	}
+
+	return nil
+}
+
+// PermissionSource - права роли субъекта
+type PermissionSource func(ctx context.Context, role string) ([]string, error)
+
+// Permissions - права роли субъекта в claim permissions, чтобы потребители не дублировали у себя матрицу ролей
+type Permissions struct {
+	source PermissionSource
+}
+
+func NewPermissions(source PermissionSource) Permissions {
+	return Permissions{
+		source: source,
+	}
+}
+
+func (Permissions) Name() string {
+	return EnricherPermissions
+}
+
+func (p Permissions) Enrich(ctx context.Context, subject Subject, claims Claims) error {
+	permissions, err := p.source(ctx, subject.Role)
+	if err != nil {
+		return errors.Wrap(err, "permission source")
+	}
+
+	claims["permissions"] = permissions
+	return nil
+}
+
+// Static - заданные в конфигурации claims по oauth-клиентам (tenant, тарифные возможности и т.п.)
+type Static struct {
+	clients map[string]Claims
+}
+
+// NewStatic - статические claims по идентификаторам клиентов, зарезервированные имена отклоняются сразу,
+// а не при первом выпуске токена
+func NewStatic(clients map[string]Claims, reserved []string) (Static, error) {
+	set := reservedSet(reserved)
+	for clientID, claims := range clients {
+		for name := range claims {
+			if _, ok := set[name]; ok || name == "" {
+				return Static{}, errors.Wrapf(ErrReservedClaim, "client [%s] claim [%s]", clientID, name)
+			}
+		}
+	}
+
+	return Static{
+		clients: clients,
+	}, nil
+}
+
+func (Static) Name() string {
+	return EnricherStatic
+}
+
+func (s Static) Enrich(_ context.Context, subject Subject, claims Claims) error {
+	if subject.ClientID == "" {
+		return nil
+	}
+
+	for name, value := range s.clients[subject.ClientID] {
+		claims[name] = value
+	}
+
+	return nil
+}
+
+// reservedSet - зарегистрированные claims jwt вместе с перечисленными
+func reservedSet(reserved []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(Registered)+len(reserved))
+	for _, name := range Registered {
+		set[name] = struct{}{}
+	}
+	for _, name := range reserved {
+		set[name] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,154 @@
+package claims
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+// enricherFunc - обогатитель из функции для проверки конвейера
+type enricherFunc func(claims Claims) error
+
+func (enricherFunc) Name() string {
+	return "test"
+}
+
+func (f enricherFunc) Enrich(_ context.Context, _ Subject, claims Claims) error {
+	return f(claims)
+}
+
+func TestPipeline(t *testing.T) {
+	tests := []struct {
+		name      string
+		enrichers []Enricher
+		want      Claims
+		wantErr   error
+	}{
+		{
+			name: "no enrichers",
+			want: Claims{},
+		},
+		{
+			name: "later enricher wins",
+			enrichers: []Enricher{
+				enricherFunc(func(claims Claims) error {
+					claims["tenant"] = "acme"
+					claims["plan"] = "free"
+					return nil
+				}),
+				enricherFunc(func(claims Claims) error {
+					claims["plan"] = "pro"
+					return nil
+				}),
+			},
+			want: Claims{"tenant": "acme", "plan": "pro"},
+		},
+		{
+			name: "registered claim",
+			enrichers: []Enricher{
+				enricherFunc(func(claims Claims) error {
+					claims["sub"] = "admin"
+					return nil
+				}),
+			},
+			wantErr: ErrReservedClaim,
+		},
+		{
+			name: "configured reserved claim",
+			enrichers: []Enricher{
+				enricherFunc(func(claims Claims) error {
+					claims["role"] = "super-admin"
+					return nil
+				}),
+			},
+			wantErr: ErrReservedClaim,
+		},
+		{
+			name: "too large",
+			enrichers: []Enricher{
+				enricherFunc(func(claims Claims) error {
+					claims["tenant"] = strings.Repeat("a", 64)
+					return nil
+				}),
+			},
+			wantErr: ErrClaimsTooLarge,
+		},
+		{
+			name: "enricher error",
+			enrichers: []Enricher{
+				enricherFunc(func(claims Claims) error {
+					return context.Canceled
+				}),
+			},
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline := NewPipeline(48, []string{"role"}, tt.enrichers...)
+
+			claims, err := pipeline.Enrich(context.Background(), Subject{ID: "user"})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, claims)
+		})
+	}
+}
+
+func TestProfile(t *testing.T) {
+	claims := Claims{}
+	require.NoError(t, Profile{}.Enrich(context.Background(), Subject{Name: "Иван", Surname: "Петров"}, claims))
+	assert.Equal(t, Claims{"given_name": "Иван", "family_name": "Петров", "name": "Иван Петров"}, claims)
+
+	claims = Claims{}
+	require.NoError(t, Profile{}.Enrich(context.Background(), Subject{Name: "Иван"}, claims))
+	assert.Equal(t, Claims{"given_name": "Иван", "name": "Иван"}, claims)
+}
+
+func TestPermissions(t *testing.T) {
+	enricher := NewPermissions(func(_ context.Context, role string) ([]string, error) {
+		if role != "admin" {
+			return nil, errors.New("unknown role")
+		}
+		return []string{"users:read", "roles:manage"}, nil
+	})
+
+	claims := Claims{}
+	require.NoError(t, enricher.Enrich(context.Background(), Subject{Role: "admin"}, claims))
+	assert.Equal(t, Claims{"permissions": []string{"users:read", "roles:manage"}}, claims)
+
+	assert.Error(t, enricher.Enrich(context.Background(), Subject{Role: "guest"}, Claims{}))
+}
+
+func TestStatic(t *testing.T) {
+	enricher, err := NewStatic(map[string]Claims{
+		"portal": {"tenant": "acme", "features": []any{"export"}},
+	}, nil)
+	require.NoError(t, err)
+
+	claims := Claims{}
+	require.NoError(t, enricher.Enrich(context.Background(), Subject{ClientID: "portal"}, claims))
+	assert.Equal(t, Claims{"tenant": "acme", "features": []any{"export"}}, claims)
+
+	claims = Claims{}
+	require.NoError(t, enricher.Enrich(context.Background(), Subject{ClientID: "other"}, claims))
+	assert.Empty(t, claims)
+
+	claims = Claims{}
+	require.NoError(t, enricher.Enrich(context.Background(), Subject{}, claims))
+	assert.Empty(t, claims)
+
+	_, err = NewStatic(map[string]Claims{"portal": {"email": "admin@example.com"}}, []string{"email"})
+	assert.ErrorIs(t, err, ErrReservedClaim)
+
+	_, err = NewStatic(map[string]Claims{"portal": {"iss": "evil"}}, nil)
+	assert.ErrorIs(t, err, ErrReservedClaim)
+}
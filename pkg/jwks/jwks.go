@@ -0,0 +1,258 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"github.com/pkg/errors"
+	"math/big"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+
+	useSignature = "sig"
+	rsaKeyBits   = 2048
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrUnsupportedKey       = errors.New("unsupported key type")
+)
+
+// Key - публичный ключ в формате JWK (RFC 7517)
+type Key struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// Set - набор публичных ключей (JWKS)
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+// NewKey - формирование JWK из публичного ключа
+func NewKey(kid, alg string, publicKey crypto.PublicKey) (Key, error) {
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		return Key{
+			Kty: "RSA",
+			Kid: kid,
+			Use: useSignature,
+			Alg: alg,
+			N:   encode(pub.N.Bytes()),
+			E:   encode(big.NewInt(int64(pub.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		return Key{
+			Kty: "EC",
+			Kid: kid,
+			Use: useSignature,
+			Alg: alg,
+			Crv: pub.Curve.Params().Name,
+			X:   encode(pub.X.FillBytes(make([]byte, size))),
+			Y:   encode(pub.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return Key{
+			Kty: "OKP",
+			Kid: kid,
+			Use: useSignature,
+			Alg: alg,
+			Crv: "Ed25519",
+			X:   encode(pub),
+		}, nil
+	default:
+		return Key{}, ErrUnsupportedKey
+	}
+}
+
+// PublicKey - восстановление публичного ключа из JWK
+func (k Key) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode n")
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode e")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve [%s]", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode x")
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode y")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve [%s]", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode x")
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+// Find - поиск ключа в наборе по идентификатору
+func (s Set) Find(kid string) (Key, bool) {
+	for _, key := range s.Keys {
+		if key.Kid == kid {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// Thumbprint - отпечаток публичного ключа по RFC 7638, используется как kid
+func Thumbprint(publicKey crypto.PublicKey) (string, error) {
+	key, err := NewKey("", "", publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	// поля должны идти в лексикографическом порядке без пробелов
+	var members string
+	switch key.Kty {
+	case "RSA":
+		members = fmt.Sprintf(`{"e":%q,"kty":%q,"n":%q}`, key.E, key.Kty, key.N)
+	case "EC":
+		members = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q,"y":%q}`, key.Crv, key.Kty, key.X, key.Y)
+	default:
+		members = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q}`, key.Crv, key.Kty, key.X)
+	}
+
+	sum := sha256.Sum256([]byte(members))
+	return encode(sum[:]), nil
+}
+
+// GenerateKey - генерация приватного ключа под алгоритм подписи
+func GenerateKey(alg string) (crypto.Signer, error) {
+	switch alg {
+	case AlgRS256:
+		return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgES256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgEdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return private, nil
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+}
+
+// ValidateKey - проверка соответствия приватного ключа алгоритму подписи
+func ValidateKey(alg string, key crypto.Signer) error {
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		if alg == AlgRS256 {
+			return nil
+		}
+	case *ecdsa.PrivateKey:
+		if alg == AlgES256 {
+			return nil
+		}
+	case ed25519.PrivateKey:
+		if alg == AlgEdDSA {
+			return nil
+		}
+	default:
+		return ErrUnsupportedKey
+	}
+
+	return fmt.Errorf("key type %T does not match algorithm [%s]", key, alg)
+}
+
+// ParsePrivateKeyPEM - чтение приватного ключа из PEM (PKCS#8, PKCS#1, SEC1)
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("pem block not found")
+	}
+
+	var (
+		key any
+		err error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "parse private key")
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+
+	return signer, nil
+}
+
+// EncodePrivateKeyPEM - кодирование приватного ключа в PEM (PKCS#8)
+func EncodePrivateKeyPEM(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal private key")
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,77 @@
+package jwks
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestKeyRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		alg  string
+		kty  string
+	}{
+		{
+			name: "rsa",
+			alg:  AlgRS256,
+			kty:  "RSA",
+		},
+		{
+			name: "ecdsa",
+			alg:  AlgES256,
+			kty:  "EC",
+		},
+		{
+			name: "ed25519",
+			alg:  AlgEdDSA,
+			kty:  "OKP",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			privateKey, err := GenerateKey(tt.alg)
+			require.NoError(t, err)
+			require.NoError(t, ValidateKey(tt.alg, privateKey))
+
+			key, err := NewKey("kid", tt.alg, privateKey.Public())
+			require.NoError(t, err)
+			assert.Equal(t, tt.kty, key.Kty)
+
+			publicKey, err := key.PublicKey()
+			require.NoError(t, err)
+			assert.Equal(t, privateKey.Public(), publicKey)
+
+			data, err := EncodePrivateKeyPEM(privateKey)
+			require.NoError(t, err)
+
+			parsed, err := ParsePrivateKeyPEM(data)
+			require.NoError(t, err)
+			assert.Equal(t, privateKey.Public(), parsed.Public())
+		})
+	}
+}
+
+func TestValidateKeyMismatch(t *testing.T) {
+	privateKey, err := GenerateKey(AlgEdDSA)
+	require.NoError(t, err)
+
+	assert.Error(t, ValidateKey(AlgRS256, privateKey))
+}
+
+func TestThumbprint(t *testing.T) {
+	// пример из RFC 7638, раздел 3.1
+	key := Key{
+		Kty: "RSA",
+		N:   "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:   "AQAB",
+	}
+
+	publicKey, err := key.PublicKey()
+	require.NoError(t, err)
+
+	thumbprint, err := Thumbprint(publicKey)
+	require.NoError(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint)
+}
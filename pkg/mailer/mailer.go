@@ -0,0 +1,222 @@
+// Package mailer - отправка писем через SMTP или в файл/лог для разработки и тестов без почтового сервера
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/GermanBogatov/auth-service/pkg/logging"
+	"github.com/pkg/errors"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+	DriverLog  = "log"
+
+	// implicitTLSPort - порт SMTP с TLS с первого байта (RFC 8314), на остальных портах используется STARTTLS
+	implicitTLSPort = 465
+)
+
+var ErrInvalidMessage = errors.New("invalid mail message")
+
+// Message - текстовое письмо одному получателю
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+}
+
+// Mailer - доставка писем
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// SMTP - отправка через SMTP-сервер с STARTTLS или неявным TLS на порту 465
+type SMTP struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	timeout  time.Duration
+}
+
+func NewSMTP(host string, port int, username, password, from string, timeout time.Duration) *SMTP {
+	return &SMTP{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+		timeout:  timeout,
+	}
+}
+
+func (s *SMTP) Send(ctx context.Context, message Message) error {
+	data, err := compose(s.from, message)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	if s.port == implicitTLSPort {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: s.host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return errors.Wrap(err, "dial smtp")
+	}
+
+	deadline, _ := ctx.Deadline()
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		_ = conn.Close()
+		return errors.Wrap(err, "set smtp deadline")
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		_ = conn.Close()
+		return errors.Wrap(err, "smtp client")
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		err = client.StartTLS(&tls.Config{ServerName: s.host})
+		if err != nil {
+			return errors.Wrap(err, "starttls")
+		}
+	}
+
+	// smtp.PlainAuth сам отказывается передавать пароль без TLS на нелокальный сервер
+	if s.username != "" {
+		err = client.Auth(smtp.PlainAuth("", s.username, s.password, s.host))
+		if err != nil {
+			return errors.Wrap(err, "smtp auth")
+		}
+	}
+
+	err = client.Mail(s.from)
+	if err != nil {
+		return errors.Wrap(err, "smtp mail")
+	}
+	err = client.Rcpt(message.To)
+	if err != nil {
+		return errors.Wrap(err, "smtp rcpt")
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return errors.Wrap(err, "smtp data")
+	}
+	_, err = w.Write(data)
+	if err != nil {
+		return errors.Wrap(err, "write message")
+	}
+	err = w.Close()
+	if err != nil {
+		return errors.Wrap(err, "close message")
+	}
+
+	return client.Quit()
+}
+
+// File - письма дописываются в файл целиком, включая заголовки, чтобы тесты могли достать из них ссылки и токены
+type File struct {
+	path string
+	from string
+	mu   sync.Mutex
+}
+
+func NewFile(path, from string) *File {
+	return &File{
+		path: path,
+		from: from,
+	}
+}
+
+func (f *File) Send(_ context.Context, message Message) error {
+	data, err := compose(f.from, message)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "open mail file")
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, "\r\n"...))
+	if err != nil {
+		return errors.Wrap(err, "write mail file")
+	}
+
+	return nil
+}
+
+// Log - письма пишутся в лог сервиса
+type Log struct{}
+
+func (Log) Send(_ context.Context, message Message) error {
+	if err := validate(message); err != nil {
+		return err
+	}
+
+	logging.Infof("mail to [%s] subject [%s]:\n%s", message.To, message.Subject, message.Text)
+	return nil
+}
+
+// compose - письмо в формате RFC 5322 с темой в кодировке RFC 2047
+func compose(from string, message Message) ([]byte, error) {
+	err := validate(message)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Text, "\r\n", "\n"), "\n", "\r\n"))
+	buf.WriteString("\r\n")
+
+	return buf.Bytes(), nil
+}
+
+// validate - адрес получателя и тема не должны позволять подставить свои заголовки
+func validate(message Message) error {
+	address, err := mail.ParseAddress(message.To)
+	if err != nil || address.Address != message.To {
+		return errors.Wrap(ErrInvalidMessage, "recipient")
+	}
+	if strings.ContainsAny(message.Subject, "\r\n") {
+		return errors.Wrap(ErrInvalidMessage, "subject")
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package mailer
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.log")
+	sink := NewFile(path, "no-reply@example.com")
+
+	require.NoError(t, sink.Send(context.Background(), Message{
+		To:      "user@example.com",
+		Subject: "Восстановление пароля",
+		Text:    "строка 1\nстрока 2",
+	}))
+	require.NoError(t, sink.Send(context.Background(), Message{To: "other@example.com", Subject: "second", Text: "text"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	text := string(data)
+	assert.Contains(t, text, "From: no-reply@example.com\r\n")
+	assert.Contains(t, text, "To: user@example.com\r\n")
+	assert.Contains(t, text, "Subject: =?utf-8?q?")
+	assert.Contains(t, text, "строка 1\r\nстрока 2\r\n")
+	assert.Contains(t, text, "To: other@example.com\r\n")
+}
+
+func TestInvalidMessage(t *testing.T) {
+	sink := NewFile(filepath.Join(t.TempDir(), "mail.log"), "no-reply@example.com")
+
+	for _, message := range []Message{
+		{To: "not-an-email", Subject: "subject"},
+		{To: "user@example.com\r\nBcc: victim@example.com", Subject: "subject"},
+		{To: "Имя <user@example.com>", Subject: "subject"},
+		{To: "user@example.com", Subject: "subject\r\nBcc: victim@example.com"},
+	} {
+		assert.ErrorIs(t, sink.Send(context.Background(), message), ErrInvalidMessage, message.To)
+		assert.ErrorIs(t, Log{}.Send(context.Background(), message), ErrInvalidMessage, message.To)
+	}
+}
+
+func TestSMTP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan []string, 1)
+	go serveSMTP(t, listener, received)
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	portNumber, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	sender := NewSMTP(host, portNumber, "", "", "no-reply@example.com", 5*time.Second)
+	require.NoError(t, sender.Send(context.Background(), Message{To: "user@example.com", Subject: "subject", Text: "text"}))
+
+	commands := <-received
+	assert.Contains(t, commands, "MAIL FROM:<no-reply@example.com>")
+	assert.Contains(t, commands, "RCPT TO:<user@example.com>")
+	assert.Contains(t, commands, "text")
+}
+
+// serveSMTP - минимальный SMTP-сервер без расширений, принимает одно письмо
+func serveSMTP(t *testing.T, listener net.Listener, received chan<- []string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+	var commands []string
+	reply := func(line string) {
+		assert.NoError(t, text.PrintfLine("%s", line))
+	}
+
+	reply("220 localhost ESMTP")
+	for {
+		line, errRead := text.ReadLine()
+		if errRead != nil {
+			received <- commands
+			return
+		}
+		commands = append(commands, line)
+
+		switch {
+		case strings.HasPrefix(line, "EHLO"), strings.HasPrefix(line, "HELO"):
+			reply("250 localhost")
+		case line == "DATA":
+			reply("354 go ahead")
+			lines, errData := text.ReadDotLines()
+			assert.NoError(t, errData)
+			commands = append(commands, lines...)
+			reply("250 ok")
+		case line == "QUIT":
+			reply("221 bye")
+			received <- commands
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/GermanBogatov/auth-service/pkg/jwks"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+
+	// keysRefreshInterval - минимальный интервал перезапроса JWKS при встрече незнакомого kid
+	keysRefreshInterval = time.Minute
+	// maxResponseSize - ограничение размера ответов провайдера
+	maxResponseSize = 1 << 20
+)
+
+var (
+	ErrIssuerMismatch = errors.New("issuer in provider metadata does not match configured issuer")
+	ErrNonceMismatch  = errors.New("id_token nonce does not match")
+	ErrKeyNotFound    = errors.New("id_token signing key not found")
+	ErrMissingIDToken = errors.New("token response does not contain id_token")
+	ErrInvalidAZP     = errors.New("id_token azp does not match client id")
+)
+
+// Config - настройки клиента внешнего OpenID-провайдера
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Metadata - метаданные провайдера (OpenID Connect Discovery 1.0, раздел 3)
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Token - ответ токен-эндпоинта провайдера
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Claims - проверенные claims id_token провайдера
+type Claims struct {
+	jwt.RegisteredClaims
+	AuthorizedParty string `json:"azp,omitempty"`
+	Nonce           string `json:"nonce,omitempty"`
+	Email           string `json:"email,omitempty"`
+	EmailVerified   bool   `json:"email_verified,omitempty"`
+	Name            string `json:"name,omitempty"`
+	GivenName       string `json:"given_name,omitempty"`
+	FamilyName      string `json:"family_name,omitempty"`
+}
+
+// tokenError - ошибка токен-эндпоинта провайдера (RFC 6749, раздел 5.2)
+type tokenError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Provider - клиент внешнего OpenID-провайдера. Метаданные запрашиваются при первом обращении,
+// чтобы недоступность провайдера не мешала старту сервиса
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu          sync.Mutex
+	metadata    *Metadata
+	keys        jwks.Set
+	keysFetched time.Time
+}
+
+// NewProvider - создание клиента провайдера
+func NewProvider(cfg Config, client *http.Client) *Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Provider{
+		cfg:    cfg,
+		client: client,
+	}
+}
+
+// AuthCodeURL - адрес авторизационного эндпоинта провайдера для перенаправления пользователя
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	target, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", errors.Wrap(err, "parse authorization endpoint")
+	}
+
+	params := target.Query()
+	params.Set("response_type", "code")
+	params.Set("client_id", p.cfg.ClientID)
+	params.Set("redirect_uri", p.cfg.RedirectURL)
+	params.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+	target.RawQuery = params.Encode()
+
+	return target.String(), nil
+}
+
+// Exchange - обмен кода авторизации на токены провайдера
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (Token, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return Token{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, errors.Wrap(err, "new token request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Token{}, errors.Wrap(err, "token request")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return Token{}, errors.Wrap(err, "read token response")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var tokenErr tokenError
+		if json.Unmarshal(body, &tokenErr) == nil && tokenErr.Error != "" {
+			return Token{}, fmt.Errorf("token endpoint: %s: %s", tokenErr.Error, tokenErr.ErrorDescription)
+		}
+		return Token{}, fmt.Errorf("token endpoint: unexpected status [%d]", resp.StatusCode)
+	}
+
+	var token Token
+	err = json.Unmarshal(body, &token)
+	if err != nil {
+		return Token{}, errors.Wrap(err, "decode token response")
+	}
+
+	if token.IDToken == "" {
+		return Token{}, ErrMissingIDToken
+	}
+
+	return token, nil
+}
+
+// VerifyIDToken - проверка подписи и claims id_token (OpenID Connect Core 1.0, раздел 3.1.3.7)
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (Claims, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	var claims Claims
+	_, err = jwt.ParseWithClaims(rawIDToken, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwks.AlgRS256, jwks.AlgES256, jwks.AlgEdDSA}),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return Claims{}, errors.Wrap(err, "parse id_token")
+	}
+
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return Claims{}, ErrInvalidAZP
+	}
+
+	if claims.Nonce != nonce {
+		return Claims{}, ErrNonceMismatch
+	}
+
+	return claims, nil
+}
+
+// discover - получение метаданных провайдера, успешный ответ кешируется
+func (p *Provider) discover(ctx context.Context) (Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return *p.metadata, nil
+	}
+
+	var metadata Metadata
+	err := p.getJSON(ctx, strings.TrimSuffix(p.cfg.Issuer, "/")+discoveryPath, &metadata)
+	if err != nil {
+		return Metadata{}, errors.Wrap(err, "discovery")
+	}
+
+	if metadata.Issuer != p.cfg.Issuer {
+		return Metadata{}, ErrIssuerMismatch
+	}
+
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return Metadata{}, errors.New("discovery: incomplete provider metadata")
+	}
+
+	p.metadata = &metadata
+	return metadata, nil
+}
+
+// publicKey - поиск ключа проверки подписи, при незнакомом kid набор ключей перезапрашивается
+func (p *Provider) publicKey(ctx context.Context, kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, ok := p.findKey(kid)
+	if !ok && time.Since(p.keysFetched) >= keysRefreshInterval {
+		var set jwks.Set
+		err := p.getJSON(ctx, p.metadata.JWKSURI, &set)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch jwks")
+		}
+		p.keys = set
+		p.keysFetched = time.Now()
+
+		key, ok = p.findKey(kid)
+	}
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	return key.PublicKey()
+}
+
+// findKey - поиск ключа по kid, без kid подходит единственный ключ набора
+func (p *Provider) findKey(kid string) (jwks.Key, bool) {
+	if kid == "" {
+		if len(p.keys.Keys) == 1 {
+			return p.keys.Keys[0], true
+		}
+		return jwks.Key{}, false
+	}
+
+	return p.keys.Find(kid)
+}
+
+func (p *Provider) getJSON(ctx context.Context, target string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status [%d]", resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
@@ -0,0 +1,248 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"github.com/GermanBogatov/auth-service/pkg/jwks"
+	"github.com/GermanBogatov/auth-service/pkg/pkce"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID     = "auth-service"
+	testClientSecret = "secret"
+	testRedirectURL  = "http://localhost:8080/public/v1/auth/sso/test/callback"
+	testCode         = "code"
+	testVerifier     = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+// testServer - локальный OpenID-провайдер для тестов
+type testServer struct {
+	*httptest.Server
+	t        *testing.T
+	key      crypto.Signer
+	kid      string
+	issuer   string
+	claims   func(issuer string) jwt.MapClaims
+	jwksHits int
+}
+
+func newTestServer(t *testing.T) *testServer {
+	key, err := jwks.GenerateKey(jwks.AlgRS256)
+	require.NoError(t, err)
+
+	s := &testServer{t: t, key: key, kid: "key-1"}
+	s.claims = func(issuer string) jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":            issuer,
+			"sub":            "external-subject",
+			"aud":            testClientID,
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(time.Minute).Unix(),
+			"nonce":          "nonce",
+			"email":          "user@example.com",
+			"email_verified": true,
+			"given_name":     "Иван",
+			"family_name":    "Иванов",
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(discoveryPath, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, Metadata{
+			Issuer:                s.issuer,
+			AuthorizationEndpoint: s.URL + "/authorize",
+			TokenEndpoint:         s.URL + "/token",
+			JWKSURI:               s.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		s.jwksHits++
+		key, err := jwks.NewKey(s.kid, jwks.AlgRS256, s.key.Public())
+		require.NoError(t, err)
+		writeJSON(w, jwks.Set{Keys: []jwks.Key{key}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok || clientID != testClientID || clientSecret != testClientSecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			writeJSON(w, tokenError{Error: "invalid_client"})
+			return
+		}
+		if r.PostFormValue("code") != testCode || r.PostFormValue("redirect_uri") != testRedirectURL ||
+			r.PostFormValue("code_verifier") != testVerifier {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, tokenError{Error: "invalid_grant", ErrorDescription: "bad code"})
+			return
+		}
+		writeJSON(w, Token{AccessToken: "access", TokenType: "Bearer", IDToken: s.sign(s.claims(s.issuer))})
+	})
+
+	s.Server = httptest.NewServer(mux)
+	s.issuer = s.URL
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *testServer) sign(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.kid
+	signed, err := token.SignedString(s.key)
+	require.NoError(s.t, err)
+	return signed
+}
+
+func (s *testServer) provider() *Provider {
+	return NewProvider(Config{
+		Issuer:       s.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+	}, s.Client())
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	server := newTestServer(t)
+
+	target, err := server.provider().AuthCodeURL(context.Background(), "state", "nonce", pkce.Challenge(testVerifier))
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(target)
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+
+	params := parsed.Query()
+	assert.Equal(t, "code", params.Get("response_type"))
+	assert.Equal(t, testClientID, params.Get("client_id"))
+	assert.Equal(t, testRedirectURL, params.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", params.Get("scope"))
+	assert.Equal(t, "state", params.Get("state"))
+	assert.Equal(t, "nonce", params.Get("nonce"))
+	assert.Equal(t, pkce.Challenge(testVerifier), params.Get("code_challenge"))
+	assert.Equal(t, "S256", params.Get("code_challenge_method"))
+}
+
+func TestExchangeAndVerify(t *testing.T) {
+	server := newTestServer(t)
+	provider := server.provider()
+	ctx := context.Background()
+
+	token, err := provider.Exchange(ctx, testCode, testVerifier)
+	require.NoError(t, err)
+
+	claims, err := provider.VerifyIDToken(ctx, token.IDToken, "nonce")
+	require.NoError(t, err)
+	assert.Equal(t, "external-subject", claims.Subject)
+	assert.Equal(t, "user@example.com", claims.Email)
+	assert.True(t, claims.EmailVerified)
+	assert.Equal(t, "Иван", claims.GivenName)
+	assert.Equal(t, "Иванов", claims.FamilyName)
+}
+
+func TestExchangeError(t *testing.T) {
+	server := newTestServer(t)
+
+	_, err := server.provider().Exchange(context.Background(), "wrong", testVerifier)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid_grant")
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(claims jwt.MapClaims)
+		nonce  string
+		want   error
+	}{
+		{
+			name:   "nonce mismatch",
+			modify: func(jwt.MapClaims) {},
+			nonce:  "other",
+			want:   ErrNonceMismatch,
+		},
+		{
+			name:   "wrong audience",
+			modify: func(claims jwt.MapClaims) { claims["aud"] = "other-client" },
+			nonce:  "nonce",
+			want:   jwt.ErrTokenInvalidAudience,
+		},
+		{
+			name:   "wrong issuer",
+			modify: func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+			nonce:  "nonce",
+			want:   jwt.ErrTokenInvalidIssuer,
+		},
+		{
+			name:   "expired",
+			modify: func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+			nonce:  "nonce",
+			want:   jwt.ErrTokenExpired,
+		},
+		{
+			name:   "azp mismatch",
+			modify: func(claims jwt.MapClaims) { claims["aud"] = []string{testClientID, "other-client"} },
+			nonce:  "nonce",
+			want:   ErrInvalidAZP,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+			claims := server.claims(server.issuer)
+			tt.modify(claims)
+
+			_, err := server.provider().VerifyIDToken(context.Background(), server.sign(claims), tt.nonce)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestVerifyIDTokenForeignKey(t *testing.T) {
+	server := newTestServer(t)
+	provider := server.provider()
+	ctx := context.Background()
+
+	_, err := provider.VerifyIDToken(ctx, server.sign(server.claims(server.issuer)), "nonce")
+	require.NoError(t, err)
+
+	// подпись чужим ключом с тем же kid не проходит проверку
+	foreign, err := jwks.GenerateKey(jwks.AlgRS256)
+	require.NoError(t, err)
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, server.claims(server.issuer))
+	token.Header["kid"] = server.kid
+	signed, err := token.SignedString(foreign)
+	require.NoError(t, err)
+
+	_, err = provider.VerifyIDToken(ctx, signed, "nonce")
+	assert.ErrorIs(t, err, jwt.ErrTokenSignatureInvalid)
+
+	// незнакомый kid сразу после загрузки ключей не приводит к повторному запросу JWKS
+	server.kid = "key-2"
+	_, err = provider.VerifyIDToken(ctx, server.sign(server.claims(server.issuer)), "nonce")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, 1, server.jwksHits)
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	server := newTestServer(t)
+	server.issuer = "https://evil.example.com"
+
+	_, err := server.provider().AuthCodeURL(context.Background(), "state", "nonce", pkce.Challenge(testVerifier))
+	assert.ErrorIs(t, err, ErrIssuerMismatch)
+}
@@ -0,0 +1,54 @@
+// Package pkce - проверка Proof Key for Code Exchange (RFC 7636)
+package pkce
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+)
+
+const (
+	MethodS256 = "S256"
+
+	minVerifierLength = 43
+	maxVerifierLength = 128
+)
+
+// ValidVerifier - соответствие code_verifier грамматике RFC 7636, раздел 4.1
+func ValidVerifier(verifier string) bool {
+	if len(verifier) < minVerifierLength || len(verifier) > maxVerifierLength {
+		return false
+	}
+
+	for _, c := range verifier {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidChallenge - code_challenge для S256 это base64url без паддинга от sha256, то есть ровно 43 символа
+func ValidChallenge(challenge string) bool {
+	decoded, err := base64.RawURLEncoding.DecodeString(challenge)
+	return err == nil && len(decoded) == sha256.Size
+}
+
+// Challenge - вычисление code_challenge для метода S256
+func Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Verify - проверка code_verifier против сохраненного code_challenge методом S256
+func Verify(verifier, challenge string) bool {
+	if !ValidVerifier(verifier) {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(Challenge(verifier)), []byte(challenge)) == 1
+}
@@ -0,0 +1,63 @@
+package pkce
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	// пример из RFC 7636, приложение B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{
+			name:      "rfc example",
+			verifier:  verifier,
+			challenge: challenge,
+			want:      true,
+		},
+		{
+			name:      "wrong verifier",
+			verifier:  strings.Replace(verifier, "d", "e", 1),
+			challenge: challenge,
+			want:      false,
+		},
+		{
+			name:      "plain verifier as challenge",
+			verifier:  verifier,
+			challenge: verifier,
+			want:      false,
+		},
+		{
+			name:      "too short verifier",
+			verifier:  "abc",
+			challenge: Challenge("abc"),
+			want:      false,
+		},
+		{
+			name:      "invalid characters",
+			verifier:  verifier[:42] + "+",
+			challenge: Challenge(verifier[:42] + "+"),
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Verify(tt.verifier, tt.challenge))
+		})
+	}
+}
+
+func TestValidChallenge(t *testing.T) {
+	assert.True(t, ValidChallenge("E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"))
+	assert.False(t, ValidChallenge("E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-c"))
+	assert.False(t, ValidChallenge("not a challenge"))
+}
@@ -0,0 +1,276 @@
+// Package pwhash - хэширование паролей argon2id и bcrypt. Параметры хранятся в самом хэше (формат PHC для argon2id,
+// modular crypt для bcrypt), поэтому смена параметров не ломает проверку уже сохраненных хэшей.
+// Пароль дополнительно смешивается с перцем - секретом вне бд. Версия перца хранится в параметре keyid: для argon2id
+// в параметрах PHC, для bcrypt в префиксе $bcrypt-hmac$keyid=<версия> перед хэшем modular crypt
+package pwhash
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strconv"
+	"strings"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+
+	saltLength = 16
+	keyLength  = 32
+
+	// prefixBcryptPepper - префикс хэша bcrypt с перцем, сам формат bcrypt не позволяет сохранить версию перца
+	prefixBcryptPepper = "$bcrypt-hmac$"
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported password hash algorithm")
+	ErrInvalidHash          = errors.New("invalid password hash")
+	ErrUnknownPepper        = errors.New("unknown password pepper version")
+)
+
+// Params - алгоритм и его параметры для новых хэшей
+type Params struct {
+	Algorithm string
+	// Memory - память argon2id в KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	BcryptCost  int
+	// Peppers - версии перца, старые версии нужны для проверки хэшей до ротации
+	Peppers map[int][]byte
+	// PepperVersion - версия перца для новых хэшей, 0 - без перца
+	PepperVersion int
+}
+
+// Hasher - хэширование и проверка паролей
+type Hasher struct {
+	params Params
+}
+
+func NewHasher(params Params) *Hasher {
+	return &Hasher{
+		params: params,
+	}
+}
+
+// Hash - хэш пароля с уникальной солью текущим алгоритмом
+func (h *Hasher) Hash(password string) (string, error) {
+	switch h.params.Algorithm {
+	case AlgorithmArgon2id:
+		salt := make([]byte, saltLength)
+		_, err := rand.Read(salt)
+		if err != nil {
+			return "", errors.Wrap(err, "generate salt")
+		}
+
+		input, err := h.pepper(password, h.params.PepperVersion)
+		if err != nil {
+			return "", err
+		}
+
+		params := fmt.Sprintf("m=%d,t=%d,p=%d", h.params.Memory, h.params.Iterations, h.params.Parallelism)
+		if h.params.PepperVersion != 0 {
+			params += ",keyid=" + strconv.Itoa(h.params.PepperVersion)
+		}
+
+		key := argon2.IDKey(input, salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, keyLength)
+		return fmt.Sprintf("$argon2id$v=%d$%s$%s$%s", argon2.Version, params,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	case AlgorithmBcrypt:
+		input, err := h.bcryptInput(password, h.params.PepperVersion)
+		if err != nil {
+			return "", err
+		}
+
+		hash, err := bcrypt.GenerateFromPassword(input, h.params.BcryptCost)
+		if err != nil {
+			return "", errors.Wrap(err, "bcrypt")
+		}
+		if h.params.PepperVersion == 0 {
+			return string(hash), nil
+		}
+		return prefixBcryptPepper + "keyid=" + strconv.Itoa(h.params.PepperVersion) + string(hash), nil
+	default:
+		return "", ErrUnsupportedAlgorithm
+	}
+}
+
+// Verify - проверка пароля по хэшу любого поддерживаемого алгоритма с параметрами из самого хэша
+func (h *Hasher) Verify(password, encoded string) (bool, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		hash, err := parseArgon2id(encoded)
+		if err != nil {
+			return false, err
+		}
+
+		input, err := h.pepper(password, hash.pepperVersion)
+		if err != nil {
+			return false, err
+		}
+
+		key := argon2.IDKey(input, hash.salt, hash.iterations, hash.memory, hash.parallelism, uint32(len(hash.key)))
+		return subtle.ConstantTimeCompare(key, hash.key) == 1, nil
+	case isBcrypt(encoded) || strings.HasPrefix(encoded, prefixBcryptPepper):
+		hash, pepperVersion, err := parseBcrypt(encoded)
+		if err != nil {
+			return false, err
+		}
+
+		input, err := h.bcryptInput(password, pepperVersion)
+		if err != nil {
+			return false, err
+		}
+
+		err = bcrypt.CompareHashAndPassword(hash, input)
+		if err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, nil
+			}
+			return false, errors.Wrap(ErrInvalidHash, err.Error())
+		}
+		return true, nil
+	default:
+		return false, ErrUnsupportedAlgorithm
+	}
+}
+
+// NeedsRehash - хэш получен другим алгоритмом или с другими параметрами и должен быть пересчитан при входе
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	switch h.params.Algorithm {
+	case AlgorithmArgon2id:
+		hash, err := parseArgon2id(encoded)
+		return err != nil || hash.memory != h.params.Memory || hash.iterations != h.params.Iterations ||
+			hash.parallelism != h.params.Parallelism || len(hash.key) != keyLength ||
+			hash.pepperVersion != h.params.PepperVersion
+	case AlgorithmBcrypt:
+		hash, pepperVersion, err := parseBcrypt(encoded)
+		if err != nil {
+			return true
+		}
+		cost, err := bcrypt.Cost(hash)
+		return err != nil || cost != h.params.BcryptCost || pepperVersion != h.params.PepperVersion
+	default:
+		return true
+	}
+}
+
+// pepper - пароль, смешанный с перцем нужной версии через HMAC-SHA256, версия 0 - пароль как есть
+func (h *Hasher) pepper(password string, version int) ([]byte, error) {
+	if version == 0 {
+		return []byte(password), nil
+	}
+
+	pepper, ok := h.params.Peppers[version]
+	if !ok {
+		return nil, errors.Wrapf(ErrUnknownPepper, "version %d", version)
+	}
+
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write([]byte(password))
+	return mac.Sum(nil), nil
+}
+
+// bcryptInput - вход bcrypt: пароль как есть без перца или HMAC с перцем в base64. Base64 убирает нулевые байты
+// дайджеста и укладывается в 72 байта, которые учитывает bcrypt
+func (h *Hasher) bcryptInput(password string, version int) ([]byte, error) {
+	input, err := h.pepper(password, version)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		return input, nil
+	}
+
+	return []byte(base64.StdEncoding.EncodeToString(input)), nil
+}
+
+// IsSupported - хэш в одном из форматов пакета, остальные форматы вызывающий проверяет сам
+func IsSupported(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$") || isBcrypt(encoded) || strings.HasPrefix(encoded, prefixBcryptPepper)
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// parseBcrypt - разбор хэша bcrypt без перца или формата $bcrypt-hmac$keyid=<версия перца><хэш bcrypt>
+func parseBcrypt(encoded string) ([]byte, int, error) {
+	if isBcrypt(encoded) {
+		return []byte(encoded), 0, nil
+	}
+
+	params, ok := strings.CutPrefix(encoded, prefixBcryptPepper+"keyid=")
+	if !ok {
+		return nil, 0, ErrInvalidHash
+	}
+	pepperVersion, hash, ok := strings.Cut(params, "$")
+	if !ok || !isBcrypt("$"+hash) {
+		return nil, 0, errors.Wrap(ErrInvalidHash, "bcrypt")
+	}
+
+	version, err := strconv.Atoi(pepperVersion)
+	if err != nil || version <= 0 {
+		return nil, 0, errors.Wrap(ErrInvalidHash, "keyid")
+	}
+
+	return []byte("$" + hash), version, nil
+}
+
+// argon2idHash - разобранный хэш argon2id
+type argon2idHash struct {
+	salt        []byte
+	key         []byte
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	// pepperVersion - версия перца, 0 - хэш без перца
+	pepperVersion int
+}
+
+// parseArgon2id - разбор хэша формата $argon2id$v=19$m=<KiB>,t=<итерации>,p=<потоки>[,keyid=<версия перца>]$<соль>$<ключ>
+func parseArgon2id(encoded string) (argon2idHash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return argon2idHash{}, ErrInvalidHash
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return argon2idHash{}, errors.Wrap(ErrInvalidHash, "version")
+	}
+
+	var hash argon2idHash
+	params, pepperVersion, hasPepper := strings.Cut(parts[3], ",keyid=")
+	_, err = fmt.Sscanf(params, "m=%d,t=%d,p=%d", &hash.memory, &hash.iterations, &hash.parallelism)
+	if err != nil || hash.iterations == 0 || hash.parallelism == 0 ||
+		params != fmt.Sprintf("m=%d,t=%d,p=%d", hash.memory, hash.iterations, hash.parallelism) {
+		return argon2idHash{}, errors.Wrap(ErrInvalidHash, "params")
+	}
+	if hasPepper {
+		hash.pepperVersion, err = strconv.Atoi(pepperVersion)
+		if err != nil || hash.pepperVersion <= 0 {
+			return argon2idHash{}, errors.Wrap(ErrInvalidHash, "keyid")
+		}
+	}
+
+	hash.salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return argon2idHash{}, errors.Wrap(ErrInvalidHash, "salt")
+	}
+
+	hash.key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(hash.key) == 0 {
+		return argon2idHash{}, errors.Wrap(ErrInvalidHash, "key")
+	}
+
+	return hash, nil
+}
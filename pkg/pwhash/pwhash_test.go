@@ -0,0 +1,227 @@
+package pwhash
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+// параметры ниже рабочих, чтобы тесты не тратили время и память
+var (
+	argon2idParams = Params{Algorithm: AlgorithmArgon2id, Memory: 64, Iterations: 1, Parallelism: 1}
+	bcryptParams   = Params{Algorithm: AlgorithmBcrypt, BcryptCost: 4}
+)
+
+func TestHashAndVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		prefix string
+	}{
+		{
+			name:   "argon2id",
+			params: argon2idParams,
+			prefix: "$argon2id$v=19$m=64,t=1,p=1$",
+		},
+		{
+			name:   "bcrypt",
+			params: bcryptParams,
+			prefix: "$2a$04$",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher := NewHasher(tt.params)
+
+			hash, err := hasher.Hash("correct horse")
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(hash, tt.prefix), hash)
+			assert.True(t, IsSupported(hash))
+			assert.False(t, hasher.NeedsRehash(hash))
+
+			ok, err := hasher.Verify("correct horse", hash)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = hasher.Verify("battery staple", hash)
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			// соль уникальна для каждого хэша
+			other, err := hasher.Hash("correct horse")
+			require.NoError(t, err)
+			assert.NotEqual(t, hash, other)
+		})
+	}
+}
+
+func TestVerifyUsesParamsFromHash(t *testing.T) {
+	hash, err := NewHasher(argon2idParams).Hash("correct horse")
+	require.NoError(t, err)
+
+	hasher := NewHasher(Params{Algorithm: AlgorithmArgon2id, Memory: 128, Iterations: 2, Parallelism: 1})
+	ok, err := hasher.Verify("correct horse", hash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, hasher.NeedsRehash(hash))
+
+	// хэш другого алгоритма проверяется, но подлежит пересчету
+	bcryptHasher := NewHasher(bcryptParams)
+	ok, err = bcryptHasher.Verify("correct horse", hash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, bcryptHasher.NeedsRehash(hash))
+}
+
+func TestPepperRotation(t *testing.T) {
+	tests := []struct {
+		name   string
+		params Params
+		prefix string
+		// unpeppered - тот же хэш без сведений о перце
+		unpeppered func(hash string) string
+	}{
+		{
+			name:   "argon2id",
+			params: argon2idParams,
+			prefix: "$argon2id$v=19$m=64,t=1,p=1,keyid=1$",
+			unpeppered: func(hash string) string {
+				return strings.Replace(hash, ",keyid=1", "", 1)
+			},
+		},
+		{
+			name:   "bcrypt",
+			params: bcryptParams,
+			prefix: "$bcrypt-hmac$keyid=1$2a$04$",
+			unpeppered: func(hash string) string {
+				return strings.TrimPrefix(hash, "$bcrypt-hmac$keyid=1")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := tt.params
+			first.Peppers = map[int][]byte{1: []byte("first pepper")}
+			first.PepperVersion = 1
+
+			hash, err := NewHasher(tt.params).Hash("correct horse")
+			require.NoError(t, err)
+
+			// хэш без перца проверяется и пересчитывается с текущей версией
+			hasher := NewHasher(first)
+			ok, err := hasher.Verify("correct horse", hash)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.True(t, hasher.NeedsRehash(hash))
+
+			hash, err = hasher.Hash("correct horse")
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(hash, tt.prefix), hash)
+			assert.True(t, IsSupported(hash))
+			assert.False(t, hasher.NeedsRehash(hash))
+
+			// после ротации старая версия продолжает проверяться
+			second := first
+			second.Peppers = map[int][]byte{1: []byte("first pepper"), 2: []byte("second pepper")}
+			second.PepperVersion = 2
+
+			hasher = NewHasher(second)
+			ok, err = hasher.Verify("correct horse", hash)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.True(t, hasher.NeedsRehash(hash))
+
+			ok, err = hasher.Verify("battery staple", hash)
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			// перец - часть хэша: без него тот же пароль не подходит
+			ok, err = hasher.Verify("correct horse", tt.unpeppered(hash))
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			// удаленная версия перца - ошибка конфигурации, а не неверный пароль
+			_, err = NewHasher(tt.params).Verify("correct horse", hash)
+			assert.ErrorIs(t, err, ErrUnknownPepper)
+		})
+	}
+}
+
+func TestBcryptPepperLongPassword(t *testing.T) {
+	params := bcryptParams
+	params.Peppers = map[int][]byte{1: []byte("first pepper")}
+	params.PepperVersion = 1
+	hasher := NewHasher(params)
+
+	// с перцем bcrypt получает дайджест пароля и различает пароли, совпадающие в первых 72 байтах
+	password := strings.Repeat("a", 72)
+	hash, err := hasher.Hash(password + "1")
+	require.NoError(t, err)
+
+	ok, err := hasher.Verify(password+"2", hash)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestVerifyInvalidHash(t *testing.T) {
+	hasher := NewHasher(argon2idParams)
+
+	tests := []struct {
+		name    string
+		encoded string
+		wantErr error
+	}{
+		{
+			name:    "legacy hex",
+			encoded: "7361643334326d736c6664",
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+		{
+			name:    "wrong version",
+			encoded: "$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5",
+			wantErr: ErrInvalidHash,
+		},
+		{
+			name:    "missing params",
+			encoded: "$argon2id$v=19$m=64$c2FsdA$a2V5",
+			wantErr: ErrInvalidHash,
+		},
+		{
+			name:    "broken keyid",
+			encoded: "$argon2id$v=19$m=64,t=1,p=1,keyid=x$c2FsdA$a2V5",
+			wantErr: ErrInvalidHash,
+		},
+		{
+			name:    "bcrypt without keyid",
+			encoded: "$bcrypt-hmac$$2a$04$abcdefghijklmnopqrstuu",
+			wantErr: ErrInvalidHash,
+		},
+		{
+			name:    "bcrypt broken keyid",
+			encoded: "$bcrypt-hmac$keyid=0$2a$04$abcdefghijklmnopqrstuu",
+			wantErr: ErrInvalidHash,
+		},
+		{
+			name:    "bcrypt broken hash",
+			encoded: "$bcrypt-hmac$keyid=1$argon2id$v=19",
+			wantErr: ErrInvalidHash,
+		},
+		{
+			name:    "broken salt",
+			encoded: "$argon2id$v=19$m=64,t=1,p=1$***$a2V5",
+			wantErr: ErrInvalidHash,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := hasher.Verify("correct horse", tt.encoded)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.False(t, ok)
+			assert.True(t, hasher.NeedsRehash(tt.encoded))
+		})
+	}
+}
@@ -0,0 +1,241 @@
+// Package pwpolicy - проверка паролей по настраиваемым правилам и по списку утекших паролей. Нарушения возвращаются
+// кодами правил, чтобы клиент мог показать пользователю все претензии к паролю сразу
+package pwpolicy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1" //nolint:gosec // sha1 - формат списков утекших паролей, не защита данных
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// коды нарушений
+const (
+	ViolationTooShort           = "PASSWORD_TOO_SHORT"
+	ViolationTooLong            = "PASSWORD_TOO_LONG"
+	ViolationMissingLower       = "PASSWORD_MISSING_LOWERCASE"
+	ViolationMissingUpper       = "PASSWORD_MISSING_UPPERCASE"
+	ViolationMissingDigit       = "PASSWORD_MISSING_DIGIT"
+	ViolationMissingSymbol      = "PASSWORD_MISSING_SYMBOL"
+	ViolationForbiddenSubstring = "PASSWORD_CONTAINS_FORBIDDEN_SUBSTRING"
+	ViolationPersonalData       = "PASSWORD_CONTAINS_PERSONAL_DATA"
+	ViolationBreached           = "PASSWORD_BREACHED"
+	ViolationReused             = "PASSWORD_REUSED"
+)
+
+// классы символов
+const (
+	ClassLower  = "lower"
+	ClassUpper  = "upper"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+)
+
+// minPersonalLength - короткие части персональных данных (имя из двух букв) не запрещаются, иначе под запрет
+// попадут обычные сочетания букв
+const minPersonalLength = 3
+
+var ErrUnsortedCorpus = errors.New("breached password corpus is not sorted")
+
+// Rules - правила паролей
+type Rules struct {
+	// MinLength - минимальная длина в символах
+	MinLength int
+	// MaxLength - максимальная длина в байтах utf-8, ограничивает стоимость хэширования
+	MaxLength int
+	// RequiredClasses - обязательные классы символов: lower, upper, digit, symbol
+	RequiredClasses []string
+	// ForbiddenSubstrings - подстроки, запрещенные без учета регистра (название компании, сервиса и т.п.)
+	ForbiddenSubstrings []string
+}
+
+// Policy - проверка пароля по правилам и списку утекших паролей
+type Policy struct {
+	rules    Rules
+	breached *Corpus
+}
+
+// NewPolicy - политика паролей, breached может быть nil, если список утекших паролей не загружен
+func NewPolicy(rules Rules, breached *Corpus) *Policy {
+	forbidden := make([]string, 0, len(rules.ForbiddenSubstrings))
+	for _, substring := range rules.ForbiddenSubstrings {
+		if substring = strings.ToLower(strings.TrimSpace(substring)); substring != "" {
+			forbidden = append(forbidden, substring)
+		}
+	}
+	rules.ForbiddenSubstrings = forbidden
+
+	return &Policy{
+		rules:    rules,
+		breached: breached,
+	}
+}
+
+// Check - коды всех нарушенных правил, пустой результат - пароль допустим. Personal - персональные данные
+// пользователя (email, имя, фамилия), которые не должны входить в пароль
+func (p *Policy) Check(password string, personal ...string) []string {
+	var violations []string
+
+	if utf8.RuneCountInString(password) < p.rules.MinLength {
+		violations = append(violations, ViolationTooShort)
+	}
+	if p.rules.MaxLength > 0 && len(password) > p.rules.MaxLength {
+		// слишком длинный пароль дальше не проверяется: проверки не должны стоить дороже, чем хэширование
+		return append(violations, ViolationTooLong)
+	}
+
+	violations = append(violations, p.checkClasses(password)...)
+
+	lower := strings.ToLower(password)
+	for _, substring := range p.rules.ForbiddenSubstrings {
+		if strings.Contains(lower, substring) {
+			violations = append(violations, ViolationForbiddenSubstring)
+			break
+		}
+	}
+
+	for _, value := range personalParts(personal) {
+		if strings.Contains(lower, value) {
+			violations = append(violations, ViolationPersonalData)
+			break
+		}
+	}
+
+	if p.breached != nil && p.breached.Contains(password) {
+		violations = append(violations, ViolationBreached)
+	}
+
+	return violations
+}
+
+// checkClasses - нарушения обязательных классов символов
+func (p *Policy) checkClasses(password string) []string {
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	var violations []string
+	for _, class := range p.rules.RequiredClasses {
+		switch {
+		case class == ClassLower && !hasLower:
+			violations = append(violations, ViolationMissingLower)
+		case class == ClassUpper && !hasUpper:
+			violations = append(violations, ViolationMissingUpper)
+		case class == ClassDigit && !hasDigit:
+			violations = append(violations, ViolationMissingDigit)
+		case class == ClassSymbol && !hasSymbol:
+			violations = append(violations, ViolationMissingSymbol)
+		}
+	}
+
+	return violations
+}
+
+// personalParts - персональные данные в нижнем регистре, email дополнительно разбивается на имя ящика и домен
+func personalParts(personal []string) []string {
+	parts := make([]string, 0, len(personal)*2)
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+
+		if local, domain, ok := strings.Cut(value, "@"); ok {
+			parts = append(parts, local)
+			// домен без зоны: в пароле чаще встречается "example", чем "example.com"
+			domain, _, _ = strings.Cut(domain, ".")
+			parts = append(parts, domain)
+		}
+		parts = append(parts, value)
+	}
+
+	result := parts[:0]
+	for _, part := range parts {
+		if utf8.RuneCountInString(part) >= minPersonalLength {
+			result = append(result, part)
+		}
+	}
+
+	return result
+}
+
+// Corpus - список sha1 утекших паролей, отсортированный для двоичного поиска
+type Corpus struct {
+	// hashes - sha1 подряд по sha1.Size байт
+	hashes []byte
+}
+
+// LoadCorpus - загрузка списка из файла со строками <sha1 в hex>[:<число утечек>], отсортированными по хэшу,
+// как в выгрузке Have I Been Pwned. Пустые строки пропускаются, неотсортированный файл отклоняется
+func LoadCorpus(path string) (*Corpus, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open corpus")
+	}
+	defer file.Close()
+
+	var (
+		hashes []byte
+		hash   = make([]byte, sha1.Size)
+		line   int
+	)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		text, _, _ = strings.Cut(text, ":")
+		if len(text) != hex.EncodedLen(sha1.Size) {
+			return nil, errors.Errorf("corpus line %d: invalid sha1", line)
+		}
+		_, err = hex.Decode(hash, []byte(text))
+		if err != nil {
+			return nil, errors.Wrapf(err, "corpus line %d", line)
+		}
+
+		if len(hashes) != 0 && bytes.Compare(hashes[len(hashes)-sha1.Size:], hash) >= 0 {
+			return nil, errors.Wrapf(ErrUnsortedCorpus, "line %d", line)
+		}
+		hashes = append(hashes, hash...)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, errors.Wrap(err, "read corpus")
+	}
+
+	return &Corpus{
+		hashes: hashes,
+	}, nil
+}
+
+// Len - число паролей в списке
+func (c *Corpus) Len() int {
+	return len(c.hashes) / sha1.Size
+}
+
+// Contains - пароль есть в списке утекших
+func (c *Corpus) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	i := sort.Search(c.Len(), func(i int) bool {
+		return bytes.Compare(c.hashes[i*sha1.Size:(i+1)*sha1.Size], sum[:]) >= 0
+	})
+
+	return i < c.Len() && bytes.Equal(c.hashes[i*sha1.Size:(i+1)*sha1.Size], sum[:])
+}
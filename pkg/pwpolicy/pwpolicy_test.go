@@ -0,0 +1,118 @@
+package pwpolicy
+
+import (
+	"crypto/sha1" //nolint:gosec
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	policy := NewPolicy(Rules{
+		MinLength:           8,
+		MaxLength:           32,
+		RequiredClasses:     []string{ClassLower, ClassUpper, ClassDigit, ClassSymbol},
+		ForbiddenSubstrings: []string{" Acme "},
+	}, nil)
+
+	tests := []struct {
+		name     string
+		password string
+		personal []string
+		want     []string
+	}{
+		{
+			name:     "valid",
+			password: "Correct-Horse-7",
+			personal: []string{"ivan.petrov@example.com", "Иван", "Петров"},
+		},
+		{
+			name:     "too short and missing classes",
+			password: "1",
+			want:     []string{ViolationTooShort, ViolationMissingLower, ViolationMissingUpper, ViolationMissingSymbol},
+		},
+		{
+			name:     "too long",
+			password: strings.Repeat("Aa1!", 9),
+			want:     []string{ViolationTooLong},
+		},
+		{
+			name:     "cyrillic classes",
+			password: "Пароль-надежный-7",
+		},
+		{
+			name:     "forbidden substring",
+			password: "ACME-rocks-2024",
+			want:     []string{ViolationForbiddenSubstring},
+		},
+		{
+			name:     "email local part",
+			password: "Ivan.Petrov-2024",
+			personal: []string{"ivan.petrov@example.com"},
+			want:     []string{ViolationPersonalData},
+		},
+		{
+			name:     "email domain",
+			password: "Example-2024!",
+			personal: []string{"ivan.petrov@example.com"},
+			want:     []string{ViolationPersonalData},
+		},
+		{
+			name:     "surname",
+			password: "петров-Лучший-1",
+			personal: []string{"Петров"},
+			want:     []string{ViolationPersonalData},
+		},
+		{
+			name:     "short name is ignored",
+			password: "Li-Correct-Horse-7",
+			personal: []string{"Li"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.Check(tt.password, tt.personal...))
+		})
+	}
+}
+
+func TestCorpus(t *testing.T) {
+	breached := []string{"password", "123456", "qwerty", "Correct-Horse-7"}
+	lines := make([]string, 0, len(breached))
+	for i, password := range breached {
+		sum := sha1.Sum([]byte(password)) //nolint:gosec
+		lines = append(lines, strings.ToUpper(hex.EncodeToString(sum[:]))+":"+strings.Repeat("1", i+1))
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n\n"), 0o600))
+
+	corpus, err := LoadCorpus(path)
+	require.NoError(t, err)
+	assert.Equal(t, len(breached), corpus.Len())
+
+	for _, password := range breached {
+		assert.True(t, corpus.Contains(password), password)
+	}
+	assert.False(t, corpus.Contains("Battery-Staple-9"))
+
+	policy := NewPolicy(Rules{MinLength: 8}, corpus)
+	assert.Equal(t, []string{ViolationBreached}, policy.Check("Correct-Horse-7"))
+
+	// неотсортированный список сломал бы двоичный поиск
+	lines[0], lines[1] = lines[1], lines[0]
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600))
+	_, err = LoadCorpus(path)
+	assert.ErrorIs(t, err, ErrUnsortedCorpus)
+
+	require.NoError(t, os.WriteFile(path, []byte("not-a-hash\n"), 0o600))
+	_, err = LoadCorpus(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,195 @@
+// Package secrets - загрузка секретов сервиса из переменных окружения, смонтированных файлов (секреты Kubernetes)
+// или Vault-совместимого HTTP API, чтобы секреты не попадали в код и образ
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderEnv   = "env"
+	ProviderFile  = "file"
+	ProviderVault = "vault"
+
+	// maxSecretSize - ограничение размера файла секрета и ответа Vault
+	maxSecretSize = 1 << 20
+)
+
+var (
+	ErrNotFound    = errors.New("secret not found")
+	ErrInvalidName = errors.New("invalid secret name")
+)
+
+// Provider - источник секретов по имени. Отсутствующий секрет - ErrNotFound, решение о его обязательности
+// принимает вызывающий
+type Provider interface {
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// Static - секреты, уже прочитанные вместе с конфигурацией из окружения или файла конфигурации.
+// Пустое значение считается отсутствующим
+type Static map[string]string
+
+func (s Static) Get(_ context.Context, name string) (string, error) {
+	value := s[name]
+	if value == "" {
+		return "", errors.Wrap(ErrNotFound, name)
+	}
+	return value, nil
+}
+
+// File - секреты в отдельных файлах каталога, имя файла совпадает с именем секрета
+type File struct {
+	dir string
+}
+
+func NewFile(dir string) File {
+	return File{
+		dir: dir,
+	}
+}
+
+func (f File) Get(_ context.Context, name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", errors.Wrap(ErrInvalidName, name)
+	}
+
+	file, err := os.Open(filepath.Join(f.dir, name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", errors.Wrap(ErrNotFound, name)
+		}
+		return "", errors.Wrapf(err, "open secret [%s]", name)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSecretSize))
+	if err != nil {
+		return "", errors.Wrapf(err, "read secret [%s]", name)
+	}
+
+	// редакторы и kubectl create secret --from-file оставляют перевод строки в конце файла
+	value := strings.TrimRight(string(data), "\r\n")
+	if value == "" {
+		return "", errors.Wrap(ErrNotFound, name)
+	}
+
+	return value, nil
+}
+
+// Vault - секреты в одной записи Vault (KV v1 или v2), имя секрета - ключ внутри записи
+type Vault struct {
+	addr   string
+	token  string
+	path   string
+	client *http.Client
+}
+
+// NewVault - клиент Vault по адресу сервера, токену и пути записи, например secret/data/auth-service для KV v2
+func NewVault(addr, token, path string, timeout time.Duration) *Vault {
+	return &Vault{
+		addr:  strings.TrimSuffix(addr, "/"),
+		token: token,
+		path:  strings.Trim(path, "/"),
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// vaultResponse - ответ на чтение записи: в KV v1 ключи лежат в data, в KV v2 - в data.data
+type vaultResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []string        `json:"errors"`
+}
+
+func (v *Vault) Get(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		return "", ErrInvalidName
+	}
+
+	data, err := v.read(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := data[name]
+	if !ok || value == "" {
+		return "", errors.Wrap(ErrNotFound, name)
+	}
+
+	return value, nil
+}
+
+// read - чтение всей записи, секреты читаются только при старте, поэтому запись не кэшируется
+func (v *Vault) read(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.addr+"/v1/"+v.path, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "new vault request")
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "vault request")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSecretSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "read vault response")
+	}
+
+	var response vaultResponse
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errors.Wrapf(ErrNotFound, "vault path [%s]", v.path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if json.Unmarshal(body, &response) == nil && len(response.Errors) != 0 {
+			return nil, fmt.Errorf("vault: unexpected status [%d]: %s", resp.StatusCode, strings.Join(response.Errors, "; "))
+		}
+		return nil, fmt.Errorf("vault: unexpected status [%d]", resp.StatusCode)
+	}
+
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode vault response")
+	}
+
+	var kv2 struct {
+		Data map[string]any `json:"data"`
+	}
+	err = json.Unmarshal(response.Data, &kv2)
+	if err == nil && kv2.Data != nil {
+		return stringValues(kv2.Data), nil
+	}
+
+	var kv1 map[string]any
+	err = json.Unmarshal(response.Data, &kv1)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode vault data")
+	}
+
+	return stringValues(kv1), nil
+}
+
+// stringValues - строковые значения записи, значения других типов секретами не считаются
+func stringValues(data map[string]any) map[string]string {
+	values := make(map[string]string, len(data))
+	for key, value := range data {
+		if s, ok := value.(string); ok {
+			values[key] = s
+		}
+	}
+	return values
+}
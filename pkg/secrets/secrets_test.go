@@ -0,0 +1,115 @@
+package secrets
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStatic(t *testing.T) {
+	provider := Static{"pepper": "value", "empty": ""}
+
+	value, err := provider.Get(context.Background(), "pepper")
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	_, err = provider.Get(context.Background(), "empty")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = provider.Get(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pepper"), []byte("value\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "empty"), []byte("\n"), 0o600))
+
+	provider := NewFile(dir)
+
+	value, err := provider.Get(context.Background(), "pepper")
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	_, err = provider.Get(context.Background(), "empty")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = provider.Get(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	for _, name := range []string{"", "..", "../pepper", "nested/pepper"} {
+		_, err = provider.Get(context.Background(), name)
+		assert.ErrorIs(t, err, ErrInvalidName, name)
+	}
+}
+
+func TestVault(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "kv v2",
+			status: http.StatusOK,
+			body:   `{"data":{"data":{"pepper":"value"},"metadata":{"version":3}}}`,
+			want:   "value",
+		},
+		{
+			name:   "kv v1",
+			status: http.StatusOK,
+			body:   `{"data":{"pepper":"value"}}`,
+			want:   "value",
+		},
+		{
+			name:    "missing key",
+			status:  http.StatusOK,
+			body:    `{"data":{"data":{"other":"value"}}}`,
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "missing path",
+			status:  http.StatusNotFound,
+			body:    `{"errors":[]}`,
+			wantErr: ErrNotFound,
+		},
+		{
+			name:   "forbidden",
+			status: http.StatusForbidden,
+			body:   `{"errors":["permission denied"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v1/secret/data/auth-service", r.URL.Path)
+				assert.Equal(t, "token", r.Header.Get("X-Vault-Token"))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			provider := NewVault(server.URL+"/", "token", "/secret/data/auth-service", time.Second)
+
+			value, err := provider.Get(context.Background(), "pepper")
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.want == "":
+				assert.Error(t, err)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, value)
+			}
+		})
+	}
+}